@@ -1,6 +1,11 @@
 package main
 
-import "ToDoVerba/internal/app"
+import (
+	"ToDoVerba/internal/app"
+	"fmt"
+	"os"
+	"strings"
+)
 
 // @title           ToDo service
 // @version         1.0
@@ -15,5 +20,48 @@ import "ToDoVerba/internal/app"
 // @externalDocs.description  OpenAPI
 // @externalDocs.url          https://swagger.io/resources/open-api/
 func main() {
-	app.Run()
+	// Without a command the binary serves, as it did before it had commands.
+	args := os.Args[1:]
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		app.Serve(args)
+		return
+	}
+
+	if args[0] == "help" {
+		usage()
+		return
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		usage()
+		os.Exit(2)
+	}
+	command(args[1:])
+}
+
+var commands = map[string]func(args []string){
+	"serve":        app.Serve,
+	"migrate":      app.Migrate,
+	"seed":         app.Seed,
+	"check-config": app.CheckConfig,
+	"user":         app.User,
+	"backup":       app.Backup,
+	"restore":      app.Restore,
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, `Usage: todoverba <command> [flags] [args]
+
+Commands:
+  serve          run the server, the default
+  migrate        apply or revert database migrations
+  seed           load fixture tasks
+  check-config   validate the config and print it with secrets redacted
+  user           create, list and delete users
+  backup         write a backup of the tasks
+  restore        restore a backup
+
+Run todoverba <command> -h for the flags of a command.
+`)
 }
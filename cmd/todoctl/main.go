@@ -0,0 +1,11 @@
+// Command todoctl manages tasks through the REST api of the service.
+package main
+
+import (
+	"ToDoVerba/internal/todoctl"
+	"os"
+)
+
+func main() {
+	os.Exit(todoctl.New().Run(os.Args[1:]))
+}
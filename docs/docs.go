@@ -19,27 +19,101 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/tasks": {
+        "/admin/backup": {
             "get": {
-                "description": "List Task Description",
+                "description": "Stream a versioned NDJSON backup of all tasks with their events, versions, reminders and caldav objects. The last line counts the records, a backup without it was cut off",
+                "produces": [
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "Admin API"
+                ],
+                "summary": "Backup Summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer admin token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ndjson",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/restore": {
+            "post": {
+                "description": "Restore an NDJSON backup, all of it or nothing. Tasks get new ids and their records are remapped. merge adds the backup to the existing tasks, replace deletes them first. Restored tasks publish no events",
                 "consumes": [
-                    "application/json"
+                    "application/x-ndjson"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Task API"
+                    "Admin API"
+                ],
+                "summary": "Restore Summary",
+                "parameters": [
+                    {
+                        "description": "NDJSON backup",
+                        "name": "backup",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "merge (default) or replace",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer admin token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
                 ],
-                "summary": "List Task Summary",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/schemas.ResponseTaskRead"
-                            }
+                            "$ref": "#/definitions/schemas.ResponseBackupStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
                         }
                     },
                     "500": {
@@ -49,9 +123,72 @@ const docTemplate = `{
                         }
                     }
                 }
+            }
+        },
+        "/api/v2/tasks": {
+            "get": {
+                "description": "Tasks in id order, a page at a time. The links of the envelope point to the next and previous pages.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task API v2"
+                ],
+                "summary": "List tasks",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only completed or open tasks",
+                        "name": "completed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Due at or after, RFC3339",
+                        "name": "due_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Due before, RFC3339",
+                        "name": "due_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 50 by default, at most 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Tasks to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.taskListEnvelope"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v2.errorEnvelope"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v2.errorEnvelope"
+                        }
+                    }
+                }
             },
             "post": {
-                "description": "Create Task Description",
                 "consumes": [
                     "application/json"
                 ],
@@ -59,91 +196,124 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Task API"
+                    "Task API v2"
                 ],
-                "summary": "Create Task Summary",
+                "summary": "Create a task",
                 "parameters": [
                     {
-                        "description": "Task base",
+                        "description": "Task",
                         "name": "Task",
                         "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/schemas.RequestTaskCreate"
+                            "$ref": "#/definitions/schemas.RequestTaskV2"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Advisory actor recorded in task history, not authenticated",
+                        "name": "X-Actor",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key get the response of the first request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/schemas.ResponseTaskRead"
+                            "$ref": "#/definitions/v2.taskEnvelope"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Path of the new task"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/v1.errorJSON"
+                            "$ref": "#/definitions/v2.errorEnvelope"
+                        }
+                    },
+                    "409": {
+                        "description": "A request with the key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/v2.errorEnvelope"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/v2.errorEnvelope"
+                        }
+                    },
+                    "422": {
+                        "description": "The key was used with a different request",
+                        "schema": {
+                            "$ref": "#/definitions/v2.errorEnvelope"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/v1.errorJSON"
+                            "$ref": "#/definitions/v2.errorEnvelope"
                         }
                     }
                 }
             }
         },
-        "/tasks/{id}": {
+        "/api/v2/tasks/{id}": {
             "get": {
-                "description": "Find Task by id Description",
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Task API"
+                    "Task API v2"
                 ],
-                "summary": "Find Task by id Summary",
+                "summary": "Find a task by id",
                 "parameters": [
                     {
                         "type": "integer",
                         "description": "Task id",
                         "name": "id",
-                        "in": "path"
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/schemas.ResponseTaskRead"
+                            "$ref": "#/definitions/v2.taskEnvelope"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/v1.errorJSON"
+                            "$ref": "#/definitions/v2.errorEnvelope"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/v1.errorJSON"
+                            "$ref": "#/definitions/v2.errorEnvelope"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/v1.errorJSON"
+                            "$ref": "#/definitions/v2.errorEnvelope"
                         }
                     }
                 }
             },
             "put": {
-                "description": "Update Task Description",
                 "consumes": [
                     "application/json"
                 ],
@@ -151,54 +321,138 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Task API"
+                    "Task API v2"
                 ],
-                "summary": "Update Task Summary",
+                "summary": "Replace a task",
                 "parameters": [
                     {
                         "type": "integer",
                         "description": "Task id",
                         "name": "id",
-                        "in": "path"
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "description": "Task update",
+                        "description": "Task",
                         "name": "Task",
                         "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/schemas.RequestTaskUpdate"
+                            "$ref": "#/definitions/schemas.RequestTaskV2"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only replace the task at this version, as \\",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Advisory actor recorded in task history, not authenticated",
+                        "name": "X-Actor",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/schemas.ResponseTaskRead"
+                            "$ref": "#/definitions/v2.taskEnvelope"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/v1.errorJSON"
+                            "$ref": "#/definitions/v2.errorEnvelope"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/v1.errorJSON"
+                            "$ref": "#/definitions/v2.errorEnvelope"
+                        }
+                    },
+                    "412": {
+                        "description": "The task is at another version",
+                        "schema": {
+                            "$ref": "#/definitions/v2.errorEnvelope"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/v2.errorEnvelope"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/v1.errorJSON"
+                            "$ref": "#/definitions/v2.errorEnvelope"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Delete Task by id Description",
+                "tags": [
+                    "Task API v2"
+                ],
+                "summary": "Delete a task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only delete the task at this version, as \\",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Advisory actor recorded in task history, not authenticated",
+                        "name": "X-Actor",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v2.errorEnvelope"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v2.errorEnvelope"
+                        }
+                    },
+                    "412": {
+                        "description": "The task is at another version",
+                        "schema": {
+                            "$ref": "#/definitions/v2.errorEnvelope"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v2.errorEnvelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/audit": {
+            "get": {
+                "description": "Task events across all tasks, oldest first",
                 "consumes": [
                     "application/json"
                 ],
@@ -206,22 +460,66 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Task API"
+                    "Audit API"
                 ],
-                "summary": "Delete Task by id Summary",
+                "summary": "Audit log Summary",
                 "parameters": [
                     {
                         "type": "integer",
                         "description": "Task id",
-                        "name": "id",
-                        "in": "path"
+                        "name": "task_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Actor",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "create",
+                            "update",
+                            "delete"
+                        ],
+                        "type": "string",
+                        "description": "Action",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Events at or after, RFC3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Events before, RFC3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit, 100 by default",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/schemas.ResponseTaskRead"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/schemas.ResponseTaskEvent"
+                            }
                         }
                     },
                     "400": {
@@ -230,8 +528,76 @@ const docTemplate = `{
                             "$ref": "#/definitions/v1.errorJSON"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/calendar/tasks.ics": {
+            "get": {
+                "description": "iCalendar feed of the tasks for calendar subscriptions, authenticated by the feed token. Accepts the filters of GET /tasks. Send If-None-Match with the last ETag to get 304 when nothing changed",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "Calendar API"
+                ],
+                "summary": "Task calendar feed Summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Feed token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "vtodo (default) or vevent",
+                        "name": "component",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only completed or open tasks",
+                        "name": "completed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Due at or after, RFC3339",
+                        "name": "due_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Due before, RFC3339",
+                        "name": "due_before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "calendar",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/v1.errorJSON"
                         }
@@ -244,40 +610,1894 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
+        },
+        "/calendar/token": {
+            "post": {
+                "description": "Issue a new feed token for the calling user. The previous feed url stops working",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Calendar API"
+                ],
+                "summary": "Rotate calendar feed token Summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer api token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.ResponseCalendarToken"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/dav/tasks/{name}": {
+            "get": {
+                "description": "The task as a VCALENDAR with one VTODO. The ETag is the task id and version. Authenticate with the api token as basic auth password",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "CalDAV"
+                ],
+                "summary": "Get task calendar object Summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Resource name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "calendar object",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Create or update the task of a resource from a VCALENDAR with one VTODO. SUMMARY, DESCRIPTION, DUE and STATUS are kept, other properties are dropped, so no ETag is returned. Honors If-Match and If-None-Match",
+                "consumes": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "CalDAV"
+                ],
+                "summary": "Put task calendar object Summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Resource name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "VCALENDAR",
+                        "name": "calendar",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created"
+                    },
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "412": {
+                        "description": "Precondition Failed"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete the task of a resource. Honors If-Match",
+                "tags": [
+                    "CalDAV"
+                ],
+                "summary": "Delete task calendar object Summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Resource name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks": {
+            "get": {
+                "description": "List Task Description",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task API"
+                ],
+                "summary": "List Task Summary",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only completed or open tasks",
+                        "name": "completed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Due at or after, RFC3339",
+                        "name": "due_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Due before, RFC3339",
+                        "name": "due_before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/schemas.ResponseTaskRead"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create Task Description",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task API"
+                ],
+                "summary": "Create Task Summary",
+                "parameters": [
+                    {
+                        "description": "Task base",
+                        "name": "Task",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.RequestTaskCreate"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Advisory actor recorded in task history, not authenticated",
+                        "name": "X-Actor",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retries with the same key get the response of the first request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.ResponseTaskRead"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Path of the new task"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "409": {
+                        "description": "A request with the key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "422": {
+                        "description": "The key was used with a different request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/events": {
+            "get": {
+                "description": "Server-Sent Events stream of the task.created, task.updated, task.completed and task.deleted events. Send Last-Event-ID to resume after a reconnect. Browsers pass the token as access_token query parameter",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Task API"
+                ],
+                "summary": "Task events stream Summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer api token",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Api token",
+                        "name": "access_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only events caused by this actor",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only events of this task",
+                        "name": "task_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated event types",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Id of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "event stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/export.csv": {
+            "get": {
+                "description": "Stream the tasks as CSV in id order. Accepts the filters of GET /tasks. A title or description starting with =, +, -, @, a tab, a carriage return or ' is prefixed with ', so spreadsheets don't run it as a formula",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "Task API"
+                ],
+                "summary": "Export Tasks as CSV Summary",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only completed or open tasks",
+                        "name": "completed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Due at or after, RFC3339",
+                        "name": "due_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Due before, RFC3339",
+                        "name": "due_before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "csv",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/export.md": {
+            "get": {
+                "description": "Stream the tasks as a Markdown checklist in id order, written like todo.txt lines after the checkbox. Accepts the filters of GET /tasks",
+                "produces": [
+                    "text/markdown"
+                ],
+                "tags": [
+                    "Task API"
+                ],
+                "summary": "Export Tasks as Markdown checklist Summary",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only completed or open tasks",
+                        "name": "completed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Due at or after, RFC3339",
+                        "name": "due_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Due before, RFC3339",
+                        "name": "due_before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "markdown",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/export.txt": {
+            "get": {
+                "description": "Stream the tasks as todo.txt lines in id order, with the due date as due tag. Priority, +projects and @contexts come from a description written by a todo.txt or Markdown import. Accepts the filters of GET /tasks",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Task API"
+                ],
+                "summary": "Export Tasks as todo.txt Summary",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only completed or open tasks",
+                        "name": "completed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Due at or after, RFC3339",
+                        "name": "due_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Due before, RFC3339",
+                        "name": "due_before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "todo.txt",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/import": {
+            "post": {
+                "description": "Create tasks from a CSV with a header row, a todo.txt file (text/plain) or a Markdown checklist (text/markdown), all of them or none. CSV columns are matched by the names title, description, due_date and completed unless mapped with map=field:header. The ' prefix of an export is removed from titles and descriptions. todo.txt lines and checklist items take the due date from the due tag and keep priority, +projects and @contexts in the description. A due date without offset is read in timezone. Entries that fail validation are reported with their line number and nothing is imported. dry_run only validates",
+                "consumes": [
+                    "text/csv",
+                    "text/plain",
+                    "text/markdown"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task API"
+                ],
+                "summary": "Import Tasks Summary",
+                "parameters": [
+                    {
+                        "description": "CSV, todo.txt or Markdown",
+                        "name": "tasks",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only validate",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone of due dates without offset, UTC by default",
+                        "name": "timezone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "CSV column mapping field:header",
+                        "name": "map",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Advisory actor recorded in task history, not authenticated",
+                        "name": "X-Actor",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.ResponseTaskImport"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.ResponseTaskImport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.ResponseTaskImport"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}": {
+            "get": {
+                "description": "Find Task by id Description",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task API"
+                ],
+                "summary": "Find Task by id Summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task id",
+                        "name": "id",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.ResponseTaskRead"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update Task Description",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task API"
+                ],
+                "summary": "Update Task Summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task id",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Task update",
+                        "name": "Task",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.RequestTaskUpdate"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Advisory actor recorded in task history, not authenticated",
+                        "name": "X-Actor",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.ResponseTaskRead"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete Task by id Description",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task API"
+                ],
+                "summary": "Delete Task by id Summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task id",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Advisory actor recorded in task history, not authenticated",
+                        "name": "X-Actor",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/history": {
+            "get": {
+                "description": "Every create, update and delete of the task, oldest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit API"
+                ],
+                "summary": "Task history Summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task id",
+                        "name": "id",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/schemas.ResponseTaskEvent"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/reminders": {
+            "get": {
+                "description": "Reminders of the task ordered by fire time. Status is pending, sent or failed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task Reminder API"
+                ],
+                "summary": "List Task reminders Summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task id",
+                        "name": "id",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/schemas.ResponseReminder"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Remind offset before the due date of the task, e.g. 15m or 24h. Channels default to the server configuration. Creating a reminder with an existing offset replaces it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task Reminder API"
+                ],
+                "summary": "Create Task reminder Summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task id",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Reminder",
+                        "name": "Reminder",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.RequestReminderCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.ResponseReminder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/reminders/{rid}": {
+            "delete": {
+                "description": "Delete Task reminder Description",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task Reminder API"
+                ],
+                "summary": "Delete Task reminder Summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task id",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Reminder id",
+                        "name": "rid",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/reminders/{rid}/snooze": {
+            "post": {
+                "description": "Fire the reminder again after the given duration, also when it was already sent",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task Reminder API"
+                ],
+                "summary": "Snooze Task reminder Summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task id",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Reminder id",
+                        "name": "rid",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Snooze duration",
+                        "name": "Snooze",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.RequestReminderSnooze"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.ResponseReminder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/revert": {
+            "post": {
+                "description": "Restore a previous version of the task as a new update",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task Version API"
+                ],
+                "summary": "Revert Task Summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task id",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Version to restore",
+                        "name": "Revert",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.RequestTaskRevert"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Advisory actor recorded in task history, not authenticated",
+                        "name": "X-Actor",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.ResponseTaskRead"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/versions": {
+            "get": {
+                "description": "Snapshots of every version of the task, oldest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task Version API"
+                ],
+                "summary": "List Task versions Summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task id",
+                        "name": "id",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/schemas.ResponseTaskVersion"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/versions/{n}": {
+            "get": {
+                "description": "Snapshot of the task as it was at version n",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task Version API"
+                ],
+                "summary": "Find Task version Summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task id",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version number",
+                        "name": "n",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.ResponseTaskVersion"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "List Webhook Description",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook API"
+                ],
+                "summary": "List Webhook Summary",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/schemas.ResponseWebhookRead"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscribe an url to task events. Empty events means all events. The secret is generated when omitted and is only returned here",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook API"
+                ],
+                "summary": "Create Webhook Summary",
+                "parameters": [
+                    {
+                        "description": "Webhook base",
+                        "name": "Webhook",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.RequestWebhookCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.ResponseWebhookRead"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "description": "Find Webhook by id Description",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook API"
+                ],
+                "summary": "Find Webhook by id Summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook id",
+                        "name": "id",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.ResponseWebhookRead"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update Webhook Description",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook API"
+                ],
+                "summary": "Update Webhook Summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook id",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "description": "Webhook update",
+                        "name": "Webhook",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.RequestWebhookUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.ResponseWebhookRead"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete the subscription together with its delivery log",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook API"
+                ],
+                "summary": "Delete Webhook by id Summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook id",
+                        "name": "id",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Deliveries of the webhook, newest first. Status is pending, delivered or dead",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhook API"
+                ],
+                "summary": "Webhook delivery log Summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Webhook id",
+                        "name": "id",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit, 50 by default",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/schemas.ResponseWebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws": {
+            "get": {
+                "description": "Bidirectional task channel. Clients send schemas.SocketCommand messages to subscribe to the tasks or task:\u003cid\u003e topics and to create or update tasks, and receive schemas.SocketReply acks, errors and events. Browsers pass the token as access_token query parameter",
+                "tags": [
+                    "Task API"
+                ],
+                "summary": "Task WebSocket Summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer api token",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Api token",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/v1.errorJSON"
+                        }
+                    }
+                }
+            }
+        }
+    },
     "definitions": {
+        "schemas.RequestReminderCreate": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "offset": {
+                    "type": "string"
+                }
+            }
+        },
+        "schemas.RequestReminderSnooze": {
+            "type": "object",
+            "properties": {
+                "for": {
+                    "type": "string"
+                }
+            }
+        },
         "schemas.RequestTaskCreate": {
             "type": "object",
             "properties": {
+                "completed": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "due_date": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "schemas.RequestTaskRevert": {
+            "type": "object",
+            "properties": {
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "schemas.RequestTaskUpdate": {
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "due_date": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "schemas.RequestTaskV2": {
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "boolean"
+                },
                 "description": {
-                    "type": "string"
+                    "type": "string",
+                    "x-nullable": true
                 },
                 "due_date": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "2024-09-05T15:04:05Z"
                 },
                 "title": {
                     "type": "string"
                 }
             }
         },
-        "schemas.RequestTaskUpdate": {
+        "schemas.RequestWebhookCreate": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "schemas.RequestWebhookUpdate": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "schemas.ResponseBackupStats": {
+            "type": "object",
+            "properties": {
+                "caldav_objects": {
+                    "type": "integer"
+                },
+                "reminders": {
+                    "type": "integer"
+                },
+                "task_events": {
+                    "type": "integer"
+                },
+                "task_versions": {
+                    "type": "integer"
+                },
+                "tasks": {
+                    "type": "integer"
+                }
+            }
+        },
+        "schemas.ResponseCalendarToken": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "schemas.ResponseFieldChange": {
+            "type": "object",
+            "properties": {
+                "after": {},
+                "before": {}
+            }
+        },
+        "schemas.ResponseImportError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "schemas.ResponseReminder": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "fire_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "string"
+                },
+                "sent_at": {
+                    "type": "string"
+                },
+                "sent_channels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "x-nullable": true
+                },
+                "status": {
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "schemas.ResponseTaskEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/schemas.ResponseFieldChange"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "schemas.ResponseTaskImport": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/schemas.ResponseImportError"
+                    }
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "integer"
+                }
+            }
+        },
+        "schemas.ResponseTaskRead": {
             "type": "object",
             "properties": {
+                "completed": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
                 "description": {
                     "type": "string"
                 },
                 "due_date": {
                     "type": "string"
                 },
+                "id": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "schemas.ResponseTaskV2": {
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string",
+                    "x-nullable": true
+                },
+                "description": {
+                    "type": "string",
+                    "x-nullable": true
+                },
+                "due_date": {
+                    "type": "string",
+                    "x-nullable": true
+                },
+                "id": {
+                    "type": "integer"
+                },
                 "title": {
                     "type": "string"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "x-nullable": true
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "schemas.ResponseTaskRead": {
+        "schemas.ResponseTaskVersion": {
             "type": "object",
             "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "completed": {
+                    "type": "boolean"
+                },
                 "created_at": {
                     "type": "string"
                 },
@@ -287,14 +2507,84 @@ const docTemplate = `{
                 "due_date": {
                     "type": "string"
                 },
-                "id": {
+                "task_id": {
                     "type": "integer"
                 },
                 "title": {
                     "type": "string"
                 },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "schemas.ResponseWebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "response_code": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "schemas.ResponseWebhookRead": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string"
+                },
+                "url": {
+                    "type": "string"
                 }
             }
         },
@@ -305,6 +2595,93 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "v2.emptyMeta": {
+            "type": "object"
+        },
+        "v2.errorBody": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v2.errorEnvelope": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/v2.errorBody"
+                }
+            }
+        },
+        "v2.links": {
+            "type": "object",
+            "properties": {
+                "next": {
+                    "type": "string"
+                },
+                "prev": {
+                    "type": "string"
+                },
+                "self": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2.pageMeta": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v2.taskEnvelope": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/schemas.ResponseTaskV2"
+                },
+                "links": {
+                    "$ref": "#/definitions/v2.links"
+                },
+                "meta": {
+                    "$ref": "#/definitions/v2.emptyMeta"
+                }
+            }
+        },
+        "v2.taskListEnvelope": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/schemas.ResponseTaskV2"
+                    }
+                },
+                "links": {
+                    "$ref": "#/definitions/v2.links"
+                },
+                "meta": {
+                    "$ref": "#/definitions/v2.pageMeta"
+                }
+            }
         }
     },
     "externalDocs": {
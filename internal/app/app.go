@@ -4,56 +4,101 @@ import (
 	"ToDoVerba/docs"
 	"ToDoVerba/internal/config"
 	"ToDoVerba/internal/crud"
+	"ToDoVerba/internal/memory"
 	"ToDoVerba/internal/repos"
 	"ToDoVerba/internal/route"
+	"ToDoVerba/internal/route/api/openapi"
+	v1 "ToDoVerba/internal/route/api/v1"
+	"ToDoVerba/internal/route/gql"
+	"ToDoVerba/internal/route/rpc"
 	"ToDoVerba/internal/service"
+	"ToDoVerba/internal/sqlite"
+	"ToDoVerba/migration"
 	"ToDoVerba/pkg/logging"
 	"ToDoVerba/pkg/migrator"
+	"context"
 	"encoding/json"
 	"errors"
-	"github.com/golang-migrate/migrate/v4"
+	"flag"
 	"github.com/julienschmidt/httprouter"
 	"github.com/sirupsen/logrus"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"google.golang.org/grpc"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
-func Run() {
+// Serve runs the server. Its flags override the settings of the config file
+// and the environment, see config.RegisterFlags.
+func Serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	config.RegisterFlags(flags)
+	flags.Parse(args)
+
 	// Init config and logger
 	logger := logging.GetLogger()
 	logger.Info("Start application")
 	conf := config.GetConfig(logger)
-
-	loglvl, err := logrus.ParseLevel(conf.Server.LogLevel)
-	if err != nil {
-		logger.Error(err.Error())
+	if err := conf.Validate(); err != nil {
+		logger.Fatalf("Invalid config: %s", err)
 	}
-	logger.SetLevel(loglvl)
+
+	setLogLevel(logger, conf)
 
 	ex, _ := os.Executable()
 	wd, _ := os.Getwd()
 
 	logger.Debugf("Working directory: %s; Executable: %s", wd, ex)
 
-	configJSON, _ := json.Marshal(conf)
+	configJSON, _ := json.Marshal(conf.Redacted())
 	logger.Debugf("Config: %s", string(configJSON))
 
-	// Run migrations
-	RunMigration(conf, logger)
-
-	// Init db connection
-	pool := crud.GetPool(conf, logger)
-	defer pool.Close()
-
-	// Init repositories, service
-	repositories := repos.NewRepositories(pool, logger)
+	// Init storage, repositories, service
+	repositories, closeStorage := openStorage(conf, logger)
+	defer closeStorage()
 
 	services := service.NewServices(service.Deps{
 		Repos:  repositories,
 		Logger: logger,
+		Config: conf,
 	})
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Run background workers. Except for the idempotency keys they work on
+	// the tables of Postgres only.
+	go services.Idempotency.Run(ctx)
+	if conf.Storage.Driver == config.DriverPostgres {
+		go services.Webhook.Run(ctx)
+		go services.Outbox.Run(ctx)
+		go services.Reminder.Run(ctx)
+		if conf.Notify.Enabled {
+			go services.Notify.Run(ctx)
+		}
+	}
+
+	// Init gRPC server
+	var grpcServer *grpc.Server
+	if conf.Server.GrpcPort != "" {
+		lis, err := net.Listen("tcp", ":"+conf.Server.GrpcPort)
+		if err != nil {
+			logger.Fatalf("failed to listen on gRPC port: %s", err.Error())
+		}
+		grpcServer = rpc.NewServer(rpc.Deps{
+			Services: services,
+			Logger:   logger,
+		})
+		go func() {
+			logger.Fatal(grpcServer.Serve(lis))
+		}()
+		logger.Infof("gRPC server listening on %s", lis.Addr())
+	}
+
 	// Init router and handlers
 	r := httprouter.New()
 
@@ -66,52 +111,132 @@ func Run() {
 		logger.Infof("Swagger enabled")
 	}
 
+	persistedQueries, err := gql.LoadPersistedQueries(conf.GraphQL.PersistedQueries)
+	if err != nil {
+		logger.Fatalf("failed to load persisted GraphQL queries: %s", err.Error())
+	}
+
 	h := route.NewHandler(route.Deps{
 		Services: services,
 		Logger:   logger,
+		GraphQL: gql.Options{
+			MaxDepth:         conf.GraphQL.MaxDepth,
+			MaxComplexity:    conf.GraphQL.MaxComplexity,
+			PersistedQueries: persistedQueries,
+			PersistedOnly:    conf.GraphQL.PersistedOnly,
+		},
+		V1Deprecation: v1.Deprecation{
+			At:     conf.API.V1Deprecation,
+			Sunset: conf.API.V1Sunset,
+		},
+		Validation: openapi.Mode(conf.API.Validation),
 	})
 
 	h.Init(r)
-	logger.Fatal(http.ListenAndServe(":"+conf.Server.Port, r))
-}
+	handler, err := h.Validate(r)
+	if err != nil {
+		logger.Fatalf("failed to load the api spec for API_VALIDATION: %s", err.Error())
+	}
+	server := &http.Server{Addr: ":" + conf.Server.Port, Handler: handler}
+	go func() {
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal(err)
+		}
+	}()
 
-func RunMigration(conf *config.Config, logger logging.Logger) {
-	if len(conf.Storage.Migration) == 0 {
-		logger.Info("Migration file env not set in config. Skipping migration")
-		return
+	// Shut down on a signal, so the deferred calls close the storage.
+	<-ctx.Done()
+	logger.Info("Shutting down")
+	if grpcServer != nil {
+		grpcServer.Stop()
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Errorf("Error while shutting down the server: %s", err)
 	}
+}
 
-	m, err := migrator.NewMigrator(migrator.Deps{
-		Username: conf.Storage.Username,
-		Password: conf.Storage.Password,
-		Host:     conf.Storage.Host,
-		Port:     conf.Storage.Port,
-		Database: conf.Storage.Database,
-		Source:   conf.Storage.Migration,
-	})
-	defer func() {
-		if m != nil {
-			source, database := m.Close()
-			if source != nil {
-				logger.Errorf("Error while closing migrator source: %s", source.Error())
+// shutdownTimeout bounds the wait for the requests in progress on shutdown.
+// Streams don't end by themselves and are cut after it.
+const shutdownTimeout = 10 * time.Second
+
+// openStorage returns the repositories of the storage driver and a function
+// that closes the storage. The memory driver saves its snapshot then.
+func openStorage(conf *config.Config, logger logging.Logger) (repos.Repositories, func()) {
+	switch conf.Storage.Driver {
+	case config.DriverSQLite:
+		db, err := sqlite.Open(conf.Storage.SQLitePath)
+		if err != nil {
+			logger.Fatalf("Can't open sqlite database: %s", err)
+		}
+		logger.Infof("Opened sqlite database %s", conf.Storage.SQLitePath)
+		return repos.NewSQLiteRepositories(db, logger), func() {
+			if err := db.Close(); err != nil {
+				logger.Errorf("Error while closing sqlite database: %s", err)
 			}
-			if database != nil {
-				logger.Errorf("Error while closing migrator connection: %s", database.Error())
+		}
+	case config.DriverMemory:
+		logger.Warn("Idempotency keys are kept in memory, retries after a restart run again")
+		tasks := memory.NewTaskMemory(logger)
+		if conf.Storage.Snapshot == "" {
+			logger.Info("Tasks are kept in memory and lost on shutdown, set STORAGE_SNAPSHOT to keep them")
+			return repos.NewMemoryRepositories(tasks, logger), func() {}
+		}
+		if err := tasks.Load(conf.Storage.Snapshot); err != nil {
+			logger.Fatalf("Can't load task snapshot: %s", err)
+		}
+		return repos.NewMemoryRepositories(tasks, logger), func() {
+			if err := tasks.Save(conf.Storage.Snapshot); err != nil {
+				logger.Errorf("Can't save task snapshot: %s", err)
 			}
 		}
-	}()
+	}
 
+	if conf.Storage.AutoMigrate {
+		RunMigration(conf, logger)
+	}
+	pool := crud.GetPool(conf, logger)
+	return repos.NewRepositories(pool, conf.Cache, logger), pool.Close
+}
+
+func setLogLevel(logger logging.Logger, conf *config.Config) {
+	loglvl, err := logrus.ParseLevel(conf.Server.LogLevel)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+	logger.SetLevel(loglvl)
+}
+
+func RunMigration(conf *config.Config, logger logging.Logger) {
+	m, closeMigrator := newMigrator(conf, logger)
+	defer closeMigrator()
+
+	migrateDone(logger, m.Up())
+}
+
+func newMigrator(conf *config.Config, logger logging.Logger) (*migrator.Migrator, func()) {
+	m, err := migrator.NewMigrator(migrator.Deps{
+		Username:    conf.Storage.Username,
+		Password:    conf.Storage.Password,
+		Host:        conf.Storage.Host,
+		Port:        conf.Storage.Port,
+		Database:    conf.Storage.Database,
+		Source:      conf.Storage.Migration,
+		Files:       migration.FS,
+		LockTimeout: conf.Storage.MigrationLockTimeout,
+	})
 	if err != nil {
 		logger.Fatalf("Error while initializing migrator: %s", err.Error())
 	}
 
-	err = m.Up()
-	noChange := errors.Is(err, migrate.ErrNoChange)
-	if err != nil && !noChange {
-		logger.Fatalf("Error while up migrator: %s", err.Error())
-	} else if noChange {
-		logger.Infof("Database migration already up-to-date")
-	} else {
-		logger.Infof("Successfully migrated database to last version")
+	return m, func() {
+		source, database := m.Close()
+		if source != nil {
+			logger.Errorf("Error while closing migrator source: %s", source.Error())
+		}
+		if database != nil {
+			logger.Errorf("Error while closing migrator connection: %s", database.Error())
+		}
 	}
 }
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"ToDoVerba/internal/config"
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/schemas"
+	"ToDoVerba/internal/service"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"flag"
+	"io"
+	"os"
+	"os/signal"
+)
+
+// Backup writes a backup to the file given with -o, or to stdout.
+func Backup(args []string) {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	output := flags.String("o", "", "write the backup to `file` instead of stdout")
+	flags.Parse(args)
+
+	logger, services, closeDB := setupCommand()
+	defer closeDB()
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			logger.Fatalf("Can't create backup file: %s", err)
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				logger.Fatalf("Can't write backup file: %s", err)
+			}
+		}()
+		w = file
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	stats, err := services.Backup.Backup(ctx, w)
+	if err != nil {
+		logger.Fatalf("Backup failed: %s", err)
+	}
+	logger.Infof("Backed up %d tasks, %d events, %d versions, %d reminders, %d caldav objects",
+		stats.Tasks, stats.TaskEvents, stats.TaskVersions, stats.Reminders, stats.CalDAVObjects)
+}
+
+// Restore restores the backup file given as argument, or stdin, with the
+// -mode merge or replace.
+func Restore(args []string) {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	mode := flags.String("mode", dto.BackupModeMerge, "merge into or replace the existing tasks")
+	flags.Parse(args)
+
+	options := schemas.RequestRestore{Mode: *mode}
+	if err := options.Valid(); err != nil {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	logger, services, closeDB := setupCommand()
+	defer closeDB()
+
+	var r io.Reader = os.Stdin
+	if path := flags.Arg(0); path != "" && path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			logger.Fatalf("Can't open backup file: %s", err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	stats, err := services.Backup.Restore(ctx, r, options.Mode)
+	if err != nil {
+		logger.Fatalf("Restore failed: %s", err)
+	}
+	logger.Infof("Restored %d tasks, %d events, %d versions, %d reminders, %d caldav objects",
+		stats.Tasks, stats.TaskEvents, stats.TaskVersions, stats.Reminders, stats.CalDAVObjects)
+}
+
+// setupCommand opens the storage for a command like Serve does, with the
+// log on stderr so it stays apart from the output of the command.
+func setupCommand() (logging.Logger, service.Services, func()) {
+	logger := logging.GetLogger()
+	logger.SetWriter(os.Stderr)
+	conf := config.GetConfig(logger)
+	if err := conf.Validate(); err != nil {
+		logger.Fatalf("Invalid config: %s", err)
+	}
+	setLogLevel(logger, conf)
+
+	repositories, closeStorage := openStorage(conf, logger)
+	services := service.NewServices(service.Deps{
+		Repos:  repositories,
+		Logger: logger,
+		Config: conf,
+	})
+	return logger, services, closeStorage
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"ToDoVerba/internal/config"
+	"ToDoVerba/pkg/logging"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+)
+
+// CheckConfig reads the config like Serve, flags included, and prints it
+// with the secrets redacted. It exits with 1 if the config is invalid, so a
+// deployment can check its config before rolling out.
+func CheckConfig(args []string) {
+	flags := flag.NewFlagSet("check-config", flag.ExitOnError)
+	config.RegisterFlags(flags)
+	flags.Parse(args)
+
+	logger := logging.GetLogger()
+	logger.SetWriter(os.Stderr)
+	conf := config.GetConfig(logger)
+
+	out, err := yaml.Marshal(conf.Redacted())
+	if err != nil {
+		logger.Fatalf("Can't print config: %s", err)
+	}
+	fmt.Print(string(out))
+
+	if err = conf.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config:\n%s\n", err)
+		os.Exit(1)
+	}
+	logger.Info("Config is valid")
+}
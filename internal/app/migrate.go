@@ -0,0 +1,212 @@
+package app
+
+import (
+	"ToDoVerba/internal/config"
+	"ToDoVerba/pkg/logging"
+	"ToDoVerba/pkg/migrator"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+const migrateUsage = `Usage: migrate <command>
+
+Commands:
+  up [N]        apply all or the next N migrations
+  down N|-all   revert the last N migrations, or all of them
+  goto V        migrate up or down to version V
+  force V       set version V without migrating and clear the dirty flag
+  version       print the current version
+  status        list the migrations and whether they are applied
+`
+
+// Migrate runs the migrations on their own, so they can run as a job before
+// the servers start with POSTGRES_AUTO_MIGRATE off.
+func Migrate(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), migrateUsage) }
+	flags.Parse(args)
+
+	command, args := flags.Arg(0), flags.Args()
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	run, ok := map[string]func(args []string) error{
+		"up":      migrateUp,
+		"down":    migrateDown,
+		"goto":    migrateGoto,
+		"force":   migrateForce,
+		"version": migrateVersion,
+		"status":  migrateStatus,
+	}[command]
+	if !ok {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if err := run(args); err != nil {
+		fmt.Fprintf(os.Stderr, "migrate %s: %s\n", command, err)
+		flags.Usage()
+		os.Exit(2)
+	}
+}
+
+func migrateUp(args []string) error {
+	n, err := optionalCount(args)
+	if err != nil {
+		return err
+	}
+	logger, m, closeMigrator := setupMigrator()
+	defer closeMigrator()
+	if n == 0 {
+		migrateDone(logger, m.Up())
+	} else {
+		migrateDone(logger, m.Steps(n))
+	}
+	return nil
+}
+
+func migrateDown(args []string) error {
+	if len(args) == 1 && args[0] == "-all" {
+		logger, m, closeMigrator := setupMigrator()
+		defer closeMigrator()
+		migrateDone(logger, m.Down())
+		return nil
+	}
+	// Reverting everything by accident is hard to undo, so down wants a
+	// count.
+	n, err := optionalCount(args)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("expected a count or -all")
+	}
+	logger, m, closeMigrator := setupMigrator()
+	defer closeMigrator()
+	migrateDone(logger, m.Steps(-n))
+	return nil
+}
+
+func migrateGoto(args []string) error {
+	if len(args) != 1 {
+		return errors.New("expected a version")
+	}
+	version, err := strconv.ParseUint(args[0], 10, 0)
+	if err != nil {
+		return fmt.Errorf("invalid version %q", args[0])
+	}
+	logger, m, closeMigrator := setupMigrator()
+	defer closeMigrator()
+	migrateDone(logger, m.Goto(uint(version)))
+	return nil
+}
+
+func migrateForce(args []string) error {
+	if len(args) != 1 {
+		return errors.New("expected a version")
+	}
+	version, err := strconv.Atoi(args[0])
+	if err != nil || version < -1 {
+		return fmt.Errorf("invalid version %q", args[0])
+	}
+	logger, m, closeMigrator := setupMigrator()
+	defer closeMigrator()
+	if err = m.Force(version); err != nil {
+		logger.Fatalf("Error while forcing version: %s", err)
+	}
+	logger.Infof("Forced database to version %d", version)
+	return nil
+}
+
+func migrateVersion(args []string) error {
+	if len(args) != 0 {
+		return errors.New("unexpected arguments")
+	}
+	logger, m, closeMigrator := setupMigrator()
+	defer closeMigrator()
+	version, dirty, err := m.Version()
+	if err != nil {
+		logger.Fatalf("Error while reading version: %s", err)
+	}
+	if dirty {
+		fmt.Printf("%d (dirty)\n", version)
+	} else {
+		fmt.Println(version)
+	}
+	return nil
+}
+
+func migrateStatus(args []string) error {
+	if len(args) != 0 {
+		return errors.New("unexpected arguments")
+	}
+	logger, m, closeMigrator := setupMigrator()
+	defer closeMigrator()
+	migrations, err := m.Status()
+	if err != nil {
+		logger.Fatalf("Error while reading migrations: %s", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+	for _, migration := range migrations {
+		status := "pending"
+		if migration.Dirty {
+			status = "dirty"
+		} else if migration.Applied {
+			status = "applied"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", migration.Version, migration.Name, status)
+	}
+	return w.Flush()
+}
+
+func optionalCount(args []string) (int, error) {
+	switch len(args) {
+	case 0:
+		return 0, nil
+	case 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid count %q", args[0])
+		}
+		return n, nil
+	default:
+		return 0, errors.New("unexpected arguments")
+	}
+}
+
+// setupMigrator opens the migrator for a migrate command, with the log on
+// stderr like setupCommand.
+func setupMigrator() (logging.Logger, *migrator.Migrator, func()) {
+	logger := logging.GetLogger()
+	logger.SetWriter(os.Stderr)
+	conf := config.GetConfig(logger)
+	if err := conf.Validate(); err != nil {
+		logger.Fatalf("Invalid config: %s", err)
+	}
+	setLogLevel(logger, conf)
+	if conf.Storage.Driver != config.DriverPostgres {
+		logger.Fatalf("migrate is for the postgres storage driver, %s needs none or migrates when opened", conf.Storage.Driver)
+	}
+	m, closeMigrator := newMigrator(conf, logger)
+	return logger, m, closeMigrator
+}
+
+func migrateDone(logger logging.Logger, err error) {
+	var dirty migrator.ErrDirty
+	switch {
+	case errors.As(err, &dirty):
+		logger.Fatalf("Database is dirty at version %d after a failed migration: "+
+			"fix it by hand, then set its version with migrate force", dirty.Version)
+	case errors.Is(err, migrator.ErrNoChange):
+		logger.Infof("Database migration already up-to-date")
+	case err != nil:
+		logger.Fatalf("Error while migrating: %s", err)
+	default:
+		logger.Infof("Successfully migrated database")
+	}
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"ToDoVerba/internal/schemas"
+	_ "embed"
+	"errors"
+	"flag"
+	"github.com/jackc/pgx/v5"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// seedTasks are the tasks seed loads without -f, in todo.txt format.
+//
+//go:embed fixtures/tasks.txt
+var seedTasks string
+
+// Seed imports the tasks of a fixture file, a CSV, todo.txt or Markdown
+// checklist read like the import endpoint does, or the built-in fixture. It
+// refuses to seed a database that has tasks unless -force is given.
+func Seed(args []string) {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	file := flags.String("f", "", "import the tasks of `file` (.csv, .txt or .md) instead of the built-in fixture")
+	timezone := flags.String("tz", "", "IANA time zone of due dates without offset, UTC by default")
+	actor := flags.String("actor", "seed", "actor recorded in the task history")
+	force := flags.Bool("force", false, "seed even if the database has tasks")
+	flags.Parse(args)
+
+	options := schemas.RequestTaskImport{Timezone: *timezone}
+	if err := options.Valid(); err != nil {
+		flags.Usage()
+		os.Exit(2)
+	}
+	scan := options.ScanTodoTxt
+	switch strings.ToLower(filepath.Ext(*file)) {
+	case "", ".txt":
+	case ".csv":
+		scan = options.ScanCSV
+	case ".md", ".markdown":
+		scan = options.ScanChecklist
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	logger, services, closeDB := setupCommand()
+	defer closeDB()
+
+	var r io.Reader = strings.NewReader(seedTasks)
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			logger.Fatalf("Can't open fixture file: %s", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	cTasks, rowErrors, err := scan(r, *actor)
+	if err != nil {
+		logger.Fatalf("Can't read fixture file: %s", err)
+	}
+	if len(rowErrors) > 0 {
+		for _, rowError := range rowErrors {
+			logger.Errorf("Line %d: %s", rowError.Row, rowError.Error)
+		}
+		logger.Fatalf("Fixture file has %d invalid tasks, nothing seeded", len(rowErrors))
+	}
+
+	if !*force {
+		tasks, err := services.Task.List()
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			logger.Fatalf("Can't list tasks: %s", err)
+		}
+		if len(tasks) > 0 {
+			logger.Fatalf("Database has %d tasks, use -force to seed anyway", len(tasks))
+		}
+	}
+
+	rTasks, err := services.Task.Import(cTasks)
+	if err != nil {
+		logger.Fatalf("Seed failed: %s", err)
+	}
+	logger.Infof("Seeded %d tasks", len(rTasks))
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"ToDoVerba/pkg/logging"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const userUsage = `Usage: user <command>
+
+Commands:
+  create NAME             create a user and print its API token
+  ls                      list the users
+  rm ID                   delete a user
+  rotate-feed-token ID    replace the calendar feed token of a user and print it
+`
+
+// User administers the users without the admin API, for the first user of a
+// new deployment.
+func User(args []string) {
+	flags := flag.NewFlagSet("user", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), userUsage) }
+	flags.Parse(args)
+
+	command, args := flags.Arg(0), flags.Args()
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	run, ok := map[string]func(args []string) error{
+		"create":            userCreate,
+		"ls":                userList,
+		"rm":                userDelete,
+		"rotate-feed-token": userRotateFeedToken,
+	}[command]
+	if !ok {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if err := run(args); err != nil {
+		fmt.Fprintf(os.Stderr, "user %s: %s\n", command, err)
+		flags.Usage()
+		os.Exit(2)
+	}
+}
+
+func userCreate(args []string) error {
+	if len(args) != 1 || args[0] == "" {
+		return errors.New("expected a name")
+	}
+	logger, services, closeDB := setupCommand()
+	defer closeDB()
+
+	user, token, err := services.User.Create(args[0])
+	if err != nil {
+		logger.Fatalf("Can't create user: %s", err)
+	}
+	logger.Infof("Created user %d, its token is only shown once", user.Id)
+	fmt.Println(token)
+	return nil
+}
+
+func userList(args []string) error {
+	if len(args) != 0 {
+		return errors.New("unexpected arguments")
+	}
+	logger, services, closeDB := setupCommand()
+	defer closeDB()
+
+	users, err := services.User.List()
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		logger.Fatalf("Can't list users: %s", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tCREATED")
+	for _, user := range users {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", user.Id, user.Name, user.CreatedAt.Time.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+func userDelete(args []string) error {
+	id, err := userId(args)
+	if err != nil {
+		return err
+	}
+	logger, services, closeDB := setupCommand()
+	defer closeDB()
+
+	if err = services.User.DeleteById(id); err != nil {
+		userFatal(logger, id, "Can't delete user", err)
+	}
+	logger.Infof("Deleted user %d", id)
+	return nil
+}
+
+func userRotateFeedToken(args []string) error {
+	id, err := userId(args)
+	if err != nil {
+		return err
+	}
+	logger, services, closeDB := setupCommand()
+	defer closeDB()
+
+	token, err := services.User.RotateFeedToken(id)
+	if err != nil {
+		userFatal(logger, id, "Can't rotate feed token", err)
+	}
+	fmt.Println(token)
+	return nil
+}
+
+func userId(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, errors.New("expected a user id")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid user id %q", args[0])
+	}
+	return id, nil
+}
+
+func userFatal(logger logging.Logger, id int, msg string, err error) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		logger.Fatalf("%s: no user with id %d", msg, id)
+	}
+	logger.Fatalf("%s: %s", msg, err)
+}
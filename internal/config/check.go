@@ -0,0 +1,142 @@
+package config
+
+import (
+	"ToDoVerba/internal/dto"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// redacted replaces the value of a set secret in the output of Redacted.
+const redacted = "[REDACTED]"
+
+var (
+	outboxPublishers = []string{"webhook", "stdout"}
+	reminderChannels = []string{dto.ReminderChannelLog, dto.ReminderChannelWebhook, dto.ReminderChannelEmail}
+)
+
+// Validate checks the values cleanenv can't: enums, ranges and settings
+// that only work together. It reports all problems at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	checkPort := func(name, port string, optional bool) {
+		if port == "" && optional {
+			return
+		}
+		n, err := strconv.Atoi(port)
+		check(err == nil && n > 0 && n <= 65535, "%s must be a port number, got %q", name, port)
+	}
+	checkPositive := func(name string, value any) {
+		switch v := value.(type) {
+		case int:
+			check(v > 0, "%s must be positive, got %d", name, v)
+		case time.Duration:
+			check(v > 0, "%s must be positive, got %s", name, v)
+		}
+	}
+
+	_, err := logrus.ParseLevel(c.Server.LogLevel)
+	check(err == nil, "APP_LOG_LEVEL %q is not a log level", c.Server.LogLevel)
+	checkPort("APP_PORT", c.Server.Port, false)
+	checkPort("APP_GRPC_PORT", c.Server.GrpcPort, true)
+	checkPort("SMTP_PORT", c.SMTP.Port, true)
+
+	switch c.Storage.Driver {
+	case DriverPostgres:
+		// cleanenv can't make them required for one driver only.
+		check(c.Storage.Username != "", "POSTGRES_USER is required by the postgres storage driver")
+		check(c.Storage.Password != "", "POSTGRES_PASSWORD is required by the postgres storage driver")
+		check(c.Storage.Host != "", "POSTGRES_HOST is required by the postgres storage driver")
+		check(c.Storage.Database != "", "POSTGRES_DB is required by the postgres storage driver")
+		checkPort("POSTGRES_PORT", c.Storage.Port, false)
+		checkPositive("POSTGRES_MIGRATION_LOCK_TIMEOUT", c.Storage.MigrationLockTimeout)
+	case DriverSQLite:
+		check(c.Storage.SQLitePath != "", "SQLITE_PATH is required by the sqlite storage driver")
+	case DriverMemory:
+	default:
+		check(false, "STORAGE_DRIVER must be %s, %s or %s, got %q",
+			DriverPostgres, DriverSQLite, DriverMemory, c.Storage.Driver)
+	}
+
+	check(c.Cache.TaskSize >= 0, "CACHE_TASK_SIZE must not be negative")
+	if c.Cache.TaskSize > 0 {
+		checkPositive("CACHE_TASK_TTL", c.Cache.TaskTTL)
+	}
+
+	checkPositive("IDEMPOTENCY_TTL", c.Idempotency.TTL)
+	if !c.API.V1Sunset.IsZero() {
+		check(!c.API.V1Deprecation.IsZero() && c.API.V1Sunset.After(c.API.V1Deprecation),
+			"API_V1_SUNSET needs an earlier API_V1_DEPRECATION")
+	}
+	switch c.API.Validation {
+	case ValidationOff, ValidationLog, ValidationStrict:
+	default:
+		check(false, "API_VALIDATION must be %s, %s or %s, got %q",
+			ValidationOff, ValidationLog, ValidationStrict, c.API.Validation)
+	}
+	checkPositive("WEBHOOK_MAX_ATTEMPTS", c.Webhook.MaxAttempts)
+	checkPositive("WEBHOOK_BACKOFF", c.Webhook.Backoff)
+	checkPositive("WEBHOOK_TIMEOUT", c.Webhook.Timeout)
+	checkPositive("WEBHOOK_POLL_INTERVAL", c.Webhook.PollInterval)
+	checkPositive("OUTBOX_POLL_INTERVAL", c.Outbox.PollInterval)
+	checkPositive("OUTBOX_BATCH_SIZE", c.Outbox.BatchSize)
+	checkPositive("OUTBOX_RETENTION", c.Outbox.Retention)
+	checkPositive("STREAM_REPLAY_SIZE", c.Stream.ReplaySize)
+	checkPositive("STREAM_BUFFER_SIZE", c.Stream.BufferSize)
+	checkPositive("NOTIFY_BACKOFF", c.Notify.Backoff)
+	checkPositive("NOTIFY_MAX_BACKOFF", c.Notify.MaxBackoff)
+	checkPositive("REMINDER_POLL_INTERVAL", c.Reminder.PollInterval)
+	checkPositive("REMINDER_BATCH_SIZE", c.Reminder.BatchSize)
+	checkPositive("REMINDER_MAX_ATTEMPTS", c.Reminder.MaxAttempts)
+	checkPositive("REMINDER_BACKOFF", c.Reminder.Backoff)
+	check(c.GraphQL.MaxDepth >= 0, "GRAPHQL_MAX_DEPTH must not be negative")
+	check(c.GraphQL.MaxComplexity >= 0, "GRAPHQL_MAX_COMPLEXITY must not be negative")
+
+	for _, name := range c.Outbox.Publishers {
+		check(slices.Contains(outboxPublishers, name), "OUTBOX_PUBLISHERS: unknown publisher %q", name)
+	}
+	for _, name := range c.Reminder.Channels {
+		check(slices.Contains(reminderChannels, name), "REMINDER_CHANNELS: unknown channel %q", name)
+	}
+	if slices.Contains(c.Reminder.Channels, dto.ReminderChannelWebhook) {
+		check(c.Reminder.WebhookUrl != "", "REMINDER_CHANNELS: the webhook channel needs REMINDER_WEBHOOK_URL")
+	}
+	if slices.Contains(c.Reminder.Channels, dto.ReminderChannelEmail) {
+		check(c.SMTP.Host != "" && len(c.Reminder.EmailTo) > 0,
+			"REMINDER_CHANNELS: the email channel needs SMTP_HOST and REMINDER_EMAIL_TO")
+	}
+	check(!c.GraphQL.PersistedOnly || c.GraphQL.PersistedQueries != "",
+		"GRAPHQL_PERSISTED_ONLY needs GRAPHQL_PERSISTED_QUERIES")
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of the config with the set secrets replaced, safe
+// to print or log.
+func (c *Config) Redacted() *Config {
+	copied := *c
+	redact(reflect.ValueOf(&copied).Elem())
+	return &copied
+}
+
+func redact(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			redact(field)
+			continue
+		}
+		if _, ok := v.Type().Field(i).Tag.Lookup("secret"); ok && field.String() != "" {
+			field.SetString(redacted)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"os"
 	"sync"
+	"time"
 )
 
 type Config struct {
@@ -13,17 +14,143 @@ type Config struct {
 		LogLevel   string `yaml:"log_level" env:"APP_LOG_LEVEL" env-default:"debug"`
 		EnableSwag bool   `yaml:"enable_swag" env:"APP_ENABLE_SWAG"`
 		Host       string `yaml:"host" env:"APP_HOST" env-default:"localhost"`
+		GrpcPort   string `yaml:"grpc_port" env:"APP_GRPC_PORT"`
 	} `yaml:"server"`
-	Storage Storage `yaml:"storage"`
+	Storage     Storage     `yaml:"storage"`
+	Cache       Cache       `yaml:"cache"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	API         API         `yaml:"api"`
+	Webhook     Webhook     `yaml:"webhook"`
+	Outbox      Outbox      `yaml:"outbox"`
+	Stream      Stream      `yaml:"stream"`
+	Notify      Notify      `yaml:"notify"`
+	Reminder    Reminder    `yaml:"reminder"`
+	SMTP        SMTP        `yaml:"smtp"`
+	Admin       Admin       `yaml:"admin"`
+	GraphQL     GraphQL     `yaml:"graphql"`
 }
 
+// Storage drivers.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
 type Storage struct {
-	Username  string `yaml:"username" env:"POSTGRES_USER" env-required:""`
-	Password  string `yaml:"password" env:"POSTGRES_PASSWORD" env-required:""`
-	Host      string `yaml:"host" env:"POSTGRES_HOST" env-required:""`
-	Port      string `yaml:"port" env:"POSTGRES_PORT" env-required:""`
-	Database  string `yaml:"database" env:"POSTGRES_DB" env-required:""`
+	// Driver is postgres, sqlite for a database file, or memory to keep the
+	// tasks in memory without a database. The POSTGRES_ settings are for
+	// postgres.
+	Driver string `yaml:"driver" env:"STORAGE_DRIVER" env-default:"postgres"`
+	// SQLitePath is the database file of the sqlite driver.
+	SQLitePath string `yaml:"sqlite_path" env:"SQLITE_PATH" env-default:"todoverba.db"`
+	// Snapshot is the file the memory driver loads the tasks from on start
+	// and saves them to on shutdown. They are lost when empty.
+	Snapshot string `yaml:"snapshot" env:"STORAGE_SNAPSHOT"`
+	Username string `yaml:"username" env:"POSTGRES_USER"`
+	Password string `yaml:"password" env:"POSTGRES_PASSWORD" secret:""`
+	Host     string `yaml:"host" env:"POSTGRES_HOST"`
+	Port     string `yaml:"port" env:"POSTGRES_PORT"`
+	Database string `yaml:"database" env:"POSTGRES_DB"`
+	// Migration is the URL of the migrations, the ones embedded in the
+	// binary when empty.
 	Migration string `yaml:"migration" env:"POSTGRES_MIGRATION"`
+	// MigrationLockTimeout bounds the wait for the migrations another server
+	// or job runs.
+	MigrationLockTimeout time.Duration `yaml:"migration_lock_timeout" env:"POSTGRES_MIGRATION_LOCK_TIMEOUT" env-default:"5m"`
+	// AutoMigrate runs the migrations when the server starts. Turn it off
+	// when they run as a separate job with the migrate command.
+	AutoMigrate bool `yaml:"auto_migrate" env:"POSTGRES_AUTO_MIGRATE" env-default:"true"`
+}
+
+// Cache is the cache of tasks by id in front of the postgres driver.
+type Cache struct {
+	// TaskSize is the number of tasks kept, 0 turns the cache off.
+	TaskSize int `yaml:"task_size" env:"CACHE_TASK_SIZE"`
+	// TaskTTL bounds how long a task changed by a replica that missed the
+	// notification is served stale.
+	TaskTTL time.Duration `yaml:"task_ttl" env:"CACHE_TASK_TTL" env-default:"1m"`
+}
+
+type Idempotency struct {
+	// TTL is how long a response is replayed to retries with the same
+	// Idempotency-Key. The memory driver forgets the keys on restart.
+	TTL time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" env-default:"24h"`
+}
+
+// Validation modes of the requests and responses against the OpenAPI document.
+const (
+	ValidationOff    = "off"
+	ValidationLog    = "log"
+	ValidationStrict = "strict"
+)
+
+// API announces the retirement of the v1 routes that v2 replaces, and checks
+// the API against its OpenAPI document.
+type API struct {
+	// V1Deprecation is when they were deprecated, sent in their Deprecation
+	// header. Unset sends no headers.
+	V1Deprecation time.Time `yaml:"v1_deprecation" env:"API_V1_DEPRECATION"`
+	// V1Sunset is when they go away, sent in their Sunset header.
+	V1Sunset time.Time `yaml:"v1_sunset" env:"API_V1_SUNSET"`
+	// Validation is off, log to log the requests and responses that don't
+	// match the OpenAPI document, or strict to reject them.
+	Validation string `yaml:"validation" env:"API_VALIDATION" env-default:"off"`
+}
+
+type Webhook struct {
+	MaxAttempts  int           `yaml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" env-default:"8"`
+	Backoff      time.Duration `yaml:"backoff" env:"WEBHOOK_BACKOFF" env-default:"30s"`
+	Timeout      time.Duration `yaml:"timeout" env:"WEBHOOK_TIMEOUT" env-default:"10s"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"WEBHOOK_POLL_INTERVAL" env-default:"5s"`
+}
+
+type Outbox struct {
+	Publishers   []string      `yaml:"publishers" env:"OUTBOX_PUBLISHERS" env-separator:"," env-default:"webhook"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"OUTBOX_POLL_INTERVAL" env-default:"1s"`
+	BatchSize    int           `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE" env-default:"100"`
+	Retention    time.Duration `yaml:"retention" env:"OUTBOX_RETENTION" env-default:"24h"`
+}
+
+type Stream struct {
+	ReplaySize int `yaml:"replay_size" env:"STREAM_REPLAY_SIZE" env-default:"1000"`
+	BufferSize int `yaml:"buffer_size" env:"STREAM_BUFFER_SIZE" env-default:"64"`
+}
+
+type Notify struct {
+	Enabled    bool          `yaml:"enabled" env:"NOTIFY_ENABLED" env-default:"true"`
+	Backoff    time.Duration `yaml:"backoff" env:"NOTIFY_BACKOFF" env-default:"1s"`
+	MaxBackoff time.Duration `yaml:"max_backoff" env:"NOTIFY_MAX_BACKOFF" env-default:"30s"`
+}
+
+type Reminder struct {
+	Channels      []string      `yaml:"channels" env:"REMINDER_CHANNELS" env-separator:"," env-default:"log"`
+	PollInterval  time.Duration `yaml:"poll_interval" env:"REMINDER_POLL_INTERVAL" env-default:"15s"`
+	BatchSize     int           `yaml:"batch_size" env:"REMINDER_BATCH_SIZE" env-default:"20"`
+	MaxAttempts   int           `yaml:"max_attempts" env:"REMINDER_MAX_ATTEMPTS" env-default:"5"`
+	Backoff       time.Duration `yaml:"backoff" env:"REMINDER_BACKOFF" env-default:"1m"`
+	WebhookUrl    string        `yaml:"webhook_url" env:"REMINDER_WEBHOOK_URL"`
+	WebhookSecret string        `yaml:"webhook_secret" env:"REMINDER_WEBHOOK_SECRET" secret:""`
+	EmailTo       []string      `yaml:"email_to" env:"REMINDER_EMAIL_TO" env-separator:","`
+}
+
+type SMTP struct {
+	Host     string `yaml:"host" env:"SMTP_HOST"`
+	Port     string `yaml:"port" env:"SMTP_PORT" env-default:"587"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD" secret:""`
+	From     string `yaml:"from" env:"SMTP_FROM"`
+}
+
+type Admin struct {
+	Token string `yaml:"token" env:"ADMIN_TOKEN" secret:""`
+}
+
+type GraphQL struct {
+	MaxDepth         int    `yaml:"max_depth" env:"GRAPHQL_MAX_DEPTH" env-default:"8"`
+	MaxComplexity    int    `yaml:"max_complexity" env:"GRAPHQL_MAX_COMPLEXITY" env-default:"5000"`
+	PersistedQueries string `yaml:"persisted_queries" env:"GRAPHQL_PERSISTED_QUERIES"`
+	PersistedOnly    bool   `yaml:"persisted_only" env:"GRAPHQL_PERSISTED_ONLY"`
 }
 
 var once sync.Once
@@ -51,6 +178,7 @@ func GetConfig(logger logging.Logger) *Config {
 			}
 		}
 
+		applyFlags(instance)
 		logger.Info("Successfully read config.")
 	})
 	return instance
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"flag"
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// readEnv reads a config with the required settings and defaults.
+func readEnv(t *testing.T) *Config {
+	for env, value := range map[string]string{
+		"APP_PORT":          "8082",
+		"POSTGRES_USER":     "user1",
+		"POSTGRES_PASSWORD": "1234",
+		"POSTGRES_HOST":     "localhost",
+		"POSTGRES_PORT":     "5435",
+		"POSTGRES_DB":       "dev",
+	} {
+		t.Setenv(env, value)
+	}
+	conf := &Config{}
+	require.NoError(t, cleanenv.ReadEnv(conf))
+	return conf
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		errs   []string
+	}{
+		{
+			name:   "defaults",
+			modify: func(c *Config) {},
+		},
+		{
+			name: "invalid values",
+			modify: func(c *Config) {
+				c.Server.LogLevel = "loud"
+				c.Server.Port = "80a"
+				c.Server.GrpcPort = "70000"
+				c.Outbox.BatchSize = 0
+				c.Webhook.Backoff = -time.Second
+				c.Outbox.Publishers = []string{"webhook", "kafka"}
+			},
+			errs: []string{
+				`APP_LOG_LEVEL "loud" is not a log level`,
+				`APP_PORT must be a port number, got "80a"`,
+				`APP_GRPC_PORT must be a port number, got "70000"`,
+				"OUTBOX_BATCH_SIZE must be positive, got 0",
+				"WEBHOOK_BACKOFF must be positive, got -1s",
+				`OUTBOX_PUBLISHERS: unknown publisher "kafka"`,
+			},
+		},
+		{
+			name: "channels without settings",
+			modify: func(c *Config) {
+				c.Reminder.Channels = []string{"log", "webhook", "email"}
+				c.SMTP.Host = "smtp.example.com"
+			},
+			errs: []string{
+				"REMINDER_CHANNELS: the webhook channel needs REMINDER_WEBHOOK_URL",
+				"REMINDER_CHANNELS: the email channel needs SMTP_HOST and REMINDER_EMAIL_TO",
+			},
+		},
+		{
+			name: "task cache",
+			modify: func(c *Config) {
+				c.Cache.TaskSize = 100
+				c.Cache.TaskTTL = 0
+			},
+			errs: []string{"CACHE_TASK_TTL must be positive, got 0s"},
+		},
+		{
+			name: "postgres without settings",
+			modify: func(c *Config) {
+				c.Storage.Host = ""
+				c.Storage.Port = ""
+			},
+			errs: []string{
+				"POSTGRES_HOST is required by the postgres storage driver",
+				`POSTGRES_PORT must be a port number, got ""`,
+			},
+		},
+		{
+			name: "memory needs no postgres",
+			modify: func(c *Config) {
+				c.Storage = Storage{Driver: DriverMemory}
+			},
+		},
+		{
+			name: "sqlite without path",
+			modify: func(c *Config) {
+				c.Storage = Storage{Driver: DriverSQLite}
+			},
+			errs: []string{"SQLITE_PATH is required by the sqlite storage driver"},
+		},
+		{
+			name: "unknown driver",
+			modify: func(c *Config) {
+				c.Storage.Driver = "mongo"
+			},
+			errs: []string{`STORAGE_DRIVER must be postgres, sqlite or memory, got "mongo"`},
+		},
+		{
+			name: "persisted only without queries",
+			modify: func(c *Config) {
+				c.GraphQL.PersistedOnly = true
+			},
+			errs: []string{"GRAPHQL_PERSISTED_ONLY needs GRAPHQL_PERSISTED_QUERIES"},
+		},
+		{
+			name: "sunset before deprecation",
+			modify: func(c *Config) {
+				c.API.V1Deprecation = time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+				c.API.V1Sunset = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+			},
+			errs: []string{"API_V1_SUNSET needs an earlier API_V1_DEPRECATION"},
+		},
+		{
+			name: "unknown validation mode",
+			modify: func(c *Config) {
+				c.API.Validation = "loud"
+			},
+			errs: []string{`API_VALIDATION must be off, log or strict, got "loud"`},
+		},
+		{
+			name: "sunset without deprecation",
+			modify: func(c *Config) {
+				c.API.V1Sunset = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+			},
+			errs: []string{"API_V1_SUNSET needs an earlier API_V1_DEPRECATION"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := readEnv(t)
+			tt.modify(conf)
+
+			err := conf.Validate()
+			if len(tt.errs) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, msg := range tt.errs {
+				assert.Contains(t, err.Error(), msg)
+			}
+		})
+	}
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	conf := readEnv(t)
+	conf.Admin.Token = "admin-token"
+
+	redactedConf := conf.Redacted()
+	assert.Equal(t, "[REDACTED]", redactedConf.Storage.Password)
+	assert.Equal(t, "[REDACTED]", redactedConf.Admin.Token)
+	assert.Equal(t, "", redactedConf.SMTP.Password, "unset secrets stay empty")
+	assert.Equal(t, "user1", redactedConf.Storage.Username)
+
+	assert.Equal(t, "1234", conf.Storage.Password, "the config itself is unchanged")
+	assert.Equal(t, "admin-token", conf.Admin.Token)
+}
+
+func TestRegisterFlags(t *testing.T) {
+	t.Cleanup(func() { clear(flagValues) })
+	// The flags set the variables too, restore them afterwards.
+	for _, env := range []string{"APP_PORT", "POSTGRES_AUTO_MIGRATE", "OUTBOX_RETENTION", "REMINDER_BATCH_SIZE", "REMINDER_CHANNELS", "API_V1_SUNSET"} {
+		t.Setenv(env, "")
+	}
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(flags)
+	require.NoError(t, flags.Parse([]string{
+		"-app-port", "9090",
+		"-postgres-auto-migrate=false",
+		"-outbox-retention", "2h",
+		"-reminder-batch-size", "7",
+		"-reminder-channels", "log,webhook",
+		"-api-v1-sunset", "2027-05-01T00:00:00Z",
+	}))
+
+	conf := readEnv(t)
+	conf.Server.Port = "8082"
+	applyFlags(conf)
+
+	assert.Equal(t, "9090", conf.Server.Port)
+	assert.False(t, conf.Storage.AutoMigrate)
+	assert.Equal(t, 2*time.Hour, conf.Outbox.Retention)
+	assert.Equal(t, 7, conf.Reminder.BatchSize)
+	assert.Equal(t, []string{"log", "webhook"}, conf.Reminder.Channels)
+	assert.Equal(t, time.Date(2027, 5, 1, 0, 0, 0, 0, time.UTC), conf.API.V1Sunset)
+	assert.Equal(t, "localhost", conf.Storage.Host, "settings without flags are kept")
+
+	err := flags.Parse([]string{"-outbox-batch-size", "many"})
+	assert.Error(t, err, "invalid values are rejected when parsed")
+	err = flags.Parse([]string{"-api-v1-deprecation", "tomorrow"})
+	assert.Error(t, err)
+}
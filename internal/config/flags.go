@@ -0,0 +1,143 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// flagValues holds the settings given as flags by environment variable.
+var flagValues = make(map[string]string)
+
+// RegisterFlags adds a flag for every setting of Config, named after its
+// environment variable: -app-port sets APP_PORT. The flags must be parsed
+// before GetConfig, they take precedence over both the environment and the
+// config file.
+func RegisterFlags(flags *flag.FlagSet) {
+	registerFlags(flags, reflect.TypeOf(Config{}), "")
+}
+
+func registerFlags(flags *flag.FlagSet, t reflect.Type, path string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := path + strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if isSection(field) {
+			registerFlags(flags, field.Type, name+".")
+			continue
+		}
+
+		env := field.Tag.Get("env")
+		if env == "" {
+			continue
+		}
+		usage := "sets " + name + " (" + env + ")"
+		if def, ok := field.Tag.Lookup("env-default"); ok {
+			usage += ", default " + def
+		}
+		flags.Var(&envFlag{env: env, field: field}, flagName(env), usage)
+	}
+}
+
+// isSection tells a struct of settings from a setting of a struct type, such
+// as a time.
+func isSection(field reflect.StructField) bool {
+	return field.Type.Kind() == reflect.Struct && field.Tag.Get("env") == ""
+}
+
+func flagName(env string) string {
+	return strings.ReplaceAll(strings.ToLower(env), "_", "-")
+}
+
+// applyFlags sets the settings given as flags on c. A config file in env
+// format overwrites the environment when it is read, so setting the variable
+// alone is not enough.
+func applyFlags(c *Config) {
+	applyFlagValues(reflect.ValueOf(c).Elem())
+}
+
+func applyFlagValues(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if isSection(field) {
+			applyFlagValues(v.Field(i))
+			continue
+		}
+		if value, ok := flagValues[field.Tag.Get("env")]; ok {
+			// The value was checked by Set.
+			_ = setField(v.Field(i), field, value)
+		}
+	}
+}
+
+func setField(v reflect.Value, field reflect.StructField, value string) error {
+	switch {
+	case field.Type == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case field.Type == reflect.TypeOf(time.Time{}):
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+	case field.Type.Kind() == reflect.String:
+		v.SetString(value)
+	case field.Type.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case field.Type.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.String:
+		var values []string
+		if value != "" {
+			sep := field.Tag.Get("env-separator")
+			if sep == "" {
+				sep = ","
+			}
+			values = strings.Split(value, sep)
+		}
+		v.Set(reflect.ValueOf(values))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type)
+	}
+	return nil
+}
+
+type envFlag struct {
+	env   string
+	field reflect.StructField
+}
+
+func (f *envFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return flagValues[f.env]
+}
+
+func (f *envFlag) Set(value string) error {
+	if err := setField(reflect.New(f.field.Type).Elem(), f.field, value); err != nil {
+		return err
+	}
+	flagValues[f.env] = value
+	// Also set the variable, a required setting may come from a flag alone.
+	return os.Setenv(f.env, value)
+}
+
+func (f *envFlag) IsBoolFlag() bool {
+	return f.field.Type.Kind() == reflect.Bool
+}
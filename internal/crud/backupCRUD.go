@@ -0,0 +1,273 @@
+package crud
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"io"
+	"time"
+)
+
+// BackupCRUD reads and writes tasks together with their events, versions,
+// reminders and caldav objects. Webhooks, the outbox and api users are
+// bound to an environment and are left out.
+type BackupCRUD struct {
+	client Client
+	logger logging.Logger
+}
+
+// Export calls each for every record, tasks first and then the records that
+// refer to them, all read from one snapshot of the database.
+func (c *BackupCRUD) Export(ctx context.Context, each func(record *dto.BackupRecord) error) error {
+	tx, err := c.client.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, `SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY`); err != nil {
+		return err
+	}
+
+	err = exportRows(ctx, tx, `SELECT id, title, description, due_date, completed, created_at, updated_at, version
+		  FROM public.tasks
+		  ORDER BY id`,
+		func(rows pgx.Rows) (*dto.BackupRecord, error) {
+			t := &dto.TaskRead{}
+			err := rows.Scan(&t.Id, &t.Title, &t.Description, &t.DueDate, &t.Completed, &t.CreatedAt, &t.UpdatedAt, &t.Version)
+			return &dto.BackupRecord{Task: t}, err
+		}, each)
+	if err != nil {
+		return err
+	}
+
+	err = exportRows(ctx, tx, `SELECT id, task_id, action, actor, changes, created_at
+		  FROM public.task_events
+		  ORDER BY id`,
+		func(rows pgx.Rows) (*dto.BackupRecord, error) {
+			e := &dto.TaskEvent{}
+			var changes []byte
+			if err := rows.Scan(&e.Id, &e.TaskId, &e.Action, &e.Actor, &changes, &e.CreatedAt); err != nil {
+				return nil, err
+			}
+			err := json.Unmarshal(changes, &e.Changes)
+			return &dto.BackupRecord{TaskEvent: e}, err
+		}, each)
+	if err != nil {
+		return err
+	}
+
+	err = exportRows(ctx, tx, `SELECT task_id, version, title, description, due_date, completed, actor, created_at
+		  FROM public.task_versions
+		  ORDER BY task_id, version`,
+		func(rows pgx.Rows) (*dto.BackupRecord, error) {
+			v := &dto.TaskVersion{}
+			err := rows.Scan(&v.TaskId, &v.Version, &v.Title, &v.Description, &v.DueDate, &v.Completed, &v.Actor, &v.CreatedAt)
+			return &dto.BackupRecord{TaskVersion: v}, err
+		}, each)
+	if err != nil {
+		return err
+	}
+
+	err = exportRows(ctx, tx, `SELECT `+reminderColumns+`
+		  FROM public.task_reminders r
+		  ORDER BY r.id`,
+		func(rows pgx.Rows) (*dto.BackupRecord, error) {
+			r := &dto.Reminder{}
+			var offset int
+			err := rows.Scan(&r.Id, &r.TaskId, &offset, &r.Channels, &r.SentChannels, &r.FireAt, &r.Status,
+				&r.Generation, &r.Attempts, &r.LastError, &r.SentAt, &r.CreatedAt, &r.UpdatedAt)
+			r.Offset = time.Duration(offset) * time.Second
+			return &dto.BackupRecord{Reminder: r}, err
+		}, each)
+	if err != nil {
+		return err
+	}
+
+	return exportRows(ctx, tx, `SELECT task_id, href, uid
+		  FROM public.caldav_objects
+		  ORDER BY task_id`,
+		func(rows pgx.Rows) (*dto.BackupRecord, error) {
+			o := &dto.CalDAVObject{}
+			err := rows.Scan(&o.TaskId, &o.Href, &o.Uid)
+			return &dto.BackupRecord{CalDAVObject: o}, err
+		}, each)
+}
+
+func exportRows(ctx context.Context, tx pgx.Tx, q string, scan func(rows pgx.Rows) (*dto.BackupRecord, error),
+	each func(record *dto.BackupRecord) error) error {
+	rows, err := tx.Query(ctx, q)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record, err := scan(rows)
+		if err != nil {
+			return err
+		}
+		if err = each(record); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Restore inserts the records returned by next until it returns io.EOF, in a
+// single transaction. Every task gets a new id and the records referring to
+// it are remapped; a task id that only appears in references, such as the
+// events of a deleted task, is given a fresh id as well so it can't collide
+// with an existing task. On merge a caldav href that is already taken keeps
+// its current task. Nothing is published, restored tasks don't trigger
+// webhooks or reminders of their own.
+func (c *BackupCRUD) Restore(ctx context.Context, mode string, next func() (*dto.BackupRecord, error)) (*dto.BackupStats, error) {
+	tx, err := c.client.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if mode == dto.BackupModeReplace {
+		// Event ids keep counting up, they serve as caldav sync tokens.
+		q := `TRUNCATE public.task_reminders, public.task_versions, public.task_events, public.caldav_objects, public.tasks`
+		if _, err = tx.Exec(ctx, q); err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make(map[int]int)
+	taskId := func(old int) (int, error) {
+		if id, ok := ids[old]; ok {
+			return id, nil
+		}
+		var id int
+		err := tx.QueryRow(ctx, `SELECT nextval(pg_get_serial_sequence('public.tasks', 'id'))`).Scan(&id)
+		ids[old] = id
+		return id, err
+	}
+
+	stats := &dto.BackupStats{}
+	for {
+		record, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case record.Task != nil:
+			err = restoreTask(ctx, tx, record.Task, taskId)
+			stats.Tasks++
+		case record.TaskEvent != nil:
+			err = restoreTaskEvent(ctx, tx, record.TaskEvent, taskId)
+			stats.TaskEvents++
+		case record.TaskVersion != nil:
+			err = restoreTaskVersion(ctx, tx, record.TaskVersion, taskId)
+			stats.TaskVersions++
+		case record.Reminder != nil:
+			err = restoreReminder(ctx, tx, record.Reminder, taskId)
+			stats.Reminders++
+		case record.CalDAVObject != nil:
+			var restored bool
+			restored, err = restoreCalDAVObject(ctx, tx, record.CalDAVObject, taskId)
+			if restored {
+				stats.CalDAVObjects++
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func restoreTask(ctx context.Context, tx pgx.Tx, task *dto.TaskRead, taskId func(int) (int, error)) error {
+	q := `INSERT INTO public.tasks (id, title, description, due_date, completed, version, created_at, updated_at)
+		  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	id, err := taskId(task.Id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, q, id, task.Title, task.Description, task.DueDate, task.Completed, task.Version,
+		task.CreatedAt, task.UpdatedAt)
+	return err
+}
+
+func restoreTaskEvent(ctx context.Context, tx pgx.Tx, event *dto.TaskEvent, taskId func(int) (int, error)) error {
+	q := `INSERT INTO public.task_events (task_id, action, actor, changes, created_at)
+		  VALUES ($1, $2, $3, $4, $5)`
+
+	id, err := taskId(event.TaskId)
+	if err != nil {
+		return err
+	}
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, q, id, event.Action, event.Actor, changes, event.CreatedAt)
+	return err
+}
+
+func restoreTaskVersion(ctx context.Context, tx pgx.Tx, version *dto.TaskVersion, taskId func(int) (int, error)) error {
+	q := `INSERT INTO public.task_versions (task_id, version, title, description, due_date, completed, actor, created_at)
+		  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	id, err := taskId(version.TaskId)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, q, id, version.Version, version.Title, version.Description, version.DueDate,
+		version.Completed, version.Actor, version.CreatedAt)
+	return err
+}
+
+func restoreReminder(ctx context.Context, tx pgx.Tx, r *dto.Reminder, taskId func(int) (int, error)) error {
+	q := `INSERT INTO public.task_reminders (task_id, offset_seconds, channels, sent_channels, fire_at, status,
+			generation, attempts, last_error, sent_at, created_at, updated_at)
+		  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+	id, err := taskId(r.TaskId)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, q, id, int(r.Offset/time.Second), r.Channels, r.SentChannels, r.FireAt, r.Status,
+		r.Generation, r.Attempts, r.LastError, r.SentAt, r.CreatedAt, r.UpdatedAt)
+	return err
+}
+
+func restoreCalDAVObject(ctx context.Context, tx pgx.Tx, object *dto.CalDAVObject, taskId func(int) (int, error)) (bool, error) {
+	q := `INSERT INTO public.caldav_objects (task_id, href, uid, created_at)
+		  VALUES ($1, $2, $3, $4)
+		  ON CONFLICT (href) DO NOTHING`
+
+	id, err := taskId(object.TaskId)
+	if err != nil {
+		return false, err
+	}
+	tag, err := tx.Exec(ctx, q, id, object.Href, object.Uid, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func NewBackupCRUD(client Client, logger logging.Logger) *BackupCRUD {
+	return &BackupCRUD{
+		client: client,
+		logger: logger,
+	}
+}
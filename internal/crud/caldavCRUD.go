@@ -0,0 +1,127 @@
+package crud
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+// CalDAVCRUD stores client chosen resource names. Rows are kept after their
+// task is deleted so sync reports can still name the removed resource.
+type CalDAVCRUD struct {
+	client Client
+	logger logging.Logger
+}
+
+func (c *CalDAVCRUD) List(ctx context.Context) ([]dto.CalDAVObject, error) {
+	q := `SELECT task_id, href, uid
+		  FROM public.caldav_objects
+		  ORDER BY task_id`
+
+	rows, err := c.client.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objects []dto.CalDAVObject
+
+	for rows.Next() {
+		object := dto.CalDAVObject{}
+		if err := rows.Scan(&object.TaskId, &object.Href, &object.Uid); err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return objects, nil
+}
+
+func (c *CalDAVCRUD) FindByHref(ctx context.Context, href string) (*dto.CalDAVObject, error) {
+	q := `SELECT task_id, href, uid
+		  FROM public.caldav_objects
+		  WHERE href = $1`
+
+	object := &dto.CalDAVObject{}
+
+	err := c.client.QueryRow(ctx, q, href).Scan(&object.TaskId, &object.Href, &object.Uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return object, nil
+}
+
+func (c *CalDAVCRUD) FindByTaskID(ctx context.Context, taskId int) (*dto.CalDAVObject, error) {
+	q := `SELECT task_id, href, uid
+		  FROM public.caldav_objects
+		  WHERE task_id = $1`
+
+	object := &dto.CalDAVObject{}
+
+	err := c.client.QueryRow(ctx, q, taskId).Scan(&object.TaskId, &object.Href, &object.Uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return object, nil
+}
+
+// Create creates the task and names it object.Href in one transaction, and
+// sets object.TaskId. A href left behind by a deleted task is taken over,
+// one of a task returns dto.ErrVersionConflict and creates nothing.
+func (c *CalDAVCRUD) Create(ctx context.Context, cTask *dto.TaskCreate, object *dto.CalDAVObject) (*dto.TaskRead, error) {
+	qRelease := `DELETE FROM public.caldav_objects o
+		  WHERE o.href = $1 AND NOT EXISTS (SELECT 1 FROM public.tasks t WHERE t.id = o.task_id)`
+	qInsert := `INSERT INTO public.caldav_objects (task_id, href, uid, created_at)
+		  VALUES ($1, $2, $3, $4)
+		  ON CONFLICT (href) DO NOTHING
+		  RETURNING task_id`
+
+	tx, err := c.client.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, qRelease, object.Href); err != nil {
+		return nil, err
+	}
+
+	rTask, err := createTask(ctx, tx, cTask)
+	if err != nil {
+		return nil, err
+	}
+
+	// A concurrent create of the href waits for this insert and then finds
+	// the href taken.
+	err = tx.QueryRow(ctx, qInsert, rTask.Id, object.Href, object.Uid, time.Now().UTC()).Scan(&object.TaskId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, dto.ErrVersionConflict
+		}
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return rTask, nil
+}
+
+func NewCalDAVCRUD(client Client, logger logging.Logger) *CalDAVCRUD {
+	return &CalDAVCRUD{
+		client: client,
+		logger: logger,
+	}
+}
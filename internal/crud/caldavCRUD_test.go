@@ -0,0 +1,46 @@
+package crud_test
+
+import (
+	"ToDoVerba/internal/crud"
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCalDAVCRUD_Create(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	_, err := pool.Exec(ctx, `TRUNCATE public.tasks, public.task_events, public.task_versions, public.outbox, public.caldav_objects RESTART IDENTITY CASCADE`)
+	require.NoError(t, err)
+
+	logger := logging.GetLoggerTest()
+	tasks := crud.NewTaskCRUD(pool, logger)
+	objects := crud.NewCalDAVCRUD(pool, logger)
+	cTask := &dto.TaskCreate{Title: "Buy milk", DueDate: pgtype.Timestamptz{Time: time.Now(), Valid: true}}
+
+	object := &dto.CalDAVObject{Href: "a.ics", Uid: "a"}
+	first, err := objects.Create(ctx, cTask, object)
+	require.NoError(t, err)
+	assert.Equal(t, first.Id, object.TaskId)
+
+	// The href of a task is taken, and nothing is created.
+	_, err = objects.Create(ctx, cTask, &dto.CalDAVObject{Href: "a.ics", Uid: "a"})
+	assert.ErrorIs(t, err, dto.ErrVersionConflict)
+	listed, err := tasks.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, listed, 1)
+
+	// The href of a deleted task is taken over.
+	_, err = tasks.DeleteByID(ctx, first.Id, 0, "alice")
+	require.NoError(t, err)
+	second, err := objects.Create(ctx, cTask, &dto.CalDAVObject{Href: "a.ics", Uid: "a"})
+	require.NoError(t, err)
+	found, err := objects.FindByHref(ctx, "a.ics")
+	require.NoError(t, err)
+	assert.Equal(t, second.Id, found.TaskId)
+}
@@ -0,0 +1,100 @@
+package crud
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type IdempotencyCRUD struct {
+	client Client
+	logger logging.Logger
+}
+
+// Claim inserts the key, or takes over an expired or stale claim of it. A
+// concurrent claim of the same key waits for this insert to commit and then
+// finds the key taken.
+func (c *IdempotencyCRUD) Claim(ctx context.Context, claim *dto.IdempotencyClaim) (*dto.IdempotencyRecord, bool, error) {
+	qClaim := `INSERT INTO public.idempotency_keys (key, claim_token, fingerprint, created_at, expires_at)
+		  VALUES ($1, $6, $2, $3, $4)
+		  ON CONFLICT (key) DO UPDATE
+		  SET (claim_token, fingerprint, status, header, body, created_at, expires_at) =
+		      (EXCLUDED.claim_token, EXCLUDED.fingerprint, NULL, NULL, NULL, EXCLUDED.created_at, EXCLUDED.expires_at)
+		  WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+		     OR (idempotency_keys.status IS NULL AND idempotency_keys.created_at < $5)
+		  RETURNING key`
+	qFind := `SELECT key, fingerprint, COALESCE(status, 0), header, body, created_at, expires_at
+		  FROM public.idempotency_keys
+		  WHERE key = $1`
+
+	// The key may be released between the two queries, it is claimed again
+	// then.
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		var key string
+		err = c.client.QueryRow(ctx, qClaim, claim.Key, claim.Fingerprint, time.Now().UTC(), claim.ExpiresAt, claim.StaleBefore,
+			claim.Token).
+			Scan(&key)
+		if err == nil {
+			return nil, true, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, err
+		}
+
+		record := &dto.IdempotencyRecord{}
+		err = c.client.QueryRow(ctx, qFind, claim.Key).
+			Scan(&record.Key, &record.Fingerprint, &record.Status, &record.Header, &record.Body, &record.CreatedAt, &record.ExpiresAt)
+		if err == nil {
+			return record, false, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, err
+		}
+	}
+
+	return nil, false, err
+}
+
+// Complete stores the response of the request that claimed the key, unless
+// its claim was taken over.
+func (c *IdempotencyCRUD) Complete(ctx context.Context, record *dto.IdempotencyRecord) error {
+	q := `UPDATE public.idempotency_keys
+		  SET (status, header, body) = ($3, $4, $5)
+		  WHERE key = $1 AND claim_token = $2 AND status IS NULL
+		  RETURNING key`
+
+	var key string
+	return c.client.QueryRow(ctx, q, record.Key, record.Token, record.Status, record.Header, record.Body).Scan(&key)
+}
+
+// Release deletes a claim still in progress, so the key can be retried. A
+// claim taken over in the meantime is left alone.
+func (c *IdempotencyCRUD) Release(ctx context.Context, key, token string) error {
+	q := `DELETE FROM public.idempotency_keys
+		  WHERE key = $1 AND claim_token = $2 AND status IS NULL`
+
+	_, err := c.client.Exec(ctx, q, key, token)
+	return err
+}
+
+func (c *IdempotencyCRUD) DeleteExpired(ctx context.Context) (int64, error) {
+	q := `DELETE FROM public.idempotency_keys
+		  WHERE expires_at <= $1`
+
+	tag, err := c.client.Exec(ctx, q, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func NewIdempotencyCRUD(client Client, logger logging.Logger) *IdempotencyCRUD {
+	return &IdempotencyCRUD{
+		client: client,
+		logger: logger,
+	}
+}
@@ -0,0 +1,106 @@
+package crud
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	TaskChangesChannel = "task_changes"
+
+	// maxNotifyPayload stays below the 8000 bytes Postgres accepts for a
+	// NOTIFY payload.
+	maxNotifyPayload = 7900
+)
+
+// nodeId tells the notifications of this process apart from the ones of other
+// replicas.
+var nodeId = func() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}()
+
+type taskChange struct {
+	Node  string    `json:"node"`
+	Event dto.Event `json:"event"`
+}
+
+type NotifyCRUD struct {
+	client Client
+	logger logging.Logger
+}
+
+// Listen holds a dedicated connection listening on TaskChangesChannel and
+// passes task changes made by other replicas to handle. It returns when ctx
+// is cancelled or the connection fails; the caller is expected to reconnect.
+// onListen is called once the connection is listening.
+func (c *NotifyCRUD) Listen(ctx context.Context, onListen func(), handle func(event *dto.Event)) error {
+	pooled, err := c.client.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The connection is taken out of the pool: it stays in LISTEN mode for
+	// its whole life and must not serve other queries.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "LISTEN "+TaskChangesChannel); err != nil {
+		return err
+	}
+	onListen()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		change := taskChange{}
+		if err = json.Unmarshal([]byte(n.Payload), &change); err != nil {
+			c.logger.Errorf("crud error on decode task change notification: %s", err)
+			continue
+		}
+		if change.Node == nodeId {
+			continue
+		}
+		handle(&change.Event)
+	}
+}
+
+// notifyTaskChanges sends the events to the listeners of all replicas. A task
+// that does not fit into the payload is left out; listeners reload it.
+func notifyTaskChanges(ctx context.Context, tx pgx.Tx, events []dto.Event) error {
+	q := `SELECT pg_notify($1, $2)`
+
+	for _, event := range events {
+		payload, err := json.Marshal(taskChange{Node: nodeId, Event: event})
+		if err != nil {
+			return err
+		}
+		if len(payload) > maxNotifyPayload {
+			event.Task = nil
+			if payload, err = json.Marshal(taskChange{Node: nodeId, Event: event}); err != nil {
+				return err
+			}
+		}
+
+		if _, err = tx.Exec(ctx, q, TaskChangesChannel, string(payload)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func NewNotifyCRUD(client Client, logger logging.Logger) *NotifyCRUD {
+	return &NotifyCRUD{
+		client: client,
+		logger: logger,
+	}
+}
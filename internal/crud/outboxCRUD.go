@@ -0,0 +1,161 @@
+package crud
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/jackc/pgx/v5"
+	"strconv"
+	"time"
+)
+
+type OutboxCRUD struct {
+	client Client
+	logger logging.Logger
+}
+
+// ProcessBatch locks up to limit unpublished messages and hands them to handle
+// in insertion order. Messages for which handle succeeds are marked published
+// in the same transaction; the others are retried by a later batch.
+//
+// Rows are claimed with FOR UPDATE SKIP LOCKED so several relays can run at
+// once. The per-key advisory lock keeps all pending messages of one ordering
+// key in a single relay, so messages of a key are never published out of order.
+func (c *OutboxCRUD) ProcessBatch(ctx context.Context, limit int, handle func(msg *dto.OutboxMessage) error) (int, error) {
+	q := `SELECT id, payload, created_at
+		  FROM public.outbox
+		  WHERE published_at IS NULL AND pg_try_advisory_xact_lock(hashtext(ordering_key))
+		  ORDER BY id
+		  LIMIT $1
+		  FOR UPDATE SKIP LOCKED`
+	qPublished := `UPDATE public.outbox SET published_at = $2 WHERE id = ANY($1)`
+
+	tx, err := c.client.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, q, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var messages []dto.OutboxMessage
+	for rows.Next() {
+		msg := dto.OutboxMessage{}
+		var payload []byte
+		if err = rows.Scan(&msg.Id, &payload, &msg.CreatedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if err = json.Unmarshal(payload, &msg.Event); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		messages = append(messages, msg)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	published := make([]int64, 0, len(messages))
+	for i := range messages {
+		if err := handle(&messages[i]); err != nil {
+			continue
+		}
+		published = append(published, messages[i].Id)
+	}
+
+	if len(published) > 0 {
+		_, err = tx.Exec(ctx, qPublished, published, time.Now().UTC())
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(published), tx.Commit(ctx)
+}
+
+func (c *OutboxCRUD) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	q := `DELETE FROM public.outbox WHERE published_at < $1`
+
+	tag, err := c.client.Exec(ctx, q, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// insertOutboxEvents stores lifecycle events of the task for the relay. It must
+// be called with the transaction that performed the task mutation.
+func insertOutboxEvents(ctx context.Context, tx pgx.Tx, events []dto.Event) error {
+	q := `INSERT INTO public.outbox (event_id, event_type, ordering_key, payload, created_at)
+		  VALUES ($1, $2, $3, $4, $5)`
+
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, q, event.Id, event.Type, event.OrderingKey, payload, event.OccurredAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newTaskEvents builds one event per type for a task mutation.
+func newTaskEvents(taskId int, task *dto.TaskRead, actor string, eventTypes ...string) ([]dto.Event, error) {
+	if actor == "" {
+		actor = AnonymousActor
+	}
+
+	events := make([]dto.Event, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return nil, err
+		}
+		events = append(events, dto.Event{
+			Id:          hex.EncodeToString(id),
+			Type:        eventType,
+			TaskId:      taskId,
+			Actor:       actor,
+			Task:        task,
+			OrderingKey: "task:" + strconv.Itoa(taskId),
+			OccurredAt:  time.Now().UTC(),
+		})
+	}
+
+	return events, nil
+}
+
+// publishTaskEvents writes the events of a task mutation to the outbox and
+// notifies the listeners of other replicas. Both take effect on commit only.
+func publishTaskEvents(ctx context.Context, tx pgx.Tx, taskId int, task *dto.TaskRead, actor string, eventTypes ...string) error {
+	events, err := newTaskEvents(taskId, task, actor, eventTypes...)
+	if err != nil {
+		return err
+	}
+
+	if err = insertOutboxEvents(ctx, tx, events); err != nil {
+		return err
+	}
+
+	return notifyTaskChanges(ctx, tx, events)
+}
+
+func NewOutboxCRUD(client Client, logger logging.Logger) *OutboxCRUD {
+	return &OutboxCRUD{
+		client: client,
+		logger: logger,
+	}
+}
@@ -0,0 +1,220 @@
+package crud
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"time"
+)
+
+const reminderColumns = `r.id, r.task_id, r.offset_seconds, r.channels, r.sent_channels, r.fire_at, r.status,
+			r.generation, r.attempts, r.last_error, r.sent_at, r.created_at, r.updated_at`
+
+type ReminderCRUD struct {
+	client Client
+	logger logging.Logger
+}
+
+// Create schedules the reminder relative to the current due date of the task.
+// It returns pgx.ErrNoRows if the task does not exist.
+func (c *ReminderCRUD) Create(ctx context.Context, cReminder *dto.ReminderCreate) (*dto.Reminder, error) {
+	q := `INSERT INTO public.task_reminders AS r (task_id, offset_seconds, channels, fire_at, status, created_at, updated_at)
+		  SELECT t.id, $2::int, $3, t.due_date - make_interval(secs => $2::int), $4, $5, $5
+		  FROM public.tasks t
+		  WHERE t.id = $1
+		  ON CONFLICT (task_id, offset_seconds) DO UPDATE
+		  SET (channels, sent_channels, fire_at, status, generation, attempts, last_error, sent_at, claimed_until, updated_at) =
+		      (EXCLUDED.channels, '{}', EXCLUDED.fire_at, EXCLUDED.status, r.generation + 1, 0, '', NULL, NULL, EXCLUDED.updated_at)
+		  RETURNING ` + reminderColumns
+
+	rows, err := c.client.Query(ctx, q, cReminder.TaskId, int(cReminder.Offset/time.Second), cReminder.Channels,
+		dto.ReminderPending, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanReminder(rows)
+}
+
+func (c *ReminderCRUD) ListByTaskID(ctx context.Context, taskId int) ([]dto.Reminder, error) {
+	q := `SELECT ` + reminderColumns + `
+		  FROM public.task_reminders r
+		  WHERE r.task_id = $1
+		  ORDER BY r.fire_at, r.id`
+
+	rows, err := c.client.Query(ctx, q, taskId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders, err := scanReminders(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(reminders) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return reminders, nil
+}
+
+// ListByTaskIDs returns the reminders of all the tasks. Tasks without
+// reminders are simply missing from the result.
+func (c *ReminderCRUD) ListByTaskIDs(ctx context.Context, taskIds []int) ([]dto.Reminder, error) {
+	q := `SELECT ` + reminderColumns + `
+		  FROM public.task_reminders r
+		  WHERE r.task_id = ANY($1)
+		  ORDER BY r.fire_at, r.id`
+
+	rows, err := c.client.Query(ctx, q, taskIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanReminders(rows)
+}
+
+// Snooze moves the reminder to until and arms it again if it was already sent.
+func (c *ReminderCRUD) Snooze(ctx context.Context, taskId int, id int64, until time.Time) (*dto.Reminder, error) {
+	q := `UPDATE public.task_reminders r
+		  SET (fire_at, status, sent_channels, generation, attempts, last_error, sent_at, claimed_until, updated_at) =
+		      ($3, $4, '{}', r.generation + 1, 0, '', NULL, NULL, $5)
+		  WHERE r.task_id = $1 AND r.id = $2
+		  RETURNING ` + reminderColumns
+
+	rows, err := c.client.Query(ctx, q, taskId, id, until.UTC(), dto.ReminderPending, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanReminder(rows)
+}
+
+func (c *ReminderCRUD) DeleteByID(ctx context.Context, taskId int, id int64) (int64, error) {
+	q := `DELETE FROM public.task_reminders WHERE task_id = $1 AND id = $2 RETURNING id`
+
+	err := c.client.QueryRow(ctx, q, taskId, id).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ProcessDue dispatches up to limit pending reminders due at now, one at a
+// time: each is claimed right before dispatch gets it, and dispatch's update
+// is stored. The claim is a statement of its own, so no row stays locked
+// while dispatch sends. It holds the reminder for claimFor, so claimFor must
+// outlast one dispatch, and bumps the generation and the attempts of the
+// reminder. An update is only stored if the generation is still the claimed
+// one: a reminder snoozed, rescheduled or claimed again in the meantime keeps
+// its new state. Reminders of completed tasks are left alone. The status is
+// inlined so the planner can use the partial index on fire_at.
+func (c *ReminderCRUD) ProcessDue(ctx context.Context, now time.Time, limit int, claimFor time.Duration, dispatch func(r *dto.Reminder) *dto.ReminderUpdate) (int, error) {
+	qClaim := `WITH due AS (
+			  SELECT r.id
+			  FROM public.task_reminders r
+			  JOIN public.tasks t ON t.id = r.task_id
+			  WHERE r.status = 'pending' AND r.fire_at <= $1 AND NOT t.completed
+			    AND (r.claimed_until IS NULL OR r.claimed_until <= $2)
+			  ORDER BY r.fire_at
+			  LIMIT 1
+			  FOR UPDATE OF r SKIP LOCKED
+		  )
+		  UPDATE public.task_reminders r
+		  SET (generation, attempts, claimed_until) = (r.generation + 1, r.attempts + 1, $3)
+		  FROM due, public.tasks t
+		  WHERE r.id = due.id AND t.id = r.task_id
+		  RETURNING ` + reminderColumns + `,
+			     t.id, t.title, t.description, t.due_date, t.completed, t.created_at, t.updated_at, t.version`
+	qUpdate := `UPDATE public.task_reminders
+		  SET (status, sent_channels, fire_at, attempts, last_error, sent_at, claimed_until, updated_at) =
+		      ($3, $4, $5, $6, $7, $8, NULL, $9)
+		  WHERE id = $1 AND generation = $2`
+
+	n := 0
+	for ; n < limit; n++ {
+		claimedAt := time.Now().UTC()
+		r := dto.Reminder{Task: &dto.TaskRead{}}
+		var offset int
+		err := c.client.QueryRow(ctx, qClaim, now.UTC(), claimedAt, claimedAt.Add(claimFor)).
+			Scan(&r.Id, &r.TaskId, &offset, &r.Channels, &r.SentChannels, &r.FireAt, &r.Status,
+				&r.Generation, &r.Attempts, &r.LastError, &r.SentAt, &r.CreatedAt, &r.UpdatedAt,
+				&r.Task.Id, &r.Task.Title, &r.Task.Description, &r.Task.DueDate, &r.Task.Completed,
+				&r.Task.CreatedAt, &r.Task.UpdatedAt, &r.Task.Version)
+		if errors.Is(err, pgx.ErrNoRows) {
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+		r.Offset = time.Duration(offset) * time.Second
+
+		u := dispatch(&r)
+		tag, err := c.client.Exec(ctx, qUpdate, u.Id, r.Generation, u.Status, u.SentChannels, u.FireAt,
+			u.Attempts, u.LastError, u.SentAt, time.Now().UTC())
+		if err != nil {
+			return n, err
+		}
+		if tag.RowsAffected() == 0 {
+			c.logger.Debugf("reminder %d changed while it was dispatched, its update is dropped", u.Id)
+		}
+	}
+
+	return n, nil
+}
+
+// rescheduleReminders moves the pending and sent reminders of the task to the
+// new due date. It must be called with the transaction that changed it.
+func rescheduleReminders(ctx context.Context, tx pgx.Tx, taskId int, dueDate pgtype.Timestamptz) error {
+	q := `UPDATE public.task_reminders
+		  SET (fire_at, status, sent_channels, generation, attempts, last_error, sent_at, claimed_until, updated_at) =
+		      ($2 - make_interval(secs => offset_seconds), $3, '{}', generation + 1, 0, '', NULL, NULL, $4)
+		  WHERE task_id = $1`
+
+	_, err := tx.Exec(ctx, q, taskId, dueDate, dto.ReminderPending, time.Now().UTC())
+	return err
+}
+
+func scanReminder(rows pgx.Rows) (*dto.Reminder, error) {
+	reminders, err := scanReminders(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(reminders) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &reminders[0], nil
+}
+
+func scanReminders(rows pgx.Rows) ([]dto.Reminder, error) {
+	var reminders []dto.Reminder
+
+	for rows.Next() {
+		r := dto.Reminder{}
+		var offset int
+		err := rows.Scan(&r.Id, &r.TaskId, &offset, &r.Channels, &r.SentChannels, &r.FireAt, &r.Status,
+			&r.Generation, &r.Attempts, &r.LastError, &r.SentAt, &r.CreatedAt, &r.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		r.Offset = time.Duration(offset) * time.Second
+		reminders = append(reminders, r)
+	}
+
+	return reminders, rows.Err()
+}
+
+func NewReminderCRUD(client Client, logger logging.Logger) *ReminderCRUD {
+	return &ReminderCRUD{
+		client: client,
+		logger: logger,
+	}
+}
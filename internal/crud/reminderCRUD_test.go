@@ -0,0 +1,75 @@
+package crud_test
+
+import (
+	"ToDoVerba/internal/crud"
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A batch that takes longer than one claim doesn't lose its later reminders
+// to a concurrent ProcessDue: each reminder is claimed right before its own
+// dispatch.
+func TestReminderCRUD_ProcessDue_concurrent(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	_, err := pool.Exec(ctx, `TRUNCATE public.tasks, public.task_events, public.task_versions, public.outbox RESTART IDENTITY CASCADE`)
+	require.NoError(t, err)
+
+	logger := logging.GetLoggerTest()
+	rTask, err := crud.NewTaskCRUD(pool, logger).Create(ctx, &dto.TaskCreate{
+		Title:   "Ship it",
+		DueDate: pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+	reminders := crud.NewReminderCRUD(pool, logger)
+	for _, offset := range []time.Duration{0, time.Minute} {
+		_, err = reminders.Create(ctx, &dto.ReminderCreate{TaskId: rTask.Id, Offset: offset, Channels: []string{dto.ReminderChannelLog}})
+		require.NoError(t, err)
+	}
+
+	const claimFor, dispatchTime = 300 * time.Millisecond, 200 * time.Millisecond
+	var mu sync.Mutex
+	dispatched := map[int64]int{}
+	dispatch := func(r *dto.Reminder) *dto.ReminderUpdate {
+		time.Sleep(dispatchTime)
+		mu.Lock()
+		dispatched[r.Id]++
+		mu.Unlock()
+		return &dto.ReminderUpdate{Id: r.Id, Status: dto.ReminderSent, SentChannels: r.Channels, FireAt: r.FireAt,
+			Attempts: r.Attempts, SentAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		n, err := reminders.ProcessDue(ctx, time.Now(), 10, claimFor, dispatch)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+	}()
+
+	// The batch runs for twice dispatchTime, past the claimFor of a claim made
+	// when it started.
+	time.Sleep(claimFor + dispatchTime/4)
+	n, err := reminders.ProcessDue(ctx, time.Now(), 10, claimFor, dispatch)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	wg.Wait()
+
+	assert.Len(t, dispatched, 2)
+	for id, count := range dispatched {
+		assert.Equal(t, 1, count, "reminder %d", id)
+	}
+	listed, err := reminders.ListByTaskID(ctx, rTask.Id)
+	require.NoError(t, err)
+	for _, r := range listed {
+		assert.Equal(t, dto.ReminderSent, r.Status)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"ToDoVerba/internal/dto"
 	"ToDoVerba/pkg/logging"
 	"context"
+	"errors"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"time"
@@ -15,9 +16,54 @@ type TaskCRUD struct {
 }
 
 func (c *TaskCRUD) Create(ctx context.Context, cTask *dto.TaskCreate) (*dto.TaskRead, error) {
-	q := `INSERT INTO public.tasks (title, description, due_date, created_at, updated_at) 
-		  VALUES ($1, $2, $3, $4, $5) 
-          RETURNING id, title, description, due_date, created_at, updated_at`
+	tx, err := c.client.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rTask, err := createTask(ctx, tx, cTask)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return rTask, nil
+}
+
+// CreateMany inserts the tasks in a single transaction, so either all of
+// them are created or none.
+func (c *TaskCRUD) CreateMany(ctx context.Context, cTasks []dto.TaskCreate) ([]dto.TaskRead, error) {
+	tx, err := c.client.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tasks := make([]dto.TaskRead, 0, len(cTasks))
+	for i := range cTasks {
+		rTask, err := createTask(ctx, tx, &cTasks[i])
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *rTask)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// createTask inserts a task with its history, first version and events.
+func createTask(ctx context.Context, tx pgx.Tx, cTask *dto.TaskCreate) (*dto.TaskRead, error) {
+	q := `INSERT INTO public.tasks (title, description, due_date, completed, created_at, updated_at) 
+		  VALUES ($1, $2, $3, $4, $5, $6) 
+          RETURNING id, title, description, due_date, completed, created_at, updated_at, version`
 
 	curTime := pgtype.Timestamptz{
 		Time:             time.Now().UTC(),
@@ -26,8 +72,23 @@ func (c *TaskCRUD) Create(ctx context.Context, cTask *dto.TaskCreate) (*dto.Task
 	}
 	rTask := &dto.TaskRead{}
 
-	err := c.client.QueryRow(ctx, q, cTask.Title, cTask.Description, cTask.DueDate, curTime, curTime).
-		Scan(&rTask.Id, &rTask.Title, &rTask.Description, &rTask.DueDate, &rTask.CreatedAt, &rTask.UpdatedAt)
+	err := tx.QueryRow(ctx, q, cTask.Title, cTask.Description, cTask.DueDate, cTask.Completed, curTime, curTime).
+		Scan(&rTask.Id, &rTask.Title, &rTask.Description, &rTask.DueDate, &rTask.Completed, &rTask.CreatedAt, &rTask.UpdatedAt, &rTask.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertTaskEvent(ctx, tx, rTask.Id, dto.TaskEventCreate, cTask.Actor, TaskDiff(nil, rTask))
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertTaskVersion(ctx, tx, rTask, cTask.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	err = publishTaskEvents(ctx, tx, rTask.Id, rTask, cTask.Actor, dto.EventTaskCreated)
 	if err != nil {
 		return nil, err
 	}
@@ -36,14 +97,14 @@ func (c *TaskCRUD) Create(ctx context.Context, cTask *dto.TaskCreate) (*dto.Task
 }
 
 func (c *TaskCRUD) FindById(ctx context.Context, id int) (*dto.TaskRead, error) {
-	q := `SELECT id, title, description, due_date, created_at, updated_at 
+	q := `SELECT id, title, description, due_date, completed, created_at, updated_at, version 
 		  FROM public.tasks 
 		  WHERE id = $1`
 
 	rTask := &dto.TaskRead{}
 
 	err := c.client.QueryRow(ctx, q, id).
-		Scan(&rTask.Id, &rTask.Title, &rTask.Description, &rTask.DueDate, &rTask.CreatedAt, &rTask.UpdatedAt)
+		Scan(&rTask.Id, &rTask.Title, &rTask.Description, &rTask.DueDate, &rTask.Completed, &rTask.CreatedAt, &rTask.UpdatedAt, &rTask.Version)
 	if err != nil {
 		return nil, err
 	}
@@ -52,63 +113,178 @@ func (c *TaskCRUD) FindById(ctx context.Context, id int) (*dto.TaskRead, error)
 }
 
 func (c *TaskCRUD) List(ctx context.Context) ([]dto.TaskRead, error) {
-	q := `SELECT id, title, description, due_date, created_at, updated_at 
-		  FROM public.tasks`
+	q := `SELECT id, title, description, due_date, completed, created_at, updated_at, version 
+		  FROM public.tasks 
+		  ORDER BY id`
 
 	rows, err := c.client.Query(ctx, q)
-	defer rows.Close()
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	var tasks []dto.TaskRead
 
 	for rows.Next() {
 		rTask := dto.TaskRead{}
-		err := rows.Scan(&rTask.Id, &rTask.Title, &rTask.Description, &rTask.DueDate, &rTask.CreatedAt, &rTask.UpdatedAt)
+		err := rows.Scan(&rTask.Id, &rTask.Title, &rTask.Description, &rTask.DueDate, &rTask.Completed, &rTask.CreatedAt, &rTask.UpdatedAt, &rTask.Version)
 		if err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, rTask)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
 	if len(tasks) == 0 {
 		return nil, pgx.ErrNoRows
 	}
 
-	return tasks, err
+	return tasks, nil
 }
 
-func (c *TaskCRUD) UpdateByID(ctx context.Context, id int, update *dto.TaskUpdate) (*dto.TaskRead, error) {
-	q := `UPDATE public.tasks 
-		  SET (title, description, due_date, updated_at) = ($2, $3, $4, $5) 
+// Stream calls each for every task in id order while reading the rows, so
+// the list is never held in memory. An error of each stops the stream.
+func (c *TaskCRUD) Stream(ctx context.Context, each func(task *dto.TaskRead) error) error {
+	q := `SELECT id, title, description, due_date, completed, created_at, updated_at, version 
+		  FROM public.tasks 
+		  ORDER BY id`
+
+	rows, err := c.client.Query(ctx, q)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rTask := dto.TaskRead{}
+		err := rows.Scan(&rTask.Id, &rTask.Title, &rTask.Description, &rTask.DueDate, &rTask.Completed, &rTask.CreatedAt, &rTask.UpdatedAt, &rTask.Version)
+		if err != nil {
+			return err
+		}
+		if err = each(&rTask); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (c *TaskCRUD) UpdateByID(ctx context.Context, id int, update *dto.TaskUpdate) (*dto.TaskRead, []string, error) {
+	qSelect := `SELECT id, title, description, due_date, completed, created_at, updated_at, version 
+		  FROM public.tasks 
 		  WHERE id = $1 
-		  RETURNING id, title, description, due_date, created_at, updated_at`
+		  FOR UPDATE`
+	q := `UPDATE public.tasks 
+		  SET (title, description, due_date, completed, updated_at, version) = ($2, $3, $4, $5, $6, version + 1) 
+		  WHERE id = $1 AND ($7 = 0 OR version = $7)
+		  RETURNING id, title, description, due_date, completed, created_at, updated_at, version`
 
 	curTime := pgtype.Timestamptz{
 		Time:             time.Now().UTC(),
 		InfinityModifier: 0,
 		Valid:            true,
 	}
+	oldTask := &dto.TaskRead{}
 	rTask := &dto.TaskRead{}
 
-	err := c.client.QueryRow(ctx, q, id, update.Title, update.Description, update.DueDate, curTime).
-		Scan(&rTask.Id, &rTask.Title, &rTask.Description, &rTask.DueDate, &rTask.CreatedAt, &rTask.UpdatedAt)
+	tx, err := c.client.Begin(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	defer tx.Rollback(ctx)
 
-	return rTask, nil
+	err = tx.QueryRow(ctx, qSelect, id).
+		Scan(&oldTask.Id, &oldTask.Title, &oldTask.Description, &oldTask.DueDate, &oldTask.Completed, &oldTask.CreatedAt, &oldTask.UpdatedAt, &oldTask.Version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The task is locked, a missing row is another version.
+	err = tx.QueryRow(ctx, q, id, update.Title, update.Description, update.DueDate, update.Completed, curTime, update.Version).
+		Scan(&rTask.Id, &rTask.Title, &rTask.Description, &rTask.DueDate, &rTask.Completed, &rTask.CreatedAt, &rTask.UpdatedAt, &rTask.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, dto.ErrVersionConflict
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = insertTaskEvent(ctx, tx, rTask.Id, dto.TaskEventUpdate, update.Actor, TaskDiff(oldTask, rTask))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = insertTaskVersion(ctx, tx, rTask, update.Actor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !rTask.DueDate.Time.Equal(oldTask.DueDate.Time) {
+		err = rescheduleReminders(ctx, tx, rTask.Id, rTask.DueDate)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	eventTypes := dto.TaskUpdateEvents(oldTask, rTask)
+	err = publishTaskEvents(ctx, tx, rTask.Id, rTask, update.Actor, eventTypes...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	return rTask, eventTypes, nil
 }
 
-func (c *TaskCRUD) DeleteByID(ctx context.Context, id int) (int, error) {
-	q := `DELETE FROM public.tasks WHERE id = $1 RETURNING id`
+func (c *TaskCRUD) DeleteByID(ctx context.Context, id, version int, actor string) (int, error) {
+	q := `DELETE FROM public.tasks 
+		  WHERE id = $1 AND ($2 = 0 OR version = $2)
+		  RETURNING id, title, description, due_date, completed, created_at, updated_at, version`
+	qExists := `SELECT EXISTS (SELECT 1 FROM public.tasks WHERE id = $1)`
+
+	oldTask := &dto.TaskRead{}
+
+	tx, err := c.client.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, q, id, version).
+		Scan(&oldTask.Id, &oldTask.Title, &oldTask.Description, &oldTask.DueDate, &oldTask.Completed, &oldTask.CreatedAt, &oldTask.UpdatedAt, &oldTask.Version)
+	if errors.Is(err, pgx.ErrNoRows) && version != 0 {
+		var exists bool
+		if err = tx.QueryRow(ctx, qExists, id).Scan(&exists); err != nil {
+			return 0, err
+		}
+		if exists {
+			return 0, dto.ErrVersionConflict
+		}
+		return 0, pgx.ErrNoRows
+	}
+	if err != nil {
+		return 0, err
+	}
 
-	err := c.client.QueryRow(ctx, q, id).Scan(&id)
+	err = insertTaskEvent(ctx, tx, oldTask.Id, dto.TaskEventDelete, actor, TaskDiff(oldTask, nil))
 	if err != nil {
 		return 0, err
 	}
 
-	return id, nil
+	err = publishTaskEvents(ctx, tx, oldTask.Id, nil, actor, dto.EventTaskDeleted)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return oldTask.Id, nil
 }
 
 func NewTaskCRUD(client Client, logger logging.Logger) *TaskCRUD {
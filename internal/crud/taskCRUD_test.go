@@ -0,0 +1,61 @@
+package crud_test
+
+import (
+	"ToDoVerba/internal/crud"
+	"ToDoVerba/internal/repos"
+	"ToDoVerba/internal/repos/repostest"
+	"ToDoVerba/migration"
+	"ToDoVerba/pkg/logging"
+	"ToDoVerba/pkg/migrator"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+	"os"
+	"strconv"
+	"testing"
+)
+
+// dsnEnv names the Postgres database the tests run against. The tests
+// migrate it and truncate its tables, never point it at real data.
+const dsnEnv = "TODOVERBA_TEST_POSTGRES_DSN"
+
+func testPool(t *testing.T) *pgxpool.Pool {
+	dsn := os.Getenv(dsnEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", dsnEnv)
+	}
+
+	conf, err := pgx.ParseConfig(dsn)
+	require.NoError(t, err)
+	m, err := migrator.NewMigrator(migrator.Deps{
+		Username: conf.User,
+		Password: conf.Password,
+		Host:     conf.Host,
+		Port:     strconv.Itoa(int(conf.Port)),
+		Database: conf.Database,
+		Files:    migration.FS,
+	})
+	require.NoError(t, err)
+	defer m.Close()
+	if err = m.Up(); err != nil && !errors.Is(err, migrator.ErrNoChange) {
+		require.NoError(t, err)
+	}
+
+	pool, err := pgxpool.New(context.Background(), dsn)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestTaskCRUD_conformance(t *testing.T) {
+	pool := testPool(t)
+
+	repostest.TestTaskRepository(t, func(t *testing.T) repos.TaskRepository {
+		_, err := pool.Exec(context.Background(),
+			`TRUNCATE public.tasks, public.task_events, public.task_versions, public.outbox RESTART IDENTITY CASCADE`)
+		require.NoError(t, err)
+		return crud.NewTaskCRUD(pool, logging.GetLoggerTest())
+	})
+}
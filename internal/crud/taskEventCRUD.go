@@ -0,0 +1,192 @@
+package crud
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"strings"
+	"time"
+)
+
+// AnonymousActor is recorded for changes made without an actor.
+const AnonymousActor = "anonymous"
+
+type TaskEventCRUD struct {
+	client Client
+	logger logging.Logger
+}
+
+func (c *TaskEventCRUD) ListByTaskID(ctx context.Context, taskId int) ([]dto.TaskEvent, error) {
+	q := `SELECT id, task_id, action, actor, changes, created_at
+		  FROM public.task_events
+		  WHERE task_id = $1
+		  ORDER BY id`
+
+	rows, err := c.client.Query(ctx, q, taskId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events, err := scanTaskEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return events, nil
+}
+
+// ListByTaskIDs returns the events of all the tasks ordered by id. Tasks
+// without events are simply missing from the result.
+func (c *TaskEventCRUD) ListByTaskIDs(ctx context.Context, taskIds []int) ([]dto.TaskEvent, error) {
+	q := `SELECT id, task_id, action, actor, changes, created_at
+		  FROM public.task_events
+		  WHERE task_id = ANY($1)
+		  ORDER BY id`
+
+	rows, err := c.client.Query(ctx, q, taskIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTaskEvents(rows)
+}
+
+func (c *TaskEventCRUD) List(ctx context.Context, filter *dto.TaskEventFilter) ([]dto.TaskEvent, error) {
+	var conds []string
+	var args []any
+	addCond := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.TaskId != 0 {
+		addCond("task_id = $%d", filter.TaskId)
+	}
+	if filter.Actor != "" {
+		addCond("actor = $%d", filter.Actor)
+	}
+	if filter.Action != "" {
+		addCond("action = $%d", filter.Action)
+	}
+	if filter.From.Valid {
+		addCond("created_at >= $%d", filter.From)
+	}
+	if filter.To.Valid {
+		addCond("created_at < $%d", filter.To)
+	}
+	if filter.AfterId != 0 {
+		addCond("id > $%d", filter.AfterId)
+	}
+
+	q := `SELECT id, task_id, action, actor, changes, created_at
+		  FROM public.task_events`
+	if len(conds) > 0 {
+		q += " WHERE " + strings.Join(conds, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	q += fmt.Sprintf(" ORDER BY id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := c.client.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events, err := scanTaskEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return events, nil
+}
+
+// LatestID returns the id of the last recorded event, 0 when there is none.
+func (c *TaskEventCRUD) LatestID(ctx context.Context) (int64, error) {
+	q := `SELECT COALESCE(MAX(id), 0) FROM public.task_events`
+
+	var id int64
+	err := c.client.QueryRow(ctx, q).Scan(&id)
+	return id, err
+}
+
+func scanTaskEvents(rows pgx.Rows) ([]dto.TaskEvent, error) {
+	var events []dto.TaskEvent
+
+	for rows.Next() {
+		event := dto.TaskEvent{}
+		var changes []byte
+		err := rows.Scan(&event.Id, &event.TaskId, &event.Action, &event.Actor, &changes, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(changes, &event.Changes); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// insertTaskEvent records a task mutation in the audit trail. It must be called
+// with the transaction that performed the mutation.
+func insertTaskEvent(ctx context.Context, tx pgx.Tx, taskId int, action, actor string, changes map[string]dto.FieldChange) error {
+	q := `INSERT INTO public.task_events (task_id, action, actor, changes, created_at)
+		  VALUES ($1, $2, $3, $4, $5)`
+
+	if actor == "" {
+		actor = AnonymousActor
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, q, taskId, action, actor, changesJSON, time.Now().UTC())
+	return err
+}
+
+// TaskDiff returns the user-editable fields that differ between before and after.
+// A nil before means the task was created, a nil after means it was deleted.
+// Other storages record their history with it too, so it reads the same.
+func TaskDiff(before, after *dto.TaskRead) map[string]dto.FieldChange {
+	fields := func(t *dto.TaskRead) map[string]any {
+		if t == nil {
+			return map[string]any{"title": nil, "description": nil, "due_date": nil, "completed": nil}
+		}
+		return map[string]any{
+			"title":       t.Title,
+			"description": t.Description,
+			"due_date":    t.DueDate.Time.UTC().Format(time.RFC3339),
+			"completed":   t.Completed,
+		}
+	}
+
+	b, a := fields(before), fields(after)
+	changes := make(map[string]dto.FieldChange)
+	for name := range b {
+		if b[name] != a[name] {
+			changes[name] = dto.FieldChange{Before: b[name], After: a[name]}
+		}
+	}
+
+	return changes
+}
+
+func NewTaskEventCRUD(client Client, logger logging.Logger) *TaskEventCRUD {
+	return &TaskEventCRUD{
+		client: client,
+		logger: logger,
+	}
+}
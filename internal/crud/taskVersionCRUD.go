@@ -0,0 +1,84 @@
+package crud
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"github.com/jackc/pgx/v5"
+)
+
+type TaskVersionCRUD struct {
+	client Client
+	logger logging.Logger
+}
+
+func (c *TaskVersionCRUD) ListByTaskID(ctx context.Context, taskId int) ([]dto.TaskVersion, error) {
+	q := `SELECT task_id, version, title, description, due_date, completed, actor, created_at
+		  FROM public.task_versions
+		  WHERE task_id = $1
+		  ORDER BY version`
+
+	rows, err := c.client.Query(ctx, q, taskId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []dto.TaskVersion
+
+	for rows.Next() {
+		rVersion := dto.TaskVersion{}
+		err := rows.Scan(&rVersion.TaskId, &rVersion.Version, &rVersion.Title, &rVersion.Description,
+			&rVersion.DueDate, &rVersion.Completed, &rVersion.Actor, &rVersion.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, rVersion)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return versions, nil
+}
+
+func (c *TaskVersionCRUD) FindByVersion(ctx context.Context, taskId, version int) (*dto.TaskVersion, error) {
+	q := `SELECT task_id, version, title, description, due_date, completed, actor, created_at
+		  FROM public.task_versions
+		  WHERE task_id = $1 AND version = $2`
+
+	rVersion := &dto.TaskVersion{}
+
+	err := c.client.QueryRow(ctx, q, taskId, version).
+		Scan(&rVersion.TaskId, &rVersion.Version, &rVersion.Title, &rVersion.Description,
+			&rVersion.DueDate, &rVersion.Completed, &rVersion.Actor, &rVersion.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return rVersion, nil
+}
+
+// insertTaskVersion stores an immutable snapshot of the task as it is after a
+// mutation. It must be called with the transaction that performed the mutation.
+func insertTaskVersion(ctx context.Context, tx pgx.Tx, task *dto.TaskRead, actor string) error {
+	q := `INSERT INTO public.task_versions (task_id, version, title, description, due_date, completed, actor, created_at)
+		  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	if actor == "" {
+		actor = AnonymousActor
+	}
+
+	_, err := tx.Exec(ctx, q, task.Id, task.Version, task.Title, task.Description, task.DueDate, task.Completed, actor, task.UpdatedAt)
+	return err
+}
+
+func NewTaskVersionCRUD(client Client, logger logging.Logger) *TaskVersionCRUD {
+	return &TaskVersionCRUD{
+		client: client,
+		logger: logger,
+	}
+}
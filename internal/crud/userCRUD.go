@@ -0,0 +1,114 @@
+package crud
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type UserCRUD struct {
+	client Client
+	logger logging.Logger
+}
+
+func (c *UserCRUD) Create(ctx context.Context, cUser *dto.UserCreate) (*dto.UserRead, error) {
+	q := `INSERT INTO public.api_users (name, token_hash, created_at)
+		  VALUES ($1, $2, $3)
+		  RETURNING id, name, created_at`
+
+	rUser := &dto.UserRead{}
+
+	err := c.client.QueryRow(ctx, q, cUser.Name, cUser.TokenHash, time.Now().UTC()).
+		Scan(&rUser.Id, &rUser.Name, &rUser.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return rUser, nil
+}
+
+func (c *UserCRUD) FindByTokenHash(ctx context.Context, tokenHash string) (*dto.UserRead, error) {
+	q := `SELECT id, name, created_at
+		  FROM public.api_users
+		  WHERE token_hash = $1`
+
+	rUser := &dto.UserRead{}
+
+	err := c.client.QueryRow(ctx, q, tokenHash).Scan(&rUser.Id, &rUser.Name, &rUser.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return rUser, nil
+}
+
+func (c *UserCRUD) FindByFeedTokenHash(ctx context.Context, tokenHash string) (*dto.UserRead, error) {
+	q := `SELECT id, name, created_at
+		  FROM public.api_users
+		  WHERE feed_token_hash = $1`
+
+	rUser := &dto.UserRead{}
+
+	err := c.client.QueryRow(ctx, q, tokenHash).Scan(&rUser.Id, &rUser.Name, &rUser.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return rUser, nil
+}
+
+func (c *UserCRUD) SetFeedTokenHash(ctx context.Context, id int, tokenHash string) error {
+	q := `UPDATE public.api_users SET feed_token_hash = $2 WHERE id = $1 RETURNING id`
+
+	return c.client.QueryRow(ctx, q, id, tokenHash).Scan(&id)
+}
+
+func (c *UserCRUD) List(ctx context.Context) ([]dto.UserRead, error) {
+	q := `SELECT id, name, created_at
+		  FROM public.api_users
+		  ORDER BY id`
+
+	rows, err := c.client.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []dto.UserRead
+
+	for rows.Next() {
+		rUser := dto.UserRead{}
+		if err := rows.Scan(&rUser.Id, &rUser.Name, &rUser.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, rUser)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return users, nil
+}
+
+func (c *UserCRUD) DeleteByID(ctx context.Context, id int) (int, error) {
+	q := `DELETE FROM public.api_users WHERE id = $1 RETURNING id`
+
+	err := c.client.QueryRow(ctx, q, id).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func NewUserCRUD(client Client, logger logging.Logger) *UserCRUD {
+	return &UserCRUD{
+		client: client,
+		logger: logger,
+	}
+}
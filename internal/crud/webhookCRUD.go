@@ -0,0 +1,240 @@
+package crud
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"time"
+)
+
+type WebhookCRUD struct {
+	client Client
+	logger logging.Logger
+}
+
+func (c *WebhookCRUD) Create(ctx context.Context, cWebhook *dto.WebhookCreate) (*dto.WebhookRead, error) {
+	q := `INSERT INTO public.webhooks (url, secret, events, active, created_at, updated_at)
+		  VALUES ($1, $2, $3, $4, $5, $6)
+		  RETURNING id, url, secret, events, active, created_at, updated_at`
+
+	curTime := pgtype.Timestamptz{
+		Time:             time.Now().UTC(),
+		InfinityModifier: 0,
+		Valid:            true,
+	}
+	rWebhook := &dto.WebhookRead{}
+
+	err := c.client.QueryRow(ctx, q, cWebhook.Url, cWebhook.Secret, cWebhook.Events, cWebhook.Active, curTime, curTime).
+		Scan(&rWebhook.Id, &rWebhook.Url, &rWebhook.Secret, &rWebhook.Events, &rWebhook.Active, &rWebhook.CreatedAt, &rWebhook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return rWebhook, nil
+}
+
+func (c *WebhookCRUD) FindById(ctx context.Context, id int) (*dto.WebhookRead, error) {
+	q := `SELECT id, url, secret, events, active, created_at, updated_at
+		  FROM public.webhooks
+		  WHERE id = $1`
+
+	rWebhook := &dto.WebhookRead{}
+
+	err := c.client.QueryRow(ctx, q, id).
+		Scan(&rWebhook.Id, &rWebhook.Url, &rWebhook.Secret, &rWebhook.Events, &rWebhook.Active, &rWebhook.CreatedAt, &rWebhook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return rWebhook, nil
+}
+
+func (c *WebhookCRUD) List(ctx context.Context) ([]dto.WebhookRead, error) {
+	q := `SELECT id, url, secret, events, active, created_at, updated_at
+		  FROM public.webhooks
+		  ORDER BY id`
+
+	return c.queryWebhooks(ctx, q)
+}
+
+// ListActiveByEvent returns active subscriptions for the event type. A
+// subscription without event filters receives every event.
+func (c *WebhookCRUD) ListActiveByEvent(ctx context.Context, event string) ([]dto.WebhookRead, error) {
+	q := `SELECT id, url, secret, events, active, created_at, updated_at
+		  FROM public.webhooks
+		  WHERE active AND (cardinality(events) = 0 OR $1 = ANY(events))
+		  ORDER BY id`
+
+	return c.queryWebhooks(ctx, q, event)
+}
+
+func (c *WebhookCRUD) queryWebhooks(ctx context.Context, q string, args ...any) ([]dto.WebhookRead, error) {
+	rows, err := c.client.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []dto.WebhookRead
+
+	for rows.Next() {
+		rWebhook := dto.WebhookRead{}
+		err := rows.Scan(&rWebhook.Id, &rWebhook.Url, &rWebhook.Secret, &rWebhook.Events, &rWebhook.Active, &rWebhook.CreatedAt, &rWebhook.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, rWebhook)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(webhooks) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return webhooks, nil
+}
+
+func (c *WebhookCRUD) UpdateByID(ctx context.Context, id int, update *dto.WebhookUpdate) (*dto.WebhookRead, error) {
+	q := `UPDATE public.webhooks
+		  SET (url, events, active, updated_at) = ($2, $3, $4, $5)
+		  WHERE id = $1
+		  RETURNING id, url, secret, events, active, created_at, updated_at`
+
+	curTime := pgtype.Timestamptz{
+		Time:             time.Now().UTC(),
+		InfinityModifier: 0,
+		Valid:            true,
+	}
+	rWebhook := &dto.WebhookRead{}
+
+	err := c.client.QueryRow(ctx, q, id, update.Url, update.Events, update.Active, curTime).
+		Scan(&rWebhook.Id, &rWebhook.Url, &rWebhook.Secret, &rWebhook.Events, &rWebhook.Active, &rWebhook.CreatedAt, &rWebhook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return rWebhook, nil
+}
+
+func (c *WebhookCRUD) DeleteByID(ctx context.Context, id int) (int, error) {
+	q := `DELETE FROM public.webhooks WHERE id = $1 RETURNING id`
+
+	err := c.client.QueryRow(ctx, q, id).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (c *WebhookCRUD) CreateDeliveries(ctx context.Context, deliveries []dto.WebhookDeliveryCreate) error {
+	q := `INSERT INTO public.webhook_deliveries (webhook_id, event_id, event, payload, status, next_attempt_at, created_at, updated_at)
+		  VALUES ($1, $2, $3, $4, $5, $6, $6, $6)`
+
+	curTime := time.Now().UTC()
+
+	tx, err := c.client.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, d := range deliveries {
+		_, err = tx.Exec(ctx, q, d.WebhookId, d.EventId, d.Event, d.Payload, dto.DeliveryPending, curTime)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ClaimDueDeliveries leases up to limit pending deliveries whose attempt is due.
+// A claimed delivery is hidden from other workers until the lease expires, so a
+// crashed worker's deliveries are picked up again.
+func (c *WebhookCRUD) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]dto.WebhookDelivery, error) {
+	q := `UPDATE public.webhook_deliveries d
+		  SET next_attempt_at = $2
+		  FROM public.webhooks w
+		  WHERE w.id = d.webhook_id AND d.id IN (
+		      SELECT id FROM public.webhook_deliveries
+		      WHERE status = $3 AND next_attempt_at <= $1
+		      ORDER BY next_attempt_at
+		      LIMIT $4
+		      FOR UPDATE SKIP LOCKED
+		  )
+		  RETURNING d.id, d.webhook_id, w.url, w.secret, d.event_id, d.event, d.payload, d.status,
+		            d.attempts, d.next_attempt_at, d.last_error, d.response_code, d.created_at, d.updated_at`
+
+	curTime := time.Now().UTC()
+
+	rows, err := c.client.Query(ctx, q, curTime, curTime.Add(lease), dto.DeliveryPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanWebhookDeliveries(rows)
+}
+
+func (c *WebhookCRUD) UpdateDelivery(ctx context.Context, update *dto.WebhookDeliveryUpdate) error {
+	q := `UPDATE public.webhook_deliveries
+		  SET (status, attempts, next_attempt_at, last_error, response_code, updated_at) = ($2, $3, $4, $5, $6, $7)
+		  WHERE id = $1`
+
+	_, err := c.client.Exec(ctx, q, update.Id, update.Status, update.Attempts, update.NextAttemptAt,
+		update.LastError, update.ResponseCode, time.Now().UTC())
+	return err
+}
+
+func (c *WebhookCRUD) ListDeliveries(ctx context.Context, webhookId, offset, limit int) ([]dto.WebhookDelivery, error) {
+	q := `SELECT d.id, d.webhook_id, w.url, w.secret, d.event_id, d.event, d.payload, d.status,
+		         d.attempts, d.next_attempt_at, d.last_error, d.response_code, d.created_at, d.updated_at
+		  FROM public.webhook_deliveries d
+		  JOIN public.webhooks w ON w.id = d.webhook_id
+		  WHERE d.webhook_id = $1
+		  ORDER BY d.id DESC
+		  LIMIT $2 OFFSET $3`
+
+	rows, err := c.client.Query(ctx, q, webhookId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return deliveries, nil
+}
+
+func scanWebhookDeliveries(rows pgx.Rows) ([]dto.WebhookDelivery, error) {
+	var deliveries []dto.WebhookDelivery
+
+	for rows.Next() {
+		d := dto.WebhookDelivery{}
+		err := rows.Scan(&d.Id, &d.WebhookId, &d.Url, &d.Secret, &d.EventId, &d.Event, &d.Payload, &d.Status,
+			&d.Attempts, &d.NextAttemptAt, &d.LastError, &d.ResponseCode, &d.CreatedAt, &d.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func NewWebhookCRUD(client Client, logger logging.Logger) *WebhookCRUD {
+	return &WebhookCRUD{
+		client: client,
+		logger: logger,
+	}
+}
@@ -0,0 +1,33 @@
+package dto
+
+const (
+	// BackupFormat and BackupVersion identify a backup. The version grows
+	// whenever the records change in a way older restores can't read.
+	BackupFormat  = "todoverba-backup"
+	BackupVersion = 1
+
+	// BackupModeMerge adds the backup to the existing data, BackupModeReplace
+	// deletes the existing tasks and their related data first.
+	BackupModeMerge   = "merge"
+	BackupModeReplace = "replace"
+)
+
+// BackupRecord holds one row of a backup, exactly one field is set. Ids are
+// the ones of the backed up database; a restore gives every task a new id
+// and remaps the records that refer to it.
+type BackupRecord struct {
+	Task         *TaskRead
+	TaskEvent    *TaskEvent
+	TaskVersion  *TaskVersion
+	Reminder     *Reminder
+	CalDAVObject *CalDAVObject
+}
+
+// BackupStats counts the records of a backup or restore.
+type BackupStats struct {
+	Tasks         int
+	TaskEvents    int
+	TaskVersions  int
+	Reminders     int
+	CalDAVObjects int
+}
@@ -0,0 +1,23 @@
+package dto
+
+// CalDAVObject is the resource name and UID a CalDAV client chose for a task.
+// Tasks created through other APIs have none and use the default ones.
+type CalDAVObject struct {
+	TaskId int
+	Href   string
+	Uid    string
+}
+
+// CalDAVResource is a task as a calendar object resource.
+type CalDAVResource struct {
+	Href string
+	Uid  string
+	Task TaskRead
+}
+
+// CalDAVChanges lists the resources changed and removed since a sync token.
+type CalDAVChanges struct {
+	Changed   []CalDAVResource
+	Removed   []string
+	SyncToken int64
+}
@@ -0,0 +1,41 @@
+package dto
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+	"time"
+)
+
+const (
+	EventTaskCreated   = "task.created"
+	EventTaskUpdated   = "task.updated"
+	EventTaskCompleted = "task.completed"
+	EventTaskDeleted   = "task.deleted"
+)
+
+// TaskUpdateEvents are the event types of an update from old to updated:
+// task.updated, followed by task.completed when the update completed the task.
+func TaskUpdateEvents(old, updated *TaskRead) []string {
+	eventTypes := []string{EventTaskUpdated}
+	if updated.Completed && !old.Completed {
+		eventTypes = append(eventTypes, EventTaskCompleted)
+	}
+	return eventTypes
+}
+
+// Event is a task lifecycle notification. Task is nil for deleted tasks.
+// Events sharing an OrderingKey are published in the order they happened.
+type Event struct {
+	Id          string
+	Type        string
+	TaskId      int
+	Actor       string
+	Task        *TaskRead
+	OrderingKey string
+	OccurredAt  time.Time
+}
+
+type OutboxMessage struct {
+	Id        int64
+	Event     Event
+	CreatedAt pgtype.Timestamptz
+}
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+	"net/http"
+)
+
+// IdempotencyClaim reserves a key for a request. An earlier claim of the key
+// is taken over once it expired, or when it is still in progress but was
+// made before StaleBefore, as its request is assumed lost. Token tells the
+// claims of a key apart, so a request whose claim was taken over can't
+// complete or release the new one.
+type IdempotencyClaim struct {
+	Key         string
+	Token       string
+	Fingerprint string
+	ExpiresAt   pgtype.Timestamptz
+	StaleBefore pgtype.Timestamptz
+}
+
+// IdempotencyRecord is the response of the request that claimed Key. Status
+// is 0 while that request is in progress. Token is the token of its claim.
+type IdempotencyRecord struct {
+	Key         string
+	Token       string
+	Fingerprint string
+	Status      int
+	Header      http.Header
+	Body        []byte
+	CreatedAt   pgtype.Timestamptz
+	ExpiresAt   pgtype.Timestamptz
+}
@@ -0,0 +1,55 @@
+package dto
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+	"time"
+)
+
+const (
+	ReminderPending = "pending"
+	ReminderSent    = "sent"
+	ReminderFailed  = "failed"
+
+	ReminderChannelEmail   = "email"
+	ReminderChannelWebhook = "webhook"
+	ReminderChannelLog     = "log"
+)
+
+// ReminderCreate schedules a reminder Offset before the due date of the task.
+type ReminderCreate struct {
+	TaskId   int
+	Offset   time.Duration
+	Channels []string
+}
+
+// Reminder fires at FireAt through every channel not yet in SentChannels.
+// Generation grows whenever the reminder is snoozed, rescheduled or claimed
+// for dispatch. Attempts counts the claims since it was last armed, so
+// Generation minus Attempts tells separate firings apart. Task is filled in
+// when the reminder is claimed for dispatch.
+type Reminder struct {
+	Id           int64
+	TaskId       int
+	Offset       time.Duration
+	Channels     []string
+	SentChannels []string
+	FireAt       pgtype.Timestamptz
+	Status       string
+	Generation   int
+	Attempts     int
+	LastError    string
+	SentAt       pgtype.Timestamptz
+	CreatedAt    pgtype.Timestamptz
+	UpdatedAt    pgtype.Timestamptz
+	Task         *TaskRead
+}
+
+type ReminderUpdate struct {
+	Id           int64
+	Status       string
+	SentChannels []string
+	FireAt       pgtype.Timestamptz
+	Attempts     int
+	LastError    string
+	SentAt       pgtype.Timestamptz
+}
@@ -0,0 +1,14 @@
+package dto
+
+// StreamMessage is an event as seen by live subscribers. Id is what clients
+// send back in Last-Event-ID to resume.
+type StreamMessage struct {
+	Id    string
+	Event Event
+}
+
+type StreamFilter struct {
+	Actor  string
+	TaskId int
+	Types  []string
+}
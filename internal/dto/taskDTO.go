@@ -1,11 +1,20 @@
 package dto
 
-import "github.com/jackc/pgx/v5/pgtype"
+import (
+	"errors"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrVersionConflict is returned by a write conditional on a version of the
+// task when the task is at another one.
+var ErrVersionConflict = errors.New("task was changed in the meantime")
 
 type TaskCreate struct {
 	Title       string
 	Description string
 	DueDate     pgtype.Timestamptz
+	Completed   bool
+	Actor       string
 }
 
 type TaskRead struct {
@@ -13,12 +22,19 @@ type TaskRead struct {
 	Title       string
 	Description string
 	DueDate     pgtype.Timestamptz
+	Completed   bool
 	CreatedAt   pgtype.Timestamptz
 	UpdatedAt   pgtype.Timestamptz
+	Version     int
 }
 
+// TaskUpdate replaces the fields of a task. When Version is set, the update
+// only applies to that version of the task.
 type TaskUpdate struct {
 	Title       string
 	Description string
 	DueDate     pgtype.Timestamptz
+	Completed   bool
+	Actor       string
+	Version     int
 }
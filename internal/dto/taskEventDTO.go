@@ -0,0 +1,34 @@
+package dto
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+const (
+	TaskEventCreate = "create"
+	TaskEventUpdate = "update"
+	TaskEventDelete = "delete"
+)
+
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+type TaskEvent struct {
+	Id        int64
+	TaskId    int
+	Action    string
+	Actor     string
+	Changes   map[string]FieldChange
+	CreatedAt pgtype.Timestamptz
+}
+
+type TaskEventFilter struct {
+	TaskId  int
+	Actor   string
+	Action  string
+	From    pgtype.Timestamptz
+	To      pgtype.Timestamptz
+	AfterId int64
+	Offset  int
+	Limit   int
+}
@@ -0,0 +1,14 @@
+package dto
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type TaskVersion struct {
+	TaskId      int
+	Version     int
+	Title       string
+	Description string
+	DueDate     pgtype.Timestamptz
+	Completed   bool
+	Actor       string
+	CreatedAt   pgtype.Timestamptz
+}
@@ -0,0 +1,14 @@
+package dto
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type UserCreate struct {
+	Name      string
+	TokenHash string
+}
+
+type UserRead struct {
+	Id        int
+	Name      string
+	CreatedAt pgtype.Timestamptz
+}
@@ -0,0 +1,67 @@
+package dto
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+type WebhookCreate struct {
+	Url    string
+	Secret string
+	Events []string
+	Active bool
+}
+
+type WebhookRead struct {
+	Id        int
+	Url       string
+	Secret    string
+	Events    []string
+	Active    bool
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+}
+
+type WebhookUpdate struct {
+	Url    string
+	Events []string
+	Active bool
+}
+
+type WebhookDeliveryCreate struct {
+	WebhookId int
+	EventId   string
+	Event     string
+	Payload   []byte
+}
+
+// WebhookDelivery is a single event sent to a single subscription. Url and
+// Secret are copied from the subscription when the delivery is claimed.
+type WebhookDelivery struct {
+	Id            int64
+	WebhookId     int
+	Url           string
+	Secret        string
+	EventId       string
+	Event         string
+	Payload       []byte
+	Status        string
+	Attempts      int
+	NextAttemptAt pgtype.Timestamptz
+	LastError     string
+	ResponseCode  int
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+}
+
+type WebhookDeliveryUpdate struct {
+	Id            int64
+	Status        string
+	Attempts      int
+	NextAttemptAt pgtype.Timestamptz
+	LastError     string
+	ResponseCode  int
+}
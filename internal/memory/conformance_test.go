@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"ToDoVerba/internal/memory"
+	"ToDoVerba/internal/repos"
+	"ToDoVerba/internal/repos/repostest"
+	"ToDoVerba/pkg/logging"
+	"testing"
+)
+
+func TestTaskMemory_conformance(t *testing.T) {
+	repostest.TestTaskRepository(t, func(t *testing.T) repos.TaskRepository {
+		return memory.NewTaskMemory(logging.GetLoggerTest())
+	})
+}
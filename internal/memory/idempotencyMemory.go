@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"github.com/jackc/pgx/v5"
+	"sync"
+	"time"
+)
+
+// IdempotencyMemory keeps idempotency keys in memory with the semantics of
+// crud.IdempotencyCRUD. The keys live as long as the process, like the tasks
+// of the memory driver.
+type IdempotencyMemory struct {
+	mu      sync.Mutex
+	records map[string]dto.IdempotencyRecord
+	logger  logging.Logger
+}
+
+func (m *IdempotencyMemory) Claim(ctx context.Context, claim *dto.IdempotencyClaim) (*dto.IdempotencyRecord, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	curTime := now()
+	if record, ok := m.records[claim.Key]; ok {
+		expired := !record.ExpiresAt.Time.After(curTime.Time)
+		stale := record.Status == 0 && record.CreatedAt.Time.Before(claim.StaleBefore.Time)
+		if !expired && !stale {
+			return &record, false, nil
+		}
+	}
+
+	m.records[claim.Key] = dto.IdempotencyRecord{
+		Key:         claim.Key,
+		Token:       claim.Token,
+		Fingerprint: claim.Fingerprint,
+		CreatedAt:   curTime,
+		ExpiresAt:   timestamptz(claim.ExpiresAt),
+	}
+	return nil, true, nil
+}
+
+func (m *IdempotencyMemory) Complete(ctx context.Context, completed *dto.IdempotencyRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.records[completed.Key]
+	if !ok || record.Token != completed.Token || record.Status != 0 {
+		return pgx.ErrNoRows
+	}
+	record.Status = completed.Status
+	record.Header = completed.Header.Clone()
+	record.Body = append([]byte(nil), completed.Body...)
+	m.records[completed.Key] = record
+	return nil
+}
+
+func (m *IdempotencyMemory) Release(ctx context.Context, key, token string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if record, ok := m.records[key]; ok && record.Token == token && record.Status == 0 {
+		delete(m.records, key)
+	}
+	return nil
+}
+
+func (m *IdempotencyMemory) DeleteExpired(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	curTime := time.Now()
+	var deleted int64
+	for key, record := range m.records {
+		if !record.ExpiresAt.Time.After(curTime) {
+			delete(m.records, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func NewIdempotencyMemory(logger logging.Logger) *IdempotencyMemory {
+	return &IdempotencyMemory{
+		records: make(map[string]dto.IdempotencyRecord),
+		logger:  logger,
+	}
+}
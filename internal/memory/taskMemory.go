@@ -0,0 +1,290 @@
+package memory
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// errNoDueDate stands for the NOT NULL constraint of tasks.due_date.
+var errNoDueDate = errors.New("task due date is required")
+
+// TaskMemory keeps the tasks in memory with the semantics of crud.TaskCRUD:
+// ids of a sequence that never reuses them, UTC timestamps in microseconds
+// like timestamptz, a version starting at 1 and pgx.ErrNoRows for a missing
+// task or an empty list. It keeps no history, versions or outbox.
+type TaskMemory struct {
+	mu     sync.RWMutex
+	tasks  map[int]dto.TaskRead
+	lastId int
+	logger logging.Logger
+}
+
+func (m *TaskMemory) Create(ctx context.Context, cTask *dto.TaskCreate) (*dto.TaskRead, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if !cTask.DueDate.Valid {
+		return nil, errNoDueDate
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rTask := m.insert(cTask, now())
+	return &rTask, nil
+}
+
+// CreateMany inserts the tasks at once, with the same timestamp like the
+// single transaction of TaskCRUD.
+func (m *TaskMemory) CreateMany(ctx context.Context, cTasks []dto.TaskCreate) ([]dto.TaskRead, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range cTasks {
+		if !cTasks[i].DueDate.Valid {
+			return nil, errNoDueDate
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	curTime := now()
+	tasks := make([]dto.TaskRead, 0, len(cTasks))
+	for i := range cTasks {
+		tasks = append(tasks, m.insert(&cTasks[i], curTime))
+	}
+	return tasks, nil
+}
+
+func (m *TaskMemory) insert(cTask *dto.TaskCreate, curTime pgtype.Timestamptz) dto.TaskRead {
+	m.lastId++
+	rTask := dto.TaskRead{
+		Id:          m.lastId,
+		Title:       cTask.Title,
+		Description: cTask.Description,
+		DueDate:     timestamptz(cTask.DueDate),
+		Completed:   cTask.Completed,
+		CreatedAt:   curTime,
+		UpdatedAt:   curTime,
+		Version:     1,
+	}
+	m.tasks[rTask.Id] = rTask
+	return rTask
+}
+
+func (m *TaskMemory) FindById(ctx context.Context, id int) (*dto.TaskRead, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rTask, ok := m.tasks[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &rTask, nil
+}
+
+func (m *TaskMemory) List(ctx context.Context) ([]dto.TaskRead, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	tasks, _ := m.sorted()
+	if len(tasks) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return tasks, nil
+}
+
+// Stream calls each for every task in id order. It works on a copy, so each
+// may change the tasks.
+func (m *TaskMemory) Stream(ctx context.Context, each func(task *dto.TaskRead) error) error {
+	tasks, _ := m.sorted()
+	for _, rTask := range tasks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := each(&rTask); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sorted returns a copy of the tasks in id order and the last id.
+func (m *TaskMemory) sorted() ([]dto.TaskRead, int) {
+	m.mu.RLock()
+	tasks := make([]dto.TaskRead, 0, len(m.tasks))
+	for _, rTask := range m.tasks {
+		tasks = append(tasks, rTask)
+	}
+	lastId := m.lastId
+	m.mu.RUnlock()
+
+	slices.SortFunc(tasks, func(a, b dto.TaskRead) int { return a.Id - b.Id })
+	return tasks, lastId
+}
+
+func (m *TaskMemory) UpdateByID(ctx context.Context, id int, update *dto.TaskUpdate) (*dto.TaskRead, []string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldTask, ok := m.tasks[id]
+	if !ok {
+		return nil, nil, pgx.ErrNoRows
+	}
+	if update.Version != 0 && update.Version != oldTask.Version {
+		return nil, nil, dto.ErrVersionConflict
+	}
+	if !update.DueDate.Valid {
+		return nil, nil, errNoDueDate
+	}
+	rTask := oldTask
+	rTask.Title = update.Title
+	rTask.Description = update.Description
+	rTask.DueDate = timestamptz(update.DueDate)
+	rTask.Completed = update.Completed
+	rTask.UpdatedAt = now()
+	rTask.Version++
+	m.tasks[id] = rTask
+	return &rTask, dto.TaskUpdateEvents(&oldTask, &rTask), nil
+}
+
+func (m *TaskMemory) DeleteByID(ctx context.Context, id, version int, actor string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok {
+		return 0, pgx.ErrNoRows
+	}
+	if version != 0 && version != task.Version {
+		return 0, dto.ErrVersionConflict
+	}
+	delete(m.tasks, id)
+	return id, nil
+}
+
+// taskSnapshot is the file Save writes. LastId keeps the sequence, so ids
+// of deleted tasks are not reused after a restart.
+type taskSnapshot struct {
+	LastId int            `json:"last_id"`
+	Tasks  []snapshotTask `json:"tasks"`
+}
+
+type snapshotTask struct {
+	Id          int                `json:"id"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	DueDate     pgtype.Timestamptz `json:"due_date"`
+	Completed   bool               `json:"completed"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	Version     int                `json:"version"`
+}
+
+// Load replaces the tasks with the ones of the snapshot file. A missing file
+// is an empty snapshot.
+func (m *TaskMemory) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		m.logger.Infof("Task snapshot %s not found, starting empty", path)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	snapshot := taskSnapshot{}
+	if err = json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tasks = make(map[int]dto.TaskRead, len(snapshot.Tasks))
+	m.lastId = snapshot.LastId
+	for _, task := range snapshot.Tasks {
+		m.tasks[task.Id] = dto.TaskRead(task)
+		m.lastId = max(m.lastId, task.Id)
+	}
+	m.logger.Infof("Loaded %d tasks from snapshot %s", len(m.tasks), path)
+	return nil
+}
+
+// Save writes the tasks to the snapshot file. It writes a temporary file
+// and renames it, so a crash never leaves half a snapshot.
+func (m *TaskMemory) Save(path string) error {
+	tasks, lastId := m.sorted()
+	snapshot := taskSnapshot{LastId: lastId, Tasks: make([]snapshotTask, 0, len(tasks))}
+	for _, rTask := range tasks {
+		snapshot.Tasks = append(snapshot.Tasks, snapshotTask(rTask))
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	m.logger.Infof("Saved %d tasks to snapshot %s", len(snapshot.Tasks), path)
+	return nil
+}
+
+func now() pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: time.Now().UTC().Round(time.Microsecond), Valid: true}
+}
+
+// timestamptz rounds t to the microseconds Postgres keeps.
+func timestamptz(t pgtype.Timestamptz) pgtype.Timestamptz {
+	if t.Valid {
+		t.Time = t.Time.UTC().Round(time.Microsecond)
+	}
+	return t
+}
+
+func NewTaskMemory(logger logging.Logger) *TaskMemory {
+	return &TaskMemory{
+		tasks:  make(map[int]dto.TaskRead),
+		logger: logger,
+	}
+}
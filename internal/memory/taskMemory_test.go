@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func due(s string) pgtype.Timestamptz {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func TestTaskMemory(t *testing.T) {
+	ctx := context.Background()
+	m := NewTaskMemory(logging.GetLoggerTest())
+
+	_, err := m.List(ctx)
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "an empty list is no rows like TaskCRUD")
+
+	created, err := m.Create(ctx, &dto.TaskCreate{Title: "a", DueDate: due("2030-01-02T03:04:05.1234567+02:00")})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created.Id)
+	assert.Equal(t, 1, created.Version)
+	assert.Equal(t, time.UTC, created.CreatedAt.Time.Location())
+	assert.Equal(t, created.CreatedAt, created.UpdatedAt)
+	assert.Equal(t, "2030-01-02T01:04:05.123457Z", created.DueDate.Time.Format(time.RFC3339Nano),
+		"due dates are kept in microseconds like timestamptz")
+
+	updated, _, err := m.UpdateByID(ctx, created.Id, &dto.TaskUpdate{Title: "b", DueDate: created.DueDate, Completed: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, created.CreatedAt, updated.CreatedAt)
+	assert.False(t, updated.UpdatedAt.Time.Before(created.UpdatedAt.Time))
+
+	id, err := m.DeleteByID(ctx, created.Id, 0, "")
+	require.NoError(t, err)
+	assert.Equal(t, created.Id, id)
+
+	_, err = m.FindById(ctx, created.Id)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	_, _, err = m.UpdateByID(ctx, created.Id, &dto.TaskUpdate{})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	_, err = m.DeleteByID(ctx, created.Id, 0, "")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	next, err := m.Create(ctx, &dto.TaskCreate{Title: "c", DueDate: created.DueDate})
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.Id, "ids are not reused")
+
+	_, err = m.Create(ctx, &dto.TaskCreate{Title: "d"})
+	assert.ErrorIs(t, err, errNoDueDate, "the due date is NOT NULL like in tasks")
+}
+
+func TestTaskMemory_concurrent(t *testing.T) {
+	ctx := context.Background()
+	m := NewTaskMemory(logging.GetLoggerTest())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			task, err := m.Create(ctx, &dto.TaskCreate{Title: "t", DueDate: due("2030-01-01T00:00:00Z")})
+			if assert.NoError(t, err) {
+				_, _, err = m.UpdateByID(ctx, task.Id, &dto.TaskUpdate{Title: "u", DueDate: task.DueDate})
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	tasks, err := m.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, tasks, 50)
+	for i, task := range tasks {
+		assert.Equal(t, i+1, task.Id, "listed in id order")
+		assert.Equal(t, 2, task.Version)
+	}
+}
+
+func TestTaskMemory_snapshot(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	m := NewTaskMemory(logging.GetLoggerTest())
+	require.NoError(t, m.Load(path), "a missing snapshot is empty")
+	_, err := m.CreateMany(ctx, []dto.TaskCreate{
+		{Title: "a", Description: "first", DueDate: due("2030-01-01T00:00:00Z")},
+		{Title: "b", Completed: true, DueDate: due("2030-01-02T00:00:00Z")},
+		{Title: "c", DueDate: due("2030-01-03T00:00:00Z")},
+	})
+	require.NoError(t, err)
+	_, err = m.DeleteByID(ctx, 3, 0, "")
+	require.NoError(t, err)
+	require.NoError(t, m.Save(path))
+
+	loaded := NewTaskMemory(logging.GetLoggerTest())
+	require.NoError(t, loaded.Load(path))
+
+	want, _ := m.List(ctx)
+	got, err := loaded.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, got, len(want))
+	for i := range want {
+		assert.Equal(t, want[i].Id, got[i].Id)
+		assert.Equal(t, want[i].Title, got[i].Title)
+		assert.Equal(t, want[i].Description, got[i].Description)
+		assert.Equal(t, want[i].Completed, got[i].Completed)
+		assert.True(t, want[i].DueDate.Time.Equal(got[i].DueDate.Time))
+		assert.True(t, want[i].CreatedAt.Time.Equal(got[i].CreatedAt.Time))
+	}
+
+	next, err := loaded.Create(ctx, &dto.TaskCreate{Title: "d", DueDate: due("2030-01-04T00:00:00Z")})
+	require.NoError(t, err)
+	assert.Equal(t, 4, next.Id, "the sequence survives the snapshot")
+}
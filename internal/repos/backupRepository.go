@@ -0,0 +1,11 @@
+package repos
+
+import (
+	"ToDoVerba/internal/dto"
+	"context"
+)
+
+type BackupRepository interface {
+	Export(ctx context.Context, each func(record *dto.BackupRecord) error) error
+	Restore(ctx context.Context, mode string, next func() (*dto.BackupRecord, error)) (*dto.BackupStats, error)
+}
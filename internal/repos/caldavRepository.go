@@ -0,0 +1,15 @@
+package repos
+
+import (
+	"ToDoVerba/internal/dto"
+	"context"
+)
+
+type CalDAVRepository interface {
+	List(ctx context.Context) ([]dto.CalDAVObject, error)
+	FindByHref(ctx context.Context, href string) (*dto.CalDAVObject, error)
+	FindByTaskID(ctx context.Context, taskId int) (*dto.CalDAVObject, error)
+	// Create creates the task and names it object.Href in one transaction. It
+	// returns dto.ErrVersionConflict if another task has the href.
+	Create(ctx context.Context, cTask *dto.TaskCreate, object *dto.CalDAVObject) (*dto.TaskRead, error)
+}
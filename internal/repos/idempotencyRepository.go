@@ -0,0 +1,19 @@
+package repos
+
+import (
+	"ToDoVerba/internal/dto"
+	"context"
+)
+
+type IdempotencyRepository interface {
+	// Claim stores the key for a new request and returns true. When the key
+	// is held by an earlier request it returns that request's record.
+	Claim(ctx context.Context, claim *dto.IdempotencyClaim) (*dto.IdempotencyRecord, bool, error)
+	// Complete stores the response of the request that claimed record.Key
+	// with record.Token. It returns pgx.ErrNoRows if that claim was taken
+	// over.
+	Complete(ctx context.Context, record *dto.IdempotencyRecord) error
+	// Release deletes the claim of key with token if it is still in progress.
+	Release(ctx context.Context, key, token string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
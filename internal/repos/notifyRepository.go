@@ -0,0 +1,10 @@
+package repos
+
+import (
+	"ToDoVerba/internal/dto"
+	"context"
+)
+
+type NotifyRepository interface {
+	Listen(ctx context.Context, onListen func(), handle func(event *dto.Event)) error
+}
@@ -0,0 +1,12 @@
+package repos
+
+import (
+	"ToDoVerba/internal/dto"
+	"context"
+	"time"
+)
+
+type OutboxRepository interface {
+	ProcessBatch(ctx context.Context, limit int, handle func(msg *dto.OutboxMessage) error) (int, error)
+	DeletePublished(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,16 @@
+package repos
+
+import (
+	"ToDoVerba/internal/dto"
+	"context"
+	"time"
+)
+
+type ReminderRepository interface {
+	Create(ctx context.Context, cReminder *dto.ReminderCreate) (*dto.Reminder, error)
+	ListByTaskID(ctx context.Context, taskId int) ([]dto.Reminder, error)
+	ListByTaskIDs(ctx context.Context, taskIds []int) ([]dto.Reminder, error)
+	Snooze(ctx context.Context, taskId int, id int64, until time.Time) (*dto.Reminder, error)
+	DeleteByID(ctx context.Context, taskId int, id int64) (int64, error)
+	ProcessDue(ctx context.Context, now time.Time, limit int, claimFor time.Duration, dispatch func(r *dto.Reminder) *dto.ReminderUpdate) (int, error)
+}
@@ -1,16 +1,71 @@
 package repos
 
 import (
+	"ToDoVerba/internal/config"
 	"ToDoVerba/internal/crud"
+	"ToDoVerba/internal/memory"
+	"ToDoVerba/internal/sqlite"
 	"ToDoVerba/pkg/logging"
+	"database/sql"
 )
 
 type Repositories struct {
-	Task TaskRepository
+	Task        TaskRepository
+	TaskEvent   TaskEventRepository
+	TaskVersion TaskVersionRepository
+	Webhook     WebhookRepository
+	Outbox      OutboxRepository
+	Notify      NotifyRepository
+	User        UserRepository
+	Reminder    ReminderRepository
+	CalDAV      CalDAVRepository
+	Backup      BackupRepository
+	Idempotency IdempotencyRepository
 }
 
-func NewRepositories(pool crud.Client, logger logging.Logger) Repositories {
-	return Repositories{
-		Task: crud.NewTaskCRUD(pool, logger),
+// NewRepositories keeps everything in Postgres. With a cache size the
+// tasks are looked up by id through a TaskCache.
+func NewRepositories(pool crud.Client, cache config.Cache, logger logging.Logger) Repositories {
+	r := Repositories{
+		Task:        crud.NewTaskCRUD(pool, logger),
+		TaskEvent:   crud.NewTaskEventCRUD(pool, logger),
+		TaskVersion: crud.NewTaskVersionCRUD(pool, logger),
+		Webhook:     crud.NewWebhookCRUD(pool, logger),
+		Outbox:      crud.NewOutboxCRUD(pool, logger),
+		Notify:      crud.NewNotifyCRUD(pool, logger),
+		User:        crud.NewUserCRUD(pool, logger),
+		Reminder:    crud.NewReminderCRUD(pool, logger),
+		CalDAV:      crud.NewCalDAVCRUD(pool, logger),
+		Backup:      crud.NewBackupCRUD(pool, logger),
+		Idempotency: crud.NewIdempotencyCRUD(pool, logger),
 	}
+	if cache.TaskSize > 0 {
+		tasks := NewTaskCache(r.Task, cache.TaskSize, cache.TaskTTL, logger)
+		r.Task = tasks
+		r.Notify = cachedNotify{r.Notify, tasks}
+		r.Backup = cachedBackup{r.Backup, tasks}
+	}
+	return r
+}
+
+// NewMemoryRepositories keeps the tasks and idempotency keys in memory, for
+// tests and running without Postgres. The other repositories return
+// ErrUnsupported.
+func NewMemoryRepositories(tasks *memory.TaskMemory, logger logging.Logger) Repositories {
+	return withUnsupported(Repositories{
+		Task:        tasks,
+		Idempotency: memory.NewIdempotencyMemory(logger),
+	})
+}
+
+// NewSQLiteRepositories keeps the tasks with their history and versions, and
+// the idempotency keys in a SQLite database. The other repositories return
+// ErrUnsupported.
+func NewSQLiteRepositories(db *sql.DB, logger logging.Logger) Repositories {
+	return withUnsupported(Repositories{
+		Task:        sqlite.NewTaskSQLite(db, logger),
+		TaskEvent:   sqlite.NewTaskEventSQLite(db, logger),
+		TaskVersion: sqlite.NewTaskVersionSQLite(db, logger),
+		Idempotency: sqlite.NewIdempotencySQLite(db, logger),
+	})
 }
@@ -0,0 +1,411 @@
+// Package repostest checks that implementations of the repositories behave
+// the same, whatever their storage.
+package repostest
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/repos"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestTaskRepository runs the conformance tests of repos.TaskRepository.
+// newRepo must return an empty repository for every call.
+//
+// The behaviour is the one of crud.TaskCRUD: ids increase and are never
+// reused, a missing task or an empty list is pgx.ErrNoRows, times are kept
+// in microseconds, the version starts at 1 and increases by one with every
+// update, and lists are in id order.
+func TestTaskRepository(t *testing.T, newRepo func(t *testing.T) repos.TaskRepository) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo repos.TaskRepository)
+	}{
+		{"create and find", testCreateFind},
+		{"update", testUpdate},
+		{"delete", testDelete},
+		{"not found", testNotFound},
+		{"version conflict", testVersionConflict},
+		{"timestamps", testTimestamps},
+		{"list order", testListOrder},
+		{"stream", testStream},
+		{"create many", testCreateMany},
+		{"canceled context", testCanceled},
+		{"concurrent creates", testConcurrentCreates},
+		{"concurrent updates", testConcurrentUpdates},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepo(t))
+		})
+	}
+}
+
+func timestamp(s string) pgtype.Timestamptz {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		panic(err)
+	}
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func newTask(title string) *dto.TaskCreate {
+	return &dto.TaskCreate{
+		Title:       title,
+		Description: title + " description",
+		DueDate:     timestamp("2030-01-02T03:04:05Z"),
+		Actor:       "repostest",
+	}
+}
+
+// assertTask compares tasks with times compared as instants, a storage may
+// return them in another location.
+func assertTask(t *testing.T, want, got *dto.TaskRead) {
+	t.Helper()
+	assert.Equal(t, want.Id, got.Id, "id")
+	assert.Equal(t, want.Title, got.Title, "title")
+	assert.Equal(t, want.Description, got.Description, "description")
+	assert.Equal(t, want.Completed, got.Completed, "completed")
+	assert.Equal(t, want.Version, got.Version, "version")
+	assertTime(t, want.DueDate, got.DueDate, "due date")
+	assertTime(t, want.CreatedAt, got.CreatedAt, "created at")
+	assertTime(t, want.UpdatedAt, got.UpdatedAt, "updated at")
+}
+
+func assertTime(t *testing.T, want, got pgtype.Timestamptz, name string) {
+	t.Helper()
+	assert.Equal(t, want.Valid, got.Valid, "%s valid", name)
+	assert.True(t, want.Time.Equal(got.Time), "%s: want %s, got %s", name, want.Time, got.Time)
+}
+
+func ids(tasks []dto.TaskRead) []int {
+	ids := make([]int, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.Id
+	}
+	return ids
+}
+
+func testCreateFind(t *testing.T, repo repos.TaskRepository) {
+	ctx := context.Background()
+
+	cTask := newTask("create")
+	cTask.Completed = true
+	created, err := repo.Create(ctx, cTask)
+	require.NoError(t, err)
+	assert.Positive(t, created.Id)
+	assert.Equal(t, cTask.Title, created.Title)
+	assert.Equal(t, cTask.Description, created.Description)
+	assert.True(t, created.Completed)
+	assert.Equal(t, 1, created.Version)
+	assertTime(t, cTask.DueDate, created.DueDate, "due date")
+
+	found, err := repo.FindById(ctx, created.Id)
+	require.NoError(t, err)
+	assertTask(t, created, found)
+
+	other, err := repo.Create(ctx, newTask("other"))
+	require.NoError(t, err)
+	assert.Greater(t, other.Id, created.Id, "ids increase")
+}
+
+func testUpdate(t *testing.T, repo repos.TaskRepository) {
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, newTask("before"))
+	require.NoError(t, err)
+
+	update := &dto.TaskUpdate{
+		Title:       "after",
+		Description: "after description",
+		DueDate:     timestamp("2031-06-07T08:09:10Z"),
+		Completed:   true,
+		Actor:       "repostest",
+	}
+	for version := 2; version <= 3; version++ {
+		updated, eventTypes, err := repo.UpdateByID(ctx, created.Id, update)
+		require.NoError(t, err)
+		if version == 2 {
+			assert.Equal(t, []string{dto.EventTaskUpdated, dto.EventTaskCompleted}, eventTypes, "the update completed the task")
+		} else {
+			assert.Equal(t, []string{dto.EventTaskUpdated}, eventTypes, "the task was completed already")
+		}
+		assert.Equal(t, created.Id, updated.Id)
+		assert.Equal(t, update.Title, updated.Title)
+		assert.Equal(t, update.Description, updated.Description)
+		assert.True(t, updated.Completed)
+		assert.Equal(t, version, updated.Version, "every update is a version, changed or not")
+		assertTime(t, update.DueDate, updated.DueDate, "due date")
+		assertTime(t, created.CreatedAt, updated.CreatedAt, "created at")
+
+		found, err := repo.FindById(ctx, created.Id)
+		require.NoError(t, err)
+		assertTask(t, updated, found)
+	}
+}
+
+func testDelete(t *testing.T, repo repos.TaskRepository) {
+	ctx := context.Background()
+
+	deleted, err := repo.Create(ctx, newTask("deleted"))
+	require.NoError(t, err)
+	kept, err := repo.Create(ctx, newTask("kept"))
+	require.NoError(t, err)
+
+	id, err := repo.DeleteByID(ctx, deleted.Id, 0, "repostest")
+	require.NoError(t, err)
+	assert.Equal(t, deleted.Id, id)
+
+	_, err = repo.FindById(ctx, deleted.Id)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	tasks, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{kept.Id}, ids(tasks))
+
+	_, err = repo.DeleteByID(ctx, kept.Id, 0, "")
+	require.NoError(t, err, "the actor is optional")
+	next, err := repo.Create(ctx, newTask("next"))
+	require.NoError(t, err)
+	assert.Greater(t, next.Id, kept.Id, "ids of deleted tasks are not reused")
+}
+
+func testNotFound(t *testing.T, repo repos.TaskRepository) {
+	ctx := context.Background()
+
+	_, err := repo.List(ctx)
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "list of no tasks")
+
+	called := false
+	err = repo.Stream(ctx, func(task *dto.TaskRead) error {
+		called = true
+		return nil
+	})
+	assert.NoError(t, err, "stream of no tasks")
+	assert.False(t, called)
+
+	task, err := repo.Create(ctx, newTask("task"))
+	require.NoError(t, err)
+	missing := task.Id + 1000
+
+	_, err = repo.FindById(ctx, missing)
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "find")
+	_, _, err = repo.UpdateByID(ctx, missing, &dto.TaskUpdate{Title: "x", DueDate: task.DueDate})
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "update")
+	_, err = repo.DeleteByID(ctx, missing, 0, "")
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "delete")
+
+	found, err := repo.FindById(ctx, task.Id)
+	require.NoError(t, err)
+	assertTask(t, task, found)
+}
+
+func testVersionConflict(t *testing.T, repo repos.TaskRepository) {
+	ctx := context.Background()
+
+	task, err := repo.Create(ctx, newTask("task"))
+	require.NoError(t, err)
+
+	updated, _, err := repo.UpdateByID(ctx, task.Id, &dto.TaskUpdate{Title: "b", DueDate: task.DueDate, Version: task.Version})
+	require.NoError(t, err)
+	assert.Equal(t, task.Version+1, updated.Version)
+
+	_, _, err = repo.UpdateByID(ctx, task.Id, &dto.TaskUpdate{Title: "c", DueDate: task.DueDate, Version: task.Version})
+	assert.ErrorIs(t, err, dto.ErrVersionConflict, "update of an old version")
+	_, err = repo.DeleteByID(ctx, task.Id, task.Version, "")
+	assert.ErrorIs(t, err, dto.ErrVersionConflict, "delete of an old version")
+
+	found, err := repo.FindById(ctx, task.Id)
+	require.NoError(t, err)
+	assertTask(t, updated, found)
+
+	_, err = repo.DeleteByID(ctx, task.Id, updated.Version, "")
+	require.NoError(t, err)
+	_, err = repo.DeleteByID(ctx, task.Id, updated.Version, "")
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "delete of a missing task at a version")
+}
+
+func testTimestamps(t *testing.T, repo repos.TaskRepository) {
+	ctx := context.Background()
+
+	cTask := newTask("times")
+	cTask.DueDate = timestamp("2030-01-02T03:04:05.1234564+02:00")
+	before := time.Now().Add(-time.Second)
+	created, err := repo.Create(ctx, cTask)
+	require.NoError(t, err)
+	after := time.Now().Add(time.Second)
+
+	assertTime(t, timestamp("2030-01-02T01:04:05.123456Z"), created.DueDate, "due date in microseconds")
+	assert.True(t, created.CreatedAt.Valid)
+	assert.WithinRange(t, created.CreatedAt.Time, before, after, "created at is now")
+	assertTime(t, created.CreatedAt, created.UpdatedAt, "updated at of a new task")
+	assert.Equal(t, created.CreatedAt.Time, created.CreatedAt.Time.Truncate(time.Microsecond),
+		"created at in microseconds")
+
+	time.Sleep(2 * time.Millisecond)
+	updated, _, err := repo.UpdateByID(ctx, created.Id, &dto.TaskUpdate{Title: "times", DueDate: created.DueDate})
+	require.NoError(t, err)
+	assert.True(t, updated.UpdatedAt.Time.After(created.UpdatedAt.Time), "updated at moves on update")
+	assertTime(t, created.CreatedAt, updated.CreatedAt, "created at stays")
+}
+
+func testListOrder(t *testing.T, repo repos.TaskRepository) {
+	ctx := context.Background()
+
+	var want []int
+	for i := 0; i < 5; i++ {
+		task, err := repo.Create(ctx, newTask(fmt.Sprintf("task %d", i)))
+		require.NoError(t, err)
+		want = append(want, task.Id)
+	}
+	// Updated rows move in a Postgres heap, the order must not follow them.
+	for _, id := range []int{want[2], want[0]} {
+		_, _, err := repo.UpdateByID(ctx, id, &dto.TaskUpdate{Title: "updated", DueDate: timestamp("2030-01-01T00:00:00Z")})
+		require.NoError(t, err)
+	}
+
+	tasks, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, want, ids(tasks))
+}
+
+func testStream(t *testing.T, repo repos.TaskRepository) {
+	ctx := context.Background()
+
+	var want []dto.TaskRead
+	for i := 0; i < 3; i++ {
+		task, err := repo.Create(ctx, newTask(fmt.Sprintf("task %d", i)))
+		require.NoError(t, err)
+		want = append(want, *task)
+	}
+
+	var got []dto.TaskRead
+	err := repo.Stream(ctx, func(task *dto.TaskRead) error {
+		got = append(got, *task)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, ids(want), ids(got))
+	for i := range want {
+		assertTask(t, &want[i], &got[i])
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = repo.Stream(ctx, func(task *dto.TaskRead) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop, "an error of each stops the stream")
+	assert.Equal(t, 1, calls)
+}
+
+func testCreateMany(t *testing.T, repo repos.TaskRepository) {
+	ctx := context.Background()
+
+	invalid := newTask("no due date")
+	invalid.DueDate = pgtype.Timestamptz{}
+	_, err := repo.CreateMany(ctx, []dto.TaskCreate{*newTask("valid"), *invalid})
+	assert.Error(t, err, "a task without due date")
+	_, err = repo.List(ctx)
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "all or none of the tasks are created")
+
+	created, err := repo.CreateMany(ctx, []dto.TaskCreate{*newTask("a"), *newTask("b"), *newTask("c")})
+	require.NoError(t, err)
+	require.Len(t, created, 3)
+	assert.Equal(t, []string{"a", "b", "c"}, []string{created[0].Title, created[1].Title, created[2].Title})
+
+	tasks, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ids(created), ids(tasks))
+}
+
+func testCanceled(t *testing.T, repo repos.TaskRepository) {
+	task, err := repo.Create(context.Background(), newTask("task"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = repo.Create(ctx, newTask("canceled"))
+	assert.Error(t, err, "create")
+	_, err = repo.FindById(ctx, task.Id)
+	assert.Error(t, err, "find")
+	_, err = repo.List(ctx)
+	assert.Error(t, err, "list")
+	_, _, err = repo.UpdateByID(ctx, task.Id, &dto.TaskUpdate{Title: "canceled", DueDate: task.DueDate})
+	assert.Error(t, err, "update")
+	_, err = repo.DeleteByID(ctx, task.Id, 0, "")
+	assert.Error(t, err, "delete")
+
+	found, err := repo.FindById(context.Background(), task.Id)
+	require.NoError(t, err)
+	assertTask(t, task, found)
+}
+
+func testConcurrentCreates(t *testing.T, repo repos.TaskRepository) {
+	ctx := context.Background()
+	const n = 20
+
+	var wg sync.WaitGroup
+	created := make([]int, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			task, err := repo.Create(ctx, newTask(fmt.Sprintf("task %d", i)))
+			if assert.NoError(t, err) {
+				created[i] = task.Id
+			}
+		}()
+	}
+	wg.Wait()
+
+	tasks, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, tasks, n)
+	assert.ElementsMatch(t, created, ids(tasks), "every create has its own id")
+}
+
+func testConcurrentUpdates(t *testing.T, repo repos.TaskRepository) {
+	ctx := context.Background()
+	const n = 20
+
+	task, err := repo.Create(ctx, newTask("task"))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	versions := make([]int, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			updated, _, err := repo.UpdateByID(ctx, task.Id, &dto.TaskUpdate{
+				Title:   fmt.Sprintf("update %d", i),
+				DueDate: task.DueDate,
+			})
+			if assert.NoError(t, err) {
+				versions[i] = updated.Version
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := make([]int, n)
+	for i := range want {
+		want[i] = i + 2
+	}
+	assert.ElementsMatch(t, want, versions, "no update is lost")
+
+	found, err := repo.FindById(ctx, task.Id)
+	require.NoError(t, err)
+	assert.Equal(t, n+1, found.Version)
+}
@@ -0,0 +1,204 @@
+package repos
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"ToDoVerba/pkg/metrics"
+	"container/list"
+	"context"
+	"golang.org/x/sync/singleflight"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	taskCacheHits   = metrics.NewCounter("todoverba_task_cache_hits_total", "Task lookups by id served from the cache.")
+	taskCacheMisses = metrics.NewCounter("todoverba_task_cache_misses_total", "Task lookups by id that went to the storage.")
+)
+
+// TaskCache is a read-through cache of FindById in front of another task
+// repository. It keeps up to size tasks, least recently used first out, for
+// at most ttl. Updates and deletes through the cache drop the task; writes
+// of other replicas are dropped by Invalidate, or are seen after the ttl.
+// Concurrent misses of a task share a single load.
+type TaskCache struct {
+	next   TaskRepository
+	size   int
+	ttl    time.Duration
+	logger logging.Logger
+
+	mu    sync.Mutex
+	items map[int]*list.Element
+	// recent holds *taskCacheEntry, the most recently used in front.
+	recent *list.List
+	// epoch counts the invalidations. A load that started before one may
+	// have read the old task, so it is neither cached nor shared after it.
+	epoch uint64
+	loads singleflight.Group
+}
+
+type taskCacheEntry struct {
+	task    dto.TaskRead
+	expires time.Time
+}
+
+func (c *TaskCache) Create(ctx context.Context, cTask *dto.TaskCreate) (*dto.TaskRead, error) {
+	return c.next.Create(ctx, cTask)
+}
+
+func (c *TaskCache) CreateMany(ctx context.Context, cTasks []dto.TaskCreate) ([]dto.TaskRead, error) {
+	return c.next.CreateMany(ctx, cTasks)
+}
+
+func (c *TaskCache) FindById(ctx context.Context, id int) (*dto.TaskRead, error) {
+	if rTask, ok := c.get(id); ok {
+		taskCacheHits.Inc()
+		return rTask, nil
+	}
+	taskCacheMisses.Inc()
+
+	c.mu.Lock()
+	epoch := c.epoch
+	c.mu.Unlock()
+
+	// The load is shared, so it must not end with the context of the caller
+	// that happened to start it. Every caller still stops waiting on its own.
+	key := strconv.Itoa(id) + "@" + strconv.FormatUint(epoch, 10)
+	result := c.loads.DoChan(key, func() (any, error) {
+		rTask, err := c.next.FindById(context.WithoutCancel(ctx), id)
+		if err != nil {
+			return nil, err
+		}
+		c.put(rTask, epoch)
+		return *rTask, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		rTask := res.Val.(dto.TaskRead)
+		return &rTask, nil
+	}
+}
+
+func (c *TaskCache) List(ctx context.Context) ([]dto.TaskRead, error) {
+	return c.next.List(ctx)
+}
+
+func (c *TaskCache) Stream(ctx context.Context, each func(task *dto.TaskRead) error) error {
+	return c.next.Stream(ctx, each)
+}
+
+func (c *TaskCache) UpdateByID(ctx context.Context, id int, update *dto.TaskUpdate) (*dto.TaskRead, []string, error) {
+	defer c.Invalidate(id)
+	return c.next.UpdateByID(ctx, id, update)
+}
+
+func (c *TaskCache) DeleteByID(ctx context.Context, id, version int, actor string) (int, error) {
+	defer c.Invalidate(id)
+	return c.next.DeleteByID(ctx, id, version, actor)
+}
+
+// Invalidate drops the task, the next FindById loads it again.
+func (c *TaskCache) Invalidate(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+	if elem, ok := c.items[id]; ok {
+		c.recent.Remove(elem)
+		delete(c.items, id)
+	}
+}
+
+// Purge drops all tasks, for writes that replace the whole table.
+func (c *TaskCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+	clear(c.items)
+	c.recent.Init()
+}
+
+func (c *TaskCache) get(id int) (*dto.TaskRead, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[id]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*taskCacheEntry)
+	if time.Now().After(entry.expires) {
+		c.recent.Remove(elem)
+		delete(c.items, id)
+		return nil, false
+	}
+	c.recent.MoveToFront(elem)
+	rTask := entry.task
+	return &rTask, true
+}
+
+func (c *TaskCache) put(rTask *dto.TaskRead, epoch uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if epoch != c.epoch {
+		return
+	}
+	entry := &taskCacheEntry{task: *rTask, expires: time.Now().Add(c.ttl)}
+	if elem, ok := c.items[rTask.Id]; ok {
+		elem.Value = entry
+		c.recent.MoveToFront(elem)
+		return
+	}
+	c.items[rTask.Id] = c.recent.PushFront(entry)
+	if c.recent.Len() > c.size {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.items, oldest.Value.(*taskCacheEntry).task.Id)
+	}
+}
+
+func NewTaskCache(next TaskRepository, size int, ttl time.Duration, logger logging.Logger) *TaskCache {
+	return &TaskCache{
+		next:   next,
+		size:   size,
+		ttl:    ttl,
+		logger: logger,
+		items:  make(map[int]*list.Element, size),
+		recent: list.New(),
+	}
+}
+
+// cachedNotify drops the tasks other replicas change from the cache before
+// the change is handled, which may reload the task.
+type cachedNotify struct {
+	NotifyRepository
+	cache *TaskCache
+}
+
+func (n cachedNotify) Listen(ctx context.Context, onListen func(), handle func(event *dto.Event)) error {
+	return n.NotifyRepository.Listen(ctx, onListen, func(event *dto.Event) {
+		n.cache.Invalidate(event.TaskId)
+		handle(event)
+	})
+}
+
+// cachedBackup purges the cache after a restore, which replaces the tasks
+// behind the task repository.
+type cachedBackup struct {
+	BackupRepository
+	cache *TaskCache
+}
+
+func (b cachedBackup) Restore(ctx context.Context, mode string, next func() (*dto.BackupRecord, error)) (*dto.BackupStats, error) {
+	defer b.cache.Purge()
+	return b.BackupRepository.Restore(ctx, mode, next)
+}
@@ -0,0 +1,204 @@
+package repos_test
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/memory"
+	"ToDoVerba/internal/repos"
+	"ToDoVerba/internal/repos/repostest"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingTasks counts the lookups that reach the storage. While block is
+// set they wait for it to be closed.
+type countingTasks struct {
+	*memory.TaskMemory
+	finds atomic.Int32
+	block chan struct{}
+}
+
+func (c *countingTasks) FindById(ctx context.Context, id int) (*dto.TaskRead, error) {
+	c.finds.Add(1)
+	if c.block != nil {
+		<-c.block
+	}
+	return c.TaskMemory.FindById(ctx, id)
+}
+
+func newCountingTasks(t *testing.T, titles ...string) (*countingTasks, []dto.TaskRead) {
+	backend := &countingTasks{TaskMemory: memory.NewTaskMemory(logging.GetLoggerTest())}
+	var tasks []dto.TaskRead
+	for _, title := range titles {
+		task, err := backend.Create(context.Background(), &dto.TaskCreate{
+			Title:   title,
+			DueDate: pgtype.Timestamptz{Time: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		})
+		require.NoError(t, err)
+		tasks = append(tasks, *task)
+	}
+	return backend, tasks
+}
+
+func TestTaskCache_conformance(t *testing.T) {
+	repostest.TestTaskRepository(t, func(t *testing.T) repos.TaskRepository {
+		return repos.NewTaskCache(memory.NewTaskMemory(logging.GetLoggerTest()), 3, time.Minute, logging.GetLoggerTest())
+	})
+}
+
+func TestTaskCache_FindById(t *testing.T) {
+	ctx := context.Background()
+	backend, tasks := newCountingTasks(t, "a", "b")
+	cache := repos.NewTaskCache(backend, 10, time.Minute, logging.GetLoggerTest())
+
+	for i := 0; i < 3; i++ {
+		found, err := cache.FindById(ctx, tasks[0].Id)
+		require.NoError(t, err)
+		assert.Equal(t, tasks[0], *found)
+	}
+	assert.Equal(t, int32(1), backend.finds.Load(), "read through once")
+
+	found, _ := cache.FindById(ctx, tasks[0].Id)
+	found.Title = "changed by the caller"
+	found, _ = cache.FindById(ctx, tasks[0].Id)
+	assert.Equal(t, "a", found.Title, "callers get copies")
+
+	_, err := cache.FindById(ctx, 42)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	_, err = cache.FindById(ctx, 42)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	assert.Equal(t, int32(3), backend.finds.Load(), "missing tasks are not cached")
+}
+
+func TestTaskCache_invalidate(t *testing.T) {
+	ctx := context.Background()
+	backend, tasks := newCountingTasks(t, "a", "b")
+	cache := repos.NewTaskCache(backend, 10, time.Minute, logging.GetLoggerTest())
+	id := tasks[0].Id
+
+	_, err := cache.FindById(ctx, id)
+	require.NoError(t, err)
+	_, _, err = cache.UpdateByID(ctx, id, &dto.TaskUpdate{Title: "updated", DueDate: tasks[0].DueDate})
+	require.NoError(t, err)
+	found, err := cache.FindById(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "updated", found.Title, "an update drops the task")
+
+	// A write of another replica, announced by a notification.
+	_, _, err = backend.UpdateByID(ctx, id, &dto.TaskUpdate{Title: "elsewhere", DueDate: tasks[0].DueDate})
+	require.NoError(t, err)
+	found, _ = cache.FindById(ctx, id)
+	assert.Equal(t, "updated", found.Title)
+	cache.Invalidate(id)
+	found, _ = cache.FindById(ctx, id)
+	assert.Equal(t, "elsewhere", found.Title)
+
+	_, err = cache.DeleteByID(ctx, id, 0, "")
+	require.NoError(t, err)
+	_, err = cache.FindById(ctx, id)
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "a delete drops the task")
+
+	_, err = cache.FindById(ctx, tasks[1].Id)
+	require.NoError(t, err)
+	finds := backend.finds.Load()
+	cache.Purge()
+	_, err = cache.FindById(ctx, tasks[1].Id)
+	require.NoError(t, err)
+	assert.Equal(t, finds+1, backend.finds.Load(), "a purge drops all tasks")
+}
+
+func TestTaskCache_bounds(t *testing.T) {
+	ctx := context.Background()
+	backend, tasks := newCountingTasks(t, "a", "b", "c")
+	cache := repos.NewTaskCache(backend, 2, 50*time.Millisecond, logging.GetLoggerTest())
+	find := func(task dto.TaskRead) {
+		_, err := cache.FindById(ctx, task.Id)
+		require.NoError(t, err)
+	}
+
+	find(tasks[0])
+	find(tasks[1])
+	find(tasks[0])
+	find(tasks[2])
+	require.Equal(t, int32(3), backend.finds.Load())
+
+	find(tasks[0])
+	find(tasks[2])
+	assert.Equal(t, int32(3), backend.finds.Load(), "the recently used tasks are kept")
+	find(tasks[1])
+	assert.Equal(t, int32(4), backend.finds.Load(), "the least recently used task is evicted")
+
+	time.Sleep(60 * time.Millisecond)
+	find(tasks[1])
+	assert.Equal(t, int32(5), backend.finds.Load(), "tasks expire after the ttl")
+}
+
+func TestTaskCache_singleflight(t *testing.T) {
+	ctx := context.Background()
+	backend, tasks := newCountingTasks(t, "a")
+	backend.block = make(chan struct{})
+	cache := repos.NewTaskCache(backend, 10, time.Minute, logging.GetLoggerTest())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found, err := cache.FindById(ctx, tasks[0].Id)
+			if assert.NoError(t, err) {
+				assert.Equal(t, "a", found.Title)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(backend.block)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), backend.finds.Load(), "concurrent misses share a load")
+}
+
+func TestTaskCache_invalidateDuringLoad(t *testing.T) {
+	ctx := context.Background()
+	backend, tasks := newCountingTasks(t, "a")
+	backend.block = make(chan struct{})
+	cache := repos.NewTaskCache(backend, 10, time.Minute, logging.GetLoggerTest())
+	id := tasks[0].Id
+
+	loaded := make(chan struct{})
+	go func() {
+		defer close(loaded)
+		_, err := cache.FindById(ctx, id)
+		assert.NoError(t, err)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cache.Invalidate(id)
+	close(backend.block)
+	<-loaded
+
+	_, err := cache.FindById(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), backend.finds.Load(), "a load older than the invalidation is not cached")
+}
+
+func TestTaskCache_canceled(t *testing.T) {
+	backend, tasks := newCountingTasks(t, "a")
+	backend.block = make(chan struct{})
+	cache := repos.NewTaskCache(backend, 10, time.Minute, logging.GetLoggerTest())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := cache.FindById(ctx, tasks[0].Id)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "a caller stops waiting on its context")
+
+	close(backend.block)
+	found, err := cache.FindById(context.Background(), tasks[0].Id)
+	require.NoError(t, err)
+	assert.Equal(t, "a", found.Title)
+}
@@ -0,0 +1,13 @@
+package repos
+
+import (
+	"ToDoVerba/internal/dto"
+	"context"
+)
+
+type TaskEventRepository interface {
+	ListByTaskID(ctx context.Context, taskId int) ([]dto.TaskEvent, error)
+	ListByTaskIDs(ctx context.Context, taskIds []int) ([]dto.TaskEvent, error)
+	List(ctx context.Context, filter *dto.TaskEventFilter) ([]dto.TaskEvent, error)
+	LatestID(ctx context.Context) (int64, error)
+}
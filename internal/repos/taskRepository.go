@@ -9,6 +9,11 @@ type TaskRepository interface {
 	Create(ctx context.Context, cTask *dto.TaskCreate) (*dto.TaskRead, error)
 	FindById(ctx context.Context, id int) (*dto.TaskRead, error)
 	List(ctx context.Context) ([]dto.TaskRead, error)
-	UpdateByID(ctx context.Context, id int, update *dto.TaskUpdate) (*dto.TaskRead, error)
-	DeleteByID(ctx context.Context, id int) (int, error)
+	Stream(ctx context.Context, each func(task *dto.TaskRead) error) error
+	CreateMany(ctx context.Context, cTasks []dto.TaskCreate) ([]dto.TaskRead, error)
+	// UpdateByID returns the updated task and the types of the events of the
+	// update, see dto.TaskUpdateEvents.
+	UpdateByID(ctx context.Context, id int, update *dto.TaskUpdate) (*dto.TaskRead, []string, error)
+	// DeleteByID deletes the task, only at version unless it is 0.
+	DeleteByID(ctx context.Context, id, version int, actor string) (int, error)
 }
@@ -0,0 +1,11 @@
+package repos
+
+import (
+	"ToDoVerba/internal/dto"
+	"context"
+)
+
+type TaskVersionRepository interface {
+	ListByTaskID(ctx context.Context, taskId int) ([]dto.TaskVersion, error)
+	FindByVersion(ctx context.Context, taskId, version int) (*dto.TaskVersion, error)
+}
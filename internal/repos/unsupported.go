@@ -0,0 +1,216 @@
+package repos
+
+import (
+	"ToDoVerba/internal/dto"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnsupported is returned by the repositories a storage driver has no
+// implementation of.
+var ErrUnsupported = errors.New("not supported by the storage driver")
+
+func errUnsupported(what string) error {
+	return fmt.Errorf("%s: %w", what, ErrUnsupported)
+}
+
+// withUnsupported fills the repositories left nil with ones that return
+// ErrUnsupported, so a driver can provide only some of them.
+func withUnsupported(r Repositories) Repositories {
+	if r.TaskEvent == nil {
+		r.TaskEvent = unsupportedTaskEvents{}
+	}
+	if r.TaskVersion == nil {
+		r.TaskVersion = unsupportedTaskVersions{}
+	}
+	if r.Webhook == nil {
+		r.Webhook = unsupportedWebhooks{}
+	}
+	if r.Outbox == nil {
+		r.Outbox = unsupportedOutbox{}
+	}
+	if r.Notify == nil {
+		r.Notify = unsupportedNotify{}
+	}
+	if r.User == nil {
+		r.User = unsupportedUsers{}
+	}
+	if r.Reminder == nil {
+		r.Reminder = unsupportedReminders{}
+	}
+	if r.CalDAV == nil {
+		r.CalDAV = unsupportedCalDAV{}
+	}
+	if r.Backup == nil {
+		r.Backup = unsupportedBackup{}
+	}
+	return r
+}
+
+type unsupportedBackup struct{}
+
+func (unsupportedBackup) Export(ctx context.Context, each func(record *dto.BackupRecord) error) error {
+	return errUnsupported("backups")
+}
+
+func (unsupportedBackup) Restore(ctx context.Context, mode string, next func() (*dto.BackupRecord, error)) (*dto.BackupStats, error) {
+	return nil, errUnsupported("backups")
+}
+
+type unsupportedCalDAV struct{}
+
+func (unsupportedCalDAV) List(ctx context.Context) ([]dto.CalDAVObject, error) {
+	return nil, errUnsupported("caldav objects")
+}
+
+func (unsupportedCalDAV) FindByHref(ctx context.Context, href string) (*dto.CalDAVObject, error) {
+	return nil, errUnsupported("caldav objects")
+}
+
+func (unsupportedCalDAV) FindByTaskID(ctx context.Context, taskId int) (*dto.CalDAVObject, error) {
+	return nil, errUnsupported("caldav objects")
+}
+
+func (unsupportedCalDAV) Create(ctx context.Context, cTask *dto.TaskCreate, object *dto.CalDAVObject) (*dto.TaskRead, error) {
+	return nil, errUnsupported("caldav objects")
+}
+
+type unsupportedNotify struct{}
+
+func (unsupportedNotify) Listen(ctx context.Context, onListen func(), handle func(event *dto.Event)) error {
+	return errUnsupported("notifications")
+}
+
+type unsupportedOutbox struct{}
+
+func (unsupportedOutbox) ProcessBatch(ctx context.Context, limit int, handle func(msg *dto.OutboxMessage) error) (int, error) {
+	return 0, errUnsupported("the outbox")
+}
+
+func (unsupportedOutbox) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	return 0, errUnsupported("the outbox")
+}
+
+type unsupportedReminders struct{}
+
+func (unsupportedReminders) Create(ctx context.Context, cReminder *dto.ReminderCreate) (*dto.Reminder, error) {
+	return nil, errUnsupported("reminders")
+}
+
+func (unsupportedReminders) ListByTaskID(ctx context.Context, taskId int) ([]dto.Reminder, error) {
+	return nil, errUnsupported("reminders")
+}
+
+func (unsupportedReminders) ListByTaskIDs(ctx context.Context, taskIds []int) ([]dto.Reminder, error) {
+	return nil, errUnsupported("reminders")
+}
+
+func (unsupportedReminders) Snooze(ctx context.Context, taskId int, id int64, until time.Time) (*dto.Reminder, error) {
+	return nil, errUnsupported("reminders")
+}
+
+func (unsupportedReminders) DeleteByID(ctx context.Context, taskId int, id int64) (int64, error) {
+	return 0, errUnsupported("reminders")
+}
+
+func (unsupportedReminders) ProcessDue(ctx context.Context, now time.Time, limit int, claimFor time.Duration, dispatch func(r *dto.Reminder) *dto.ReminderUpdate) (int, error) {
+	return 0, errUnsupported("reminders")
+}
+
+type unsupportedTaskEvents struct{}
+
+func (unsupportedTaskEvents) ListByTaskID(ctx context.Context, taskId int) ([]dto.TaskEvent, error) {
+	return nil, errUnsupported("task history")
+}
+
+func (unsupportedTaskEvents) ListByTaskIDs(ctx context.Context, taskIds []int) ([]dto.TaskEvent, error) {
+	return nil, errUnsupported("task history")
+}
+
+func (unsupportedTaskEvents) List(ctx context.Context, filter *dto.TaskEventFilter) ([]dto.TaskEvent, error) {
+	return nil, errUnsupported("task history")
+}
+
+func (unsupportedTaskEvents) LatestID(ctx context.Context) (int64, error) {
+	return 0, errUnsupported("task history")
+}
+
+type unsupportedTaskVersions struct{}
+
+func (unsupportedTaskVersions) ListByTaskID(ctx context.Context, taskId int) ([]dto.TaskVersion, error) {
+	return nil, errUnsupported("task versions")
+}
+
+func (unsupportedTaskVersions) FindByVersion(ctx context.Context, taskId, version int) (*dto.TaskVersion, error) {
+	return nil, errUnsupported("task versions")
+}
+
+type unsupportedUsers struct{}
+
+func (unsupportedUsers) Create(ctx context.Context, cUser *dto.UserCreate) (*dto.UserRead, error) {
+	return nil, errUnsupported("users")
+}
+
+func (unsupportedUsers) FindByTokenHash(ctx context.Context, tokenHash string) (*dto.UserRead, error) {
+	return nil, errUnsupported("users")
+}
+
+func (unsupportedUsers) FindByFeedTokenHash(ctx context.Context, tokenHash string) (*dto.UserRead, error) {
+	return nil, errUnsupported("users")
+}
+
+func (unsupportedUsers) SetFeedTokenHash(ctx context.Context, id int, tokenHash string) error {
+	return errUnsupported("users")
+}
+
+func (unsupportedUsers) List(ctx context.Context) ([]dto.UserRead, error) {
+	return nil, errUnsupported("users")
+}
+
+func (unsupportedUsers) DeleteByID(ctx context.Context, id int) (int, error) {
+	return 0, errUnsupported("users")
+}
+
+type unsupportedWebhooks struct{}
+
+func (unsupportedWebhooks) Create(ctx context.Context, cWebhook *dto.WebhookCreate) (*dto.WebhookRead, error) {
+	return nil, errUnsupported("webhooks")
+}
+
+func (unsupportedWebhooks) FindById(ctx context.Context, id int) (*dto.WebhookRead, error) {
+	return nil, errUnsupported("webhooks")
+}
+
+func (unsupportedWebhooks) List(ctx context.Context) ([]dto.WebhookRead, error) {
+	return nil, errUnsupported("webhooks")
+}
+
+func (unsupportedWebhooks) ListActiveByEvent(ctx context.Context, event string) ([]dto.WebhookRead, error) {
+	return nil, errUnsupported("webhooks")
+}
+
+func (unsupportedWebhooks) UpdateByID(ctx context.Context, id int, update *dto.WebhookUpdate) (*dto.WebhookRead, error) {
+	return nil, errUnsupported("webhooks")
+}
+
+func (unsupportedWebhooks) DeleteByID(ctx context.Context, id int) (int, error) {
+	return 0, errUnsupported("webhooks")
+}
+
+func (unsupportedWebhooks) CreateDeliveries(ctx context.Context, deliveries []dto.WebhookDeliveryCreate) error {
+	return errUnsupported("webhooks")
+}
+
+func (unsupportedWebhooks) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]dto.WebhookDelivery, error) {
+	return nil, errUnsupported("webhooks")
+}
+
+func (unsupportedWebhooks) UpdateDelivery(ctx context.Context, update *dto.WebhookDeliveryUpdate) error {
+	return errUnsupported("webhooks")
+}
+
+func (unsupportedWebhooks) ListDeliveries(ctx context.Context, webhookId, offset, limit int) ([]dto.WebhookDelivery, error) {
+	return nil, errUnsupported("webhooks")
+}
@@ -0,0 +1,15 @@
+package repos
+
+import (
+	"ToDoVerba/internal/dto"
+	"context"
+)
+
+type UserRepository interface {
+	Create(ctx context.Context, cUser *dto.UserCreate) (*dto.UserRead, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*dto.UserRead, error)
+	FindByFeedTokenHash(ctx context.Context, tokenHash string) (*dto.UserRead, error)
+	SetFeedTokenHash(ctx context.Context, id int, tokenHash string) error
+	List(ctx context.Context) ([]dto.UserRead, error)
+	DeleteByID(ctx context.Context, id int) (int, error)
+}
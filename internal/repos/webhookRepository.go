@@ -0,0 +1,20 @@
+package repos
+
+import (
+	"ToDoVerba/internal/dto"
+	"context"
+	"time"
+)
+
+type WebhookRepository interface {
+	Create(ctx context.Context, cWebhook *dto.WebhookCreate) (*dto.WebhookRead, error)
+	FindById(ctx context.Context, id int) (*dto.WebhookRead, error)
+	List(ctx context.Context) ([]dto.WebhookRead, error)
+	ListActiveByEvent(ctx context.Context, event string) ([]dto.WebhookRead, error)
+	UpdateByID(ctx context.Context, id int, update *dto.WebhookUpdate) (*dto.WebhookRead, error)
+	DeleteByID(ctx context.Context, id int) (int, error)
+	CreateDeliveries(ctx context.Context, deliveries []dto.WebhookDeliveryCreate) error
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]dto.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, update *dto.WebhookDeliveryUpdate) error
+	ListDeliveries(ctx context.Context, webhookId, offset, limit int) ([]dto.WebhookDelivery, error)
+}
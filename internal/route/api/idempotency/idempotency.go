@@ -0,0 +1,116 @@
+// Package idempotency lets clients retry a request without running it twice:
+// the first request with an Idempotency-Key runs, the retries get its stored
+// response.
+package idempotency
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/service/idempotencyService"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"net/http"
+)
+
+const (
+	KeyHeader = "Idempotency-Key"
+	// ReplayedHeader marks a response stored for an earlier request.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// Service runs the requests, see idempotencyService.IdempotencyService.
+type Service interface {
+	Do(key, fingerprint string, handle func() (int, http.Header, []byte)) (*dto.IdempotencyRecord, bool, error)
+}
+
+// Handle runs next once per Idempotency-Key and answers the retries with its
+// stored response. Requests without the header run as usual. Errors are
+// written with writeErr, in the format of the API of next.
+func Handle(s Service, writeErr func(w http.ResponseWriter, code int, err error), next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		key := r.Header.Get(KeyHeader)
+		if key == "" {
+			next(w, r, ps)
+			return
+		}
+		if len(key) > maxKeyLength {
+			writeErr(w, http.StatusBadRequest, fmt.Errorf("%s is longer than %d characters", KeyHeader, maxKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeErr(w, http.StatusBadRequest, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		record, replayed, err := s.Do(key, requestFingerprint(r, body), func() (int, http.Header, []byte) {
+			rec := newResponseRecorder()
+			next(rec, r, ps)
+			return rec.status, rec.header, rec.body.Bytes()
+		})
+		switch {
+		case errors.Is(err, idempotencyService.ErrMismatch):
+			writeErr(w, http.StatusUnprocessableEntity, err)
+			return
+		case errors.Is(err, idempotencyService.ErrInProgress):
+			w.Header().Set("Retry-After", "1")
+			writeErr(w, http.StatusConflict, err)
+			return
+		case err != nil:
+			writeErr(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		for name, values := range record.Header {
+			w.Header()[name] = values
+		}
+		if replayed {
+			w.Header().Set(ReplayedHeader, "true")
+		}
+		w.WriteHeader(record.Status)
+		w.Write(record.Body)
+	}
+}
+
+// requestFingerprint tells apart requests sent with the same key. The actor
+// is part of it, so one caller can't replay the response of another.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	for _, part := range []string{r.Method, r.URL.Path, r.Header.Get("Content-Type"), r.Header.Get("X-Actor")} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder keeps a response to be stored before it is sent.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: http.Header{}, status: http.StatusOK}
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
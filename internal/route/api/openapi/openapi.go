@@ -0,0 +1,234 @@
+// Package openapi checks the requests and responses of the API against the
+// OpenAPI document it serves, so that the swag annotations and the handlers
+// can't drift apart unnoticed.
+package openapi
+
+import (
+	"ToDoVerba/pkg/logging"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Mode is what the validation does with a violation of the document.
+type Mode string
+
+const (
+	ModeOff Mode = "off"
+	// ModeLog logs violations and serves the request as usual, for development.
+	ModeLog Mode = "log"
+	// ModeStrict rejects invalid requests with a 400 and replaces invalid
+	// responses with a 500.
+	ModeStrict Mode = "strict"
+)
+
+var errInvalidResponse = errors.New("response does not match the api spec")
+
+// Validator checks requests and responses against a swagger 2.0 document,
+// such as the one swag generates.
+type Validator struct {
+	router routers.Router
+}
+
+func NewValidator(doc []byte) (*Validator, error) {
+	var doc2 openapi2.T
+	if err := json.Unmarshal(doc, &doc2); err != nil {
+		return nil, fmt.Errorf("parse api spec: %w", err)
+	}
+	convertAdditionalProperties(&doc2)
+	doc3, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		return nil, fmt.Errorf("convert api spec: %w", err)
+	}
+	// The host of the document is where its swagger UI points to, requests
+	// can come in through any other.
+	doc3.Servers = nil
+	if err = openapi3.NewLoader().ResolveRefsIn(doc3, nil); err != nil {
+		return nil, fmt.Errorf("resolve api spec: %w", err)
+	}
+	router, err := gorillamux.NewRouter(doc3)
+	if err != nil {
+		return nil, fmt.Errorf("route api spec: %w", err)
+	}
+	return &Validator{router: router}, nil
+}
+
+// Handler validates the requests and responses of next according to mode.
+// Rejections are written with writeErr, in the format of the API of the
+// request. Routes missing from the document are served unchecked.
+func (v *Validator) Handler(mode Mode, logger logging.Logger, writeErr func(w http.ResponseWriter, r *http.Request, code int, err error), next http.Handler) http.Handler {
+	if mode == ModeOff {
+		return next
+	}
+	strict := mode == ModeStrict
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := v.Serve(w, r, next, strict, writeErr)
+		if c.Request != nil {
+			logger.Warnf("[%s] %s request does not match the api spec: %s", r.Method, r.URL.Path, c.Request)
+		}
+		if c.Response != nil {
+			logger.Warnf("[%s] %s %d response does not match the api spec: %s", r.Method, r.URL.Path, c.Status, c.Response)
+		}
+	})
+}
+
+// Check is the outcome of serving a request through Serve.
+type Check struct {
+	// Documented tells whether the document has the route of the request.
+	Documented bool
+	// Status is the status of the response, 0 if the connection was hijacked.
+	Status   int
+	Request  error
+	Response error
+}
+
+// Serve serves r with next and validates them. Responses of the routes that
+// only produce JSON are held back until they are checked, others are streamed
+// and only their status is. When strict, a violation is answered with writeErr
+// instead.
+func (v *Validator) Serve(w http.ResponseWriter, r *http.Request, next http.Handler, strict bool, writeErr func(w http.ResponseWriter, r *http.Request, code int, err error)) Check {
+	route, params, err := v.router.FindRoute(r)
+	if err != nil {
+		rec := newRecorder(w, false)
+		next.ServeHTTP(rec, r)
+		return Check{Status: rec.status}
+	}
+
+	c := Check{Documented: true}
+	options := &openapi3filter.Options{
+		ExcludeRequestBody:    !isJSON(r.Header.Get("Content-Type")),
+		IncludeResponseStatus: true,
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+		SkipSettingDefaults:   true,
+	}
+	options.WithCustomSchemaErrorFunc(schemaError)
+	input := &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: params,
+		Route:      route,
+		Options:    options,
+	}
+	if c.Request = openapi3filter.ValidateRequest(r.Context(), input); c.Request != nil && strict {
+		c.Status = http.StatusBadRequest
+		writeErr(w, r, c.Status, c.Request)
+		return c
+	}
+
+	buffered := producesJSON(route.Operation)
+	rec := newRecorder(w, buffered)
+	next.ServeHTTP(rec, r)
+	c.Status = rec.status
+	if rec.hijacked {
+		return c
+	}
+
+	// The handler is done with the request, a canceled one is still checked.
+	c.Response = openapi3filter.ValidateResponse(context.WithoutCancel(r.Context()), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 rec.status,
+		Header:                 rec.Header(),
+		Body:                   rec.body(),
+		Options:                responseOptions(buffered),
+	})
+	if !buffered {
+		return c
+	}
+	if c.Response != nil && strict {
+		writeErr(w, r, http.StatusInternalServerError, errInvalidResponse)
+		return c
+	}
+	rec.flush()
+	return c
+}
+
+func responseOptions(buffered bool) *openapi3filter.Options {
+	options := &openapi3filter.Options{
+		ExcludeResponseBody:   !buffered,
+		IncludeResponseStatus: true,
+	}
+	options.WithCustomSchemaErrorFunc(schemaError)
+	return options
+}
+
+// schemaError tells where a value is invalid and why, without the schema and
+// the value the error prints by default.
+func schemaError(err *openapi3.SchemaError) string {
+	return fmt.Sprintf("%q %s", "/"+strings.Join(err.JSONPointer(), "/"), err.Reason)
+}
+
+// convertAdditionalProperties moves the references of additionalProperties,
+// such as the one of a map of structs, to their OpenAPI 3 location. The
+// converter of this kin-openapi version leaves them as they are.
+func convertAdditionalProperties(doc *openapi2.T) {
+	for _, schema := range doc.Definitions {
+		convertSchema(schema)
+	}
+	for _, pathItem := range doc.Paths {
+		for _, op := range pathItem.Operations() {
+			for _, parameter := range op.Parameters {
+				convertSchema(parameter.Schema)
+			}
+			for _, response := range op.Responses {
+				convertSchema(response.Schema)
+			}
+		}
+	}
+}
+
+func convertSchema(schema *openapi2.SchemaRef) {
+	if schema == nil || schema.Value == nil {
+		return
+	}
+	convertSchemaV3(schema.Value.AdditionalProperties.Schema)
+	convertSchema(schema.Value.Items)
+	for _, property := range schema.Value.Properties {
+		convertSchema(property)
+	}
+	for _, s := range schema.Value.AllOf {
+		convertSchema(s)
+	}
+}
+
+// convertSchemaV3 converts the schemas kept as they are, which are OpenAPI 3
+// types holding OpenAPI 2 references.
+func convertSchemaV3(schema *openapi3.SchemaRef) {
+	if schema == nil {
+		return
+	}
+	schema.Ref = openapi2conv.ToV3Ref(schema.Ref)
+	if schema.Value == nil {
+		return
+	}
+	convertSchemaV3(schema.Value.AdditionalProperties.Schema)
+	convertSchemaV3(schema.Value.Items)
+	for _, property := range schema.Value.Properties {
+		convertSchemaV3(property)
+	}
+}
+
+// producesJSON tells whether every response of op is JSON or empty.
+func producesJSON(op *openapi3.Operation) bool {
+	for _, response := range op.Responses.Map() {
+		for contentType := range response.Value.Content {
+			if !isJSON(contentType) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
@@ -0,0 +1,175 @@
+package openapi
+
+import (
+	"ToDoVerba/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testDoc = `{
+	"swagger": "2.0",
+	"info": {"title": "test", "version": "1"},
+	"host": "localhost:8082",
+	"basePath": "/",
+	"paths": {
+		"/items": {
+			"post": {
+				"consumes": ["application/json"],
+				"produces": ["application/json"],
+				"parameters": [{"name": "Item", "in": "body", "required": true, "schema": {"$ref": "#/definitions/item"}}],
+				"responses": {
+					"201": {"description": "Created", "schema": {"$ref": "#/definitions/item"}},
+					"400": {"description": "Bad Request"}
+				}
+			}
+		},
+		"/items/events": {
+			"get": {
+				"produces": ["text/event-stream"],
+				"responses": {"200": {"description": "OK", "schema": {"type": "string"}}}
+			}
+		}
+	},
+	"definitions": {
+		"item": {
+			"type": "object",
+			"required": ["name"],
+			"properties": {
+				"name": {"type": "string"},
+				"tags": {"type": "object", "additionalProperties": {"$ref": "#/definitions/tag"}}
+			}
+		},
+		"tag": {"type": "object", "properties": {"color": {"type": "string"}}}
+	}
+}`
+
+func writeTestErr(w http.ResponseWriter, r *http.Request, code int, err error) {
+	w.WriteHeader(code)
+	w.Write([]byte("rejected: " + err.Error()))
+}
+
+func TestValidator_Handler(t *testing.T) {
+	v, err := NewValidator([]byte(testDoc))
+	require.NoError(t, err)
+
+	items := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/items":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(r.URL.Query().Get("respond")))
+		case "/items/events":
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: 1\n\n"))
+			w.(http.Flusher).Flush()
+		default:
+			w.WriteHeader(http.StatusTeapot)
+		}
+	})
+
+	testTable := []struct {
+		name         string
+		mode         Mode
+		method       string
+		path         string
+		inputBody    string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "valid",
+			mode:         ModeStrict,
+			method:       "POST",
+			path:         `/items?respond={"name":"a","tags":{"x":{"color":"red"}}}`,
+			inputBody:    `{"name": "a"}`,
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"name":"a","tags":{"x":{"color":"red"}}}`,
+		},
+		{
+			name:         "strict_invalid_request",
+			mode:         ModeStrict,
+			method:       "POST",
+			path:         `/items?respond={"name":"a"}`,
+			inputBody:    `{"tags": {}}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "rejected: request body has an error",
+		},
+		{
+			name:         "strict_invalid_response",
+			mode:         ModeStrict,
+			method:       "POST",
+			path:         `/items?respond={"tags":{"x":{"color":1}}}`,
+			inputBody:    `{"name": "a"}`,
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: "rejected: response does not match the api spec",
+		},
+		{
+			name:         "log_invalid_request_and_response",
+			mode:         ModeLog,
+			method:       "POST",
+			path:         `/items?respond={}`,
+			inputBody:    `{}`,
+			expectedCode: http.StatusCreated,
+			expectedBody: `{}`,
+		},
+		{
+			name:         "off",
+			mode:         ModeOff,
+			method:       "POST",
+			path:         `/items?respond={}`,
+			inputBody:    `{}`,
+			expectedCode: http.StatusCreated,
+			expectedBody: `{}`,
+		},
+		{
+			name:         "undocumented_route",
+			mode:         ModeStrict,
+			method:       "GET",
+			path:         "/teapot",
+			expectedCode: http.StatusTeapot,
+		},
+		{
+			name:         "streamed_route",
+			mode:         ModeStrict,
+			method:       "GET",
+			path:         "/items/events",
+			expectedCode: http.StatusOK,
+			expectedBody: "data: 1\n\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			h := v.Handler(testCase.mode, logging.GetLoggerTest(), writeTestErr, items)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, testCase.path, strings.NewReader(testCase.inputBody))
+			req.Header.Set("Content-Type", "application/json")
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			assert.True(t, strings.HasPrefix(w.Body.String(), testCase.expectedBody), w.Body.String())
+		})
+	}
+}
+
+func TestValidator_Serve_streamsUnbuffered(t *testing.T) {
+	v, err := NewValidator([]byte(testDoc))
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c := v.Serve(w, httptest.NewRequest("GET", "/items/events", nil), http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("data: 1\n\n"))
+		rw.(http.Flusher).Flush()
+		assert.True(t, w.Flushed, "the event reaches the client before the handler returns")
+	}), true, writeTestErr)
+
+	assert.True(t, c.Documented)
+	assert.Equal(t, http.StatusOK, c.Status)
+	assert.NoError(t, c.Request)
+	assert.NoError(t, c.Response)
+}
@@ -0,0 +1,45 @@
+// Package openapitest fails handler tests whose requests or responses don't
+// match the OpenAPI document of the API.
+package openapitest
+
+import (
+	"ToDoVerba/docs"
+	"ToDoVerba/internal/route/api/openapi"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+var (
+	loadOnce  sync.Once
+	validator *openapi.Validator
+	loadErr   error
+)
+
+// Handler serves next and fails t when:
+//   - the route of a request is missing from the document;
+//   - the handler accepts a request the document doesn't allow. Requests
+//     the handler rejects are expected to be invalid;
+//   - a status, header or JSON body of a response isn't documented.
+func Handler(t testing.TB, next http.Handler) http.Handler {
+	t.Helper()
+	loadOnce.Do(func() {
+		validator, loadErr = openapi.NewValidator([]byte(docs.SwaggerInfo.ReadDoc()))
+	})
+	if loadErr != nil {
+		t.Fatalf("load api spec: %s", loadErr)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := validator.Serve(w, r, next, false, nil)
+		switch {
+		case !c.Documented:
+			t.Errorf("[%s] %s is not in the api spec", r.Method, r.URL.Path)
+		case c.Request != nil && c.Status < http.StatusBadRequest:
+			t.Errorf("[%s] %s answered %d to a request the api spec doesn't allow: %s", r.Method, r.URL.Path, c.Status, c.Request)
+		}
+		if c.Response != nil {
+			t.Errorf("[%s] %s %d response does not match the api spec: %s", r.Method, r.URL.Path, c.Status, c.Response)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package openapi
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+)
+
+// recorder notes the status of a response and, when buffered, holds the
+// response back until flush.
+type recorder struct {
+	w        http.ResponseWriter
+	buffered bool
+	header   http.Header
+	status   int
+	wrote    bool
+	buf      bytes.Buffer
+	hijacked bool
+}
+
+func newRecorder(w http.ResponseWriter, buffered bool) *recorder {
+	rec := &recorder{w: w, buffered: buffered, status: http.StatusOK}
+	if buffered {
+		rec.header = http.Header{}
+	}
+	return rec
+}
+
+func (r *recorder) Header() http.Header {
+	if r.buffered {
+		return r.header
+	}
+	return r.w.Header()
+}
+
+func (r *recorder) WriteHeader(code int) {
+	if r.wrote {
+		return
+	}
+	r.status, r.wrote = code, true
+	if !r.buffered {
+		r.w.WriteHeader(code)
+	}
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	if r.buffered {
+		return r.buf.Write(b)
+	}
+	return r.w.Write(b)
+}
+
+// Flush lets streamed responses, such as server-sent events, through.
+func (r *recorder) Flush() {
+	if f, ok := r.w.(http.Flusher); ok && !r.buffered {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over, for websockets. The response is no
+// longer the recorder's to check.
+func (r *recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.w.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer can't be hijacked")
+	}
+	r.hijacked, r.status = true, 0
+	return h.Hijack()
+}
+
+func (r *recorder) body() io.ReadCloser {
+	return io.NopCloser(bytes.NewReader(r.buf.Bytes()))
+}
+
+// flush writes a buffered response out.
+func (r *recorder) flush() {
+	for name, values := range r.header {
+		r.w.Header()[name] = values
+	}
+	r.w.WriteHeader(r.status)
+	r.w.Write(r.buf.Bytes())
+}
@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"ToDoVerba/internal/schemas"
+	"ToDoVerba/internal/service/backupService"
+	"errors"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const backupContentType = "application/x-ndjson"
+
+func (h *Handler) initBackupHandler(r *httprouter.Router) {
+	r.GET("/admin/backup", h.backup)
+	r.POST("/admin/restore", h.restore)
+}
+
+// backup godoc
+// @Tags         Admin API
+// @Summary      Backup Summary
+// @Description  Stream a versioned NDJSON backup of all tasks with their events, versions, reminders and caldav objects. The last line counts the records, a backup without it was cut off
+// @Produce      application/x-ndjson
+// @Param Authorization header string true "Bearer admin token"
+// @Success      200  {string}  string "ndjson"
+// @Failure      401  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /admin/backup [get]
+func (h *Handler) backup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s backup called", r.Method, r.RemoteAddr)
+
+	if err := h.service.Backup.Authorize(requestToken(r)); err != nil {
+		writeResponseErr(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	// The status goes out with the first line, so a failure before it can
+	// still be answered with an error status.
+	bw := &backupWriter{w: w}
+	_, err := h.service.Backup.Backup(r.Context(), bw)
+	if err != nil && !bw.started {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("backup aborted: %s", err)
+	}
+}
+
+// backupWriter sends the response headers on the first write.
+type backupWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (b *backupWriter) Write(p []byte) (int, error) {
+	if !b.started {
+		b.started = true
+		filename := "todoverba-" + time.Now().UTC().Format("20060102T150405Z") + ".ndjson"
+		b.w.Header().Set("Content-Type", backupContentType)
+		b.w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		b.w.WriteHeader(http.StatusOK)
+	}
+	return b.w.Write(p)
+}
+
+// restore godoc
+// @Tags         Admin API
+// @Summary      Restore Summary
+// @Description  Restore an NDJSON backup, all of it or nothing. Tasks get new ids and their records are remapped. merge adds the backup to the existing tasks, replace deletes them first. Restored tasks publish no events
+// @Accept       application/x-ndjson
+// @Produce      json
+// @Param backup body string true "NDJSON backup"
+// @Param mode query string false "merge (default) or replace"
+// @Param Authorization header string true "Bearer admin token"
+// @Success      200  {object}  schemas.ResponseBackupStats
+// @Failure      400  {object}	errorJSON
+// @Failure      401  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /admin/restore [post]
+func (h *Handler) restore(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s restore called", r.Method, r.RemoteAddr)
+
+	if err := h.service.Backup.Authorize(requestToken(r)); err != nil {
+		writeResponseErr(w, http.StatusUnauthorized, err)
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("content-type"), backupContentType) {
+		writeResponseErr(w, http.StatusBadRequest,
+			errors.New("content-type is not "+backupContentType))
+		return
+	}
+
+	options := schemas.RequestRestore{}
+	options.ScanQuery(r.URL.Query())
+	err := options.Valid()
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stats, err := h.service.Backup.Restore(r.Context(), r.Body, options.Mode)
+	if err != nil {
+		if errors.Is(err, backupService.ErrInvalidBackup) {
+			writeResponseErr(w, http.StatusBadRequest, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := schemas.ResponseBackupStats{}
+	resp.ScanDTO(stats)
+
+	writeResponse(w, http.StatusOK, resp)
+}
@@ -0,0 +1,214 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/route/api/openapi/openapitest"
+	"ToDoVerba/internal/service"
+	"ToDoVerba/internal/service/backupService"
+	mockservice "ToDoVerba/internal/service/mocks"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler_backup(t *testing.T) {
+	type mockBehaviour func(s *mockservice.MockIBackupService)
+
+	testTable := []struct {
+		name          string
+		mockBehaviour mockBehaviour
+		expectedCode  int
+		expectedType  string
+		expectedBody  string
+	}{
+		{
+			name: "200_streamed",
+			mockBehaviour: func(s *mockservice.MockIBackupService) {
+				s.EXPECT().Authorize("secret").Return(nil)
+				s.EXPECT().Backup(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, w io.Writer) (*dto.BackupStats, error) {
+						io.WriteString(w, "{\"type\":\"header\"}\n")
+						io.WriteString(w, "{\"type\":\"footer\"}\n")
+						return &dto.BackupStats{}, nil
+					})
+			},
+			expectedCode: 200,
+			expectedType: "application/x-ndjson",
+			expectedBody: "{\"type\":\"header\"}\n{\"type\":\"footer\"}\n",
+		},
+		{
+			name: "401_unauthorized",
+			mockBehaviour: func(s *mockservice.MockIBackupService) {
+				s.EXPECT().Authorize("secret").Return(backupService.ErrUnauthorized)
+			},
+			expectedCode: 401,
+			expectedType: "application/json",
+			expectedBody: `{"error":"invalid or missing admin token"}`,
+		},
+		{
+			name: "500_before_first_line",
+			mockBehaviour: func(s *mockservice.MockIBackupService) {
+				s.EXPECT().Authorize("secret").Return(nil)
+				s.EXPECT().Backup(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+			},
+			expectedCode: 500,
+			expectedType: "application/json",
+			expectedBody: `{"error":"connection refused"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			backups := mockservice.NewMockIBackupService(c)
+			testCase.mockBehaviour(backups)
+
+			handler := NewHandler(Deps{
+				Service: service.Services{Backup: backups},
+				Logger:  logging.GetLoggerTest(),
+			})
+
+			r := httprouter.New()
+			r.GET("/admin/backup", handler.backup)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin/backup", nil)
+			req.Header.Set("Authorization", "Bearer secret")
+
+			openapitest.Handler(t, r).ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			assert.Equal(t, testCase.expectedType, w.Header().Get("Content-Type"))
+			assert.Equal(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_restore(t *testing.T) {
+	type mockBehaviour func(s *mockservice.MockIBackupService)
+
+	body := "{\"type\":\"header\"}\n"
+
+	testTable := []struct {
+		name          string
+		inputQuery    string
+		contentType   string
+		mockBehaviour mockBehaviour
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			name:        "200_merge_by_default",
+			contentType: "application/x-ndjson",
+			mockBehaviour: func(s *mockservice.MockIBackupService) {
+				s.EXPECT().Authorize("secret").Return(nil)
+				s.EXPECT().Restore(gomock.Any(), gomock.Any(), dto.BackupModeMerge).DoAndReturn(
+					func(ctx context.Context, r io.Reader, mode string) (*dto.BackupStats, error) {
+						read, _ := io.ReadAll(r)
+						assert.Equal(t, body, string(read))
+						return &dto.BackupStats{Tasks: 2, TaskEvents: 5, TaskVersions: 3, Reminders: 1}, nil
+					})
+			},
+			expectedCode: 200,
+			expectedBody: `{"tasks":2,"task_events":5,"task_versions":3,"reminders":1,"caldav_objects":0}`,
+		},
+		{
+			name:        "200_replace",
+			inputQuery:  "?mode=replace",
+			contentType: "application/x-ndjson",
+			mockBehaviour: func(s *mockservice.MockIBackupService) {
+				s.EXPECT().Authorize("secret").Return(nil)
+				s.EXPECT().Restore(gomock.Any(), gomock.Any(), dto.BackupModeReplace).Return(&dto.BackupStats{}, nil)
+			},
+			expectedCode: 200,
+			expectedBody: `{"tasks":0,"task_events":0,"task_versions":0,"reminders":0,"caldav_objects":0}`,
+		},
+		{
+			name:        "400_invalid_backup",
+			contentType: "application/x-ndjson",
+			mockBehaviour: func(s *mockservice.MockIBackupService) {
+				s.EXPECT().Authorize("secret").Return(nil)
+				s.EXPECT().Restore(gomock.Any(), gomock.Any(), dto.BackupModeMerge).Return(nil,
+					fmt.Errorf("%w: line 1: backup version 2 is not supported, expected 1", backupService.ErrInvalidBackup))
+			},
+			expectedCode: 400,
+			expectedBody: `{"error":"invalid backup: line 1: backup version 2 is not supported, expected 1"}`,
+		},
+		{
+			name:        "400_invalid_mode",
+			inputQuery:  "?mode=append",
+			contentType: "application/x-ndjson",
+			mockBehaviour: func(s *mockservice.MockIBackupService) {
+				s.EXPECT().Authorize("secret").Return(nil)
+			},
+			expectedCode: 400,
+			expectedBody: `{"error":"mode must be merge or replace;"}`,
+		},
+		{
+			name:        "400_wrong_content_type",
+			contentType: "text/csv",
+			mockBehaviour: func(s *mockservice.MockIBackupService) {
+				s.EXPECT().Authorize("secret").Return(nil)
+			},
+			expectedCode: 400,
+			expectedBody: `{"error":"content-type is not application/x-ndjson"}`,
+		},
+		{
+			name:        "401_unauthorized",
+			contentType: "application/x-ndjson",
+			mockBehaviour: func(s *mockservice.MockIBackupService) {
+				s.EXPECT().Authorize("secret").Return(backupService.ErrUnauthorized)
+			},
+			expectedCode: 401,
+			expectedBody: `{"error":"invalid or missing admin token"}`,
+		},
+		{
+			name:        "500_unknown_error",
+			contentType: "application/x-ndjson",
+			mockBehaviour: func(s *mockservice.MockIBackupService) {
+				s.EXPECT().Authorize("secret").Return(nil)
+				s.EXPECT().Restore(gomock.Any(), gomock.Any(), dto.BackupModeMerge).Return(nil, errors.New("unknown error"))
+			},
+			expectedCode: 500,
+			expectedBody: `{"error":"unknown error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			backups := mockservice.NewMockIBackupService(c)
+			testCase.mockBehaviour(backups)
+
+			handler := NewHandler(Deps{
+				Service: service.Services{Backup: backups},
+				Logger:  logging.GetLoggerTest(),
+			})
+
+			r := httprouter.New()
+			r.POST("/admin/restore", handler.restore)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/admin/restore"+testCase.inputQuery, strings.NewReader(body))
+			req.Header.Set("Content-Type", testCase.contentType)
+			req.Header.Set("Authorization", "Bearer secret")
+
+			openapitest.Handler(t, r).ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			assert.JSONEq(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
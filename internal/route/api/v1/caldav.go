@@ -0,0 +1,668 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/schemas"
+	"ToDoVerba/internal/service/caldavService"
+	"ToDoVerba/internal/service/userService"
+	"ToDoVerba/pkg/ical"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The CalDAV tree: the calendar home holds a single calendar collection with
+// one VTODO resource per task.
+const (
+	davRoot      = "/dav/"
+	davPrincipal = "/dav/principal/"
+	davTasks     = "/dav/tasks/"
+
+	davMaxBody     = 1 << 20
+	davContentType = "text/calendar; charset=utf-8; component=vtodo"
+	davStatusOK    = "HTTP/1.1 200 OK"
+	davStatusGone  = "HTTP/1.1 404 Not Found"
+
+	// Every authenticated user may read and write the tasks.
+	davPrivilegeSet = "<D:privilege><D:read/></D:privilege><D:privilege><D:write/></D:privilege>" +
+		"<D:privilege><D:write-content/></D:privilege><D:privilege><D:bind/></D:privilege>" +
+		"<D:privilege><D:unbind/></D:privilege>"
+)
+
+var (
+	davResourceType       = xml.Name{Space: schemas.NamespaceDAV, Local: "resourcetype"}
+	davDisplayName        = xml.Name{Space: schemas.NamespaceDAV, Local: "displayname"}
+	davCurrentUser        = xml.Name{Space: schemas.NamespaceDAV, Local: "current-user-principal"}
+	davPrincipalURL       = xml.Name{Space: schemas.NamespaceDAV, Local: "principal-URL"}
+	davOwner              = xml.Name{Space: schemas.NamespaceDAV, Local: "owner"}
+	davSyncToken          = xml.Name{Space: schemas.NamespaceDAV, Local: "sync-token"}
+	davSupportedReports   = xml.Name{Space: schemas.NamespaceDAV, Local: "supported-report-set"}
+	davPrivileges         = xml.Name{Space: schemas.NamespaceDAV, Local: "current-user-privilege-set"}
+	davETag               = xml.Name{Space: schemas.NamespaceDAV, Local: "getetag"}
+	davContentTypeProp    = xml.Name{Space: schemas.NamespaceDAV, Local: "getcontenttype"}
+	davLastModified       = xml.Name{Space: schemas.NamespaceDAV, Local: "getlastmodified"}
+	davSyncCollection     = xml.Name{Space: schemas.NamespaceDAV, Local: "sync-collection"}
+	davValidSyncToken     = xml.Name{Space: schemas.NamespaceDAV, Local: "valid-sync-token"}
+	davSupportedReport    = xml.Name{Space: schemas.NamespaceDAV, Local: "supported-report"}
+	calHomeSet            = xml.Name{Space: schemas.NamespaceCalDAV, Local: "calendar-home-set"}
+	calComponentSet       = xml.Name{Space: schemas.NamespaceCalDAV, Local: "supported-calendar-component-set"}
+	calData               = xml.Name{Space: schemas.NamespaceCalDAV, Local: "calendar-data"}
+	calQuery              = xml.Name{Space: schemas.NamespaceCalDAV, Local: "calendar-query"}
+	calMultiget           = xml.Name{Space: schemas.NamespaceCalDAV, Local: "calendar-multiget"}
+	calSupportedData      = xml.Name{Space: schemas.NamespaceCalDAV, Local: "supported-calendar-data"}
+	calValidData          = xml.Name{Space: schemas.NamespaceCalDAV, Local: "valid-calendar-data"}
+	calSupportedComponent = xml.Name{Space: schemas.NamespaceCalDAV, Local: "supported-calendar-component"}
+	calNoUidConflict      = xml.Name{Space: schemas.NamespaceCalDAV, Local: "no-uid-conflict"}
+	csCTag                = xml.Name{Space: schemas.NamespaceCalendarServ, Local: "getctag"}
+)
+
+// davHandle is a CalDAV handler of an authenticated user.
+type davHandle func(w http.ResponseWriter, r *http.Request, ps httprouter.Params, user *dto.UserRead)
+
+func (h *Handler) initCalDAVHandler(r *httprouter.Router) {
+	discovery := http.RedirectHandler(davRoot, http.StatusMovedPermanently)
+	r.Handler("GET", "/.well-known/caldav", discovery)
+	r.Handler("PROPFIND", "/.well-known/caldav", discovery)
+
+	for _, path := range []string{davRoot, davPrincipal, davTasks, davTasks + ":name"} {
+		r.OPTIONS(path, h.davOptions)
+	}
+	r.Handle("PROPFIND", davRoot, h.davAuth(h.davHomePropfind))
+	r.Handle("PROPFIND", davPrincipal, h.davAuth(h.davPrincipalPropfind))
+	r.Handle("PROPFIND", davTasks, h.davAuth(h.davCollectionPropfind))
+	r.Handle("REPORT", davTasks, h.davAuth(h.davCollectionReport))
+	r.Handle("PROPFIND", davTasks+":name", h.davAuth(h.davObjectPropfind))
+	r.GET(davTasks+":name", h.davAuth(h.davObjectGet))
+	r.PUT(davTasks+":name", h.davAuth(h.davObjectPut))
+	r.DELETE(davTasks+":name", h.davAuth(h.davObjectDelete))
+}
+
+// davAuth authenticates with the api token, sent as the password of basic
+// auth by most CalDAV clients.
+func (h *Handler) davAuth(next davHandle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		user, err := h.service.User.Authenticate(requestToken(r))
+		if err != nil {
+			if errors.Is(err, userService.ErrUnauthorized) {
+				w.Header().Set("WWW-Authenticate", `Basic realm="ToDoVerba"`)
+				writeResponseErr(w, http.StatusUnauthorized, err)
+				return
+			}
+			writeResponseErr(w, http.StatusInternalServerError, err)
+			return
+		}
+		next(w, r, ps, user)
+	}
+}
+
+func (h *Handler) davOptions(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("DAV", "1, 3, calendar-access")
+	w.Header().Set("Allow", "OPTIONS, GET, PUT, DELETE, PROPFIND, REPORT")
+	w.WriteHeader(http.StatusOK)
+}
+
+// davHomePropfind answers PROPFIND on the calendar home.
+func (h *Handler) davHomePropfind(w http.ResponseWriter, r *http.Request, ps httprouter.Params, user *dto.UserRead) {
+	h.logger.Debugf("[%s] %s davHomePropfind called", r.Method, r.RemoteAddr)
+
+	names, propName, err := readPropfind(r)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ms := schemas.NewMultistatus()
+	ms.Responses = append(ms.Responses, davPropResponse(davRoot, map[xml.Name]string{
+		davResourceType: "<D:collection/>",
+		davCurrentUser:  davHref(davPrincipal),
+		calHomeSet:      davHref(davRoot),
+	}, names, propName))
+
+	if r.Header.Get("Depth") != "0" {
+		props, err := h.davCollectionProps()
+		if err != nil {
+			writeResponseErr(w, http.StatusInternalServerError, err)
+			return
+		}
+		ms.Responses = append(ms.Responses, davPropResponse(davTasks, props, names, propName))
+	}
+
+	writeMultistatus(w, ms)
+}
+
+// davPrincipalPropfind answers PROPFIND on the principal of the caller.
+func (h *Handler) davPrincipalPropfind(w http.ResponseWriter, r *http.Request, ps httprouter.Params, user *dto.UserRead) {
+	h.logger.Debugf("[%s] %s davPrincipalPropfind called", r.Method, r.RemoteAddr)
+
+	names, propName, err := readPropfind(r)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ms := schemas.NewMultistatus()
+	ms.Responses = append(ms.Responses, davPropResponse(davPrincipal, map[xml.Name]string{
+		davResourceType: "<D:principal/>",
+		davDisplayName:  schemas.DAVText(user.Name),
+		davCurrentUser:  davHref(davPrincipal),
+		davPrincipalURL: davHref(davPrincipal),
+		calHomeSet:      davHref(davRoot),
+	}, names, propName))
+
+	writeMultistatus(w, ms)
+}
+
+// davCollectionPropfind answers PROPFIND on the task calendar, listing the
+// tasks unless Depth is 0.
+func (h *Handler) davCollectionPropfind(w http.ResponseWriter, r *http.Request, ps httprouter.Params, user *dto.UserRead) {
+	h.logger.Debugf("[%s] %s davCollectionPropfind called", r.Method, r.RemoteAddr)
+
+	names, propName, err := readPropfind(r)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	props, err := h.davCollectionProps()
+	if err != nil {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	ms := schemas.NewMultistatus()
+	ms.Responses = append(ms.Responses, davPropResponse(davTasks, props, names, propName))
+
+	if r.Header.Get("Depth") != "0" {
+		resources, err := h.service.CalDAV.List()
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			writeResponseErr(w, http.StatusInternalServerError, err)
+			return
+		}
+		for i := range resources {
+			response, err := davObjectResponse(&resources[i], names, propName)
+			if err != nil {
+				writeResponseErr(w, http.StatusInternalServerError, err)
+				return
+			}
+			ms.Responses = append(ms.Responses, response)
+		}
+	}
+
+	writeMultistatus(w, ms)
+}
+
+// davCollectionReport answers the calendar-query, calendar-multiget and
+// sync-collection reports of the task calendar.
+func (h *Handler) davCollectionReport(w http.ResponseWriter, r *http.Request, ps httprouter.Params, user *dto.UserRead) {
+	h.logger.Debugf("[%s] %s davCollectionReport called", r.Method, r.RemoteAddr)
+
+	report := schemas.RequestReport{}
+	if err := readDAVBody(r, &report); err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+	names := []xml.Name(report.Prop)
+	if len(names) == 0 {
+		names = []xml.Name{davETag}
+	}
+
+	ms := schemas.NewMultistatus()
+	var resources []dto.CalDAVResource
+
+	switch report.XMLName {
+	case calQuery:
+		all, err := h.service.CalDAV.List()
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			writeResponseErr(w, http.StatusInternalServerError, err)
+			return
+		}
+		for i := range all {
+			if report.Filter == nil || report.Filter.Match(schemas.ResourceToCalendar(&all[i])) {
+				resources = append(resources, all[i])
+			}
+		}
+
+	case calMultiget:
+		for _, href := range report.Hrefs {
+			name, ok := davObjectName(href)
+			if !ok {
+				ms.Responses = append(ms.Responses, schemas.ResponseDAV{Href: href, Status: davStatusGone})
+				continue
+			}
+			resource, err := h.service.CalDAV.Find(name)
+			if errors.Is(err, pgx.ErrNoRows) {
+				ms.Responses = append(ms.Responses, schemas.ResponseDAV{Href: href, Status: davStatusGone})
+				continue
+			}
+			if err != nil {
+				writeResponseErr(w, http.StatusInternalServerError, err)
+				return
+			}
+			resources = append(resources, *resource)
+		}
+
+	case davSyncCollection:
+		token, err := schemas.ParseCalDAVSyncToken(report.SyncToken)
+		if err != nil {
+			writeDAVError(w, http.StatusForbidden, davValidSyncToken)
+			return
+		}
+		changes, err := h.service.CalDAV.Changes(token)
+		if err != nil {
+			if errors.Is(err, caldavService.ErrInvalidSyncToken) {
+				writeDAVError(w, http.StatusForbidden, davValidSyncToken)
+				return
+			}
+			writeResponseErr(w, http.StatusInternalServerError, err)
+			return
+		}
+		resources = changes.Changed
+		for _, href := range changes.Removed {
+			ms.Responses = append(ms.Responses, schemas.ResponseDAV{Href: davObjectHref(href), Status: davStatusGone})
+		}
+		ms.SyncToken = schemas.CalDAVSyncToken(changes.SyncToken)
+
+	default:
+		writeDAVError(w, http.StatusForbidden, davSupportedReport)
+		return
+	}
+
+	for i := range resources {
+		response, err := davObjectResponse(&resources[i], names, false)
+		if err != nil {
+			writeResponseErr(w, http.StatusInternalServerError, err)
+			return
+		}
+		ms.Responses = append(ms.Responses, response)
+	}
+
+	writeMultistatus(w, ms)
+}
+
+// davObjectPropfind answers PROPFIND on a task resource.
+func (h *Handler) davObjectPropfind(w http.ResponseWriter, r *http.Request, ps httprouter.Params, user *dto.UserRead) {
+	h.logger.Debugf("[%s] %s davObjectPropfind called", r.Method, r.RemoteAddr)
+
+	names, propName, err := readPropfind(r)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resource, err := h.service.CalDAV.Find(ps.ByName("name"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeResponseErr(w, http.StatusNotFound, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response, err := davObjectResponse(resource, names, propName)
+	if err != nil {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	ms := schemas.NewMultistatus()
+	ms.Responses = append(ms.Responses, response)
+	writeMultistatus(w, ms)
+}
+
+// davObjectGet godoc
+// @Tags         CalDAV
+// @Summary      Get task calendar object Summary
+// @Description  The task as a VCALENDAR with one VTODO. The ETag is the task id and version. Authenticate with the api token as basic auth password
+// @Produce      text/calendar
+// @Param name path string true "Resource name"
+// @Success      200  {string}  string "calendar object"
+// @Success      304
+// @Failure      401  {object}	errorJSON
+// @Failure      404  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /dav/tasks/{name} [get]
+func (h *Handler) davObjectGet(w http.ResponseWriter, r *http.Request, ps httprouter.Params, user *dto.UserRead) {
+	h.logger.Debugf("[%s] %s davObjectGet called", r.Method, r.RemoteAddr)
+
+	resource, err := h.service.CalDAV.Find(ps.ByName("name"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeResponseErr(w, http.StatusNotFound, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	etag := taskETag(&resource.Task)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", resource.Task.UpdatedAt.Time.UTC().Format(http.TimeFormat))
+	if etagMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body := &bytes.Buffer{}
+	if err = ical.Encode(body, schemas.ResourceToCalendar(resource)); err != nil {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", davContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
+
+// davObjectPut godoc
+// @Tags         CalDAV
+// @Summary      Put task calendar object Summary
+// @Description  Create or update the task of a resource from a VCALENDAR with one VTODO. SUMMARY, DESCRIPTION, DUE and STATUS are kept, other properties are dropped, so no ETag is returned. Honors If-Match and If-None-Match
+// @Accept       text/calendar
+// @Param name path string true "Resource name"
+// @Param calendar body string true "VCALENDAR"
+// @Success      201
+// @Success      204
+// @Failure      400  {object}	errorJSON
+// @Failure      401  {object}	errorJSON
+// @Failure      403
+// @Failure      412
+// @Failure      500  {object}	errorJSON
+// @Router       /dav/tasks/{name} [put]
+func (h *Handler) davObjectPut(w http.ResponseWriter, r *http.Request, ps httprouter.Params, user *dto.UserRead) {
+	h.logger.Debugf("[%s] %s davObjectPut called", r.Method, r.RemoteAddr)
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "text/calendar") {
+		writeDAVError(w, http.StatusForbidden, calSupportedData)
+		return
+	}
+
+	bodyRaw, err := io.ReadAll(http.MaxBytesReader(w, r.Body, davMaxBody))
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+	cal, err := ical.Decode(bytes.NewReader(bodyRaw))
+	if err != nil {
+		writeDAVError(w, http.StatusForbidden, calValidData)
+		return
+	}
+	object := schemas.RequestCalDAVObject{}
+	if err = object.ScanCalendar(cal); err != nil {
+		writeDAVError(w, http.StatusForbidden, calSupportedComponent)
+		return
+	}
+	if err = object.Valid(); err != nil {
+		writeDAVError(w, http.StatusForbidden, calValidData)
+		return
+	}
+
+	name := ps.ByName("name")
+	existing, err := h.service.CalDAV.Find(name)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !davPreconditions(r, existing) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
+	update := object.ToDTO()
+	update.Actor = user.Name
+	update.Version = davCheckedVersion(r, existing)
+	_, created, err := h.service.CalDAV.Put(name, object.Uid, update)
+	if err != nil {
+		if errors.Is(err, caldavService.ErrUidConflict) {
+			writeDAVError(w, http.StatusForbidden, calNoUidConflict)
+			return
+		}
+		if errors.Is(err, dto.ErrVersionConflict) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if created {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// davObjectDelete godoc
+// @Tags         CalDAV
+// @Summary      Delete task calendar object Summary
+// @Description  Delete the task of a resource. Honors If-Match
+// @Param name path string true "Resource name"
+// @Success      204
+// @Failure      401  {object}	errorJSON
+// @Failure      404  {object}	errorJSON
+// @Failure      412
+// @Failure      500  {object}	errorJSON
+// @Router       /dav/tasks/{name} [delete]
+func (h *Handler) davObjectDelete(w http.ResponseWriter, r *http.Request, ps httprouter.Params, user *dto.UserRead) {
+	h.logger.Debugf("[%s] %s davObjectDelete called", r.Method, r.RemoteAddr)
+
+	name := ps.ByName("name")
+	existing, err := h.service.CalDAV.Find(name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeResponseErr(w, http.StatusNotFound, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !davPreconditions(r, existing) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
+	if err = h.service.CalDAV.Delete(name, davCheckedVersion(r, existing), user.Name); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeResponseErr(w, http.StatusNotFound, err)
+			return
+		}
+		if errors.Is(err, dto.ErrVersionConflict) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) davCollectionProps() (map[xml.Name]string, error) {
+	token, err := h.service.CalDAV.SyncToken()
+	if err != nil {
+		return nil, err
+	}
+	return map[xml.Name]string{
+		davResourceType:     "<D:collection/><C:calendar/>",
+		davDisplayName:      "Tasks",
+		davCurrentUser:      davHref(davPrincipal),
+		davOwner:            davHref(davPrincipal),
+		davSyncToken:        schemas.CalDAVSyncToken(token),
+		csCTag:              strconv.FormatInt(token, 10),
+		calComponentSet:     `<C:comp name="VTODO"/>`,
+		davSupportedReports: davReports(calQuery, calMultiget, davSyncCollection),
+		davPrivileges:       davPrivilegeSet,
+	}, nil
+}
+
+// davObjectResponse renders calendar-data only when it is asked for by name.
+func davObjectResponse(resource *dto.CalDAVResource, names []xml.Name, propName bool) (schemas.ResponseDAV, error) {
+	props := map[xml.Name]string{
+		davResourceType:    "",
+		davETag:            schemas.DAVText(taskETag(&resource.Task)),
+		davContentTypeProp: davContentType,
+		davLastModified:    resource.Task.UpdatedAt.Time.UTC().Format(http.TimeFormat),
+	}
+	for _, name := range names {
+		if name != calData {
+			continue
+		}
+		body := &bytes.Buffer{}
+		if err := ical.Encode(body, schemas.ResourceToCalendar(resource)); err != nil {
+			return schemas.ResponseDAV{}, err
+		}
+		props[calData] = schemas.DAVText(body.String())
+	}
+	return davPropResponse(davObjectHref(resource.Href), props, names, propName), nil
+}
+
+// davPropResponse answers names from props, with a 404 propstat for the
+// names the resource lacks. Nil names ask for every property.
+func davPropResponse(href string, props map[xml.Name]string, names []xml.Name, propName bool) schemas.ResponseDAV {
+	if names == nil {
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return names[i].Space+names[i].Local < names[j].Space+names[j].Local
+		})
+	}
+
+	found, missing := &strings.Builder{}, &strings.Builder{}
+	for _, name := range names {
+		value, ok := props[name]
+		if !ok {
+			missing.WriteString(schemas.DAVElement(name, ""))
+			continue
+		}
+		if propName {
+			value = ""
+		}
+		found.WriteString(schemas.DAVElement(name, value))
+	}
+
+	response := schemas.ResponseDAV{Href: href}
+	if found.Len() > 0 {
+		response.Propstats = append(response.Propstats, schemas.ResponsePropstat{
+			Prop:   schemas.ResponseProp{InnerXML: found.String()},
+			Status: davStatusOK,
+		})
+	}
+	if missing.Len() > 0 {
+		response.Propstats = append(response.Propstats, schemas.ResponsePropstat{
+			Prop:   schemas.ResponseProp{InnerXML: missing.String()},
+			Status: davStatusGone,
+		})
+	}
+	return response
+}
+
+// davPreconditions checks If-Match and If-None-Match against the resource,
+// nil when it does not exist yet.
+func davPreconditions(r *http.Request, existing *dto.CalDAVResource) bool {
+	etag := ""
+	if existing != nil {
+		etag = taskETag(&existing.Task)
+	}
+	if match := r.Header.Get("If-Match"); match != "" && (existing == nil || !etagMatch(match, etag)) {
+		return false
+	}
+	if noneMatch := r.Header.Get("If-None-Match"); noneMatch != "" && existing != nil && etagMatch(noneMatch, etag) {
+		return false
+	}
+	return true
+}
+
+// davCheckedVersion is the version of the task davPreconditions checked, which
+// the write must still find. Writes without preconditions apply to any version.
+func davCheckedVersion(r *http.Request, existing *dto.CalDAVResource) int {
+	if existing == nil || (r.Header.Get("If-Match") == "" && r.Header.Get("If-None-Match") == "") {
+		return 0
+	}
+	return existing.Task.Version
+}
+
+// readPropfind returns the requested names, nil for allprop, and whether
+// only the names were asked for.
+func readPropfind(r *http.Request) ([]xml.Name, bool, error) {
+	propfind := schemas.RequestPropfind{}
+	if err := readDAVBody(r, &propfind); err != nil {
+		return nil, false, err
+	}
+	if propfind.AllProp != nil || propfind.PropName != nil {
+		return nil, propfind.PropName != nil, nil
+	}
+	return propfind.Prop, false, nil
+}
+
+// readDAVBody decodes an XML body, leaving v as is when there is none.
+func readDAVBody(r *http.Request, v any) error {
+	bodyRaw, err := io.ReadAll(io.LimitReader(r.Body, davMaxBody))
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(bodyRaw)) == 0 {
+		return nil
+	}
+	return xml.Unmarshal(bodyRaw, v)
+}
+
+func writeMultistatus(w http.ResponseWriter, ms *schemas.ResponseMultistatus) {
+	body, err := xml.Marshal(ms)
+	if err != nil {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	w.Write([]byte(xml.Header))
+	w.Write(body)
+}
+
+// writeDAVError reports a failed WebDAV or CalDAV precondition.
+func writeDAVError(w http.ResponseWriter, status int, condition xml.Name) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header + `<D:error xmlns:D="` + schemas.NamespaceDAV + `" xmlns:C="` + schemas.NamespaceCalDAV + `">` +
+		schemas.DAVElement(condition, "") + `</D:error>`))
+}
+
+// davObjectName returns the resource name of a task href, which may be an
+// absolute url.
+func davObjectName(href string) (string, bool) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	name, ok := strings.CutPrefix(u.Path, davTasks)
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	return name, true
+}
+
+func davObjectHref(name string) string {
+	return davTasks + url.PathEscape(name)
+}
+
+func davHref(path string) string {
+	return "<D:href>" + schemas.DAVText(path) + "</D:href>"
+}
+
+// davReports renders a supported-report-set.
+func davReports(reports ...xml.Name) string {
+	set := ""
+	for _, report := range reports {
+		set += "<D:supported-report><D:report>" + schemas.DAVElement(report, "") + "</D:report></D:supported-report>"
+	}
+	return set
+}
+
+// taskETag is the strong ETag of a task, its id and version. The id keeps an
+// ETag of a deleted task from matching the task that took over its href.
+func taskETag(task *dto.TaskRead) string {
+	return `"` + strconv.Itoa(task.Id) + "-" + strconv.Itoa(task.Version) + `"`
+}
@@ -0,0 +1,361 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/service"
+	"ToDoVerba/internal/service/caldavService"
+	mockservice "ToDoVerba/internal/service/mocks"
+	"ToDoVerba/internal/service/userService"
+	"ToDoVerba/pkg/logging"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_calDAV(t *testing.T) {
+	parseTime := func(s string) pgtype.Timestamptz {
+		t, _ := time.Parse(time.RFC3339, s)
+		return pgtype.Timestamptz{
+			Time:  t,
+			Valid: true,
+		}
+	}
+	open := dto.CalDAVResource{
+		Href: "A1 B2.ics",
+		Uid:  "A1B2",
+		Task: dto.TaskRead{
+			Id:        7,
+			Title:     "Buy milk",
+			DueDate:   parseTime("2024-09-05T10:00:00Z"),
+			CreatedAt: parseTime("2024-09-01T10:00:00Z"),
+			UpdatedAt: parseTime("2024-09-02T10:00:00Z"),
+			Version:   3,
+		},
+	}
+	done := dto.CalDAVResource{
+		Href: "task-8.ics",
+		Uid:  "task-8@todoverba",
+		Task: dto.TaskRead{
+			Id:        8,
+			Title:     "Done",
+			DueDate:   parseTime("2024-09-06T10:00:00Z"),
+			Completed: true,
+			CreatedAt: parseTime("2024-09-01T10:00:00Z"),
+			UpdatedAt: parseTime("2024-09-03T10:00:00Z"),
+			Version:   1,
+		},
+	}
+	vtodo := func(uid string) string {
+		return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:" + uid +
+			"\r\nSUMMARY:Buy oat milk\r\nSTATUS:NEEDS-ACTION\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+	}
+
+	type mockBehavior func(s *mockservice.MockICalDAVService)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		headers  map[string]string
+		body     string
+		mock     mockBehavior
+		wantCode int
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "401 without token",
+			method:   "PROPFIND",
+			path:     "/dav/tasks/",
+			headers:  map[string]string{"Authorization": ""},
+			mock:     func(s *mockservice.MockICalDAVService) {},
+			wantCode: 401,
+		},
+		{
+			name:    "207 collection propfind",
+			method:  "PROPFIND",
+			path:    "/dav/tasks/",
+			headers: map[string]string{"Depth": "1"},
+			body: `<?xml version="1.0"?><propfind xmlns="DAV:" xmlns:x="urn:example"><prop>` +
+				`<resourcetype/><getetag/><sync-token/><x:color/></prop></propfind>`,
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().SyncToken().Return(int64(42), nil)
+				s.EXPECT().List().Return([]dto.CalDAVResource{open, done}, nil)
+			},
+			wantCode: 207,
+			contains: []string{
+				"<D:href>/dav/tasks/</D:href><D:propstat><D:prop><D:resourcetype><D:collection/><C:calendar/></D:resourcetype>",
+				"<D:sync-token>urn:todoverba:sync:42</D:sync-token>",
+				`<color xmlns="urn:example"/></D:prop><D:status>HTTP/1.1 404 Not Found</D:status>`,
+				"<D:href>/dav/tasks/A1%20B2.ics</D:href><D:propstat><D:prop><D:resourcetype/><D:getetag>&#34;7-3&#34;</D:getetag>",
+				"<D:href>/dav/tasks/task-8.ics</D:href>",
+			},
+		},
+		{
+			name:   "207 calendar-query of open tasks",
+			method: "REPORT",
+			path:   "/dav/tasks/",
+			body: `<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">` +
+				`<D:prop><D:getetag/><C:calendar-data/></D:prop>` +
+				`<C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VTODO">` +
+				`<C:prop-filter name="COMPLETED"><C:is-not-defined/></C:prop-filter>` +
+				`</C:comp-filter></C:comp-filter></C:filter></C:calendar-query>`,
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().List().Return([]dto.CalDAVResource{open, done}, nil)
+			},
+			wantCode: 207,
+			contains: []string{"/dav/tasks/A1%20B2.ics", "UID:A1B2&#xD;&#xA;"},
+			excludes: []string{"task-8.ics"},
+		},
+		{
+			name:   "207 calendar-multiget",
+			method: "REPORT",
+			path:   "/dav/tasks/",
+			body: `<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">` +
+				`<D:prop><D:getetag/></D:prop><D:href>/dav/tasks/task-8.ics</D:href>` +
+				`<D:href>http://localhost/dav/tasks/gone.ics</D:href></C:calendar-multiget>`,
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().Find("task-8.ics").Return(&done, nil)
+				s.EXPECT().Find("gone.ics").Return(nil, pgx.ErrNoRows)
+			},
+			wantCode: 207,
+			contains: []string{
+				"<D:href>http://localhost/dav/tasks/gone.ics</D:href><D:status>HTTP/1.1 404 Not Found</D:status>",
+				"<D:getetag>&#34;8-1&#34;</D:getetag>",
+			},
+		},
+		{
+			name:   "207 sync-collection",
+			method: "REPORT",
+			path:   "/dav/tasks/",
+			body: `<D:sync-collection xmlns:D="DAV:"><D:sync-token>urn:todoverba:sync:40</D:sync-token>` +
+				`<D:sync-level>1</D:sync-level><D:prop><D:getetag/></D:prop></D:sync-collection>`,
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().Changes(int64(40)).Return(&dto.CalDAVChanges{
+					Changed:   []dto.CalDAVResource{open},
+					Removed:   []string{"task-5.ics"},
+					SyncToken: 42,
+				}, nil)
+			},
+			wantCode: 207,
+			contains: []string{
+				"<D:href>/dav/tasks/task-5.ics</D:href><D:status>HTTP/1.1 404 Not Found</D:status>",
+				"<D:href>/dav/tasks/A1%20B2.ics</D:href>",
+				"<D:sync-token>urn:todoverba:sync:42</D:sync-token></D:multistatus>",
+			},
+		},
+		{
+			name:   "403 stale sync token",
+			method: "REPORT",
+			path:   "/dav/tasks/",
+			body:   `<D:sync-collection xmlns:D="DAV:"><D:sync-token>urn:todoverba:sync:99</D:sync-token></D:sync-collection>`,
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().Changes(int64(99)).Return(nil, caldavService.ErrInvalidSyncToken)
+			},
+			wantCode: 403,
+			contains: []string{"<D:valid-sync-token/>"},
+		},
+		{
+			name:   "200 get",
+			method: "GET",
+			path:   "/dav/tasks/A1%20B2.ics",
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().Find("A1 B2.ics").Return(&open, nil)
+			},
+			wantCode: 200,
+			contains: []string{"BEGIN:VTODO\r\nUID:A1B2\r\n", "SUMMARY:Buy milk\r\n"},
+		},
+		{
+			name:    "304 get unchanged",
+			method:  "GET",
+			path:    "/dav/tasks/task-8.ics",
+			headers: map[string]string{"If-None-Match": `"8-1"`},
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().Find("task-8.ics").Return(&done, nil)
+			},
+			wantCode: 304,
+		},
+		{
+			name:    "201 put new",
+			method:  "PUT",
+			path:    "/dav/tasks/NEW.ics",
+			headers: map[string]string{"Content-Type": "text/calendar; charset=utf-8", "If-None-Match": "*", "X-Actor": "mallory"},
+			body:    vtodo("NEW"),
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().Find("NEW.ics").Return(nil, pgx.ErrNoRows)
+				// The actor is the authenticated user, X-Actor is ignored.
+				s.EXPECT().Put("NEW.ics", "NEW", &dto.TaskUpdate{Title: "Buy oat milk", Actor: "alice"}).
+					Return(&open, true, nil)
+			},
+			wantCode: 201,
+		},
+		{
+			name:    "412 put new created concurrently",
+			method:  "PUT",
+			path:    "/dav/tasks/NEW.ics",
+			headers: map[string]string{"Content-Type": "text/calendar", "If-None-Match": "*"},
+			body:    vtodo("NEW"),
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().Find("NEW.ics").Return(nil, pgx.ErrNoRows)
+				s.EXPECT().Put("NEW.ics", "NEW", gomock.Any()).Return(nil, false, dto.ErrVersionConflict)
+			},
+			wantCode: 412,
+		},
+		{
+			name:    "204 put update",
+			method:  "PUT",
+			path:    "/dav/tasks/task-8.ics",
+			headers: map[string]string{"Content-Type": "text/calendar", "If-Match": `"8-1"`},
+			body:    vtodo("task-8@todoverba"),
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().Find("task-8.ics").Return(&done, nil)
+				s.EXPECT().Put("task-8.ics", "task-8@todoverba", gomock.Any()).
+					DoAndReturn(func(href, uid string, update *dto.TaskUpdate) (*dto.CalDAVResource, bool, error) {
+						assert.Equal(t, done.Task.Version, update.Version, "the write is checked against the etag")
+						return &done, false, nil
+					})
+			},
+			wantCode: 204,
+		},
+		{
+			name:    "412 put changed after the check",
+			method:  "PUT",
+			path:    "/dav/tasks/task-8.ics",
+			headers: map[string]string{"Content-Type": "text/calendar", "If-Match": `"8-1"`},
+			body:    vtodo("task-8@todoverba"),
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().Find("task-8.ics").Return(&done, nil)
+				s.EXPECT().Put("task-8.ics", "task-8@todoverba", gomock.Any()).Return(nil, false, dto.ErrVersionConflict)
+			},
+			wantCode: 412,
+		},
+		{
+			name:    "412 put stale etag",
+			method:  "PUT",
+			path:    "/dav/tasks/A1%20B2.ics",
+			headers: map[string]string{"Content-Type": "text/calendar", "If-Match": `"7-2"`},
+			body:    vtodo("A1B2"),
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().Find("A1 B2.ics").Return(&open, nil)
+			},
+			wantCode: 412,
+		},
+		{
+			name:    "412 put etag of another task",
+			method:  "PUT",
+			path:    "/dav/tasks/task-8.ics",
+			headers: map[string]string{"Content-Type": "text/calendar", "If-Match": `"7-1"`},
+			body:    vtodo("task-8@todoverba"),
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().Find("task-8.ics").Return(&done, nil)
+			},
+			wantCode: 412,
+		},
+		{
+			name:    "403 put uid conflict",
+			method:  "PUT",
+			path:    "/dav/tasks/task-8.ics",
+			headers: map[string]string{"Content-Type": "text/calendar"},
+			body:    vtodo("OTHER"),
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().Find("task-8.ics").Return(&done, nil)
+				s.EXPECT().Put("task-8.ics", "OTHER", gomock.Any()).Return(nil, false, caldavService.ErrUidConflict)
+			},
+			wantCode: 403,
+			contains: []string{"<C:no-uid-conflict/>"},
+		},
+		{
+			name:     "403 put vevent",
+			method:   "PUT",
+			path:     "/dav/tasks/event.ics",
+			headers:  map[string]string{"Content-Type": "text/calendar"},
+			body:     "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:e\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+			mock:     func(s *mockservice.MockICalDAVService) {},
+			wantCode: 403,
+			contains: []string{"<C:supported-calendar-component/>"},
+		},
+		{
+			name:     "403 put malformed calendar",
+			method:   "PUT",
+			path:     "/dav/tasks/bad.ics",
+			headers:  map[string]string{"Content-Type": "text/calendar"},
+			body:     "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\n",
+			mock:     func(s *mockservice.MockICalDAVService) {},
+			wantCode: 403,
+			contains: []string{"<C:valid-calendar-data/>"},
+		},
+		{
+			name:    "204 delete",
+			method:  "DELETE",
+			path:    "/dav/tasks/task-8.ics",
+			headers: map[string]string{"If-Match": `"8-1"`},
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().Find("task-8.ics").Return(&done, nil)
+				s.EXPECT().Delete("task-8.ics", 1, "alice").Return(nil)
+			},
+			wantCode: 204,
+		},
+		{
+			name:    "412 delete changed after the check",
+			method:  "DELETE",
+			path:    "/dav/tasks/task-8.ics",
+			headers: map[string]string{"If-Match": `"8-1"`},
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().Find("task-8.ics").Return(&done, nil)
+				s.EXPECT().Delete("task-8.ics", 1, "alice").Return(dto.ErrVersionConflict)
+			},
+			wantCode: 412,
+		},
+		{
+			name:   "404 delete unknown",
+			method: "DELETE",
+			path:   "/dav/tasks/gone.ics",
+			mock: func(s *mockservice.MockICalDAVService) {
+				s.EXPECT().Find("gone.ics").Return(nil, pgx.ErrNoRows)
+			},
+			wantCode: 404,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			caldav := mockservice.NewMockICalDAVService(c)
+			tt.mock(caldav)
+			users := mockservice.NewMockIUserService(c)
+			users.EXPECT().Authenticate("secret").Return(&dto.UserRead{Id: 1, Name: "alice"}, nil).AnyTimes()
+			users.EXPECT().Authenticate("").Return(nil, userService.ErrUnauthorized).AnyTimes()
+
+			handler := NewHandler(Deps{
+				Service: service.Services{CalDAV: caldav, User: users},
+				Logger:  logging.GetLoggerTest(),
+			})
+			r := httprouter.New()
+			handler.initCalDAVHandler(r)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.SetBasicAuth("alice", "secret")
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			for _, s := range tt.contains {
+				assert.Contains(t, w.Body.String(), s)
+			}
+			for _, s := range tt.excludes {
+				assert.NotContains(t, w.Body.String(), s)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package v1
+
+import (
+	"ToDoVerba/internal/schemas"
+	"ToDoVerba/internal/service/userService"
+	"ToDoVerba/pkg/ical"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const calendarFeedPath = "/calendar/tasks.ics"
+
+func (h *Handler) initCalendarHandler(r *httprouter.Router) {
+	r.GET(calendarFeedPath, h.calendarFeed)
+	r.POST("/calendar/token", h.calendarTokenRotate)
+}
+
+// calendarFeed godoc
+// @Tags         Calendar API
+// @Summary      Task calendar feed Summary
+// @Description  iCalendar feed of the tasks for calendar subscriptions, authenticated by the feed token. Accepts the filters of GET /tasks. Send If-None-Match with the last ETag to get 304 when nothing changed
+// @Produce      text/calendar
+// @Param token query string true "Feed token"
+// @Param component query string false "vtodo (default) or vevent"
+// @Param completed query bool false "Only completed or open tasks"
+// @Param due_after query string false "Due at or after, RFC3339"
+// @Param due_before query string false "Due before, RFC3339"
+// @Success      200  {string}  string "calendar"
+// @Success      304
+// @Failure      400  {object}	errorJSON
+// @Failure      401  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /calendar/tasks.ics [get]
+func (h *Handler) calendarFeed(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s calendarFeed called", r.Method, r.RemoteAddr)
+
+	feed := schemas.RequestCalendarFeed{}
+	feed.ScanQuery(r.URL.Query())
+	err := feed.Valid()
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = h.service.User.AuthenticateFeed(feed.Token)
+	if err != nil {
+		if errors.Is(err, userService.ErrUnauthorized) {
+			writeResponseErr(w, http.StatusUnauthorized, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rTasksDTO, err := h.service.Task.List()
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	rTasksDTO = feed.Filter.Apply(rTasksDTO)
+
+	cal := schemas.NewCalendar("Tasks")
+	for i := range rTasksDTO {
+		if feed.Component == schemas.CalendarVEvent {
+			cal.Components = append(cal.Components, schemas.TaskToVEvent(&rTasksDTO[i]))
+		} else {
+			cal.Components = append(cal.Components, schemas.TaskToVTodo(&rTasksDTO[i]))
+		}
+	}
+
+	body := &bytes.Buffer{}
+	if err = ical.Encode(body, cal); err != nil {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// The rendering only depends on the tasks, so a hash of it changes
+	// exactly when a subscribed client has something new to fetch.
+	sum := sha256.Sum256(body.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
+
+// calendarTokenRotate godoc
+// @Tags         Calendar API
+// @Summary      Rotate calendar feed token Summary
+// @Description  Issue a new feed token for the calling user. The previous feed url stops working
+// @Produce      json
+// @Param Authorization header string true "Bearer api token"
+// @Success      200  {object}  schemas.ResponseCalendarToken
+// @Failure      401  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /calendar/token [post]
+func (h *Handler) calendarTokenRotate(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s calendarTokenRotate called", r.Method, r.RemoteAddr)
+
+	user, err := h.service.User.Authenticate(requestToken(r))
+	if err != nil {
+		if errors.Is(err, userService.ErrUnauthorized) {
+			writeResponseErr(w, http.StatusUnauthorized, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	token, err := h.service.User.RotateFeedToken(user.Id)
+	if err != nil {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, schemas.ResponseCalendarToken{
+		Token: token,
+		Url:   calendarFeedPath + "?token=" + url.QueryEscape(token),
+	})
+}
+
+// etagMatch reports whether an If-None-Match header lists etag.
+func etagMatch(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
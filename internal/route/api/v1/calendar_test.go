@@ -0,0 +1,112 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/route/api/openapi/openapitest"
+	"ToDoVerba/internal/service"
+	mockservice "ToDoVerba/internal/service/mocks"
+	"ToDoVerba/internal/service/userService"
+	"ToDoVerba/pkg/logging"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_calendarFeed(t *testing.T) {
+	parseTime := func(s string) pgtype.Timestamptz {
+		t, _ := time.Parse(time.RFC3339, s)
+		return pgtype.Timestamptz{
+			Time:  t,
+			Valid: true,
+		}
+	}
+	tasks := []dto.TaskRead{
+		{
+			Id:          7,
+			Title:       "First Task",
+			Description: "Milk, eggs",
+			DueDate:     parseTime("2024-09-05T15:04:05+05:00"),
+			CreatedAt:   parseTime("2024-09-01T10:00:00Z"),
+			UpdatedAt:   parseTime("2024-09-02T10:00:00Z"),
+			Version:     2,
+		},
+		{
+			Id:        8,
+			Title:     "Done Task",
+			DueDate:   parseTime("2024-09-06T09:00:00Z"),
+			Completed: true,
+			CreatedAt: parseTime("2024-09-01T10:00:00Z"),
+			UpdatedAt: parseTime("2024-09-03T10:00:00Z"),
+			Version:   3,
+		},
+	}
+
+	c := gomock.NewController(t)
+	users := mockservice.NewMockIUserService(c)
+	users.EXPECT().AuthenticateFeed("feed").Return(&dto.UserRead{Id: 1, Name: "alice"}, nil).AnyTimes()
+	users.EXPECT().AuthenticateFeed("wrong").Return(nil, userService.ErrUnauthorized)
+	taskService := mockservice.NewMockITaskService(c)
+	taskService.EXPECT().List().Return(tasks, nil).AnyTimes()
+
+	handler := NewHandler(Deps{
+		Service: service.Services{Task: taskService, User: users},
+		Logger:  logging.GetLoggerTest(),
+	})
+	r := httprouter.New()
+	r.GET("/calendar/tasks.ics", handler.calendarFeed)
+
+	get := func(query, etag string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/calendar/tasks.ics?"+query, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		openapitest.Handler(t, r).ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("token=feed&completed=false", "")
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "BEGIN:VCALENDAR\r\n"+
+		"VERSION:2.0\r\n"+
+		"PRODID:-//ToDoVerba//Tasks//EN\r\n"+
+		"CALSCALE:GREGORIAN\r\n"+
+		"X-WR-CALNAME:Tasks\r\n"+
+		"BEGIN:VTODO\r\n"+
+		"UID:task-7@todoverba\r\n"+
+		"DTSTAMP:20240902T100000Z\r\n"+
+		"CREATED:20240901T100000Z\r\n"+
+		"LAST-MODIFIED:20240902T100000Z\r\n"+
+		"SEQUENCE:1\r\n"+
+		"SUMMARY:First Task\r\n"+
+		"DESCRIPTION:Milk\\, eggs\r\n"+
+		"DUE:20240905T100405Z\r\n"+
+		"STATUS:NEEDS-ACTION\r\n"+
+		"END:VTODO\r\n"+
+		"END:VCALENDAR\r\n", w.Body.String())
+
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	w = get("token=feed&completed=false", etag)
+	assert.Equal(t, 304, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = get("token=feed&component=vevent", etag)
+	assert.Equal(t, 200, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), "BEGIN:VEVENT\r\nUID:task-8@todoverba\r\n")
+	assert.Contains(t, w.Body.String(), "DTSTART:20240906T090000Z\r\n")
+
+	w = get("token=wrong", "")
+	assert.Equal(t, 401, w.Code)
+
+	w = get("token=feed&component=vjournal&due_after=tomorrow", "")
+	assert.Equal(t, 400, w.Code)
+	assert.JSONEq(t, `{"error":"component must be vtodo or vevent;due_after must be in RFC3339 format;"}`, w.Body.String())
+}
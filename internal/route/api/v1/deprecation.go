@@ -0,0 +1,37 @@
+package v1
+
+import (
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Deprecation announces the retirement of the routes that v2 replaces. The
+// zero value announces nothing.
+type Deprecation struct {
+	At     time.Time
+	Sunset time.Time
+}
+
+// deprecated marks the responses of next with the Deprecation (RFC 9745) and
+// Sunset (RFC 8594) headers and links them to successor, a v2 route pattern
+// whose params are filled in from the request.
+func (h *Handler) deprecated(successor string, next httprouter.Handle) httprouter.Handle {
+	if h.deprecation.At.IsZero() {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		link := successor
+		for _, p := range ps {
+			link = strings.ReplaceAll(link, ":"+p.Key, p.Value)
+		}
+		w.Header().Set("Deprecation", "@"+strconv.FormatInt(h.deprecation.At.Unix(), 10))
+		if !h.deprecation.Sunset.IsZero() {
+			w.Header().Set("Sunset", h.deprecation.Sunset.UTC().Format(http.TimeFormat))
+		}
+		w.Header().Add("Link", "<"+link+`>; rel="successor-version"`)
+		next(w, r, ps)
+	}
+}
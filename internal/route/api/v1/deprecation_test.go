@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/route/api/openapi/openapitest"
+	"ToDoVerba/internal/service"
+	mockservice "ToDoVerba/internal/service/mocks"
+	"ToDoVerba/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_deprecated(t *testing.T) {
+	testTable := []struct {
+		name            string
+		deprecation     Deprecation
+		path            string
+		expectedHeaders map[string]string
+	}{
+		{
+			name: "deprecated_with_sunset",
+			deprecation: Deprecation{
+				At:     time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+				Sunset: time.Date(2027, 5, 1, 0, 0, 0, 0, time.FixedZone("UTC+5", 5*60*60)),
+			},
+			path: "/tasks/7",
+			expectedHeaders: map[string]string{
+				"Deprecation": "@1793491200",
+				"Sunset":      "Fri, 30 Apr 2027 19:00:00 GMT",
+				"Link":        `</api/v2/tasks/7>; rel="successor-version"`,
+			},
+		},
+		{
+			name:        "deprecated_without_sunset",
+			deprecation: Deprecation{At: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+			path:        "/tasks",
+			expectedHeaders: map[string]string{
+				"Deprecation": "@1793491200",
+				"Sunset":      "",
+				"Link":        `</api/v2/tasks>; rel="successor-version"`,
+			},
+		},
+		{
+			name: "not_deprecated",
+			path: "/tasks/7",
+			expectedHeaders: map[string]string{
+				"Deprecation": "",
+				"Sunset":      "",
+				"Link":        "",
+			},
+		},
+		{
+			name:        "static_names_are_not_deprecated",
+			deprecation: Deprecation{At: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+			path:        "/tasks/export.md",
+			expectedHeaders: map[string]string{
+				"Deprecation": "",
+				"Link":        "",
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			tasksService := mockservice.NewMockITaskService(c)
+			tasksService.EXPECT().FindByID(7).Return(&dto.TaskRead{Id: 7}, nil).AnyTimes()
+			tasksService.EXPECT().List().Return(nil, nil).AnyTimes()
+			tasksService.EXPECT().Export(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+			handler := NewHandler(Deps{
+				Service:     service.Services{Task: tasksService},
+				Logger:      logging.GetLoggerTest(),
+				Deprecation: testCase.deprecation,
+			})
+			r := httprouter.New()
+			handler.initTaskHandler(r)
+
+			w := httptest.NewRecorder()
+			openapitest.Handler(t, r).ServeHTTP(w, httptest.NewRequest("GET", testCase.path, nil))
+
+			assert.Equal(t, 200, w.Code)
+			for header, value := range testCase.expectedHeaders {
+				assert.Equal(t, value, w.Header().Get(header), header)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package v1
+
+import (
+	"ToDoVerba/internal/route/api/idempotency"
+	"github.com/julienschmidt/httprouter"
+)
+
+// idempotent runs next once per Idempotency-Key header.
+func (h *Handler) idempotent(next httprouter.Handle) httprouter.Handle {
+	return idempotency.Handle(h.service.Idempotency, writeResponseErr, next)
+}
@@ -0,0 +1,129 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/memory"
+	"ToDoVerba/internal/route/api/idempotency"
+	"ToDoVerba/internal/route/api/openapi/openapitest"
+	"ToDoVerba/internal/service"
+	"ToDoVerba/internal/service/idempotencyService"
+	mockservice "ToDoVerba/internal/service/mocks"
+	"ToDoVerba/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newIdempotencyRouter(tasksService *mockservice.MockITaskService) *httprouter.Router {
+	logger := logging.GetLoggerTest()
+	handler := NewHandler(Deps{
+		Service: service.Services{
+			Task: tasksService,
+			Idempotency: idempotencyService.NewIdempotencyService(idempotencyService.Deps{
+				Repo:   memory.NewIdempotencyMemory(logger),
+				Logger: logger,
+				TTL:    time.Hour,
+			}),
+		},
+		Logger: logger,
+	})
+
+	r := httprouter.New()
+	r.POST("/tasks", handler.idempotent(handler.taskCreate))
+	return r
+}
+
+func postTask(t *testing.T, r http.Handler, key, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/tasks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(idempotency.KeyHeader, key)
+	}
+	openapitest.Handler(t, r).ServeHTTP(w, req)
+	return w
+}
+
+const idempotentTaskBody = `{"title": "Task", "description": "Description", "due_date": "2024-09-05T15:04:05Z"}`
+
+func TestHandler_taskCreate_idempotent(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tasks := mockservice.NewMockITaskService(c)
+	tasks.EXPECT().Create(gomock.Any()).Return(&dto.TaskRead{Id: 7, Title: "Task"}, nil).Times(1)
+	r := newIdempotencyRouter(tasks)
+
+	first := postTask(t, r, "retry-1", idempotentTaskBody)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, "/tasks/7", first.Header().Get("Location"))
+	assert.Empty(t, first.Header().Get(idempotency.ReplayedHeader))
+
+	retry := postTask(t, r, "retry-1", idempotentTaskBody)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(idempotency.ReplayedHeader))
+	assert.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+	assert.Equal(t, "/tasks/7", retry.Header().Get("Location"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+
+	other := postTask(t, r, "retry-1", `{"title": "Other", "description": "Description", "due_date": "2024-09-05T15:04:05Z"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, other.Code)
+	assert.Equal(t, `{"error":"idempotency key was already used with a different request"}`, other.Body.String())
+
+	long := postTask(t, r, strings.Repeat("k", 256), idempotentTaskBody)
+	assert.Equal(t, http.StatusBadRequest, long.Code)
+}
+
+func TestHandler_taskCreate_withoutKey(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tasks := mockservice.NewMockITaskService(c)
+	tasks.EXPECT().Create(gomock.Any()).Return(&dto.TaskRead{Id: 7}, nil).Times(2)
+	r := newIdempotencyRouter(tasks)
+
+	assert.Equal(t, http.StatusCreated, postTask(t, r, "", idempotentTaskBody).Code)
+	assert.Equal(t, http.StatusCreated, postTask(t, r, "", idempotentTaskBody).Code)
+}
+
+func TestHandler_taskCreate_concurrentDuplicates(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tasks := mockservice.NewMockITaskService(c)
+	tasks.EXPECT().Create(gomock.Any()).DoAndReturn(func(cTask *dto.TaskCreate) (*dto.TaskRead, error) {
+		time.Sleep(50 * time.Millisecond)
+		return &dto.TaskRead{Id: 7}, nil
+	}).Times(1)
+	r := newIdempotencyRouter(tasks)
+
+	var wg sync.WaitGroup
+	codes := make([]int, 10)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = postTask(t, r, "concurrent", idempotentTaskBody).Code
+		}()
+	}
+	wg.Wait()
+
+	created := 0
+	for _, code := range codes {
+		assert.Contains(t, []int{http.StatusCreated, http.StatusConflict}, code)
+		if code == http.StatusCreated {
+			created++
+		}
+	}
+	assert.GreaterOrEqual(t, created, 1)
+
+	retry := postTask(t, r, "concurrent", idempotentTaskBody)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(idempotency.ReplayedHeader))
+}
@@ -9,20 +9,24 @@ type errorJSON struct {
 	Err string `json:"error"`
 }
 
+// writeResponse marshals data before the status is sent, so a value that
+// can't be marshalled is answered with a 500 instead of a truncated body.
 func writeResponse(w http.ResponseWriter, code int, data any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
 	if data == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
 		return
 	}
 
 	respJSON, err := json.Marshal(data)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
 	w.Write(respJSON)
-	//TODO handle err
 }
 
 func writeResponseErr(w http.ResponseWriter, code int, err error) {
@@ -40,3 +44,8 @@ func writeResponseErr(w http.ResponseWriter, code int, err error) {
 	w.Write(respJSON)
 	//TODO handle err
 }
+
+// WriteError writes err in the format of the v1 API.
+func (h *Handler) WriteError(w http.ResponseWriter, code int, err error) {
+	writeResponseErr(w, code, err)
+}
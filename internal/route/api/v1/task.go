@@ -12,11 +12,19 @@ import (
 )
 
 func (h *Handler) initTaskHandler(r *httprouter.Router) {
-	r.POST("/tasks", h.taskCreate)
-	r.GET("/tasks", h.taskList)
-	r.GET("/tasks/:id", h.taskFindById)
-	r.PUT("/tasks/:id", h.taskUpdateById)
-	r.DELETE("/tasks/:id", h.taskDeleteById)
+	r.POST("/tasks", h.deprecated("/api/v2/tasks", h.idempotent(h.taskCreate)))
+	r.GET("/tasks", h.deprecated("/api/v2/tasks", h.taskList))
+	r.GET("/tasks/:id", withStatic("id", map[string]httprouter.Handle{
+		"events":     h.taskEventStream,
+		"export.csv": h.taskExport,
+		"export.txt": h.taskExportTodoTxt,
+		"export.md":  h.taskExportChecklist,
+	}, h.deprecated("/api/v2/tasks/:id", h.taskFindById)))
+	r.POST("/tasks/:id", withStatic("id", map[string]httprouter.Handle{
+		"import": h.taskImport,
+	}, methodNotAllowed))
+	r.PUT("/tasks/:id", h.deprecated("/api/v2/tasks/:id", h.taskUpdateById))
+	r.DELETE("/tasks/:id", h.deprecated("/api/v2/tasks/:id", h.taskDeleteById))
 }
 
 // taskCreate godoc
@@ -26,8 +34,13 @@ func (h *Handler) initTaskHandler(r *httprouter.Router) {
 // @Accept       json
 // @Produce      json
 // @Param Task body schemas.RequestTaskCreate false "Task base"
+// @Param X-Actor header string false "Advisory actor recorded in task history, not authenticated"
+// @Param Idempotency-Key header string false "Retries with the same key get the response of the first request"
 // @Success      201  {object}  schemas.ResponseTaskRead
+// @Header       201  {string}  Location "Path of the new task"
 // @Failure      400  {object}  errorJSON
+// @Failure      409  {object}  errorJSON "A request with the key is in progress"
+// @Failure      422  {object}  errorJSON "The key was used with a different request"
 // @Failure      500  {object}	errorJSON
 // @Router       /tasks [post]
 func (h *Handler) taskCreate(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
@@ -57,7 +70,9 @@ func (h *Handler) taskCreate(w http.ResponseWriter, r *http.Request, ps httprout
 		return
 	}
 
-	rTaskDTO, err := h.service.Task.Create(cTask.ToDTO())
+	cTaskDTO := cTask.ToDTO()
+	cTaskDTO.Actor = requestActor(r)
+	rTaskDTO, err := h.service.Task.Create(cTaskDTO)
 	if err != nil {
 		writeResponseErr(w, http.StatusInternalServerError, err)
 		return
@@ -66,6 +81,7 @@ func (h *Handler) taskCreate(w http.ResponseWriter, r *http.Request, ps httprout
 	rTask := schemas.ResponseTaskRead{}
 	rTask.ScanDTO(rTaskDTO)
 
+	w.Header().Set("Location", "/tasks/"+strconv.Itoa(rTaskDTO.Id))
 	writeResponse(w, http.StatusCreated, rTask)
 }
 
@@ -75,17 +91,30 @@ func (h *Handler) taskCreate(w http.ResponseWriter, r *http.Request, ps httprout
 // @Description  List Task Description
 // @Accept       json
 // @Produce      json
+// @Param completed query bool false "Only completed or open tasks"
+// @Param due_after query string false "Due at or after, RFC3339"
+// @Param due_before query string false "Due before, RFC3339"
 // @Success      200  {object}  []schemas.ResponseTaskRead
+// @Failure      400  {object}	errorJSON
 // @Failure      500  {object}	errorJSON
 // @Router       /tasks [get]
 func (h *Handler) taskList(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	h.logger.Debugf("[%s] %s taskList called", r.Method, r.RemoteAddr)
 
+	filter := schemas.RequestTaskFilter{}
+	filter.ScanQuery(r.URL.Query())
+	err := filter.Valid()
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
 	rTasksDTO, err := h.service.Task.List()
 	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		writeResponseErr(w, http.StatusInternalServerError, err)
 		return
 	}
+	rTasksDTO = filter.Apply(rTasksDTO)
 
 	rTasks := make([]schemas.ResponseTaskRead, 0, len(rTasksDTO))
 	for i := 0; i < len(rTasksDTO); i++ {
@@ -142,6 +171,7 @@ func (h *Handler) taskFindById(w http.ResponseWriter, r *http.Request, ps httpro
 // @Produce      json
 // @Param id path int false "Task id"
 // @Param Task body schemas.RequestTaskUpdate false "Task update"
+// @Param X-Actor header string false "Advisory actor recorded in task history, not authenticated"
 // @Success      200  {object}  schemas.ResponseTaskRead
 // @Failure      400  {object}  errorJSON
 // @Failure      404  {object}  errorJSON
@@ -182,7 +212,9 @@ func (h *Handler) taskUpdateById(w http.ResponseWriter, r *http.Request, ps http
 		return
 	}
 
-	rTaskDTO, err := h.service.Task.UpdateById(id, uTask.ToDTO())
+	uTaskDTO := uTask.ToDTO()
+	uTaskDTO.Actor = requestActor(r)
+	rTaskDTO, err := h.service.Task.UpdateById(id, uTaskDTO)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			writeResponseErr(w, http.StatusNotFound, err)
@@ -204,7 +236,8 @@ func (h *Handler) taskUpdateById(w http.ResponseWriter, r *http.Request, ps http
 // @Accept       json
 // @Produce      json
 // @Param id path int false "Task id"
-// @Success      204  {object}  schemas.ResponseTaskRead
+// @Param X-Actor header string false "Advisory actor recorded in task history, not authenticated"
+// @Success      204
 // @Failure      400  {object}	errorJSON
 // @Failure      404  {object}	errorJSON
 // @Failure      500  {object}	errorJSON
@@ -219,7 +252,7 @@ func (h *Handler) taskDeleteById(w http.ResponseWriter, r *http.Request, ps http
 		return
 	}
 
-	err = h.service.Task.DeleteById(id)
+	err = h.service.Task.DeleteById(id, requestActor(r))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			writeResponseErr(w, http.StatusNotFound, err)
@@ -0,0 +1,164 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/schemas"
+	"encoding/csv"
+	"errors"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"mime"
+	"net/http"
+)
+
+const (
+	importMaxBody = 10 << 20
+	// exportFlushRows is how many rows are buffered before they are sent.
+	exportFlushRows = 500
+)
+
+// taskExport godoc
+// @Tags         Task API
+// @Summary      Export Tasks as CSV Summary
+// @Description  Stream the tasks as CSV in id order. Accepts the filters of GET /tasks. A title or description starting with =, +, -, @, a tab, a carriage return or ' is prefixed with ', so spreadsheets don't run it as a formula
+// @Produce      text/csv
+// @Param completed query bool false "Only completed or open tasks"
+// @Param due_after query string false "Due at or after, RFC3339"
+// @Param due_before query string false "Due before, RFC3339"
+// @Success      200  {string}  string "csv"
+// @Failure      400  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /tasks/export.csv [get]
+func (h *Handler) taskExport(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s taskExport called", r.Method, r.RemoteAddr)
+
+	filter := schemas.RequestTaskFilter{}
+	filter.ScanQuery(r.URL.Query())
+	err := filter.Valid()
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+	match := filter.Matcher()
+
+	// The header goes out with the first row, so a failure before it can
+	// still be answered with an error status.
+	cw := csv.NewWriter(w)
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="tasks.csv"`)
+		w.WriteHeader(http.StatusOK)
+		return cw.Write(schemas.TaskCSVColumns)
+	}
+
+	rows := 0
+	err = h.service.Task.Export(r.Context(), func(task *dto.TaskRead) error {
+		if !match(task) {
+			return nil
+		}
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := cw.Write(schemas.TaskToCSVRecord(task)); err != nil {
+			return err
+		}
+		if rows++; rows%exportFlushRows == 0 {
+			cw.Flush()
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+		return cw.Error()
+	})
+	if err != nil && !started {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("task export aborted after %d rows: %s", rows, err)
+		return
+	}
+
+	if !started {
+		if err = start(); err != nil {
+			return
+		}
+	}
+	cw.Flush()
+}
+
+// taskImport godoc
+// @Tags         Task API
+// @Summary      Import Tasks Summary
+// @Description  Create tasks from a CSV with a header row, a todo.txt file (text/plain) or a Markdown checklist (text/markdown), all of them or none. CSV columns are matched by the names title, description, due_date and completed unless mapped with map=field:header. The ' prefix of an export is removed from titles and descriptions. todo.txt lines and checklist items take the due date from the due tag and keep priority, +projects and @contexts in the description. A due date without offset is read in timezone. Entries that fail validation are reported with their line number and nothing is imported. dry_run only validates
+// @Accept       text/csv
+// @Accept       plain
+// @Accept       text/markdown
+// @Produce      json
+// @Param tasks body string true "CSV, todo.txt or Markdown"
+// @Param dry_run query bool false "Only validate"
+// @Param timezone query string false "IANA time zone of due dates without offset, UTC by default"
+// @Param map query []string false "CSV column mapping field:header" collectionFormat(multi)
+// @Param X-Actor header string false "Advisory actor recorded in task history, not authenticated"
+// @Success      200  {object}  schemas.ResponseTaskImport
+// @Success      201  {object}  schemas.ResponseTaskImport
+// @Failure      400  {object}	errorJSON
+// @Failure      422  {object}  schemas.ResponseTaskImport
+// @Failure      500  {object}	errorJSON
+// @Router       /tasks/import [post]
+func (h *Handler) taskImport(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s taskImport called", r.Method, r.RemoteAddr)
+
+	options := schemas.RequestTaskImport{}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("content-type"))
+	scan, ok := map[string]func(io.Reader, string) ([]dto.TaskCreate, []schemas.ResponseImportError, error){
+		"text/csv":      options.ScanCSV,
+		"text/plain":    options.ScanTodoTxt,
+		"text/markdown": options.ScanChecklist,
+	}[mediaType]
+	if !ok {
+		writeResponseErr(w, http.StatusBadRequest,
+			errors.New("content-type is not text/csv, text/plain or text/markdown"))
+		return
+	}
+
+	options.ScanQuery(r.URL.Query())
+	err := options.Valid()
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	cTasks, rowErrors, err := scan(http.MaxBytesReader(w, r.Body, importMaxBody), requestActor(r))
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report := schemas.ResponseTaskImport{
+		DryRun: options.IsDryRun(),
+		Rows:   len(cTasks) + len(rowErrors),
+		Errors: rowErrors,
+	}
+	if len(rowErrors) > 0 {
+		writeResponse(w, http.StatusUnprocessableEntity, report)
+		return
+	}
+	if report.DryRun {
+		writeResponse(w, http.StatusOK, report)
+		return
+	}
+
+	rTasks, err := h.service.Task.Import(cTasks)
+	if err != nil {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	report.Imported = len(rTasks)
+
+	writeResponse(w, http.StatusCreated, report)
+}
@@ -0,0 +1,290 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/route/api/openapi/openapitest"
+	"ToDoVerba/internal/service"
+	mockservice "ToDoVerba/internal/service/mocks"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_taskExport(t *testing.T) {
+	type mockBehaviour func(s *mockservice.MockITaskService)
+
+	parseTime := func(s string) pgtype.Timestamptz {
+		t, _ := time.Parse(time.RFC3339, s)
+		return pgtype.Timestamptz{
+			Time:  t,
+			Valid: true,
+		}
+	}
+	tasks := []dto.TaskRead{
+		{
+			Id:          1,
+			Title:       "First Task",
+			Description: "Milk, \"eggs\"",
+			DueDate:     parseTime("2024-09-05T15:04:05+05:00"),
+			CreatedAt:   parseTime("2024-09-01T10:00:00Z"),
+			UpdatedAt:   parseTime("2024-09-02T10:00:00Z"),
+		},
+		{
+			Id:        2,
+			Title:     "Done",
+			DueDate:   parseTime("2024-09-06T09:00:00Z"),
+			Completed: true,
+			CreatedAt: parseTime("2024-09-01T10:00:00Z"),
+			UpdatedAt: parseTime("2024-09-03T10:00:00Z"),
+		},
+	}
+	stream := func(ctx context.Context, each func(task *dto.TaskRead) error) error {
+		for i := range tasks {
+			if err := each(&tasks[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	testTable := []struct {
+		name          string
+		inputQuery    string
+		mockBehaviour mockBehaviour
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			name: "200_all",
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().Export(gomock.Any(), gomock.Any()).DoAndReturn(stream)
+			},
+			expectedCode: 200,
+			expectedBody: "id,title,description,due_date,completed,created_at,updated_at\n" +
+				"1,First Task,\"Milk, \"\"eggs\"\"\",2024-09-05T15:04:05+05:00,false,2024-09-01T10:00:00Z,2024-09-02T10:00:00Z\n" +
+				"2,Done,,2024-09-06T09:00:00Z,true,2024-09-01T10:00:00Z,2024-09-03T10:00:00Z\n",
+		},
+		{
+			name: "200_formulas_escaped",
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().Export(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, each func(task *dto.TaskRead) error) error {
+						return each(&dto.TaskRead{
+							Id:          3,
+							Title:       `=HYPERLINK("http://evil.example","click")`,
+							Description: "@SUM(A1:A9)",
+							DueDate:     parseTime("2024-09-06T09:00:00Z"),
+							CreatedAt:   parseTime("2024-09-01T10:00:00Z"),
+							UpdatedAt:   parseTime("2024-09-03T10:00:00Z"),
+						})
+					})
+			},
+			expectedCode: 200,
+			expectedBody: "id,title,description,due_date,completed,created_at,updated_at\n" +
+				"3,\"'=HYPERLINK(\"\"http://evil.example\"\",\"\"click\"\")\",'@SUM(A1:A9),2024-09-06T09:00:00Z,false,2024-09-01T10:00:00Z,2024-09-03T10:00:00Z\n",
+		},
+		{
+			name:       "200_filtered_to_nothing",
+			inputQuery: "?completed=true&due_before=2024-09-06T00:00:00Z",
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().Export(gomock.Any(), gomock.Any()).DoAndReturn(stream)
+			},
+			expectedCode: 200,
+			expectedBody: "id,title,description,due_date,completed,created_at,updated_at\n",
+		},
+		{
+			name:          "400_invalid_filter",
+			inputQuery:    "?completed=maybe",
+			mockBehaviour: func(s *mockservice.MockITaskService) {},
+			expectedCode:  400,
+			expectedBody:  `{"error":"completed must be true or false;"}`,
+		},
+		{
+			name: "500_before_first_row",
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().Export(gomock.Any(), gomock.Any()).Return(errors.New("connection refused"))
+			},
+			expectedCode: 500,
+			expectedBody: `{"error":"connection refused"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tasksService := mockservice.NewMockITaskService(c)
+			testCase.mockBehaviour(tasksService)
+
+			handler := NewHandler(Deps{
+				Service: service.Services{Task: tasksService},
+				Logger:  logging.GetLoggerTest(),
+			})
+
+			r := httprouter.New()
+			r.GET("/tasks/:id", withStatic("id", map[string]httprouter.Handle{
+				"export.csv": handler.taskExport,
+			}, handler.taskFindById))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/tasks/export.csv"+testCase.inputQuery, nil)
+
+			openapitest.Handler(t, r).ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			assert.Equal(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_taskImport(t *testing.T) {
+	type mockBehaviour func(s *mockservice.MockITaskService)
+
+	due := func(s string) pgtype.Timestamptz {
+		t, _ := time.Parse(time.RFC3339, s)
+		return pgtype.Timestamptz{
+			Time:  t,
+			Valid: true,
+		}
+	}
+
+	testTable := []struct {
+		name          string
+		inputQuery    string
+		contentType   string
+		inputBody     string
+		mockBehaviour mockBehaviour
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			name:        "201_mapped_columns_in_timezone",
+			inputQuery:  "?timezone=Europe/Berlin&map=title:Task&map=due_date:Deadline&map=completed:Done",
+			contentType: "text/csv",
+			inputBody: "\ufeffTask,Description,Deadline,Done,Owner\n" +
+				"Buy milk,\"Milk,\neggs\",2024-09-05 15:04,yes,bob\n" +
+				"Call,Dentist,2024-09-06T09:00:00Z,,bob\n",
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().Import([]dto.TaskCreate{
+					{Title: "Buy milk", Description: "Milk,\neggs", DueDate: due("2024-09-05T13:04:00Z"), Completed: true, Actor: "alice"},
+					{Title: "Call", Description: "Dentist", DueDate: due("2024-09-06T09:00:00Z"), Actor: "alice"},
+				}).Return(make([]dto.TaskRead, 2), nil)
+			},
+			expectedCode: 201,
+			expectedBody: `{"dry_run":false,"rows":2,"imported":2,"errors":[]}`,
+		},
+		{
+			name:        "201_escaped_formulas_round_trip",
+			contentType: "text/csv",
+			inputBody: "title,description,due_date\n" +
+				"\"'=HYPERLINK(\"\"http://evil.example\"\",\"\"click\"\")\",'@SUM(A1:A9),2024-09-06T09:00:00Z\n" +
+				"'-1 apples,''quoted,2024-09-06T09:00:00Z\n" +
+				"it's,'plain,2024-09-06T09:00:00Z\n",
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().Import([]dto.TaskCreate{
+					{Title: `=HYPERLINK("http://evil.example","click")`, Description: "@SUM(A1:A9)", DueDate: due("2024-09-06T09:00:00Z"), Actor: "alice"},
+					{Title: "-1 apples", Description: "'quoted", DueDate: due("2024-09-06T09:00:00Z"), Actor: "alice"},
+					{Title: "it's", Description: "'plain", DueDate: due("2024-09-06T09:00:00Z"), Actor: "alice"},
+				}).Return(make([]dto.TaskRead, 3), nil)
+			},
+			expectedCode: 201,
+			expectedBody: `{"dry_run":false,"rows":3,"imported":3,"errors":[]}`,
+		},
+		{
+			name:          "200_dry_run",
+			inputQuery:    "?dry_run=true",
+			contentType:   "text/csv; charset=utf-8",
+			inputBody:     "title,description,due_date\nBuy milk,Milk,2024-09-05\n",
+			mockBehaviour: func(s *mockservice.MockITaskService) {},
+			expectedCode:  200,
+			expectedBody:  `{"dry_run":true,"rows":1,"imported":0,"errors":[]}`,
+		},
+		{
+			name:        "422_invalid_rows",
+			contentType: "text/csv",
+			inputBody: "title,description,due_date,completed\n" +
+				"Buy milk,\"multi\nline\",2024-09-05,false\n" +
+				",Milk,tomorrow,maybe\n" +
+				"Call,Dentist,2024-09-06,true\n",
+			mockBehaviour: func(s *mockservice.MockITaskService) {},
+			expectedCode:  422,
+			expectedBody: `{"dry_run":false,"rows":3,"imported":0,"errors":[{"row":4,
+				"error":"due_date is required and must be in RFC3339 format or a local date and time;completed must be true or false;Title is required;"}]}`,
+		},
+		{
+			name:          "400_missing_column",
+			inputQuery:    "?map=title:Task",
+			contentType:   "text/csv",
+			inputBody:     "title,due\nBuy milk,2024-09-05\n",
+			mockBehaviour: func(s *mockservice.MockITaskService) {},
+			expectedCode:  400,
+			expectedBody:  `{"error":"column \"Task\" for title not found;column \"due_date\" for due_date not found;"}`,
+		},
+		{
+			name:          "400_invalid_options",
+			inputQuery:    "?timezone=Mars/Olympus&map=owner:Owner&dry_run=perhaps",
+			contentType:   "text/csv",
+			mockBehaviour: func(s *mockservice.MockITaskService) {},
+			expectedCode:  400,
+			expectedBody: `{"error":"dry_run must be true or false;timezone must be an IANA time zone;` +
+				`map field \"owner\" must be one of title, description, due_date, completed;"}`,
+		},
+		{
+			name:          "400_wrong_content_type",
+			contentType:   "application/json",
+			inputBody:     "[]",
+			mockBehaviour: func(s *mockservice.MockITaskService) {},
+			expectedCode:  400,
+			expectedBody:  `{"error":"content-type is not text/csv, text/plain or text/markdown"}`,
+		},
+		{
+			name:        "500_service_failure",
+			contentType: "text/csv",
+			inputBody:   "title,description,due_date\nBuy milk,Milk,2024-09-05\n",
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().Import(gomock.Any()).Return(nil, errors.New("unknown error"))
+			},
+			expectedCode: 500,
+			expectedBody: `{"error":"unknown error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tasksService := mockservice.NewMockITaskService(c)
+			testCase.mockBehaviour(tasksService)
+
+			handler := NewHandler(Deps{
+				Service: service.Services{Task: tasksService},
+				Logger:  logging.GetLoggerTest(),
+			})
+
+			r := httprouter.New()
+			r.POST("/tasks/:id", withStatic("id", map[string]httprouter.Handle{
+				"import": handler.taskImport,
+			}, methodNotAllowed))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/tasks/import"+testCase.inputQuery, strings.NewReader(testCase.inputBody))
+			req.Header.Set("Content-Type", testCase.contentType)
+			req.Header.Set("X-Actor", "alice")
+
+			openapitest.Handler(t, r).ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			assert.JSONEq(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
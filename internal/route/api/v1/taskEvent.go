@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/schemas"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strconv"
+)
+
+func (h *Handler) initTaskEventHandler(r *httprouter.Router) {
+	r.GET("/tasks/:id/history", h.taskHistory)
+	r.GET("/audit", h.auditList)
+}
+
+// taskHistory godoc
+// @Tags         Audit API
+// @Summary      Task history Summary
+// @Description  Every create, update and delete of the task, oldest first
+// @Accept       json
+// @Produce      json
+// @Param id path int false "Task id"
+// @Success      200  {object}  []schemas.ResponseTaskEvent
+// @Failure      400  {object}	errorJSON
+// @Failure      404  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /tasks/{id}/history [get]
+func (h *Handler) taskHistory(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s taskHistory called", r.Method, r.RemoteAddr)
+
+	idStr := ps.ByName("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rEventsDTO, err := h.service.TaskEvent.History(id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeResponseErr(w, http.StatusNotFound, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, scanTaskEvents(rEventsDTO))
+}
+
+// auditList godoc
+// @Tags         Audit API
+// @Summary      Audit log Summary
+// @Description  Task events across all tasks, oldest first
+// @Accept       json
+// @Produce      json
+// @Param task_id query int false "Task id"
+// @Param actor query string false "Actor"
+// @Param action query string false "Action" Enums(create, update, delete)
+// @Param from query string false "Events at or after, RFC3339"
+// @Param to query string false "Events before, RFC3339"
+// @Param offset query int false "Offset"
+// @Param limit query int false "Limit, 100 by default"
+// @Success      200  {object}  []schemas.ResponseTaskEvent
+// @Failure      400  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /audit [get]
+func (h *Handler) auditList(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s auditList called", r.Method, r.RemoteAddr)
+
+	filter := schemas.RequestTaskEventFilter{}
+	filter.ScanQuery(r.URL.Query())
+	err := filter.Valid()
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rEventsDTO, err := h.service.TaskEvent.List(filter.ToDTO())
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, scanTaskEvents(rEventsDTO))
+}
+
+func scanTaskEvents(eventsDTO []dto.TaskEvent) []schemas.ResponseTaskEvent {
+	rEvents := make([]schemas.ResponseTaskEvent, 0, len(eventsDTO))
+	for i := 0; i < len(eventsDTO); i++ {
+		rEvent := schemas.ResponseTaskEvent{}
+		rEvent.ScanDTO(&eventsDTO[i])
+		rEvents = append(rEvents, rEvent)
+	}
+	return rEvents
+}
@@ -0,0 +1,210 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/route/api/openapi/openapitest"
+	"ToDoVerba/internal/service"
+	mockservice "ToDoVerba/internal/service/mocks"
+	"ToDoVerba/pkg/logging"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_taskHistory(t *testing.T) {
+	type mockBehaviour func(s *mockservice.MockITaskEventService, id int)
+
+	parseTime := func(s string) pgtype.Timestamptz {
+		t, _ := time.Parse(time.RFC3339, s)
+		return pgtype.Timestamptz{
+			Time:  t,
+			Valid: true,
+		}
+	}
+
+	testTable := []struct {
+		name          string
+		inputParam    string
+		inputId       int
+		mockBehaviour mockBehaviour
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			name:       "200_valid_param",
+			inputParam: "7",
+			inputId:    7,
+			mockBehaviour: func(s *mockservice.MockITaskEventService, id int) {
+				s.EXPECT().History(id).Return([]dto.TaskEvent{
+					{
+						Id:     3,
+						TaskId: 7,
+						Action: dto.TaskEventUpdate,
+						Actor:  "alice",
+						Changes: map[string]dto.FieldChange{
+							"due_date": {Before: "2024-09-05T10:04:05Z", After: "2024-09-06T10:04:05Z"},
+						},
+						CreatedAt: parseTime("2024-09-01T15:04:05+05:00"),
+					},
+				}, nil)
+			},
+			expectedCode: 200,
+			expectedBody: `[{
+								"id": 3,
+								"task_id": 7,
+								"action": "update",
+								"actor": "alice",
+								"changes": {"due_date": {"before": "2024-09-05T10:04:05Z", "after": "2024-09-06T10:04:05Z"}},
+								"created_at": "2024-09-01T15:04:05+05:00"
+							}]`,
+		},
+		{
+			name:          "400_invalid_param",
+			inputParam:    "seven",
+			mockBehaviour: func(s *mockservice.MockITaskEventService, id int) {},
+			expectedCode:  400,
+			expectedBody:  `{"error":"strconv.Atoi: parsing \"seven\": invalid syntax"}`,
+		},
+		{
+			name:       "404_no_rows_found",
+			inputParam: "7",
+			inputId:    7,
+			mockBehaviour: func(s *mockservice.MockITaskEventService, id int) {
+				s.EXPECT().History(id).Return(nil, pgx.ErrNoRows)
+			},
+			expectedCode: 404,
+			expectedBody: `{"error":"no rows in result set"}`,
+		},
+		{
+			name:       "500_unknown_error",
+			inputParam: "7",
+			inputId:    7,
+			mockBehaviour: func(s *mockservice.MockITaskEventService, id int) {
+				s.EXPECT().History(id).Return(nil, errors.New("some error"))
+			},
+			expectedCode: 500,
+			expectedBody: `{"error":"some error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			//Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+			taskEventService := mockservice.NewMockITaskEventService(c)
+			testCase.mockBehaviour(taskEventService, testCase.inputId)
+
+			services := service.Services{TaskEvent: taskEventService}
+			handler := NewHandler(Deps{
+				Service: services,
+				Logger:  logging.GetLoggerTest(),
+			})
+
+			//Test server
+			r := httprouter.New()
+			r.GET("/tasks/:id/history", handler.taskHistory)
+
+			//http test
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/tasks/"+testCase.inputParam+"/history", nil)
+
+			//Perform request
+			openapitest.Handler(t, r).ServeHTTP(w, req)
+
+			//Assert
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			assert.JSONEq(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_auditList(t *testing.T) {
+	type mockBehaviour func(s *mockservice.MockITaskEventService)
+
+	parseTime := func(s string) pgtype.Timestamptz {
+		t, _ := time.Parse(time.RFC3339, s)
+		return pgtype.Timestamptz{
+			Time:  t,
+			Valid: true,
+		}
+	}
+
+	testTable := []struct {
+		name          string
+		inputQuery    string
+		mockBehaviour mockBehaviour
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			name:       "200_time_range",
+			inputQuery: "?from=2024-09-01T00:00:00Z&to=2024-10-01T00:00:00Z&actor=alice",
+			mockBehaviour: func(s *mockservice.MockITaskEventService) {
+				s.EXPECT().List(&dto.TaskEventFilter{
+					Actor: "alice",
+					From:  parseTime("2024-09-01T00:00:00Z"),
+					To:    parseTime("2024-10-01T00:00:00Z"),
+					Limit: 100,
+				}).Return(nil, pgx.ErrNoRows)
+			},
+			expectedCode: 200,
+			expectedBody: `[]`,
+		},
+		{
+			name:          "400_invalid_query",
+			inputQuery:    "?from=yesterday&action=archive&limit=0",
+			mockBehaviour: func(s *mockservice.MockITaskEventService) {},
+			expectedCode:  400,
+			expectedBody:  `{"error":"action must be one of create, update, delete;from must be in RFC3339 format;limit must be an integer between 1 and 1000;"}`,
+		},
+		{
+			name:       "500_unknown_error",
+			inputQuery: "",
+			mockBehaviour: func(s *mockservice.MockITaskEventService) {
+				s.EXPECT().List(gomock.Any()).Return(nil, errors.New("some error"))
+			},
+			expectedCode: 500,
+			expectedBody: `{"error":"some error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			//Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+			taskEventService := mockservice.NewMockITaskEventService(c)
+			testCase.mockBehaviour(taskEventService)
+
+			services := service.Services{TaskEvent: taskEventService}
+			handler := NewHandler(Deps{
+				Service: services,
+				Logger:  logging.GetLoggerTest(),
+			})
+
+			//Test server
+			r := httprouter.New()
+			r.GET("/audit", handler.auditList)
+
+			//http test
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/audit"+testCase.inputQuery, nil)
+
+			//Perform request
+			openapitest.Handler(t, r).ServeHTTP(w, req)
+
+			//Assert
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			assert.JSONEq(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
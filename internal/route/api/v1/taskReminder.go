@@ -0,0 +1,234 @@
+package v1
+
+import (
+	"ToDoVerba/internal/schemas"
+	"ToDoVerba/internal/service/reminderService"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+func (h *Handler) initTaskReminderHandler(r *httprouter.Router) {
+	r.GET("/tasks/:id/reminders", h.taskReminderList)
+	r.POST("/tasks/:id/reminders", h.taskReminderCreate)
+	r.DELETE("/tasks/:id/reminders/:rid", h.taskReminderDelete)
+	r.POST("/tasks/:id/reminders/:rid/snooze", h.taskReminderSnooze)
+}
+
+// taskReminderCreate godoc
+// @Tags         Task Reminder API
+// @Summary      Create Task reminder Summary
+// @Description  Remind offset before the due date of the task, e.g. 15m or 24h. Channels default to the server configuration. Creating a reminder with an existing offset replaces it
+// @Accept       json
+// @Produce      json
+// @Param id path int false "Task id"
+// @Param Reminder body schemas.RequestReminderCreate false "Reminder"
+// @Success      201  {object}  schemas.ResponseReminder
+// @Failure      400  {object}  errorJSON
+// @Failure      404  {object}  errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /tasks/{id}/reminders [post]
+func (h *Handler) taskReminderCreate(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s taskReminderCreate called", r.Method, r.RemoteAddr)
+
+	contentType := r.Header.Get("content-type")
+	if contentType != "application/json" {
+		writeResponseErr(w, http.StatusBadRequest,
+			errors.New("content-type is not application/json"))
+		return
+	}
+
+	idStr := ps.ByName("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	cReminder := schemas.RequestReminderCreate{}
+	bodyRaw, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = json.Unmarshal(bodyRaw, &cReminder)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+	err = cReminder.Valid()
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rReminderDTO, err := h.service.Reminder.Create(cReminder.ToDTO(id))
+	if err != nil {
+		if errors.Is(err, reminderService.ErrChannelDisabled) {
+			writeResponseErr(w, http.StatusBadRequest, err)
+			return
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeResponseErr(w, http.StatusNotFound, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rReminder := schemas.ResponseReminder{}
+	rReminder.ScanDTO(rReminderDTO)
+	writeResponse(w, http.StatusCreated, rReminder)
+}
+
+// taskReminderList godoc
+// @Tags         Task Reminder API
+// @Summary      List Task reminders Summary
+// @Description  Reminders of the task ordered by fire time. Status is pending, sent or failed
+// @Accept       json
+// @Produce      json
+// @Param id path int false "Task id"
+// @Success      200  {object}  []schemas.ResponseReminder
+// @Failure      400  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /tasks/{id}/reminders [get]
+func (h *Handler) taskReminderList(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s taskReminderList called", r.Method, r.RemoteAddr)
+
+	idStr := ps.ByName("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rRemindersDTO, err := h.service.Reminder.List(id)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rReminders := make([]schemas.ResponseReminder, 0, len(rRemindersDTO))
+	for i := 0; i < len(rRemindersDTO); i++ {
+		rReminder := schemas.ResponseReminder{}
+		rReminder.ScanDTO(&rRemindersDTO[i])
+		rReminders = append(rReminders, rReminder)
+	}
+
+	writeResponse(w, http.StatusOK, rReminders)
+}
+
+// taskReminderSnooze godoc
+// @Tags         Task Reminder API
+// @Summary      Snooze Task reminder Summary
+// @Description  Fire the reminder again after the given duration, also when it was already sent
+// @Accept       json
+// @Produce      json
+// @Param id path int false "Task id"
+// @Param rid path int false "Reminder id"
+// @Param Snooze body schemas.RequestReminderSnooze false "Snooze duration"
+// @Success      200  {object}  schemas.ResponseReminder
+// @Failure      400  {object}  errorJSON
+// @Failure      404  {object}  errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /tasks/{id}/reminders/{rid}/snooze [post]
+func (h *Handler) taskReminderSnooze(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s taskReminderSnooze called", r.Method, r.RemoteAddr)
+
+	contentType := r.Header.Get("content-type")
+	if contentType != "application/json" {
+		writeResponseErr(w, http.StatusBadRequest,
+			errors.New("content-type is not application/json"))
+		return
+	}
+
+	id, rid, err := reminderParams(ps)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	snooze := schemas.RequestReminderSnooze{}
+	bodyRaw, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = json.Unmarshal(bodyRaw, &snooze)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+	err = snooze.Valid()
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rReminderDTO, err := h.service.Reminder.Snooze(id, rid, snooze.Duration())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeResponseErr(w, http.StatusNotFound, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rReminder := schemas.ResponseReminder{}
+	rReminder.ScanDTO(rReminderDTO)
+	writeResponse(w, http.StatusOK, rReminder)
+}
+
+// taskReminderDelete godoc
+// @Tags         Task Reminder API
+// @Summary      Delete Task reminder Summary
+// @Description  Delete Task reminder Description
+// @Accept       json
+// @Produce      json
+// @Param id path int false "Task id"
+// @Param rid path int false "Reminder id"
+// @Success      204
+// @Failure      400  {object}	errorJSON
+// @Failure      404  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /tasks/{id}/reminders/{rid} [delete]
+func (h *Handler) taskReminderDelete(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s taskReminderDelete called", r.Method, r.RemoteAddr)
+
+	id, rid, err := reminderParams(ps)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.service.Reminder.DeleteById(id, rid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeResponseErr(w, http.StatusNotFound, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeResponse(w, http.StatusNoContent, nil)
+}
+
+func reminderParams(ps httprouter.Params) (int, int64, error) {
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		return 0, 0, err
+	}
+	rid, err := strconv.ParseInt(ps.ByName("rid"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return id, rid, nil
+}
@@ -0,0 +1,128 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/route/api/openapi/openapitest"
+	"ToDoVerba/internal/service"
+	mockservice "ToDoVerba/internal/service/mocks"
+	"ToDoVerba/internal/service/reminderService"
+	"ToDoVerba/pkg/logging"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_taskReminderCreate(t *testing.T) {
+	type mockBehaviour func(s *mockservice.MockIReminderService)
+
+	parseTime := func(s string) pgtype.Timestamptz {
+		t, _ := time.Parse(time.RFC3339, s)
+		return pgtype.Timestamptz{
+			Time:  t,
+			Valid: true,
+		}
+	}
+
+	testTable := []struct {
+		name          string
+		inputParam    string
+		inputBody     string
+		mockBehaviour mockBehaviour
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			name:       "201_valid_input",
+			inputParam: "7",
+			inputBody:  `{"offset": "15m", "channels": ["log"]}`,
+			mockBehaviour: func(s *mockservice.MockIReminderService) {
+				s.EXPECT().Create(&dto.ReminderCreate{TaskId: 7, Offset: 15 * time.Minute, Channels: []string{"log"}}).
+					Return(&dto.Reminder{
+						Id:        3,
+						TaskId:    7,
+						Offset:    15 * time.Minute,
+						Channels:  []string{"log"},
+						FireAt:    parseTime("2024-09-05T14:45:00Z"),
+						Status:    dto.ReminderPending,
+						CreatedAt: parseTime("2024-09-01T10:00:00Z"),
+					}, nil)
+			},
+			expectedCode: 201,
+			expectedBody: `{
+								"id": 3,
+								"task_id": 7,
+								"offset": "15m0s",
+								"channels": ["log"],
+								"sent_channels": null,
+								"fire_at": "2024-09-05T14:45:00Z",
+								"status": "pending",
+								"attempts": 0,
+								"last_error": "",
+								"sent_at": "",
+								"created_at": "2024-09-01T10:00:00Z"
+							}`,
+		},
+		{
+			name:          "400_invalid_offset",
+			inputParam:    "7",
+			inputBody:     `{"offset": "a day", "channels": ["sms"]}`,
+			mockBehaviour: func(s *mockservice.MockIReminderService) {},
+			expectedCode:  400,
+			expectedBody:  `{"error":"offset is required and must be a non-negative duration such as 15m or 24h;channels must be email, webhook or log;"}`,
+		},
+		{
+			name:       "400_channel_disabled",
+			inputParam: "7",
+			inputBody:  `{"offset": "1h", "channels": ["email"]}`,
+			mockBehaviour: func(s *mockservice.MockIReminderService) {
+				s.EXPECT().Create(gomock.Any()).Return(nil, fmt.Errorf("%w: email", reminderService.ErrChannelDisabled))
+			},
+			expectedCode: 400,
+			expectedBody: `{"error":"reminder channel is not enabled: email"}`,
+		},
+		{
+			name:       "404_task_not_found",
+			inputParam: "8",
+			inputBody:  `{"offset": "1h"}`,
+			mockBehaviour: func(s *mockservice.MockIReminderService) {
+				s.EXPECT().Create(gomock.Any()).Return(nil, pgx.ErrNoRows)
+			},
+			expectedCode: 404,
+			expectedBody: `{"error":"no rows in result set"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			reminders := mockservice.NewMockIReminderService(c)
+			testCase.mockBehaviour(reminders)
+
+			handler := NewHandler(Deps{
+				Service: service.Services{Reminder: reminders},
+				Logger:  logging.GetLoggerTest(),
+			})
+
+			r := httprouter.New()
+			r.POST("/tasks/:id/reminders", handler.taskReminderCreate)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/tasks/"+testCase.inputParam+"/reminders", strings.NewReader(testCase.inputBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			openapitest.Handler(t, r).ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			assert.JSONEq(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
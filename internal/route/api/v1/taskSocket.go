@@ -0,0 +1,255 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/schemas"
+	"ToDoVerba/internal/service/userService"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	wsPongWait     = 60 * time.Second
+	wsPingInterval = 50 * time.Second
+	wsWriteWait    = 10 * time.Second
+	// wsSendBuffer is the number of replies and events queued for a client.
+	// A client that lets it fill up is disconnected.
+	wsSendBuffer     = 64
+	wsMaxMessageSize = int64(64 << 10)
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+}
+
+func (h *Handler) initTaskSocketHandler(r *httprouter.Router) {
+	r.GET("/ws", h.taskSocket)
+}
+
+// taskSocket godoc
+// @Tags         Task API
+// @Summary      Task WebSocket Summary
+// @Description  Bidirectional task channel. Clients send schemas.SocketCommand messages to subscribe to the tasks or task:<id> topics and to create or update tasks, and receive schemas.SocketReply acks, errors and events. Browsers pass the token as access_token query parameter
+// @Param Authorization header string false "Bearer api token"
+// @Param access_token query string false "Api token"
+// @Success      101
+// @Failure      401  {object}	errorJSON
+// @Router       /ws [get]
+func (h *Handler) taskSocket(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s taskSocket called", r.Method, r.RemoteAddr)
+
+	user, err := h.service.User.Authenticate(requestToken(r))
+	if err != nil {
+		if errors.Is(err, userService.ErrUnauthorized) {
+			writeResponseErr(w, http.StatusUnauthorized, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already replied with an error status.
+		h.logger.Debugf("[%s] %s taskSocket upgrade failed: %s", r.Method, r.RemoteAddr, err)
+		return
+	}
+
+	c := &socketConn{
+		h:      h,
+		conn:   conn,
+		user:   user,
+		send:   make(chan schemas.SocketReply, wsSendBuffer),
+		done:   make(chan struct{}),
+		topics: make(map[string]bool),
+	}
+	c.run()
+}
+
+// socketConn serves one /ws client. Reads happen on the handler goroutine,
+// all writes except control frames on writeLoop.
+type socketConn struct {
+	h    *Handler
+	conn *websocket.Conn
+	user *dto.UserRead
+	send chan schemas.SocketReply
+
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu     sync.Mutex
+	topics map[string]bool
+}
+
+func (c *socketConn) run() {
+	sub, _ := c.h.service.Broker.Subscribe("", nil)
+	defer c.h.service.Broker.Unsubscribe(sub)
+
+	go c.writeLoop()
+	go c.eventLoop(sub.C)
+
+	c.readLoop()
+	c.close(websocket.CloseNormalClosure, "")
+}
+
+func (c *socketConn) readLoop() {
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			c.h.logger.Debugf("taskSocket of %s closed: %s", c.user.Name, err)
+			return
+		}
+
+		cmd := schemas.SocketCommand{}
+		if err = json.Unmarshal(data, &cmd); err != nil {
+			c.reply(schemas.SocketReply{Type: schemas.SocketError, Error: err.Error()})
+			continue
+		}
+		c.reply(c.handle(&cmd))
+	}
+}
+
+func (c *socketConn) handle(cmd *schemas.SocketCommand) schemas.SocketReply {
+	fail := func(err error) schemas.SocketReply {
+		return schemas.SocketReply{Type: schemas.SocketError, Id: cmd.Id, Error: err.Error()}
+	}
+
+	if err := cmd.Valid(); err != nil {
+		return fail(err)
+	}
+
+	var rTaskDTO *dto.TaskRead
+	switch cmd.Type {
+	case schemas.SocketSubscribe, schemas.SocketUnsubscribe:
+		c.mu.Lock()
+		if cmd.Type == schemas.SocketSubscribe {
+			c.topics[cmd.Topic] = true
+		} else {
+			delete(c.topics, cmd.Topic)
+		}
+		c.mu.Unlock()
+		return schemas.SocketReply{Type: schemas.SocketAck, Id: cmd.Id}
+
+	case schemas.SocketCreate:
+		cTask := schemas.RequestTaskCreate{}
+		if err := json.Unmarshal(cmd.Data, &cTask); err != nil {
+			return fail(err)
+		}
+		if err := cTask.Valid(); err != nil {
+			return fail(err)
+		}
+		cTaskDTO := cTask.ToDTO()
+		cTaskDTO.Actor = c.user.Name
+		var err error
+		if rTaskDTO, err = c.h.service.Task.Create(cTaskDTO); err != nil {
+			return fail(err)
+		}
+
+	case schemas.SocketUpdate:
+		uTask := schemas.RequestTaskUpdate{}
+		if err := json.Unmarshal(cmd.Data, &uTask); err != nil {
+			return fail(err)
+		}
+		if err := uTask.Valid(); err != nil {
+			return fail(err)
+		}
+		uTaskDTO := uTask.ToDTO()
+		uTaskDTO.Actor = c.user.Name
+		var err error
+		if rTaskDTO, err = c.h.service.Task.UpdateById(cmd.TaskId, uTaskDTO); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fail(errors.New("task not found"))
+			}
+			return fail(err)
+		}
+	}
+
+	rTask := schemas.ResponseTaskRead{}
+	rTask.ScanDTO(rTaskDTO)
+	return schemas.SocketReply{Type: schemas.SocketAck, Id: cmd.Id, Task: &rTask}
+}
+
+// eventLoop forwards broker events of subscribed topics. The broker closes
+// events when this client falls behind.
+func (c *socketConn) eventLoop(events <-chan dto.StreamMessage) {
+	for {
+		select {
+		case <-c.done:
+			return
+		case msg, ok := <-events:
+			if !ok {
+				c.close(websocket.CloseTryAgainLater, "too slow")
+				return
+			}
+			if !c.subscribed(msg.Event.TaskId) {
+				continue
+			}
+			payload := schemas.EventPayload{}
+			payload.ScanDTO(&msg.Event)
+			c.reply(schemas.SocketReply{Type: schemas.SocketEvent, Event: &payload})
+		}
+	}
+}
+
+func (c *socketConn) subscribed(taskId int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.topics[schemas.TopicTasks] || c.topics[schemas.TaskTopic(taskId)]
+}
+
+// reply queues a message without blocking and disconnects a client that does
+// not keep up with its messages.
+func (c *socketConn) reply(msg schemas.SocketReply) {
+	select {
+	case <-c.done:
+	case c.send <- msg:
+	default:
+		c.close(websocket.CloseTryAgainLater, "too slow")
+	}
+}
+
+func (c *socketConn) writeLoop() {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				c.close(websocket.CloseInternalServerErr, "")
+				return
+			}
+		case <-ping.C:
+			err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+			if err != nil {
+				c.close(websocket.CloseGoingAway, "")
+				return
+			}
+		}
+	}
+}
+
+func (c *socketConn) close(code int, text string) {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		msg := websocket.FormatCloseMessage(code, text)
+		c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteWait))
+		c.conn.Close()
+	})
+}
@@ -0,0 +1,126 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/schemas"
+	"ToDoVerba/internal/service"
+	"ToDoVerba/internal/service/brokerService"
+	mockservice "ToDoVerba/internal/service/mocks"
+	"ToDoVerba/internal/service/userService"
+	"ToDoVerba/pkg/logging"
+	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSocketServer(t *testing.T) (*httptest.Server, *mockservice.MockITaskService, *brokerService.BrokerService) {
+	c := gomock.NewController(t)
+
+	users := mockservice.NewMockIUserService(c)
+	users.EXPECT().Authenticate("secret").Return(&dto.UserRead{Id: 1, Name: "alice"}, nil).AnyTimes()
+	users.EXPECT().Authenticate(gomock.Not("secret")).Return(nil, userService.ErrUnauthorized).AnyTimes()
+
+	tasks := mockservice.NewMockITaskService(c)
+	broker := brokerService.NewBrokerService(brokerService.Deps{
+		Logger:     logging.GetLoggerTest(),
+		ReplaySize: 10,
+		BufferSize: 10,
+	})
+
+	handler := NewHandler(Deps{
+		Service: service.Services{Task: tasks, User: users, Broker: broker},
+		Logger:  logging.GetLoggerTest(),
+	})
+	r := httprouter.New()
+	r.GET("/ws", handler.taskSocket)
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server, tasks, broker
+}
+
+func dialSocket(t *testing.T, server *httptest.Server, token string) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	// The actor is the authenticated user, X-Actor is ignored.
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer " + token}, "X-Actor": {"mallory"}})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	return conn
+}
+
+func exchange(t *testing.T, conn *websocket.Conn, cmd string) schemas.SocketReply {
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(cmd)))
+	reply := schemas.SocketReply{}
+	require.NoError(t, conn.ReadJSON(&reply))
+	return reply
+}
+
+func TestHandler_taskSocket_unauthorized(t *testing.T) {
+	server, _, _ := newSocketServer(t)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?access_token=wrong"
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestHandler_taskSocket_commands(t *testing.T) {
+	server, tasks, _ := newSocketServer(t)
+	conn := dialSocket(t, server, "secret")
+
+	dueDate, _ := time.Parse(time.RFC3339, "2024-09-05T15:04:05+05:00")
+	tasks.EXPECT().Create(&dto.TaskCreate{
+		Title:       "First Task",
+		Description: "First description",
+		DueDate:     pgtype.Timestamptz{Time: dueDate, Valid: true},
+		Actor:       "alice",
+	}).Return(&dto.TaskRead{Id: 7, Title: "First Task", Description: "First description"}, nil)
+	tasks.EXPECT().UpdateById(8, gomock.Any()).Return(nil, pgx.ErrNoRows)
+
+	reply := exchange(t, conn, `{"id":"1","type":"create","data":{"title":"First Task","description":"First description","due_date":"2024-09-05T15:04:05+05:00"}}`)
+	assert.Equal(t, schemas.SocketAck, reply.Type)
+	assert.Equal(t, "1", reply.Id)
+	require.NotNil(t, reply.Task)
+	assert.Equal(t, 7, reply.Task.Id)
+
+	reply = exchange(t, conn, `{"id":"2","type":"create","data":{"title":"First Task"}}`)
+	assert.Equal(t, schemas.SocketReply{Type: schemas.SocketError, Id: "2",
+		Error: "Description is required;DueDate is required and must be in RFC3339 format;"}, reply)
+
+	reply = exchange(t, conn, `{"id":"3","type":"update","task_id":8,"data":{"title":"T","description":"D","due_date":"2024-09-05T15:04:05Z"}}`)
+	assert.Equal(t, schemas.SocketReply{Type: schemas.SocketError, Id: "3", Error: "task not found"}, reply)
+
+	reply = exchange(t, conn, `{"id":"4","type":"subscribe","topic":"lists"}`)
+	assert.Equal(t, schemas.SocketReply{Type: schemas.SocketError, Id: "4", Error: "topic must be tasks or task:<id>;"}, reply)
+
+	reply = exchange(t, conn, `{"id":"5",`)
+	assert.Equal(t, schemas.SocketError, reply.Type)
+}
+
+func TestHandler_taskSocket_events(t *testing.T) {
+	server, _, broker := newSocketServer(t)
+	conn := dialSocket(t, server, "secret")
+
+	reply := exchange(t, conn, `{"id":"1","type":"subscribe","topic":"task:2"}`)
+	assert.Equal(t, schemas.SocketReply{Type: schemas.SocketAck, Id: "1"}, reply)
+
+	broker.Publish(&dto.Event{Id: "e1", Type: dto.EventTaskUpdated, TaskId: 1})
+	broker.Publish(&dto.Event{Id: "e2", Type: dto.EventTaskDeleted, TaskId: 2, Actor: "bob"})
+
+	reply = schemas.SocketReply{}
+	require.NoError(t, conn.ReadJSON(&reply))
+	assert.Equal(t, schemas.SocketEvent, reply.Type)
+	require.NotNil(t, reply.Event)
+	assert.Equal(t, "e2", reply.Event.Id)
+	assert.Equal(t, "bob", reply.Event.Actor)
+}
@@ -0,0 +1,112 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/schemas"
+	"ToDoVerba/internal/service/userService"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"time"
+)
+
+// sseHeartbeat is how often an idle stream gets a comment line, which keeps
+// proxies from closing the connection.
+var sseHeartbeat = 15 * time.Second
+
+// taskEventStream godoc
+// @Tags         Task API
+// @Summary      Task events stream Summary
+// @Description  Server-Sent Events stream of the task.created, task.updated, task.completed and task.deleted events. Send Last-Event-ID to resume after a reconnect. Browsers pass the token as access_token query parameter
+// @Produce      text/event-stream
+// @Param Authorization header string false "Bearer api token"
+// @Param access_token query string false "Api token"
+// @Param actor query string false "Only events caused by this actor"
+// @Param task_id query int false "Only events of this task"
+// @Param types query string false "Comma separated event types"
+// @Param Last-Event-ID header string false "Id of the last event received"
+// @Success      200  {string}  string "event stream"
+// @Failure      400  {object}	errorJSON
+// @Failure      401  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /tasks/events [get]
+func (h *Handler) taskEventStream(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s taskEventStream called", r.Method, r.RemoteAddr)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeResponseErr(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	_, err := h.service.User.Authenticate(requestToken(r))
+	if err != nil {
+		if errors.Is(err, userService.ErrUnauthorized) {
+			writeResponseErr(w, http.StatusUnauthorized, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	filter := schemas.RequestStreamFilter{}
+	filter.ScanQuery(r.URL.Query())
+	err = filter.Valid()
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	sub, replay := h.service.Broker.Subscribe(r.Header.Get("Last-Event-ID"), filter.ToDTO())
+	defer h.service.Broker.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 3000\n\n")
+	for i := range replay {
+		if err = writeEvent(w, &replay[i]); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			err = writeEvent(w, &msg)
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		if err != nil {
+			h.logger.Debugf("[%s] %s taskEventStream closed: %s", r.Method, r.RemoteAddr, err)
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, msg *dto.StreamMessage) error {
+	payload := schemas.EventPayload{}
+	payload.ScanDTO(&msg.Event)
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", msg.Id, msg.Event.Type, data)
+	return err
+}
@@ -0,0 +1,131 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/route/api/openapi/openapitest"
+	"ToDoVerba/internal/service"
+	"ToDoVerba/internal/service/brokerService"
+	mockservice "ToDoVerba/internal/service/mocks"
+	"ToDoVerba/internal/service/userService"
+	"ToDoVerba/pkg/logging"
+	"bufio"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newStreamUsers(t *testing.T) *mockservice.MockIUserService {
+	c := gomock.NewController(t)
+	users := mockservice.NewMockIUserService(c)
+	users.EXPECT().Authenticate("secret").Return(&dto.UserRead{Id: 1, Name: "alice"}, nil).AnyTimes()
+	users.EXPECT().Authenticate(gomock.Not("secret")).Return(nil, userService.ErrUnauthorized).AnyTimes()
+	return users
+}
+
+func TestHandler_taskEventStream(t *testing.T) {
+	broker := brokerService.NewBrokerService(brokerService.Deps{
+		Logger:     logging.GetLoggerTest(),
+		ReplaySize: 10,
+		BufferSize: 10,
+	})
+	handler := NewHandler(Deps{
+		Service: service.Services{User: newStreamUsers(t), Broker: broker},
+		Logger:  logging.GetLoggerTest(),
+	})
+
+	r := httprouter.New()
+	r.GET("/tasks/:id", withStatic("id", map[string]httprouter.Handle{
+		"events": handler.taskEventStream,
+	}, handler.taskFindById))
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	sub, _ := broker.Subscribe("", nil)
+	broker.Publish(&dto.Event{Id: "e1", Type: dto.EventTaskCreated, TaskId: 1, Actor: "alice"})
+	first := <-sub.C
+	broker.Unsubscribe(sub)
+
+	// Tasks have no owner: the stream isn't scoped to the authenticated user,
+	// actor only narrows it.
+	req, _ := http.NewRequest("GET", server.URL+"/tasks/events?actor=bob", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Last-Event-ID", first.Id)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		broker.Publish(&dto.Event{Id: "e2", Type: dto.EventTaskUpdated, TaskId: 1, Actor: "alice"})
+		broker.Publish(&dto.Event{Id: "e3", Type: dto.EventTaskDeleted, TaskId: 1, Actor: "bob"})
+	}()
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "retry:") {
+			lines = append(lines, line)
+		}
+	}
+
+	assert.True(t, strings.HasPrefix(lines[0], "id: "))
+	assert.Equal(t, "event: task.deleted", lines[1])
+	assert.Contains(t, lines[2], `"id":"e3"`)
+	assert.Contains(t, lines[2], `"actor":"bob"`)
+}
+
+func TestHandler_taskEventStream_rejected(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		path                 string
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "401_no_token",
+			path:                 "/tasks/events",
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"error":"invalid or missing api token"}`,
+		},
+		{
+			name:                 "401_wrong_token",
+			path:                 "/tasks/events?access_token=wrong",
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"error":"invalid or missing api token"}`,
+		},
+		{
+			name:                 "400_invalid_filter",
+			path:                 "/tasks/events?access_token=secret&types=task.archived",
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"error":"types must be one of task.created, task.updated, task.completed, task.deleted;"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			handler := NewHandler(Deps{
+				Service: service.Services{User: newStreamUsers(t)},
+				Logger:  logging.GetLoggerTest(),
+			})
+
+			r := httprouter.New()
+			r.GET("/tasks/events", handler.taskEventStream)
+
+			w := httptest.NewRecorder()
+			openapitest.Handler(t, r).ServeHTTP(w, httptest.NewRequest("GET", testCase.path, nil))
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
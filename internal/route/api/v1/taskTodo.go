@@ -0,0 +1,104 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/schemas"
+	"bufio"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+)
+
+// taskExportTodoTxt godoc
+// @Tags         Task API
+// @Summary      Export Tasks as todo.txt Summary
+// @Description  Stream the tasks as todo.txt lines in id order, with the due date as due tag. Priority, +projects and @contexts come from a description written by a todo.txt or Markdown import. Accepts the filters of GET /tasks
+// @Produce      plain
+// @Param completed query bool false "Only completed or open tasks"
+// @Param due_after query string false "Due at or after, RFC3339"
+// @Param due_before query string false "Due before, RFC3339"
+// @Success      200  {string}  string "todo.txt"
+// @Failure      400  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /tasks/export.txt [get]
+func (h *Handler) taskExportTodoTxt(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s taskExportTodoTxt called", r.Method, r.RemoteAddr)
+	h.exportTaskLines(w, r, "text/plain; charset=utf-8", "todo.txt", func(task *dto.TaskRead) string {
+		return schemas.TaskToTodoItem(task).String()
+	})
+}
+
+// taskExportChecklist godoc
+// @Tags         Task API
+// @Summary      Export Tasks as Markdown checklist Summary
+// @Description  Stream the tasks as a Markdown checklist in id order, written like todo.txt lines after the checkbox. Accepts the filters of GET /tasks
+// @Produce      text/markdown
+// @Param completed query bool false "Only completed or open tasks"
+// @Param due_after query string false "Due at or after, RFC3339"
+// @Param due_before query string false "Due before, RFC3339"
+// @Success      200  {string}  string "markdown"
+// @Failure      400  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /tasks/export.md [get]
+func (h *Handler) taskExportChecklist(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s taskExportChecklist called", r.Method, r.RemoteAddr)
+	h.exportTaskLines(w, r, "text/markdown; charset=utf-8", "tasks.md", schemas.TaskToChecklistItem)
+}
+
+// exportTaskLines streams the tasks matching the filters of the request, one
+// line each. Like the CSV export it answers with an error status as long as
+// nothing has been sent.
+func (h *Handler) exportTaskLines(w http.ResponseWriter, r *http.Request, contentType, filename string,
+	line func(task *dto.TaskRead) string) {
+	filter := schemas.RequestTaskFilter{}
+	filter.ScanQuery(r.URL.Query())
+	err := filter.Valid()
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+	match := filter.Matcher()
+
+	bw := bufio.NewWriter(w)
+	started := false
+	start := func() {
+		started = true
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	rows := 0
+	err = h.service.Task.Export(r.Context(), func(task *dto.TaskRead) error {
+		if !match(task) {
+			return nil
+		}
+		if !started {
+			start()
+		}
+		if _, err := bw.WriteString(line(task) + "\n"); err != nil {
+			return err
+		}
+		if rows++; rows%exportFlushRows == 0 {
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	if err != nil && !started {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("task export aborted after %d rows: %s", rows, err)
+		return
+	}
+
+	if !started {
+		start()
+	}
+	bw.Flush()
+}
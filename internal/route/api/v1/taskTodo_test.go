@@ -0,0 +1,287 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/route/api/openapi/openapitest"
+	"ToDoVerba/internal/service"
+	mockservice "ToDoVerba/internal/service/mocks"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTodoRouter(tasksService *mockservice.MockITaskService) *httprouter.Router {
+	handler := NewHandler(Deps{
+		Service: service.Services{Task: tasksService},
+		Logger:  logging.GetLoggerTest(),
+	})
+
+	r := httprouter.New()
+	r.GET("/tasks/:id", withStatic("id", map[string]httprouter.Handle{
+		"export.txt": handler.taskExportTodoTxt,
+		"export.md":  handler.taskExportChecklist,
+	}, handler.taskFindById))
+	r.POST("/tasks/:id", withStatic("id", map[string]httprouter.Handle{
+		"import": handler.taskImport,
+	}, methodNotAllowed))
+	return r
+}
+
+func TestHandler_taskImport_todo(t *testing.T) {
+	type mockBehaviour func(s *mockservice.MockITaskService)
+
+	due := func(s string) pgtype.Timestamptz {
+		t, _ := time.Parse(time.RFC3339, s)
+		return pgtype.Timestamptz{
+			Time:  t,
+			Valid: true,
+		}
+	}
+
+	testTable := []struct {
+		name          string
+		inputQuery    string
+		contentType   string
+		inputBody     string
+		mockBehaviour mockBehaviour
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			name:        "201_todo_txt",
+			contentType: "text/plain; charset=utf-8",
+			inputBody: "(A) 2024-09-01 Call mom +family @phone due:2024-09-10\n" +
+				"\n" +
+				"x 2024-09-05 Review report +work due:2024-09-06T09:00:00Z\n",
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().Import([]dto.TaskCreate{
+					{Title: "Call mom", Description: "(A) Call mom +family @phone", DueDate: due("2024-09-10T00:00:00Z"), Actor: "alice"},
+					{Title: "Review report", Description: "Review report +work", DueDate: due("2024-09-06T09:00:00Z"), Completed: true, Actor: "alice"},
+				}).Return(make([]dto.TaskRead, 2), nil)
+			},
+			expectedCode: 201,
+			expectedBody: `{"dry_run":false,"rows":2,"imported":2,"errors":[]}`,
+		},
+		{
+			name:        "201_markdown_in_timezone",
+			inputQuery:  "?timezone=Europe/Berlin",
+			contentType: "text/markdown",
+			inputBody: "# Groceries\n" +
+				"Things for the weekend:\n" +
+				"- [ ] Buy milk @store due:2024-09-05\n" +
+				"  - [x] (B) Check fridge due:2024-09-04T18:00:00+02:00\n",
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().Import([]dto.TaskCreate{
+					{Title: "Buy milk", Description: "Buy milk @store", DueDate: due("2024-09-04T22:00:00Z"), Actor: "alice"},
+					{Title: "Check fridge", Description: "(B) Check fridge", DueDate: due("2024-09-04T16:00:00Z"), Completed: true, Actor: "alice"},
+				}).Return(make([]dto.TaskRead, 2), nil)
+			},
+			expectedCode: 201,
+			expectedBody: `{"dry_run":false,"rows":2,"imported":2,"errors":[]}`,
+		},
+		{
+			name:        "422_invalid_lines",
+			contentType: "text/plain",
+			inputBody: "Buy milk due:2024-09-05\n" +
+				"Call mom\n" +
+				"+family @phone due:2024-09-10\n" +
+				"x 2024-09-05\n",
+			mockBehaviour: func(s *mockservice.MockITaskService) {},
+			expectedCode:  422,
+			expectedBody: `{"dry_run":false,"rows":4,"imported":0,"errors":[
+				{"row":2,"error":"due tag is required and must be a date or in RFC3339 format;"},
+				{"row":3,"error":"Title is required;"},
+				{"row":4,"error":"task text is empty;"}]}`,
+		},
+		{
+			name:          "400_no_items",
+			contentType:   "text/markdown",
+			inputBody:     "# Nothing to do\n",
+			mockBehaviour: func(s *mockservice.MockITaskService) {},
+			expectedCode:  400,
+			expectedBody:  `{"error":"no tasks found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tasksService := mockservice.NewMockITaskService(c)
+			testCase.mockBehaviour(tasksService)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/tasks/import"+testCase.inputQuery, strings.NewReader(testCase.inputBody))
+			req.Header.Set("Content-Type", testCase.contentType)
+			req.Header.Set("X-Actor", "alice")
+
+			openapitest.Handler(t, newTodoRouter(tasksService)).ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			assert.JSONEq(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+// TestHandler_todo_roundTrip imports a list, stores the tasks the way the
+// service would and exports them again in the same format.
+func TestHandler_todo_roundTrip(t *testing.T) {
+	testTable := []struct {
+		name        string
+		contentType string
+		export      string
+		input       string
+	}{
+		{
+			name:        "todo_txt",
+			contentType: "text/plain",
+			export:      "/tasks/export.txt",
+			input: "(A) 2024-09-01 Call mom +family @phone due:2024-09-10\n" +
+				"x 2024-09-05 2024-09-01 Review report +work pri:B due:2024-09-06T09:00:00Z\n",
+		},
+		{
+			name:        "markdown",
+			contentType: "text/markdown",
+			export:      "/tasks/export.md",
+			input: "- [ ] (A) Call mom +family @phone due:2024-09-10\n" +
+				"- [x] Review report +work due:2024-09-06T09:00:00Z\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			var stored []dto.TaskRead
+			tasksService := mockservice.NewMockITaskService(c)
+			tasksService.EXPECT().Import(gomock.Any()).DoAndReturn(func(cTasks []dto.TaskCreate) ([]dto.TaskRead, error) {
+				for i, cTask := range cTasks {
+					stored = append(stored, dto.TaskRead{
+						Id:          i + 1,
+						Title:       cTask.Title,
+						Description: cTask.Description,
+						DueDate:     cTask.DueDate,
+						Completed:   cTask.Completed,
+						CreatedAt:   pgtype.Timestamptz{Time: time.Date(2024, 9, 1, 10, 0, 0, 0, time.UTC), Valid: true},
+						UpdatedAt:   pgtype.Timestamptz{Time: time.Date(2024, 9, 5, 10, 0, 0, 0, time.UTC), Valid: true},
+						Version:     1,
+					})
+				}
+				return stored, nil
+			})
+			tasksService.EXPECT().Export(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, each func(task *dto.TaskRead) error) error {
+					for i := range stored {
+						if err := each(&stored[i]); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+			r := newTodoRouter(tasksService)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/tasks/import", strings.NewReader(testCase.input))
+			req.Header.Set("Content-Type", testCase.contentType)
+			openapitest.Handler(t, r).ServeHTTP(w, req)
+			require.Equal(t, 201, w.Code, w.Body.String())
+
+			w = httptest.NewRecorder()
+			openapitest.Handler(t, r).ServeHTTP(w, httptest.NewRequest("GET", testCase.export, nil))
+
+			assert.Equal(t, 200, w.Code)
+			assert.Equal(t, testCase.input, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_taskExportTodoTxt(t *testing.T) {
+	tasks := []dto.TaskRead{
+		{
+			Id:          1,
+			Title:       "Buy milk",
+			Description: "Two litres,\noat if there is",
+			DueDate:     pgtype.Timestamptz{Time: time.Date(2024, 9, 5, 15, 4, 5, 0, time.FixedZone("", 5*3600)), Valid: true},
+			CreatedAt:   pgtype.Timestamptz{Time: time.Date(2024, 9, 1, 23, 0, 0, 0, time.UTC), Valid: true},
+		},
+		{
+			Id:          2,
+			Title:       "Done",
+			Description: "(A) Something else entirely",
+			DueDate:     pgtype.Timestamptz{Time: time.Date(2024, 9, 6, 0, 0, 0, 0, time.UTC), Valid: true},
+			Completed:   true,
+			CreatedAt:   pgtype.Timestamptz{Time: time.Date(2024, 9, 1, 10, 0, 0, 0, time.UTC), Valid: true},
+			UpdatedAt:   pgtype.Timestamptz{Time: time.Date(2024, 9, 3, 10, 0, 0, 0, time.UTC), Valid: true},
+		},
+	}
+
+	testTable := []struct {
+		name         string
+		inputQuery   string
+		exportErr    error
+		expectedCode int
+		expectedType string
+		expectedBody string
+	}{
+		{
+			name:         "200_descriptions_without_todo_body",
+			expectedCode: 200,
+			expectedType: "text/plain; charset=utf-8",
+			expectedBody: "2024-09-01 Buy milk due:2024-09-05T10:04:05Z\n" +
+				"x 2024-09-03 2024-09-01 Done due:2024-09-06\n",
+		},
+		{
+			name:         "200_filtered",
+			inputQuery:   "?completed=false",
+			expectedCode: 200,
+			expectedType: "text/plain; charset=utf-8",
+			expectedBody: "2024-09-01 Buy milk due:2024-09-05T10:04:05Z\n",
+		},
+		{
+			name:         "500_before_first_line",
+			exportErr:    errors.New("connection refused"),
+			expectedCode: 500,
+			expectedType: "application/json",
+			expectedBody: `{"error":"connection refused"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tasksService := mockservice.NewMockITaskService(c)
+			tasksService.EXPECT().Export(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, each func(task *dto.TaskRead) error) error {
+					if testCase.exportErr != nil {
+						return testCase.exportErr
+					}
+					for i := range tasks {
+						if err := each(&tasks[i]); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+
+			w := httptest.NewRecorder()
+			openapitest.Handler(t, newTodoRouter(tasksService)).ServeHTTP(w, httptest.NewRequest("GET", "/tasks/export.txt"+testCase.inputQuery, nil))
+
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			assert.Equal(t, testCase.expectedType, w.Header().Get("Content-Type"))
+			assert.Equal(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
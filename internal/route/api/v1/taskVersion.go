@@ -0,0 +1,168 @@
+package v1
+
+import (
+	"ToDoVerba/internal/schemas"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+func (h *Handler) initTaskVersionHandler(r *httprouter.Router) {
+	r.GET("/tasks/:id/versions", h.taskVersionList)
+	r.GET("/tasks/:id/versions/:n", h.taskVersionFind)
+	r.POST("/tasks/:id/revert", h.taskRevert)
+}
+
+// taskVersionList godoc
+// @Tags         Task Version API
+// @Summary      List Task versions Summary
+// @Description  Snapshots of every version of the task, oldest first
+// @Accept       json
+// @Produce      json
+// @Param id path int false "Task id"
+// @Success      200  {object}  []schemas.ResponseTaskVersion
+// @Failure      400  {object}	errorJSON
+// @Failure      404  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /tasks/{id}/versions [get]
+func (h *Handler) taskVersionList(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s taskVersionList called", r.Method, r.RemoteAddr)
+
+	idStr := ps.ByName("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rVersionsDTO, err := h.service.TaskVersion.List(id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeResponseErr(w, http.StatusNotFound, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rVersions := make([]schemas.ResponseTaskVersion, 0, len(rVersionsDTO))
+	for i := 0; i < len(rVersionsDTO); i++ {
+		rVersion := schemas.ResponseTaskVersion{}
+		rVersion.ScanDTO(&rVersionsDTO[i])
+		rVersions = append(rVersions, rVersion)
+	}
+
+	writeResponse(w, http.StatusOK, rVersions)
+}
+
+// taskVersionFind godoc
+// @Tags         Task Version API
+// @Summary      Find Task version Summary
+// @Description  Snapshot of the task as it was at version n
+// @Accept       json
+// @Produce      json
+// @Param id path int false "Task id"
+// @Param n path int false "Version number"
+// @Success      200  {object}  schemas.ResponseTaskVersion
+// @Failure      400  {object}	errorJSON
+// @Failure      404  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /tasks/{id}/versions/{n} [get]
+func (h *Handler) taskVersionFind(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s taskVersionFind called", r.Method, r.RemoteAddr)
+
+	idStr := ps.ByName("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+	nStr := ps.ByName("n")
+	n, err := strconv.Atoi(nStr)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rVersionDTO, err := h.service.TaskVersion.Find(id, n)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeResponseErr(w, http.StatusNotFound, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rVersion := schemas.ResponseTaskVersion{}
+	rVersion.ScanDTO(rVersionDTO)
+	writeResponse(w, http.StatusOK, rVersion)
+}
+
+// taskRevert godoc
+// @Tags         Task Version API
+// @Summary      Revert Task Summary
+// @Description  Restore a previous version of the task as a new update
+// @Accept       json
+// @Produce      json
+// @Param id path int false "Task id"
+// @Param Revert body schemas.RequestTaskRevert false "Version to restore"
+// @Param X-Actor header string false "Advisory actor recorded in task history, not authenticated"
+// @Success      200  {object}  schemas.ResponseTaskRead
+// @Failure      400  {object}  errorJSON
+// @Failure      404  {object}  errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /tasks/{id}/revert [post]
+func (h *Handler) taskRevert(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s taskRevert called", r.Method, r.RemoteAddr)
+
+	contentType := r.Header.Get("content-type")
+	if contentType != "application/json" {
+		writeResponseErr(w, http.StatusBadRequest,
+			errors.New("content-type is not application/json"))
+		return
+	}
+
+	idStr := ps.ByName("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	revert := schemas.RequestTaskRevert{}
+	bodyRaw, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = json.Unmarshal(bodyRaw, &revert)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+	err = revert.Valid()
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rTaskDTO, err := h.service.TaskVersion.Revert(id, revert.Version, requestActor(r))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeResponseErr(w, http.StatusNotFound, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rTask := schemas.ResponseTaskRead{}
+	rTask.ScanDTO(rTaskDTO)
+	writeResponse(w, http.StatusOK, rTask)
+}
@@ -0,0 +1,122 @@
+package v1
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/route/api/openapi/openapitest"
+	"ToDoVerba/internal/service"
+	mockservice "ToDoVerba/internal/service/mocks"
+	"ToDoVerba/pkg/logging"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_taskRevert(t *testing.T) {
+	type mockBehaviour func(s *mockservice.MockITaskVersionService)
+
+	parseTime := func(s string) pgtype.Timestamptz {
+		t, _ := time.Parse(time.RFC3339, s)
+		return pgtype.Timestamptz{
+			Time:  t,
+			Valid: true,
+		}
+	}
+
+	testTable := []struct {
+		name          string
+		inputParam    string
+		inputBody     string
+		inputActor    string
+		mockBehaviour mockBehaviour
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			name:       "200_valid_input",
+			inputParam: "7",
+			inputBody:  `{"version": 2}`,
+			inputActor: "alice",
+			mockBehaviour: func(s *mockservice.MockITaskVersionService) {
+				s.EXPECT().Revert(7, 2, "alice").Return(&dto.TaskRead{
+					Id:          7,
+					Title:       "Old Task",
+					Description: "Old description",
+					DueDate:     parseTime("2024-09-05T15:04:05+05:00"),
+					CreatedAt:   parseTime("2022-09-05T15:04:05+05:00"),
+					UpdatedAt:   parseTime("2023-09-05T15:04:05+05:00"),
+					Version:     4,
+				}, nil)
+			},
+			expectedCode: 200,
+			expectedBody: `{
+								"id": 7,
+								"title": "Old Task",
+								"description": "Old description",
+								"due_date": "2024-09-05T15:04:05+05:00",
+								"completed": false,
+								"created_at": "2022-09-05T15:04:05+05:00",
+								"updated_at": "2023-09-05T15:04:05+05:00"
+							}`,
+		},
+		{
+			name:          "400_missing_version",
+			inputParam:    "7",
+			inputBody:     `{}`,
+			mockBehaviour: func(s *mockservice.MockITaskVersionService) {},
+			expectedCode:  400,
+			expectedBody:  `{"error":"Version is required and must be positive;"}`,
+		},
+		{
+			name:       "404_no_such_version",
+			inputParam: "7",
+			inputBody:  `{"version": 9}`,
+			mockBehaviour: func(s *mockservice.MockITaskVersionService) {
+				s.EXPECT().Revert(7, 9, "").Return(nil, pgx.ErrNoRows)
+			},
+			expectedCode: 404,
+			expectedBody: `{"error":"no rows in result set"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			//Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+			taskVersionService := mockservice.NewMockITaskVersionService(c)
+			testCase.mockBehaviour(taskVersionService)
+
+			services := service.Services{TaskVersion: taskVersionService}
+			handler := NewHandler(Deps{
+				Service: services,
+				Logger:  logging.GetLoggerTest(),
+			})
+
+			//Test server
+			r := httprouter.New()
+			r.POST("/tasks/:id/revert", handler.taskRevert)
+
+			//http test
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/tasks/"+testCase.inputParam+"/revert", strings.NewReader(testCase.inputBody))
+			req.Header.Set("Content-Type", "application/json")
+			if testCase.inputActor != "" {
+				req.Header.Set(actorHeader, testCase.inputActor)
+			}
+
+			//Perform request
+			openapitest.Handler(t, r).ServeHTTP(w, req)
+
+			//Assert
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			assert.JSONEq(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
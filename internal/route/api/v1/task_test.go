@@ -2,6 +2,7 @@ package v1
 
 import (
 	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/route/api/openapi/openapitest"
 	"ToDoVerba/internal/service"
 	mockservice "ToDoVerba/internal/service/mocks"
 	"ToDoVerba/pkg/logging"
@@ -69,6 +70,7 @@ func TestHandler_taskCreate(t *testing.T) {
 								"title": "First Task",
 								"description": "First description",
 								"due_date": "2024-09-05T15:04:05+05:00",
+								"completed": false,
 								"created_at": "2022-09-05T15:04:05+05:00",
 								"updated_at": "2023-09-05T15:04:05+05:00"
 							}`,
@@ -172,7 +174,7 @@ func TestHandler_taskCreate(t *testing.T) {
 			req.Header.Set("Content-Type", testCase.inputContType)
 
 			//Perform request
-			r.ServeHTTP(w, req)
+			openapitest.Handler(t, r).ServeHTTP(w, req)
 
 			//Assert
 			assert.Equal(t, testCase.expectedCode, w.Code)
@@ -237,6 +239,7 @@ func TestHandler_taskList(t *testing.T) {
 								"title": "First task",
 								"description": "First description",
 								"due_date": "2024-09-05T15:04:05+05:00",
+								"completed": false,
 								"created_at": "2022-09-05T15:04:05+05:00",
 								"updated_at": "2023-09-05T15:04:05+05:00"
 							},
@@ -245,6 +248,7 @@ func TestHandler_taskList(t *testing.T) {
 								"title": "Second task",
 								"description": "Second description",
 								"due_date": "2024-09-15T15:04:05+05:00",
+								"completed": false,
 								"created_at": "2022-09-15T15:04:05+05:00",
 								"updated_at": "2023-09-15T15:04:05+05:00"
 							}]`,
@@ -291,7 +295,7 @@ func TestHandler_taskList(t *testing.T) {
 			req := httptest.NewRequest("GET", "/tasks", strings.NewReader(""))
 
 			//Perform request
-			r.ServeHTTP(w, req)
+			openapitest.Handler(t, r).ServeHTTP(w, req)
 
 			//Assert
 			assert.Equal(t, testCase.expectedCode, w.Code)
@@ -350,6 +354,7 @@ func TestHandler_taskFindById(t *testing.T) {
 								"title": "First Task",
 								"description": "First description",
 								"due_date": "2024-09-05T15:04:05+05:00",
+								"completed": false,
 								"created_at": "2022-09-05T15:04:05+05:00",
 								"updated_at": "2023-09-05T15:04:05+05:00"
 							}`,
@@ -409,7 +414,7 @@ func TestHandler_taskFindById(t *testing.T) {
 			req := httptest.NewRequest("GET", "/tasks/"+testCase.inputParam, strings.NewReader(""))
 
 			//Perform request
-			r.ServeHTTP(w, req)
+			openapitest.Handler(t, r).ServeHTTP(w, req)
 
 			//Assert
 			assert.Equal(t, testCase.expectedCode, w.Code)
@@ -480,6 +485,7 @@ func TestHandler_taskUpdateById(t *testing.T) {
 								"title": "First Task",
 								"description": "First description",
 								"due_date": "2024-09-05T15:04:05+05:00",
+								"completed": false,
 								"created_at": "2022-09-05T15:04:05+05:00",
 								"updated_at": "2023-09-05T15:04:05+05:00"
 							}`,
@@ -649,7 +655,7 @@ func TestHandler_taskUpdateById(t *testing.T) {
 			req.Header.Set("Content-Type", testCase.inputContType)
 
 			//Perform request
-			r.ServeHTTP(w, req)
+			openapitest.Handler(t, r).ServeHTTP(w, req)
 
 			//Assert
 			assert.Equal(t, testCase.expectedCode, w.Code)
@@ -683,7 +689,7 @@ func TestHandler_taskDeleteById(t *testing.T) {
 			inputParam: "129",
 			inputId:    129,
 			mockBehaviour: func(s *mockservice.MockITaskService, id int) {
-				s.EXPECT().DeleteById(id).Return(nil)
+				s.EXPECT().DeleteById(id, "").Return(nil)
 			},
 			expectedCode: 204,
 			expectedBody: "",
@@ -693,7 +699,7 @@ func TestHandler_taskDeleteById(t *testing.T) {
 			inputParam: "129",
 			inputId:    129,
 			mockBehaviour: func(s *mockservice.MockITaskService, id int) {
-				s.EXPECT().DeleteById(id).Return(pgx.ErrNoRows)
+				s.EXPECT().DeleteById(id, "").Return(pgx.ErrNoRows)
 			},
 			expectedCode: 404,
 			expectedBody: "",
@@ -703,7 +709,7 @@ func TestHandler_taskDeleteById(t *testing.T) {
 			inputParam: "129",
 			inputId:    129,
 			mockBehaviour: func(s *mockservice.MockITaskService, id int) {
-				s.EXPECT().DeleteById(id).Return(errors.New("some error"))
+				s.EXPECT().DeleteById(id, "").Return(errors.New("some error"))
 			},
 			expectedCode: 404,
 			expectedBody: `{"error":"some error"}`,
@@ -734,7 +740,7 @@ func TestHandler_taskDeleteById(t *testing.T) {
 			req := httptest.NewRequest("DELETE", "/tasks/"+testCase.inputParam, strings.NewReader(""))
 
 			//Perform request
-			r.ServeHTTP(w, req)
+			openapitest.Handler(t, r).ServeHTTP(w, req)
 
 			//Assert
 			assert.Equal(t, testCase.expectedCode, w.Code)
@@ -3,26 +3,83 @@ package v1
 import (
 	"ToDoVerba/internal/service"
 	"ToDoVerba/pkg/logging"
+	"errors"
 	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strings"
 )
 
+// actorHeader names the caller recorded in the task audit trail by the routes
+// without authentication. It is advisory: nothing checks the name, so routes
+// with an authenticated user record that user instead.
+const actorHeader = "X-Actor"
+
 type Handler struct {
-	service service.Services
-	logger  logging.Logger
+	service     service.Services
+	logger      logging.Logger
+	deprecation Deprecation
 }
 
 type Deps struct {
-	Service service.Services
-	Logger  logging.Logger
+	Service     service.Services
+	Logger      logging.Logger
+	Deprecation Deprecation
 }
 
 func NewHandler(d Deps) *Handler {
 	return &Handler{
-		service: d.Service,
-		logger:  d.Logger,
+		service:     d.Service,
+		logger:      d.Logger,
+		deprecation: d.Deprecation,
 	}
 }
 
 func (h *Handler) Init(r *httprouter.Router) {
 	h.initTaskHandler(r)
+	h.initTaskEventHandler(r)
+	h.initTaskVersionHandler(r)
+	h.initWebhookHandler(r)
+	h.initTaskReminderHandler(r)
+	h.initTaskSocketHandler(r)
+	h.initCalendarHandler(r)
+	h.initCalDAVHandler(r)
+	h.initBackupHandler(r)
+}
+
+// withStatic serves requests whose param segment is one of the static names
+// with the matching handler, and everything else with next. httprouter can't
+// register a static segment such as /tasks/events next to /tasks/:id.
+func withStatic(param string, static map[string]httprouter.Handle, next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if handle, ok := static[ps.ByName(param)]; ok {
+			handle(w, r, ps)
+			return
+		}
+		next(w, r, ps)
+	}
+}
+
+// methodNotAllowed answers the methods a withStatic route only serves for its
+// static names.
+func methodNotAllowed(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writeResponseErr(w, http.StatusMethodNotAllowed, errors.New(http.StatusText(http.StatusMethodNotAllowed)))
+}
+
+// requestActor returns the actor claimed by the X-Actor header. Only the routes
+// without authentication use it.
+func requestActor(r *http.Request) string {
+	return r.Header.Get(actorHeader)
+}
+
+// requestToken returns the api token of a bearer Authorization header, the
+// password of a basic one, or the access_token query parameter for clients
+// that can't set headers.
+func requestToken(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token
+	}
+	if _, password, ok := r.BasicAuth(); ok {
+		return password
+	}
+	return r.URL.Query().Get("access_token")
 }
@@ -0,0 +1,295 @@
+package v1
+
+import (
+	"ToDoVerba/internal/schemas"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+func (h *Handler) initWebhookHandler(r *httprouter.Router) {
+	r.POST("/webhooks", h.webhookCreate)
+	r.GET("/webhooks", h.webhookList)
+	r.GET("/webhooks/:id", h.webhookFindById)
+	r.PUT("/webhooks/:id", h.webhookUpdateById)
+	r.DELETE("/webhooks/:id", h.webhookDeleteById)
+	r.GET("/webhooks/:id/deliveries", h.webhookDeliveryList)
+}
+
+// webhookCreate godoc
+// @Tags         Webhook API
+// @Summary      Create Webhook Summary
+// @Description  Subscribe an url to task events. Empty events means all events. The secret is generated when omitted and is only returned here
+// @Accept       json
+// @Produce      json
+// @Param Webhook body schemas.RequestWebhookCreate false "Webhook base"
+// @Success      201  {object}  schemas.ResponseWebhookRead
+// @Failure      400  {object}  errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /webhooks [post]
+func (h *Handler) webhookCreate(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s webhookCreate called", r.Method, r.RemoteAddr)
+	contentType := r.Header.Get("content-type")
+	if contentType != "application/json" {
+		writeResponseErr(w, http.StatusBadRequest,
+			errors.New("content-type is not application/json"))
+		return
+	}
+
+	cWebhook := schemas.RequestWebhookCreate{}
+	bodyRaw, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = json.Unmarshal(bodyRaw, &cWebhook)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+	err = cWebhook.Valid()
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rWebhookDTO, err := h.service.Webhook.Create(cWebhook.ToDTO())
+	if err != nil {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rWebhook := schemas.ResponseWebhookRead{}
+	rWebhook.ScanDTO(rWebhookDTO)
+	rWebhook.Secret = rWebhookDTO.Secret
+
+	writeResponse(w, http.StatusCreated, rWebhook)
+}
+
+// webhookList godoc
+// @Tags         Webhook API
+// @Summary      List Webhook Summary
+// @Description  List Webhook Description
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  []schemas.ResponseWebhookRead
+// @Failure      500  {object}	errorJSON
+// @Router       /webhooks [get]
+func (h *Handler) webhookList(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s webhookList called", r.Method, r.RemoteAddr)
+
+	rWebhooksDTO, err := h.service.Webhook.List()
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rWebhooks := make([]schemas.ResponseWebhookRead, 0, len(rWebhooksDTO))
+	for i := 0; i < len(rWebhooksDTO); i++ {
+		rWebhook := schemas.ResponseWebhookRead{}
+		rWebhook.ScanDTO(&rWebhooksDTO[i])
+		rWebhooks = append(rWebhooks, rWebhook)
+	}
+
+	writeResponse(w, http.StatusOK, rWebhooks)
+}
+
+// webhookFindById godoc
+// @Tags         Webhook API
+// @Summary      Find Webhook by id Summary
+// @Description  Find Webhook by id Description
+// @Accept       json
+// @Produce      json
+// @Param id path int false "Webhook id"
+// @Success      200  {object}  schemas.ResponseWebhookRead
+// @Failure      400  {object}	errorJSON
+// @Failure      404  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /webhooks/{id} [get]
+func (h *Handler) webhookFindById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s webhookFindById called", r.Method, r.RemoteAddr)
+
+	idStr := ps.ByName("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rWebhookDTO, err := h.service.Webhook.FindByID(id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeResponseErr(w, http.StatusNotFound, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rWebhook := schemas.ResponseWebhookRead{}
+	rWebhook.ScanDTO(rWebhookDTO)
+	writeResponse(w, http.StatusOK, rWebhook)
+}
+
+// webhookUpdateById godoc
+// @Tags         Webhook API
+// @Summary      Update Webhook Summary
+// @Description  Update Webhook Description
+// @Accept       json
+// @Produce      json
+// @Param id path int false "Webhook id"
+// @Param Webhook body schemas.RequestWebhookUpdate false "Webhook update"
+// @Success      200  {object}  schemas.ResponseWebhookRead
+// @Failure      400  {object}  errorJSON
+// @Failure      404  {object}  errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /webhooks/{id} [put]
+func (h *Handler) webhookUpdateById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s webhookUpdateById called", r.Method, r.RemoteAddr)
+
+	contentType := r.Header.Get("content-type")
+	if contentType != "application/json" {
+		writeResponseErr(w, http.StatusBadRequest,
+			errors.New("content-type is not application/json"))
+		return
+	}
+
+	idStr := ps.ByName("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	uWebhook := schemas.RequestWebhookUpdate{}
+	bodyRaw, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = json.Unmarshal(bodyRaw, &uWebhook)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+	err = uWebhook.Valid()
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rWebhookDTO, err := h.service.Webhook.UpdateById(id, uWebhook.ToDTO())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeResponseErr(w, http.StatusNotFound, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rWebhook := schemas.ResponseWebhookRead{}
+	rWebhook.ScanDTO(rWebhookDTO)
+	writeResponse(w, http.StatusOK, rWebhook)
+}
+
+// webhookDeleteById godoc
+// @Tags         Webhook API
+// @Summary      Delete Webhook by id Summary
+// @Description  Delete the subscription together with its delivery log
+// @Accept       json
+// @Produce      json
+// @Param id path int false "Webhook id"
+// @Success      204
+// @Failure      400  {object}	errorJSON
+// @Failure      404  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /webhooks/{id} [delete]
+func (h *Handler) webhookDeleteById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s webhookDeleteById called", r.Method, r.RemoteAddr)
+
+	idStr := ps.ByName("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.service.Webhook.DeleteById(id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeResponseErr(w, http.StatusNotFound, err)
+			return
+		}
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeResponse(w, http.StatusNoContent, nil)
+}
+
+// webhookDeliveryList godoc
+// @Tags         Webhook API
+// @Summary      Webhook delivery log Summary
+// @Description  Deliveries of the webhook, newest first. Status is pending, delivered or dead
+// @Accept       json
+// @Produce      json
+// @Param id path int false "Webhook id"
+// @Param offset query int false "Offset"
+// @Param limit query int false "Limit, 50 by default"
+// @Success      200  {object}  []schemas.ResponseWebhookDelivery
+// @Failure      400  {object}	errorJSON
+// @Failure      500  {object}	errorJSON
+// @Router       /webhooks/{id}/deliveries [get]
+func (h *Handler) webhookDeliveryList(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s webhookDeliveryList called", r.Method, r.RemoteAddr)
+
+	idStr := ps.ByName("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeResponseErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	offset, limit := 0, defaultDeliveryLimit
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			writeResponseErr(w, http.StatusBadRequest, errors.New("offset must be a non-negative integer"))
+			return
+		}
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxDeliveryLimit {
+			writeResponseErr(w, http.StatusBadRequest, errors.New("limit must be an integer between 1 and 500"))
+			return
+		}
+	}
+
+	rDeliveriesDTO, err := h.service.Webhook.ListDeliveries(id, offset, limit)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		writeResponseErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rDeliveries := make([]schemas.ResponseWebhookDelivery, 0, len(rDeliveriesDTO))
+	for i := 0; i < len(rDeliveriesDTO); i++ {
+		rDelivery := schemas.ResponseWebhookDelivery{}
+		rDelivery.ScanDTO(&rDeliveriesDTO[i])
+		rDeliveries = append(rDeliveries, rDelivery)
+	}
+
+	writeResponse(w, http.StatusOK, rDeliveries)
+}
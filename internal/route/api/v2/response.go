@@ -0,0 +1,104 @@
+package v2
+
+import (
+	"ToDoVerba/internal/schemas"
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+// links point to the resource, and for a list to its neighbouring pages.
+type links struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// emptyMeta keeps meta an object for resources that have none.
+type emptyMeta struct{}
+
+// pageMeta tells the position of a list page among Total items.
+type pageMeta struct {
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+type taskEnvelope struct {
+	Data  schemas.ResponseTaskV2 `json:"data"`
+	Meta  emptyMeta              `json:"meta"`
+	Links links                  `json:"links"`
+}
+
+type taskListEnvelope struct {
+	Data  []schemas.ResponseTaskV2 `json:"data"`
+	Meta  pageMeta                 `json:"meta"`
+	Links links                    `json:"links"`
+}
+
+type errorEnvelope struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Status  int      `json:"status"`
+	Message string   `json:"message"`
+	Details []string `json:"details,omitempty"`
+}
+
+// writeData writes an envelope. It is encoded before the status is sent, so a
+// failure is still answered with a 500. The & of links is left unescaped.
+func (h *Handler) writeData(w http.ResponseWriter, code int, data any) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(data); err != nil {
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(buf.Bytes())
+}
+
+// WriteError writes err in the error envelope, for the middleware around the
+// v2 routes.
+func (h *Handler) WriteError(w http.ResponseWriter, code int, err error) {
+	h.writeError(w, code, err)
+}
+
+// writeError writes the error envelope. Errors joined by errors.Join become
+// the details of an invalid request, server errors are logged and answered
+// with their status text only.
+func (h *Handler) writeError(w http.ResponseWriter, code int, err error) {
+	body := errorBody{Status: code, Message: err.Error()}
+	if _, ok := err.(interface{ Unwrap() []error }); ok {
+		body.Message = "request is invalid"
+		body.Details = errorDetails(err)
+	}
+	if code >= http.StatusInternalServerError {
+		h.logger.Errorf("api v2 error: %s", err)
+		body = errorBody{Status: code, Message: http.StatusText(code)}
+	}
+
+	// Strings and ints always marshal.
+	respJSON, _ := json.Marshal(errorEnvelope{Error: body})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(respJSON)
+}
+
+// errorDetails flattens errors joined by errors.Join, also when nested.
+func errorDetails(err error) []string {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []string{err.Error()}
+	}
+	var details []string
+	for _, e := range joined.Unwrap() {
+		details = append(details, errorDetails(e)...)
+	}
+	return details
+}
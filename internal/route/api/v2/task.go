@@ -0,0 +1,302 @@
+package v2
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/schemas"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+var (
+	errTaskNotFound = errors.New("task not found")
+	errIfMatch      = errors.New(`If-Match must be "*" or a quoted task version`)
+)
+
+func (h *Handler) initTaskHandler(r *httprouter.Router) {
+	r.POST(BasePath+"/tasks", h.idempotent(h.taskCreate))
+	r.GET(BasePath+"/tasks", h.taskList)
+	r.GET(BasePath+"/tasks/:id", h.taskFindById)
+	r.PUT(BasePath+"/tasks/:id", h.taskUpdateById)
+	r.DELETE(BasePath+"/tasks/:id", h.taskDeleteById)
+}
+
+func taskPath(id int) string {
+	return BasePath + "/tasks/" + strconv.Itoa(id)
+}
+
+func newTaskEnvelope(task *dto.TaskRead) taskEnvelope {
+	env := taskEnvelope{Links: links{Self: taskPath(task.Id)}}
+	env.Data.ScanDTO(task)
+	return env
+}
+
+// taskCreate godoc
+// @Tags         Task API v2
+// @Summary      Create a task
+// @Accept       json
+// @Produce      json
+// @Param Task body schemas.RequestTaskV2 true "Task"
+// @Param X-Actor header string false "Advisory actor recorded in task history, not authenticated"
+// @Param Idempotency-Key header string false "Retries with the same key get the response of the first request"
+// @Success      201  {object}  taskEnvelope
+// @Header       201  {string}  Location "Path of the new task"
+// @Failure      400  {object}  errorEnvelope
+// @Failure      409  {object}  errorEnvelope "A request with the key is in progress"
+// @Failure      415  {object}  errorEnvelope
+// @Failure      422  {object}  errorEnvelope "The key was used with a different request"
+// @Failure      500  {object}  errorEnvelope
+// @Router       /api/v2/tasks [post]
+func (h *Handler) taskCreate(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s v2 taskCreate called", r.Method, r.RemoteAddr)
+
+	cTask, ok := h.readTask(w, r)
+	if !ok {
+		return
+	}
+	cTaskDTO := cTask.ToCreateDTO()
+	cTaskDTO.Actor = requestActor(r)
+
+	rTaskDTO, err := h.service.Task.Create(cTaskDTO)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Location", taskPath(rTaskDTO.Id))
+	h.writeData(w, http.StatusCreated, newTaskEnvelope(rTaskDTO))
+}
+
+// taskList godoc
+// @Tags         Task API v2
+// @Summary      List tasks
+// @Description  Tasks in id order, a page at a time. The links of the envelope point to the next and previous pages.
+// @Produce      json
+// @Param completed query bool false "Only completed or open tasks"
+// @Param due_after query string false "Due at or after, RFC3339"
+// @Param due_before query string false "Due before, RFC3339"
+// @Param limit query int false "Page size, 50 by default, at most 500"
+// @Param offset query int false "Tasks to skip"
+// @Success      200  {object}  taskListEnvelope
+// @Failure      400  {object}  errorEnvelope
+// @Failure      500  {object}  errorEnvelope
+// @Router       /api/v2/tasks [get]
+func (h *Handler) taskList(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s v2 taskList called", r.Method, r.RemoteAddr)
+
+	filter := schemas.RequestTaskFilter{}
+	filter.ScanQuery(r.URL.Query())
+	page := schemas.RequestPage{}
+	page.ScanQuery(r.URL.Query())
+	if err := errors.Join(filter.Valid(), page.Valid()); err != nil {
+		h.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rTasksDTO, err := h.service.Task.List()
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	rTasksDTO = filter.Apply(rTasksDTO)
+
+	limit, offset := page.Bounds()
+	env := taskListEnvelope{
+		Data:  make([]schemas.ResponseTaskV2, 0, limit),
+		Meta:  pageMeta{Total: len(rTasksDTO), Limit: limit, Offset: offset},
+		Links: links{Self: pageLink(r.URL, limit, offset)},
+	}
+	for i := offset; i < len(rTasksDTO) && i < offset+limit; i++ {
+		rTask := schemas.ResponseTaskV2{}
+		rTask.ScanDTO(&rTasksDTO[i])
+		env.Data = append(env.Data, rTask)
+	}
+	if offset+limit < len(rTasksDTO) {
+		env.Links.Next = pageLink(r.URL, limit, offset+limit)
+	}
+	if offset > 0 {
+		env.Links.Prev = pageLink(r.URL, limit, max(offset-limit, 0))
+	}
+
+	h.writeData(w, http.StatusOK, env)
+}
+
+// pageLink is the request with another page, the filters kept.
+func pageLink(u *url.URL, limit, offset int) string {
+	q := u.Query()
+	q.Set("limit", strconv.Itoa(limit))
+	q.Set("offset", strconv.Itoa(offset))
+	return u.Path + "?" + q.Encode()
+}
+
+// taskFindById godoc
+// @Tags         Task API v2
+// @Summary      Find a task by id
+// @Produce      json
+// @Param id path int true "Task id"
+// @Success      200  {object}  taskEnvelope
+// @Failure      400  {object}  errorEnvelope
+// @Failure      404  {object}  errorEnvelope
+// @Failure      500  {object}  errorEnvelope
+// @Router       /api/v2/tasks/{id} [get]
+func (h *Handler) taskFindById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s v2 taskFindById called", r.Method, r.RemoteAddr)
+
+	id, ok := h.readId(w, ps)
+	if !ok {
+		return
+	}
+
+	rTaskDTO, err := h.service.Task.FindByID(id)
+	if err != nil {
+		h.writeTaskError(w, err)
+		return
+	}
+
+	h.writeData(w, http.StatusOK, newTaskEnvelope(rTaskDTO))
+}
+
+// taskUpdateById godoc
+// @Tags         Task API v2
+// @Summary      Replace a task
+// @Accept       json
+// @Produce      json
+// @Param id path int true "Task id"
+// @Param Task body schemas.RequestTaskV2 true "Task"
+// @Param If-Match header string false "Only replace the task at this version, as \"<version>\""
+// @Param X-Actor header string false "Advisory actor recorded in task history, not authenticated"
+// @Success      200  {object}  taskEnvelope
+// @Failure      400  {object}  errorEnvelope
+// @Failure      404  {object}  errorEnvelope
+// @Failure      412  {object}  errorEnvelope "The task is at another version"
+// @Failure      415  {object}  errorEnvelope
+// @Failure      500  {object}  errorEnvelope
+// @Router       /api/v2/tasks/{id} [put]
+func (h *Handler) taskUpdateById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s v2 taskUpdateById called", r.Method, r.RemoteAddr)
+
+	id, ok := h.readId(w, ps)
+	if !ok {
+		return
+	}
+	version, ok := h.readVersion(w, r)
+	if !ok {
+		return
+	}
+	uTask, ok := h.readTask(w, r)
+	if !ok {
+		return
+	}
+	uTaskDTO := uTask.ToUpdateDTO()
+	uTaskDTO.Actor = requestActor(r)
+	uTaskDTO.Version = version
+
+	rTaskDTO, err := h.service.Task.UpdateById(id, uTaskDTO)
+	if err != nil {
+		h.writeTaskError(w, err)
+		return
+	}
+
+	h.writeData(w, http.StatusOK, newTaskEnvelope(rTaskDTO))
+}
+
+// taskDeleteById godoc
+// @Tags         Task API v2
+// @Summary      Delete a task
+// @Param id path int true "Task id"
+// @Param If-Match header string false "Only delete the task at this version, as \"<version>\""
+// @Param X-Actor header string false "Advisory actor recorded in task history, not authenticated"
+// @Success      204
+// @Failure      400  {object}  errorEnvelope
+// @Failure      404  {object}  errorEnvelope
+// @Failure      412  {object}  errorEnvelope "The task is at another version"
+// @Failure      500  {object}  errorEnvelope
+// @Router       /api/v2/tasks/{id} [delete]
+func (h *Handler) taskDeleteById(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	h.logger.Debugf("[%s] %s v2 taskDeleteById called", r.Method, r.RemoteAddr)
+
+	id, ok := h.readId(w, ps)
+	if !ok {
+		return
+	}
+	version, ok := h.readVersion(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Task.DeleteByIdVersion(id, version, requestActor(r)); err != nil {
+		h.writeTaskError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) readId(w http.ResponseWriter, ps httprouter.Params) (int, bool) {
+	id, err := strconv.Atoi(ps.ByName("id"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, errors.New("id must be a number"))
+		return 0, false
+	}
+	return id, true
+}
+
+// readVersion returns the task version an If-Match header expects, 0 for
+// any version when there is none or it is *.
+func (h *Handler) readVersion(w http.ResponseWriter, r *http.Request) (int, bool) {
+	match := r.Header.Get("If-Match")
+	if match == "" || match == "*" {
+		return 0, true
+	}
+	unquoted, err := strconv.Unquote(match)
+	if err != nil || match[0] != '"' {
+		h.writeError(w, http.StatusBadRequest, errIfMatch)
+		return 0, false
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version <= 0 {
+		h.writeError(w, http.StatusBadRequest, errIfMatch)
+		return 0, false
+	}
+	return version, true
+}
+
+func (h *Handler) readTask(w http.ResponseWriter, r *http.Request) (*schemas.RequestTaskV2, bool) {
+	if !isJSON(r) {
+		h.writeError(w, http.StatusUnsupportedMediaType, errNotJSON)
+		return nil, false
+	}
+
+	bodyRaw, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+	task := &schemas.RequestTaskV2{}
+	if err = json.Unmarshal(bodyRaw, task); err != nil {
+		h.writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+	if err = task.Valid(); err != nil {
+		h.writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+	return task, true
+}
+
+func (h *Handler) writeTaskError(w http.ResponseWriter, err error) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		h.writeError(w, http.StatusNotFound, errTaskNotFound)
+		return
+	}
+	if errors.Is(err, dto.ErrVersionConflict) {
+		h.writeError(w, http.StatusPreconditionFailed, err)
+		return
+	}
+	h.writeError(w, http.StatusInternalServerError, err)
+}
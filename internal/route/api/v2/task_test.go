@@ -0,0 +1,458 @@
+package v2
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/memory"
+	"ToDoVerba/internal/route/api/idempotency"
+	"ToDoVerba/internal/route/api/openapi/openapitest"
+	"ToDoVerba/internal/service"
+	"ToDoVerba/internal/service/idempotencyService"
+	mockservice "ToDoVerba/internal/service/mocks"
+	"ToDoVerba/pkg/logging"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseTime(s string) pgtype.Timestamptz {
+	t, _ := time.Parse(time.RFC3339, s)
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func newTaskRouter(tasksService *mockservice.MockITaskService) *httprouter.Router {
+	logger := logging.GetLoggerTest()
+	handler := NewHandler(Deps{
+		Service: service.Services{
+			Task: tasksService,
+			Idempotency: idempotencyService.NewIdempotencyService(idempotencyService.Deps{
+				Repo:   memory.NewIdempotencyMemory(logger),
+				Logger: logger,
+				TTL:    time.Hour,
+			}),
+		},
+		Logger: logger,
+	})
+	r := httprouter.New()
+	handler.Init(r)
+	return r
+}
+
+func TestHandler_taskCreate(t *testing.T) {
+	type mockBehaviour func(s *mockservice.MockITaskService)
+
+	testTable := []struct {
+		name             string
+		inputBody        string
+		contentType      string
+		mockBehaviour    mockBehaviour
+		expectedCode     int
+		expectedLocation string
+		expectedBody     string
+	}{
+		{
+			name:        "201_valid_input",
+			inputBody:   `{"title": "Task", "due_date": "2024-09-05T15:04:05Z"}`,
+			contentType: "application/json; charset=utf-8",
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().Create(&dto.TaskCreate{
+					Title:   "Task",
+					DueDate: parseTime("2024-09-05T15:04:05Z"),
+				}).Return(&dto.TaskRead{
+					Id:        7,
+					Title:     "Task",
+					DueDate:   parseTime("2024-09-05T15:04:05Z"),
+					CreatedAt: parseTime("2024-09-01T10:00:00Z"),
+					Version:   1,
+				}, nil)
+			},
+			expectedCode:     201,
+			expectedLocation: "/api/v2/tasks/7",
+			expectedBody: `{
+								"data": {
+									"id": 7,
+									"title": "Task",
+									"description": null,
+									"due_date": "2024-09-05T15:04:05Z",
+									"completed": false,
+									"version": 1,
+									"created_at": "2024-09-01T10:00:00Z",
+									"updated_at": null
+								},
+								"meta": {},
+								"links": {"self": "/api/v2/tasks/7"}
+							}`,
+		},
+		{
+			name:          "400_invalid_input",
+			inputBody:     `{"due_date": "tomorrow"}`,
+			contentType:   "application/json",
+			mockBehaviour: func(s *mockservice.MockITaskService) {},
+			expectedCode:  400,
+			expectedBody: `{"error": {
+								"status": 400,
+								"message": "request is invalid",
+								"details": ["title is required", "due_date must be in RFC3339 format"]
+							}}`,
+		},
+		{
+			name:          "415_not_json",
+			inputBody:     `title=Task`,
+			contentType:   "application/x-www-form-urlencoded",
+			mockBehaviour: func(s *mockservice.MockITaskService) {},
+			expectedCode:  415,
+			expectedBody:  `{"error": {"status": 415, "message": "content-type is not application/json"}}`,
+		},
+		{
+			name:        "500_unknown_error",
+			inputBody:   `{"title": "Task", "due_date": "2024-09-05T15:04:05Z"}`,
+			contentType: "application/json",
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().Create(gomock.Any()).Return(nil, errors.New("connection refused"))
+			},
+			expectedCode: 500,
+			expectedBody: `{"error": {"status": 500, "message": "Internal Server Error"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			tasksService := mockservice.NewMockITaskService(c)
+			testCase.mockBehaviour(tasksService)
+			r := newTaskRouter(tasksService)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/v2/tasks", strings.NewReader(testCase.inputBody))
+			req.Header.Set("Content-Type", testCase.contentType)
+			openapitest.Handler(t, r).ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, testCase.expectedLocation, w.Header().Get("Location"))
+			assert.JSONEq(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_taskCreate_idempotent(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	tasksService := mockservice.NewMockITaskService(c)
+	tasksService.EXPECT().Create(gomock.Any()).Return(&dto.TaskRead{Id: 7, Title: "Task"}, nil).Times(1)
+	r := newTaskRouter(tasksService)
+
+	post := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v2/tasks", strings.NewReader(`{"title": "Task", "due_date": "2024-09-05T15:04:05Z"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(idempotency.KeyHeader, "retry-1")
+		openapitest.Handler(t, r).ServeHTTP(w, req)
+		return w
+	}
+
+	first := post()
+	assert.Equal(t, 201, first.Code)
+	assert.Equal(t, "/api/v2/tasks/7", first.Header().Get("Location"))
+
+	retry := post()
+	assert.Equal(t, 201, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(idempotency.ReplayedHeader))
+	assert.Equal(t, "/api/v2/tasks/7", retry.Header().Get("Location"))
+	assert.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+	assert.JSONEq(t, first.Body.String(), retry.Body.String())
+}
+
+func TestHandler_taskList(t *testing.T) {
+	tasks := make([]dto.TaskRead, 5)
+	for i := range tasks {
+		tasks[i] = dto.TaskRead{Id: i + 1, Title: fmt.Sprintf("Task %d", i+1), Completed: i%2 == 0}
+	}
+
+	testTable := []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedIds   []int
+		expectedMeta  pageMeta
+		expectedLinks links
+		expectedBody  string
+	}{
+		{
+			name:         "200_first_page",
+			query:        "?limit=2",
+			expectedCode: 200,
+			expectedIds:  []int{1, 2},
+			expectedMeta: pageMeta{Total: 5, Limit: 2, Offset: 0},
+			expectedLinks: links{
+				Self: "/api/v2/tasks?limit=2&offset=0",
+				Next: "/api/v2/tasks?limit=2&offset=2",
+			},
+		},
+		{
+			name:         "200_last_page",
+			query:        "?limit=2&offset=4",
+			expectedCode: 200,
+			expectedIds:  []int{5},
+			expectedMeta: pageMeta{Total: 5, Limit: 2, Offset: 4},
+			expectedLinks: links{
+				Self: "/api/v2/tasks?limit=2&offset=4",
+				Prev: "/api/v2/tasks?limit=2&offset=2",
+			},
+		},
+		{
+			name:         "200_filter_kept_in_links",
+			query:        "?completed=true&limit=1&offset=1",
+			expectedCode: 200,
+			expectedIds:  []int{3},
+			expectedMeta: pageMeta{Total: 3, Limit: 1, Offset: 1},
+			expectedLinks: links{
+				Self: "/api/v2/tasks?completed=true&limit=1&offset=1",
+				Next: "/api/v2/tasks?completed=true&limit=1&offset=2",
+				Prev: "/api/v2/tasks?completed=true&limit=1&offset=0",
+			},
+		},
+		{
+			name:         "200_past_the_end",
+			query:        "?offset=10",
+			expectedCode: 200,
+			expectedIds:  []int{},
+			expectedMeta: pageMeta{Total: 5, Limit: 50, Offset: 10},
+			expectedLinks: links{
+				Self: "/api/v2/tasks?limit=50&offset=10",
+				Prev: "/api/v2/tasks?limit=50&offset=0",
+			},
+		},
+		{
+			name:         "400_invalid_page",
+			query:        "?limit=0&offset=-1",
+			expectedCode: 400,
+			expectedBody: `{"error": {
+								"status": 400,
+								"message": "request is invalid",
+								"details": ["limit must be a number from 1 to 500", "offset must be a number from 0"]
+							}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			tasksService := mockservice.NewMockITaskService(c)
+			tasksService.EXPECT().List().Return(tasks, nil).AnyTimes()
+			r := newTaskRouter(tasksService)
+
+			w := httptest.NewRecorder()
+			openapitest.Handler(t, r).ServeHTTP(w, httptest.NewRequest("GET", "/api/v2/tasks"+testCase.query, nil))
+
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			if testCase.expectedBody != "" {
+				assert.JSONEq(t, testCase.expectedBody, w.Body.String())
+				return
+			}
+
+			var env taskListEnvelope
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &env))
+			ids := []int{}
+			for _, task := range env.Data {
+				ids = append(ids, task.Id)
+			}
+			assert.Equal(t, testCase.expectedIds, ids)
+			assert.Equal(t, testCase.expectedMeta, env.Meta)
+			assert.Equal(t, testCase.expectedLinks, env.Links)
+			assert.True(t, strings.HasPrefix(w.Body.String(), `{"data":[`), "an empty page is an empty array")
+		})
+	}
+}
+
+func TestHandler_taskById(t *testing.T) {
+	type mockBehaviour func(s *mockservice.MockITaskService)
+
+	testTable := []struct {
+		name          string
+		method        string
+		path          string
+		headers       map[string]string
+		inputBody     string
+		mockBehaviour mockBehaviour
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			name:   "200_find",
+			method: "GET",
+			path:   "/api/v2/tasks/7",
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().FindByID(7).Return(&dto.TaskRead{Id: 7, Title: "Task", Description: "Description"}, nil)
+			},
+			expectedCode: 200,
+			expectedBody: `{
+								"data": {
+									"id": 7,
+									"title": "Task",
+									"description": "Description",
+									"due_date": null,
+									"completed": false,
+									"version": 0,
+									"created_at": null,
+									"updated_at": null
+								},
+								"meta": {},
+								"links": {"self": "/api/v2/tasks/7"}
+							}`,
+		},
+		{
+			name:          "400_invalid_id",
+			method:        "GET",
+			path:          "/api/v2/tasks/seven",
+			mockBehaviour: func(s *mockservice.MockITaskService) {},
+			expectedCode:  400,
+			expectedBody:  `{"error": {"status": 400, "message": "id must be a number"}}`,
+		},
+		{
+			name:   "404_not_found",
+			method: "GET",
+			path:   "/api/v2/tasks/8",
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().FindByID(8).Return(nil, pgx.ErrNoRows)
+			},
+			expectedCode: 404,
+			expectedBody: `{"error": {"status": 404, "message": "task not found"}}`,
+		},
+		{
+			name:      "200_update",
+			method:    "PUT",
+			path:      "/api/v2/tasks/7",
+			inputBody: `{"title": "Task", "description": null, "due_date": "2024-09-05T15:04:05Z", "completed": true}`,
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().UpdateById(7, &dto.TaskUpdate{
+					Title:     "Task",
+					DueDate:   parseTime("2024-09-05T15:04:05Z"),
+					Completed: true,
+				}).Return(&dto.TaskRead{Id: 7, Title: "Task", Completed: true, Version: 2}, nil)
+			},
+			expectedCode: 200,
+			expectedBody: `{
+								"data": {
+									"id": 7,
+									"title": "Task",
+									"description": null,
+									"due_date": null,
+									"completed": true,
+									"version": 2,
+									"created_at": null,
+									"updated_at": null
+								},
+								"meta": {},
+								"links": {"self": "/api/v2/tasks/7"}
+							}`,
+		},
+		{
+			name:      "412_update_changed",
+			method:    "PUT",
+			path:      "/api/v2/tasks/7",
+			headers:   map[string]string{"If-Match": `"1"`},
+			inputBody: `{"title": "Task", "due_date": "2024-09-05T15:04:05Z"}`,
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().UpdateById(7, &dto.TaskUpdate{
+					Title:   "Task",
+					DueDate: parseTime("2024-09-05T15:04:05Z"),
+					Version: 1,
+				}).Return(nil, dto.ErrVersionConflict)
+			},
+			expectedCode: 412,
+			expectedBody: `{"error": {"status": 412, "message": "task was changed in the meantime"}}`,
+		},
+		{
+			name:          "400_invalid_if_match",
+			method:        "PUT",
+			path:          "/api/v2/tasks/7",
+			headers:       map[string]string{"If-Match": "1"},
+			inputBody:     `{"title": "Task", "due_date": "2024-09-05T15:04:05Z"}`,
+			mockBehaviour: func(s *mockservice.MockITaskService) {},
+			expectedCode:  400,
+			expectedBody:  `{"error": {"status": 400, "message": "If-Match must be \"*\" or a quoted task version"}}`,
+		},
+		{
+			name:   "404_delete",
+			method: "DELETE",
+			path:   "/api/v2/tasks/8",
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().DeleteByIdVersion(8, 0, "").Return(pgx.ErrNoRows)
+			},
+			expectedCode: 404,
+			expectedBody: `{"error": {"status": 404, "message": "task not found"}}`,
+		},
+		{
+			name:    "412_delete_changed",
+			method:  "DELETE",
+			path:    "/api/v2/tasks/8",
+			headers: map[string]string{"If-Match": `"2"`},
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().DeleteByIdVersion(8, 2, "").Return(dto.ErrVersionConflict)
+			},
+			expectedCode: 412,
+			expectedBody: `{"error": {"status": 412, "message": "task was changed in the meantime"}}`,
+		},
+		{
+			name:    "404_delete_any_version",
+			method:  "DELETE",
+			path:    "/api/v2/tasks/8",
+			headers: map[string]string{"If-Match": "*"},
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().DeleteByIdVersion(8, 0, "").Return(pgx.ErrNoRows)
+			},
+			expectedCode: 404,
+			expectedBody: `{"error": {"status": 404, "message": "task not found"}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+			tasksService := mockservice.NewMockITaskService(c)
+			testCase.mockBehaviour(tasksService)
+			r := newTaskRouter(tasksService)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, testCase.path, strings.NewReader(testCase.inputBody))
+			req.Header.Set("Content-Type", "application/json")
+			for name, value := range testCase.headers {
+				req.Header.Set(name, value)
+			}
+			openapitest.Handler(t, r).ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			assert.JSONEq(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_taskDeleteById(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+	tasksService := mockservice.NewMockITaskService(c)
+	tasksService.EXPECT().DeleteByIdVersion(7, 3, "alice").Return(nil)
+	r := newTaskRouter(tasksService)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("DELETE", "/api/v2/tasks/7", nil)
+	req.Header.Set(actorHeader, "alice")
+	req.Header.Set("If-Match", `"3"`)
+	openapitest.Handler(t, r).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+}
@@ -0,0 +1,67 @@
+// Package v2 serves API v2 under /api/v2. Every response body is an envelope:
+//
+//   - {"data": ..., "meta": {...}, "links": {"self": ...}} on success, with
+//     the paging of a list in meta and its next and prev pages in links;
+//   - {"error": {"status": 400, "message": ..., "details": [...]}} on
+//     failure, with one detail per problem of an invalid request.
+//
+// Fields without a value are null rather than a zero value, a create answers
+// with the Location of the new resource, and server errors are logged
+// rather than sent to the client.
+package v2
+
+import (
+	"ToDoVerba/internal/route/api/idempotency"
+	"ToDoVerba/internal/service"
+	"ToDoVerba/pkg/logging"
+	"errors"
+	"github.com/julienschmidt/httprouter"
+	"mime"
+	"net/http"
+)
+
+// BasePath prefixes the routes of the v2 API.
+const BasePath = "/api/v2"
+
+// actorHeader names the caller recorded in the task audit trail. Like in v1
+// it is advisory: the v2 routes have no authentication and nothing checks the
+// name.
+const actorHeader = "X-Actor"
+
+var errNotJSON = errors.New("content-type is not application/json")
+
+type Handler struct {
+	service service.Services
+	logger  logging.Logger
+}
+
+type Deps struct {
+	Service service.Services
+	Logger  logging.Logger
+}
+
+func NewHandler(d Deps) *Handler {
+	return &Handler{
+		service: d.Service,
+		logger:  d.Logger,
+	}
+}
+
+func (h *Handler) Init(r *httprouter.Router) {
+	h.initTaskHandler(r)
+}
+
+// idempotent runs next once per Idempotency-Key header.
+func (h *Handler) idempotent(next httprouter.Handle) httprouter.Handle {
+	return idempotency.Handle(h.service.Idempotency, h.writeError, next)
+}
+
+func requestActor(r *http.Request) string {
+	return r.Header.Get(actorHeader)
+}
+
+// isJSON accepts application/json with parameters such as a charset.
+func isJSON(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
@@ -0,0 +1,242 @@
+// Package gql serves tasks over GraphQL at /graphql, following the
+// GraphQL over HTTP conventions for GET and POST requests.
+package gql
+
+import (
+	"ToDoVerba/internal/service"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/julienschmidt/httprouter"
+	"mime"
+	"net/http"
+)
+
+// actorHeader names the caller recorded in the task audit trail, as in the
+// REST api.
+const actorHeader = "X-Actor"
+
+// Options limit the queries the endpoint runs. With PersistedOnly set, only
+// the queries of PersistedQueries are accepted, sent either as text or by
+// hash.
+type Options struct {
+	MaxDepth         int
+	MaxComplexity    int
+	PersistedQueries map[string]string
+	PersistedOnly    bool
+}
+
+type Handler struct {
+	service service.Services
+	logger  logging.Logger
+	options Options
+	schema  graphql.Schema
+}
+
+type Deps struct {
+	Services service.Services
+	Logger   logging.Logger
+	Options  Options
+}
+
+func NewHandler(d Deps) *Handler {
+	h := &Handler{
+		service: d.Services,
+		logger:  d.Logger,
+		options: d.Options,
+	}
+	schema, err := h.newSchema()
+	if err != nil {
+		panic("gql: invalid schema: " + err.Error())
+	}
+	h.schema = schema
+	return h
+}
+
+func (h *Handler) Init(r *httprouter.Router) {
+	r.Handler("GET", "/graphql", h)
+	r.Handler("POST", "/graphql", h)
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    struct {
+		PersistedQuery *struct {
+			Sha256Hash string `json:"sha256Hash"`
+		} `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+// ServeHTTP runs a query. Requests that can't be run, because they are
+// malformed, invalid or over the limits, are answered with 400 and errors
+// only; executed ones with 200 and the data, along with any field errors.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debugf("[%s] %s graphql called", r.Method, r.RemoteAddr)
+
+	req, err := readRequest(r)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, requestError(err))
+		return
+	}
+	query, err := h.resolveQuery(req)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, requestError(err))
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(err)...)
+		return
+	}
+	if validation := graphql.ValidateDocument(&h.schema, doc, nil); !validation.IsValid {
+		writeErrors(w, http.StatusBadRequest, validation.Errors...)
+		return
+	}
+
+	op := operation(doc, req.OperationName)
+	if op == nil {
+		writeErrors(w, http.StatusBadRequest, gqlerrors.NewFormattedError("operation not found, operationName is required for documents with several operations"))
+		return
+	}
+	if r.Method == http.MethodGet && op.Operation != ast.OperationTypeQuery {
+		w.Header().Set("Allow", http.MethodPost)
+		writeErrors(w, http.StatusMethodNotAllowed, gqlerrors.NewFormattedError("only queries can be sent with GET"))
+		return
+	}
+	err = checkLimits(&h.schema, doc, op, req.Variables, h.options.MaxDepth, h.options.MaxComplexity)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, requestError(err))
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), actorKey{}, r.Header.Get(actorHeader))
+	ctx = context.WithValue(ctx, loadersKey{}, h.newLoaders())
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+	writeJSON(w, http.StatusOK, result)
+}
+
+func readRequest(r *http.Request) (*request, error) {
+	req := &request{}
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if variables := q.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return nil, errors.New("variables must be a JSON object")
+			}
+		}
+		if extensions := q.Get("extensions"); extensions != "" {
+			if err := json.Unmarshal([]byte(extensions), &req.Extensions); err != nil {
+				return nil, errors.New("extensions must be a JSON object")
+			}
+		}
+		return req, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return nil, errors.New("content-type is not application/json")
+	}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return nil, errors.New("request body must be a JSON object")
+	}
+	return req, nil
+}
+
+// resolveQuery returns the text of the query, looked up by the hash of the
+// persistedQuery extension if the request only sends that.
+func (h *Handler) resolveQuery(req *request) (string, error) {
+	query := req.Query
+	if pq := req.Extensions.PersistedQuery; pq != nil {
+		if query == "" {
+			persisted, ok := h.options.PersistedQueries[pq.Sha256Hash]
+			if !ok {
+				return "", errors.New("PersistedQueryNotFound")
+			}
+			return persisted, nil
+		}
+		if queryHash(query) != pq.Sha256Hash {
+			return "", errors.New("provided sha256Hash does not match query")
+		}
+	}
+
+	if query == "" {
+		return "", errors.New("query is required")
+	}
+	if h.options.PersistedOnly {
+		if _, ok := h.options.PersistedQueries[queryHash(query)]; !ok {
+			return "", errors.New("query is not in the persisted query allowlist")
+		}
+	}
+	return query, nil
+}
+
+// operation returns the operation of the document to run: the named one, or
+// the only one if there is no name.
+func operation(doc *ast.Document, name string) *ast.OperationDefinition {
+	var found *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			if found != nil {
+				return nil
+			}
+			found = op
+		} else if op.Name != nil && op.Name.Value == name {
+			return op
+		}
+	}
+	return found
+}
+
+type actorKey struct{}
+
+type loadersKey struct{}
+
+func requestActor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+func requestLoaders(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func requestError(err error) gqlerrors.FormattedError {
+	return gqlerrors.NewFormattedError(err.Error())
+}
+
+// writeErrors answers a request that wasn't executed, so unlike a result
+// the body has no data entry.
+func writeErrors(w http.ResponseWriter, code int, errs ...gqlerrors.FormattedError) {
+	writeJSON(w, code, struct {
+		Errors []gqlerrors.FormattedError `json:"errors"`
+	}{errs})
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
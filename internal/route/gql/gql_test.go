@@ -0,0 +1,330 @@
+package gql
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/service"
+	mockservice "ToDoVerba/internal/service/mocks"
+	"ToDoVerba/pkg/logging"
+	"encoding/json"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testTasks(n int) []dto.TaskRead {
+	due := time.Date(2024, 9, 10, 12, 0, 0, 0, time.UTC)
+	tasks := make([]dto.TaskRead, 0, n)
+	for i := 1; i <= n; i++ {
+		tasks = append(tasks, dto.TaskRead{
+			Id:          i,
+			Title:       "title",
+			Description: "description",
+			DueDate:     pgtype.Timestamptz{Time: due, Valid: true},
+			Completed:   i%2 == 0,
+			CreatedAt:   pgtype.Timestamptz{Time: due, Valid: true},
+			UpdatedAt:   pgtype.Timestamptz{Time: due, Valid: true},
+		})
+	}
+	return tasks
+}
+
+func newTestRouter(services service.Services, options Options) *httprouter.Router {
+	r := httprouter.New()
+	NewHandler(Deps{Services: services, Logger: logging.GetLoggerTest(), Options: options}).Init(r)
+	return r
+}
+
+func post(r *httprouter.Router, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(actorHeader, "alice")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_tasksBatchesRelatedData(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tasks := mockservice.NewMockITaskService(c)
+	events := mockservice.NewMockITaskEventService(c)
+	reminders := mockservice.NewMockIReminderService(c)
+	tasks.EXPECT().List().Return(testTasks(3), nil)
+	events.EXPECT().HistoryByTasks([]int{1, 2, 3}).Return(map[int][]dto.TaskEvent{
+		2: {{Id: 7, TaskId: 2, Action: dto.TaskEventUpdate, Actor: "bob", Changes: map[string]dto.FieldChange{
+			"completed": {Before: false, After: true},
+			"title":     {Before: "old", After: "title"},
+		}}},
+	}, nil).Times(1)
+	reminders.EXPECT().ListByTasks([]int{1, 2, 3}).Return(map[int][]dto.Reminder{
+		1: {{Id: 3, TaskId: 1, Offset: time.Hour, Channels: []string{"log"}, Status: dto.ReminderPending}},
+	}, nil).Times(1)
+	r := newTestRouter(service.Services{Task: tasks, TaskEvent: events, Reminder: reminders}, Options{})
+
+	w := post(r, `{"query":"{ tasks { total items { id history { id actor changes { field before after } } reminders { id offset sentAt } } } }"}`)
+
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"data":{"tasks":{"total":3,"items":[
+		{"id":1,"history":[],"reminders":[{"id":"3","offset":"1h0m0s","sentAt":null}]},
+		{"id":2,"history":[{"id":"7","actor":"bob","changes":[
+			{"field":"completed","before":"false","after":"true"},
+			{"field":"title","before":"\"old\"","after":"\"title\""}]}],"reminders":[]},
+		{"id":3,"history":[],"reminders":[]}]}}}`, w.Body.String())
+}
+
+func TestHandler_tasks(t *testing.T) {
+	testTable := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "200 page",
+			query:        `{ tasks(offset: 1, limit: 2) { total items { id } } }`,
+			expectedCode: 200,
+			expectedBody: `{"data":{"tasks":{"total":5,"items":[{"id":2},{"id":3}]}}}`,
+		},
+		{
+			name:         "200 filter",
+			query:        `{ tasks(filter: {completed: true}) { total items { id completed } } }`,
+			expectedCode: 200,
+			expectedBody: `{"data":{"tasks":{"total":2,"items":[{"id":2,"completed":true},{"id":4,"completed":true}]}}}`,
+		},
+		{
+			name:         "200 invalid limit",
+			query:        `{ tasks(limit: 1000) { total } }`,
+			expectedCode: 200,
+			expectedBody: `{"data":null,"errors":[{"message":"limit must be between 1 and 100","locations":[{"line":1,"column":3}],"path":["tasks"],"extensions":{"code":"BAD_USER_INPUT"}}]}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tasks := mockservice.NewMockITaskService(c)
+			tasks.EXPECT().List().Return(testTasks(5), nil).MaxTimes(1)
+			r := newTestRouter(service.Services{Task: tasks}, Options{})
+
+			body, _ := json.Marshal(map[string]string{"query": testCase.query})
+			w := post(r, string(body))
+
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			assert.JSONEq(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_task(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tasks := mockservice.NewMockITaskService(c)
+	tasks.EXPECT().FindByID(1).Return(&testTasks(1)[0], nil)
+	tasks.EXPECT().FindByID(2).Return(nil, pgx.ErrNoRows)
+	r := newTestRouter(service.Services{Task: tasks}, Options{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(`{ a: task(id: 1) { title dueDate } b: task(id: 2) { title } }`), nil))
+
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"data":{"a":{"title":"title","dueDate":"2024-09-10T12:00:00Z"},"b":null}}`, w.Body.String())
+}
+
+func TestHandler_mutations(t *testing.T) {
+	due := time.Date(2024, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name          string
+		query         string
+		mockBehaviour func(s *mockservice.MockITaskService)
+		expectedBody  string
+	}{
+		{
+			name:  "create",
+			query: `mutation { createTask(input: {title: "title", description: "description", dueDate: "2024-09-10T12:00:00Z"}) { id } }`,
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().Create(&dto.TaskCreate{
+					Title:       "title",
+					Description: "description",
+					DueDate:     pgtype.Timestamptz{Time: due, Valid: true},
+					Actor:       "alice",
+				}).Return(&testTasks(1)[0], nil)
+			},
+			expectedBody: `{"data":{"createTask":{"id":1}}}`,
+		},
+		{
+			name:          "create invalid",
+			query:         `mutation { createTask(input: {title: "title", description: "", dueDate: "tomorrow"}) { id } }`,
+			mockBehaviour: func(s *mockservice.MockITaskService) {},
+			expectedBody:  `{"data":null,"errors":[{"message":"Description is required;DueDate is required and must be in RFC3339 format;","locations":[{"line":1,"column":12}],"path":["createTask"],"extensions":{"code":"BAD_USER_INPUT"}}]}`,
+		},
+		{
+			name:  "update not found",
+			query: `mutation { updateTask(id: 2, input: {title: "title", description: "description", dueDate: "2024-09-10T12:00:00Z"}) { id } }`,
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().UpdateById(2, gomock.Any()).Return(nil, pgx.ErrNoRows)
+			},
+			expectedBody: `{"data":null,"errors":[{"message":"no rows in result set","locations":[{"line":1,"column":12}],"path":["updateTask"],"extensions":{"code":"NOT_FOUND"}}]}`,
+		},
+		{
+			name:  "delete",
+			query: `mutation { deleteTask(id: 1) }`,
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().DeleteById(1, "alice").Return(nil)
+			},
+			expectedBody: `{"data":{"deleteTask":true}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tasks := mockservice.NewMockITaskService(c)
+			testCase.mockBehaviour(tasks)
+			r := newTestRouter(service.Services{Task: tasks}, Options{})
+
+			body, _ := json.Marshal(map[string]string{"query": testCase.query})
+			w := post(r, string(body))
+
+			assert.Equal(t, 200, w.Code)
+			assert.JSONEq(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_rejectedRequests(t *testing.T) {
+	persisted := `{ tasks { total } }`
+	options := Options{
+		MaxDepth:         3,
+		MaxComplexity:    200,
+		PersistedQueries: map[string]string{queryHash(persisted): persisted},
+	}
+
+	testTable := []struct {
+		name         string
+		method       string
+		body         string
+		options      Options
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "400 too deep",
+			body:         `{"query":"{ tasks { items { history { changes { field } } } } }"}`,
+			options:      options,
+			expectedCode: 400,
+			expectedBody: `{"errors":[{"message":"query depth 5 exceeds the limit of 3","locations":[]}]}`,
+		},
+		{
+			name:         "400 too complex",
+			body:         `{"query":"query($n: Int) { tasks(limit: $n) { items { ...f } } } fragment f on Task { history { id } }","variables":{"n":100}}`,
+			options:      Options{MaxComplexity: 200},
+			expectedCode: 400,
+			expectedBody: `{"errors":[{"message":"query complexity 1102 exceeds the limit of 200","locations":[]}]}`,
+		},
+		{
+			name:         "400 invalid query",
+			body:         `{"query":"{ tasks { unknown } }"}`,
+			options:      options,
+			expectedCode: 400,
+			expectedBody: `{"errors":[{"message":"Cannot query field \"unknown\" on type \"TaskPage\".","locations":[{"line":1,"column":11}]}]}`,
+		},
+		{
+			name:         "400 unknown persisted query",
+			body:         `{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"abc"}}}`,
+			options:      options,
+			expectedCode: 400,
+			expectedBody: `{"errors":[{"message":"PersistedQueryNotFound","locations":[]}]}`,
+		},
+		{
+			name:         "400 not in allowlist",
+			body:         `{"query":"{ tasks { items { id } } }"}`,
+			options:      Options{PersistedQueries: options.PersistedQueries, PersistedOnly: true},
+			expectedCode: 400,
+			expectedBody: `{"errors":[{"message":"query is not in the persisted query allowlist","locations":[]}]}`,
+		},
+		{
+			name:         "405 mutation with GET",
+			method:       "GET",
+			options:      options,
+			expectedCode: 405,
+			expectedBody: `{"errors":[{"message":"only queries can be sent with GET","locations":[]}]}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := newTestRouter(service.Services{}, testCase.options)
+
+			w := httptest.NewRecorder()
+			if testCase.method == "GET" {
+				r.ServeHTTP(w, httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(`mutation { deleteTask(id: 1) }`), nil))
+			} else {
+				w = post(r, testCase.body)
+			}
+
+			assert.Equal(t, testCase.expectedCode, w.Code)
+			assert.JSONEq(t, testCase.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_persistedQuery(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tasks := mockservice.NewMockITaskService(c)
+	tasks.EXPECT().List().Return(testTasks(2), nil).Times(2)
+	persisted := `{ tasks { total } }`
+	r := newTestRouter(service.Services{Task: tasks}, Options{
+		PersistedQueries: map[string]string{queryHash(persisted): persisted},
+		PersistedOnly:    true,
+	})
+
+	w := post(r, `{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"`+queryHash(persisted)+`"}}}`)
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"data":{"tasks":{"total":2}}}`, w.Body.String())
+
+	body, _ := json.Marshal(map[string]string{"query": persisted})
+	w = post(r, string(body))
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"data":{"tasks":{"total":2}}}`, w.Body.String())
+}
+
+func TestLoadPersistedQueries(t *testing.T) {
+	dir := t.TempDir()
+	query := `{ tasks { total } }`
+
+	valid := filepath.Join(dir, "valid.json")
+	data, _ := json.Marshal(map[string]string{queryHash(query): query})
+	require.NoError(t, os.WriteFile(valid, data, 0o600))
+	queries, err := LoadPersistedQueries(valid)
+	require.NoError(t, err)
+	assert.Equal(t, query, queries[queryHash(query)])
+
+	invalid := filepath.Join(dir, "invalid.json")
+	data, _ = json.Marshal(map[string]string{"abc": query})
+	require.NoError(t, os.WriteFile(invalid, data, 0o600))
+	_, err = LoadPersistedQueries(invalid)
+	assert.ErrorContains(t, err, "hash abc doesn't match its query")
+
+	queries, err = LoadPersistedQueries("")
+	assert.NoError(t, err)
+	assert.Empty(t, queries)
+}
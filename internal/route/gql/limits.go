@@ -0,0 +1,164 @@
+package gql
+
+import (
+	"fmt"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"strings"
+)
+
+const (
+	// limitArg is the argument that bounds the items a field returns.
+	limitArg = "limit"
+	// listCost is the number of items assumed for list fields without a
+	// limit argument, such as the history of a task.
+	listCost = 10
+)
+
+// checkLimits rejects operations nested deeper than maxDepth fields or with a
+// complexity above maxComplexity. Every field costs one, and the selections
+// of a list field count once for each item it may return: its limit
+// argument, the one of the field returning its page such as tasks for
+// TaskPage.items, or listCost. Introspection is not counted, it is bounded
+// by the size of the schema. A limit of 0 disables the check.
+func checkLimits(schema *graphql.Schema, doc *ast.Document, op *ast.OperationDefinition,
+	variables map[string]interface{}, maxDepth, maxComplexity int) error {
+	w := limitWalker{
+		schema:    schema,
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+	}
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			w.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	root := schema.QueryType()
+	if op.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+	depth, complexity := w.selections(root, op.SelectionSet, 0)
+
+	if maxDepth > 0 && depth > maxDepth {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", depth, maxDepth)
+	}
+	if maxComplexity > 0 && complexity > maxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, maxComplexity)
+	}
+	return nil
+}
+
+type limitWalker struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// selections returns the depth and complexity of a selection set made on
+// parent, with the limit of parent passed on to its list fields. The
+// document is validated before, so fragments can't form cycles.
+func (w *limitWalker) selections(parent *graphql.Object, set *ast.SelectionSet, limit int) (depth, complexity int) {
+	if parent == nil || set == nil {
+		return 0, 0
+	}
+
+	add := func(d, c int) {
+		depth = max(depth, d)
+		complexity += c
+	}
+	for _, selection := range set.Selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			add(w.field(parent, s, limit))
+		case *ast.InlineFragment:
+			add(w.selections(w.typeCondition(parent, s.TypeCondition), s.SelectionSet, limit))
+		case *ast.FragmentSpread:
+			if fragment, ok := w.fragments[s.Name.Value]; ok {
+				add(w.selections(w.typeCondition(parent, fragment.TypeCondition), fragment.SelectionSet, limit))
+			}
+		}
+	}
+	return depth, complexity
+}
+
+func (w *limitWalker) field(parent *graphql.Object, field *ast.Field, parentLimit int) (depth, complexity int) {
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return 0, 0
+	}
+	def, ok := parent.Fields()[field.Name.Value]
+	if !ok {
+		return 0, 0
+	}
+
+	fieldType, isList := def.Type, false
+	for {
+		switch t := fieldType.(type) {
+		case *graphql.NonNull:
+			fieldType = t.OfType
+			continue
+		case *graphql.List:
+			fieldType, isList = t.OfType, true
+			continue
+		}
+		break
+	}
+
+	limit, hasLimit := w.limit(def, field)
+	items := 1
+	if isList {
+		switch {
+		case hasLimit:
+			items = limit
+		case parentLimit > 0:
+			items = parentLimit
+		default:
+			items = listCost
+		}
+		limit = 0
+	}
+
+	object, _ := fieldType.(*graphql.Object)
+	depth, complexity = w.selections(object, field.SelectionSet, limit)
+	return depth + 1, 1 + items*complexity
+}
+
+// limit returns the value of the limit argument of the field, given in the
+// query, as a variable or by its default.
+func (w *limitWalker) limit(def *graphql.FieldDefinition, field *ast.Field) (int, bool) {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != limitArg {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			var limit int
+			_, err := fmt.Sscan(v.Value, &limit)
+			return limit, err == nil
+		case *ast.Variable:
+			switch value := w.variables[v.Name.Value].(type) {
+			case int:
+				return value, true
+			case float64:
+				return int(value), true
+			}
+		}
+	}
+	for _, arg := range def.Args {
+		if arg.PrivateName == limitArg {
+			limit, ok := arg.DefaultValue.(int)
+			return limit, ok
+		}
+	}
+	return 0, false
+}
+
+func (w *limitWalker) typeCondition(parent *graphql.Object, condition *ast.Named) *graphql.Object {
+	if condition == nil {
+		return parent
+	}
+	if object, ok := w.schema.Type(condition.Name.Value).(*graphql.Object); ok {
+		return object
+	}
+	return parent
+}
@@ -0,0 +1,71 @@
+package gql
+
+import (
+	"sync"
+)
+
+// batchLoader collects the keys asked for while one level of a query is
+// resolved and fetches them all with a single call once the first of them
+// is needed. graphql-go resolves the thunks returned by Load only after the
+// whole level has been walked, so the history of fifty tasks costs one query
+// instead of fifty.
+type batchLoader[V any] struct {
+	fetch func(keys []int) (map[int]V, error)
+
+	mu      sync.Mutex
+	pending []int
+	loaded  map[int]V
+	errs    map[int]error
+}
+
+func newBatchLoader[V any](fetch func(keys []int) (map[int]V, error)) *batchLoader[V] {
+	return &batchLoader[V]{
+		fetch:  fetch,
+		loaded: make(map[int]V),
+		errs:   make(map[int]error),
+	}
+}
+
+// Load queues the key and returns a thunk resolving to its value. Keys the
+// fetch leaves out resolve to the zero value.
+func (l *batchLoader[V]) Load(key int) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, ok := l.loaded[key]; !ok {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if len(l.pending) > 0 {
+			l.dispatch()
+		}
+		if err := l.errs[key]; err != nil {
+			return nil, err
+		}
+		return l.loaded[key], nil
+	}
+}
+
+func (l *batchLoader[V]) dispatch() {
+	keys := make([]int, 0, len(l.pending))
+	seen := make(map[int]bool, len(l.pending))
+	for _, key := range l.pending {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	l.pending = nil
+
+	values, err := l.fetch(keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errs[key] = err
+			continue
+		}
+		l.loaded[key] = values[key]
+	}
+}
@@ -0,0 +1,38 @@
+package gql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// LoadPersistedQueries reads the persisted queries of a JSON file mapping the
+// hex encoded SHA-256 hash of each query to its text, the manifest format of
+// Apollo and Relay clients. An empty path returns no queries.
+func LoadPersistedQueries(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	queries := make(map[string]string)
+	if err = json.Unmarshal(data, &queries); err != nil {
+		return nil, fmt.Errorf("persisted queries %s: %w", path, err)
+	}
+	for hash, query := range queries {
+		if queryHash(query) != hash {
+			return nil, fmt.Errorf("persisted queries %s: hash %s doesn't match its query", path, hash)
+		}
+	}
+	return queries, nil
+}
+
+func queryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,396 @@
+package gql
+
+import (
+	"ToDoVerba/internal/schemas"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/graphql-go/graphql"
+	"github.com/jackc/pgx/v5"
+	"sort"
+)
+
+const (
+	defaultTaskLimit = 50
+	maxTaskLimit     = 100
+)
+
+// Tasks, events and reminders resolve from the REST response structs, whose
+// field names graphql-go matches to the camelCase GraphQL ones.
+
+type taskPage struct {
+	Items []schemas.ResponseTaskRead
+	Total int
+}
+
+type fieldChange struct {
+	Field  string
+	Before *string
+	After  *string
+}
+
+func (h *Handler) newSchema() (graphql.Schema, error) {
+	fieldChangeType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "FieldChange",
+		Description: "A changed task field with its JSON encoded values.",
+		Fields: graphql.Fields{
+			"field":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"before": &graphql.Field{Type: graphql.String},
+			"after":  &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	taskEventType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TaskEvent",
+		Fields: graphql.Fields{
+			"id":     &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"taskId": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"action": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"actor":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"changes": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(fieldChangeType))),
+				Resolve: resolveChanges,
+			},
+			"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	reminderType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Reminder",
+		Fields: graphql.Fields{
+			"id":           &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"taskId":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"offset":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"channels":     &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+			"sentChannels": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+			"fireAt":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"status":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"attempts":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"lastError":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"sentAt":       &graphql.Field{Type: graphql.String, Resolve: resolveSentAt},
+			"createdAt":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	taskType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Task",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"title":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"description": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"dueDate":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"completed":   &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"createdAt":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"updatedAt":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"history": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(taskEventType))),
+				Resolve: resolveHistory,
+			},
+			"reminders": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(reminderType))),
+				Resolve: resolveReminders,
+			},
+		},
+	})
+
+	taskPageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TaskPage",
+		Fields: graphql.Fields{
+			"items": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(taskType)))},
+			"total": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	taskFilterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "TaskFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"completed": &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+			"dueAfter":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"dueBefore": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+
+	taskInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "TaskInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"description": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"dueDate":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"completed":   &graphql.InputObjectFieldConfig{Type: graphql.Boolean, DefaultValue: false},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"task": &graphql.Field{
+				Type:        taskType,
+				Description: "The task with the id, null if there is none.",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: h.resolveTask,
+			},
+			"tasks": &graphql.Field{
+				Type:        graphql.NewNonNull(taskPageType),
+				Description: "A page of the tasks matching the filter, ordered by id.",
+				Args: graphql.FieldConfigArgument{
+					"filter": &graphql.ArgumentConfig{Type: taskFilterType},
+					"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+					limitArg: &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultTaskLimit},
+				},
+				Resolve: h.resolveTasks,
+			},
+		},
+	})
+
+	mutationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createTask": &graphql.Field{
+				Type: graphql.NewNonNull(taskType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(taskInputType)},
+				},
+				Resolve: h.resolveCreateTask,
+			},
+			"updateTask": &graphql.Field{
+				Type: graphql.NewNonNull(taskType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(taskInputType)},
+				},
+				Resolve: h.resolveUpdateTask,
+			},
+			"deleteTask": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: h.resolveDeleteTask,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    queryType,
+		Mutation: mutationType,
+	})
+}
+
+func (h *Handler) resolveTask(p graphql.ResolveParams) (interface{}, error) {
+	rTaskDTO, err := h.service.Task.FindByID(p.Args["id"].(int))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, internalError(err)
+	}
+	rTask := schemas.ResponseTaskRead{}
+	rTask.ScanDTO(rTaskDTO)
+	return rTask, nil
+}
+
+func (h *Handler) resolveTasks(p graphql.ResolveParams) (interface{}, error) {
+	offset, limit := p.Args["offset"].(int), p.Args[limitArg].(int)
+	if offset < 0 {
+		return nil, userError("offset must not be negative")
+	}
+	if limit < 1 || limit > maxTaskLimit {
+		return nil, userError(fmt.Sprintf("limit must be between 1 and %d", maxTaskLimit))
+	}
+
+	filter := schemas.RequestTaskFilter{}
+	if args, ok := p.Args["filter"].(map[string]interface{}); ok {
+		if completed, ok := args["completed"].(bool); ok {
+			filter.Completed = fmt.Sprint(completed)
+		}
+		filter.DueAfter, _ = args["dueAfter"].(string)
+		filter.DueBefore, _ = args["dueBefore"].(string)
+	}
+	if err := filter.Valid(); err != nil {
+		return nil, userError(err.Error())
+	}
+
+	rTasksDTO, err := h.service.Task.List()
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, internalError(err)
+	}
+	rTasksDTO = filter.Apply(rTasksDTO)
+
+	page := taskPage{Items: make([]schemas.ResponseTaskRead, 0, limit), Total: len(rTasksDTO)}
+	for i := offset; i < len(rTasksDTO) && i < offset+limit; i++ {
+		rTask := schemas.ResponseTaskRead{}
+		rTask.ScanDTO(&rTasksDTO[i])
+		page.Items = append(page.Items, rTask)
+	}
+	return page, nil
+}
+
+func resolveHistory(p graphql.ResolveParams) (interface{}, error) {
+	task := p.Source.(schemas.ResponseTaskRead)
+	return requestLoaders(p.Context).history.Load(task.Id), nil
+}
+
+func resolveReminders(p graphql.ResolveParams) (interface{}, error) {
+	task := p.Source.(schemas.ResponseTaskRead)
+	return requestLoaders(p.Context).reminders.Load(task.Id), nil
+}
+
+func resolveChanges(p graphql.ResolveParams) (interface{}, error) {
+	event := p.Source.(schemas.ResponseTaskEvent)
+	encode := func(v any) (*string, error) {
+		if v == nil {
+			return nil, nil
+		}
+		data, err := json.Marshal(v)
+		s := string(data)
+		return &s, err
+	}
+
+	changes := make([]fieldChange, 0, len(event.Changes))
+	for name, change := range event.Changes {
+		before, err := encode(change.Before)
+		if err != nil {
+			return nil, internalError(err)
+		}
+		after, err := encode(change.After)
+		if err != nil {
+			return nil, internalError(err)
+		}
+		changes = append(changes, fieldChange{Field: name, Before: before, After: after})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+func resolveSentAt(p graphql.ResolveParams) (interface{}, error) {
+	if sentAt := p.Source.(schemas.ResponseReminder).SentAt; sentAt != "" {
+		return sentAt, nil
+	}
+	return nil, nil
+}
+
+func (h *Handler) resolveCreateTask(p graphql.ResolveParams) (interface{}, error) {
+	input := p.Args["input"].(map[string]interface{})
+	cTask := schemas.RequestTaskCreate{}
+	cTask.Title, _ = input["title"].(string)
+	cTask.Description, _ = input["description"].(string)
+	cTask.DueDate, _ = input["dueDate"].(string)
+	cTask.Completed, _ = input["completed"].(bool)
+	if err := cTask.Valid(); err != nil {
+		return nil, userError(err.Error())
+	}
+	cTaskDTO := cTask.ToDTO()
+	cTaskDTO.Actor = requestActor(p.Context)
+
+	rTaskDTO, err := h.service.Task.Create(cTaskDTO)
+	if err != nil {
+		return nil, internalError(err)
+	}
+	rTask := schemas.ResponseTaskRead{}
+	rTask.ScanDTO(rTaskDTO)
+	return rTask, nil
+}
+
+func (h *Handler) resolveUpdateTask(p graphql.ResolveParams) (interface{}, error) {
+	input := p.Args["input"].(map[string]interface{})
+	update := schemas.RequestTaskUpdate{}
+	update.Title, _ = input["title"].(string)
+	update.Description, _ = input["description"].(string)
+	update.DueDate, _ = input["dueDate"].(string)
+	update.Completed, _ = input["completed"].(bool)
+	if err := update.Valid(); err != nil {
+		return nil, userError(err.Error())
+	}
+	updateDTO := update.ToDTO()
+	updateDTO.Actor = requestActor(p.Context)
+
+	rTaskDTO, err := h.service.Task.UpdateById(p.Args["id"].(int), updateDTO)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+	rTask := schemas.ResponseTaskRead{}
+	rTask.ScanDTO(rTaskDTO)
+	return rTask, nil
+}
+
+func (h *Handler) resolveDeleteTask(p graphql.ResolveParams) (interface{}, error) {
+	if err := h.service.Task.DeleteById(p.Args["id"].(int), requestActor(p.Context)); err != nil {
+		return nil, serviceError(err)
+	}
+	return true, nil
+}
+
+// newLoaders returns the batch loaders of one request. Values are cached
+// for the request only, so a mutation is never hidden by an earlier read of
+// another request.
+func (h *Handler) newLoaders() *loaders {
+	return &loaders{
+		history: newBatchLoader(func(taskIds []int) (map[int][]schemas.ResponseTaskEvent, error) {
+			histories, err := h.service.TaskEvent.HistoryByTasks(taskIds)
+			if err != nil {
+				return nil, internalError(err)
+			}
+			rHistories := make(map[int][]schemas.ResponseTaskEvent, len(taskIds))
+			for _, taskId := range taskIds {
+				rHistories[taskId] = make([]schemas.ResponseTaskEvent, len(histories[taskId]))
+				for i := range histories[taskId] {
+					rHistories[taskId][i].ScanDTO(&histories[taskId][i])
+				}
+			}
+			return rHistories, nil
+		}),
+		reminders: newBatchLoader(func(taskIds []int) (map[int][]schemas.ResponseReminder, error) {
+			reminders, err := h.service.Reminder.ListByTasks(taskIds)
+			if err != nil {
+				return nil, internalError(err)
+			}
+			rReminders := make(map[int][]schemas.ResponseReminder, len(taskIds))
+			for _, taskId := range taskIds {
+				rReminders[taskId] = make([]schemas.ResponseReminder, len(reminders[taskId]))
+				for i := range reminders[taskId] {
+					rReminders[taskId][i].ScanDTO(&reminders[taskId][i])
+				}
+			}
+			return rReminders, nil
+		}),
+	}
+}
+
+type loaders struct {
+	history   *batchLoader[[]schemas.ResponseTaskEvent]
+	reminders *batchLoader[[]schemas.ResponseReminder]
+}
+
+// Errors carry a code in their extensions so clients needn't parse messages.
+
+type codedError struct {
+	code    string
+	message string
+}
+
+func (e *codedError) Error() string {
+	return e.message
+}
+
+func (e *codedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+func userError(message string) error {
+	return &codedError{code: "BAD_USER_INPUT", message: message}
+}
+
+func internalError(err error) error {
+	return &codedError{code: "INTERNAL_SERVER_ERROR", message: err.Error()}
+}
+
+// serviceError maps an error of the task service the way the REST handlers
+// map it to a status.
+func serviceError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &codedError{code: "NOT_FOUND", message: err.Error()}
+	}
+	return internalError(err)
+}
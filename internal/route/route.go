@@ -1,30 +1,89 @@
 package route
 
 import (
+	"ToDoVerba/docs"
+	"ToDoVerba/internal/route/api/openapi"
 	v1 "ToDoVerba/internal/route/api/v1"
+	v2 "ToDoVerba/internal/route/api/v2"
+	"ToDoVerba/internal/route/gql"
 	"ToDoVerba/internal/service"
 	"ToDoVerba/pkg/logging"
+	"ToDoVerba/pkg/metrics"
 	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strings"
 )
 
 type Handler struct {
-	services service.Services //TODO
-	logger   logging.Logger
+	services      service.Services //TODO
+	logger        logging.Logger
+	graphql       gql.Options
+	v1Deprecation v1.Deprecation
+	validation    openapi.Mode
+
+	hv1 *v1.Handler
+	hv2 *v2.Handler
 }
 
 type Deps struct {
-	Services service.Services //TODO
-	Logger   logging.Logger
+	Services      service.Services //TODO
+	Logger        logging.Logger
+	GraphQL       gql.Options
+	V1Deprecation v1.Deprecation
+	Validation    openapi.Mode
 }
 
 func NewHandler(d Deps) *Handler {
-	return &Handler{services: d.Services, logger: d.Logger}
+	return &Handler{
+		services:      d.Services,
+		logger:        d.Logger,
+		graphql:       d.GraphQL,
+		v1Deprecation: d.V1Deprecation,
+		validation:    d.Validation,
+	}
 }
 
 func (h *Handler) Init(r *httprouter.Router) {
-	hv1 := v1.NewHandler(v1.Deps{
+	h.hv1 = v1.NewHandler(v1.Deps{
+		Service:     h.services,
+		Logger:      h.logger,
+		Deprecation: h.v1Deprecation,
+	})
+	h.hv1.Init(r)
+
+	h.hv2 = v2.NewHandler(v2.Deps{
 		Service: h.services,
 		Logger:  h.logger,
 	})
-	hv1.Init(r)
+	h.hv2.Init(r)
+
+	hgql := gql.NewHandler(gql.Deps{
+		Services: h.services,
+		Logger:   h.logger,
+		Options:  h.graphql,
+	})
+	hgql.Init(r)
+
+	r.Handler(http.MethodGet, "/metrics", metrics.Handler())
+}
+
+// Validate wraps next, the router Init filled, with the validation against
+// the served OpenAPI document when it is on.
+func (h *Handler) Validate(next http.Handler) (http.Handler, error) {
+	if h.validation == openapi.ModeOff {
+		return next, nil
+	}
+	v, err := openapi.NewValidator([]byte(docs.SwaggerInfo.ReadDoc()))
+	if err != nil {
+		return nil, err
+	}
+	return v.Handler(h.validation, h.logger, h.writeValidationErr, next), nil
+}
+
+func (h *Handler) writeValidationErr(w http.ResponseWriter, r *http.Request, code int, err error) {
+	if strings.HasPrefix(r.URL.Path, v2.BasePath+"/") {
+		h.hv2.WriteError(w, code, err)
+		return
+	}
+	h.hv1.WriteError(w, code, err)
 }
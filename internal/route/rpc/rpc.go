@@ -0,0 +1,70 @@
+// Package rpc serves the task api over gRPC next to the REST handlers of
+// internal/route/api/v1. The protocol is defined in proto/todoverba/v1.
+package rpc
+
+//go:generate protoc -I ../../../proto --go_out=../../../pkg/pb --go_opt=paths=source_relative --go-grpc_out=../../../pkg/pb --go-grpc_opt=paths=source_relative todoverba/v1/task.proto
+
+import (
+	"ToDoVerba/internal/service"
+	"ToDoVerba/pkg/logging"
+	todoverbav1 "ToDoVerba/pkg/pb/todoverba/v1"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// actorMetadata names the caller recorded in the task audit trail, like the
+// X-Actor header of the REST api. It is advisory too: the service has no
+// authentication and nothing checks the name.
+const actorMetadata = "x-actor"
+
+type Deps struct {
+	Services service.Services
+	Logger   logging.Logger
+}
+
+// NewServer returns a gRPC server with the TaskService, the standard health
+// service and server reflection registered.
+func NewServer(d Deps, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	todoverbav1.RegisterTaskServiceServer(s, &taskServer{
+		service: d.Services,
+		logger:  d.Logger,
+	})
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(todoverbav1.TaskService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer)
+
+	reflection.Register(s)
+	return s
+}
+
+func requestActor(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(actorMetadata); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// toStatus maps a service error to its gRPC status the way the REST handlers
+// map it to an http status.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
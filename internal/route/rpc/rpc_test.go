@@ -0,0 +1,327 @@
+package rpc
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/service"
+	"ToDoVerba/internal/service/brokerService"
+	mockservice "ToDoVerba/internal/service/mocks"
+	"ToDoVerba/pkg/logging"
+	todoverbav1 "ToDoVerba/pkg/pb/todoverba/v1"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// dial serves the services over an in-memory listener and returns a client
+// connection to it.
+func dial(t *testing.T, services service.Services) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	server := NewServer(Deps{Services: services, Logger: logging.GetLoggerTest()})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func testTask(id int) *dto.TaskRead {
+	due := time.Date(2024, 9, 10, 12, 0, 0, 0, time.UTC)
+	return &dto.TaskRead{
+		Id:          id,
+		Title:       "title",
+		Description: "description",
+		DueDate:     pgtype.Timestamptz{Time: due, Valid: true},
+		CreatedAt:   pgtype.Timestamptz{Time: due.Add(-time.Hour), Valid: true},
+		UpdatedAt:   pgtype.Timestamptz{Time: due.Add(-time.Hour), Valid: true},
+		Version:     1,
+	}
+}
+
+func TestTaskServer_Create(t *testing.T) {
+	due := time.Date(2024, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name          string
+		input         *todoverbav1.CreateTaskRequest
+		mockBehaviour func(s *mockservice.MockITaskService)
+		expectedCode  codes.Code
+	}{
+		{
+			name: "ok",
+			input: &todoverbav1.CreateTaskRequest{
+				Title:       "title",
+				Description: "description",
+				DueDate:     timestamppb.New(due),
+			},
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().Create(&dto.TaskCreate{
+					Title:       "title",
+					Description: "description",
+					DueDate:     pgtype.Timestamptz{Time: due, Valid: true},
+					Actor:       "alice",
+				}).Return(testTask(1), nil)
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name:          "missing fields",
+			input:         &todoverbav1.CreateTaskRequest{Title: "title"},
+			mockBehaviour: func(s *mockservice.MockITaskService) {},
+			expectedCode:  codes.InvalidArgument,
+		},
+		{
+			name: "unknown error",
+			input: &todoverbav1.CreateTaskRequest{
+				Title:       "title",
+				Description: "description",
+				DueDate:     timestamppb.New(due),
+			},
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().Create(gomock.Any()).Return(nil, errors.New("unknown error"))
+			},
+			expectedCode: codes.Internal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tasks := mockservice.NewMockITaskService(c)
+			testCase.mockBehaviour(tasks)
+			client := todoverbav1.NewTaskServiceClient(dial(t, service.Services{Task: tasks}))
+
+			ctx := metadata.AppendToOutgoingContext(context.Background(), actorMetadata, "alice")
+			task, err := client.Create(ctx, testCase.input)
+			assert.Equal(t, testCase.expectedCode, status.Code(err))
+			if testCase.expectedCode == codes.OK {
+				assert.Equal(t, int64(1), task.GetId())
+				assert.True(t, due.Equal(task.GetDueDate().AsTime()))
+			}
+		})
+	}
+}
+
+func TestTaskServer_Get(t *testing.T) {
+	testTable := []struct {
+		name          string
+		id            int64
+		mockBehaviour func(s *mockservice.MockITaskService)
+		expectedCode  codes.Code
+	}{
+		{
+			name: "ok",
+			id:   1,
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().FindByID(1).Return(testTask(1), nil)
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name: "not found",
+			id:   2,
+			mockBehaviour: func(s *mockservice.MockITaskService) {
+				s.EXPECT().FindByID(2).Return(nil, pgx.ErrNoRows)
+			},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:          "invalid id",
+			id:            0,
+			mockBehaviour: func(s *mockservice.MockITaskService) {},
+			expectedCode:  codes.InvalidArgument,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tasks := mockservice.NewMockITaskService(c)
+			testCase.mockBehaviour(tasks)
+			client := todoverbav1.NewTaskServiceClient(dial(t, service.Services{Task: tasks}))
+
+			_, err := client.Get(context.Background(), &todoverbav1.GetTaskRequest{Id: testCase.id})
+			assert.Equal(t, testCase.expectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestTaskServer_List(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	done := testTask(2)
+	done.Completed = true
+	tasks := mockservice.NewMockITaskService(c)
+	tasks.EXPECT().Export(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, each func(task *dto.TaskRead) error) error {
+			for _, task := range []*dto.TaskRead{testTask(1), done, testTask(3)} {
+				if err := each(task); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	client := todoverbav1.NewTaskServiceClient(dial(t, service.Services{Task: tasks}))
+
+	completed := false
+	stream, err := client.List(context.Background(), &todoverbav1.ListTasksRequest{Completed: &completed})
+	require.NoError(t, err)
+
+	var ids []int64
+	for {
+		task, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		ids = append(ids, task.GetId())
+	}
+	assert.Equal(t, []int64{1, 3}, ids)
+}
+
+func TestTaskServer_Update(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	due := time.Date(2024, 9, 10, 12, 0, 0, 0, time.UTC)
+	tasks := mockservice.NewMockITaskService(c)
+	tasks.EXPECT().UpdateById(1, &dto.TaskUpdate{
+		Title:       "new title",
+		Description: "new description",
+		DueDate:     pgtype.Timestamptz{Time: due, Valid: true},
+		Completed:   true,
+	}).Return(testTask(1), nil)
+	tasks.EXPECT().UpdateById(2, gomock.Any()).Return(nil, pgx.ErrNoRows)
+	client := todoverbav1.NewTaskServiceClient(dial(t, service.Services{Task: tasks}))
+
+	req := &todoverbav1.UpdateTaskRequest{
+		Id:          1,
+		Title:       "new title",
+		Description: "new description",
+		DueDate:     timestamppb.New(due),
+		Completed:   true,
+	}
+	_, err := client.Update(context.Background(), req)
+	assert.NoError(t, err)
+
+	req.Id = 2
+	_, err = client.Update(context.Background(), req)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestTaskServer_Delete(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tasks := mockservice.NewMockITaskService(c)
+	tasks.EXPECT().DeleteById(1, "alice").Return(nil)
+	tasks.EXPECT().DeleteById(2, "").Return(pgx.ErrNoRows)
+	client := todoverbav1.NewTaskServiceClient(dial(t, service.Services{Task: tasks}))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), actorMetadata, "alice")
+	_, err := client.Delete(ctx, &todoverbav1.DeleteTaskRequest{Id: 1})
+	assert.NoError(t, err)
+
+	_, err = client.Delete(context.Background(), &todoverbav1.DeleteTaskRequest{Id: 2})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestTaskServer_Watch(t *testing.T) {
+	broker := brokerService.NewBrokerService(brokerService.Deps{
+		Logger:     logging.GetLoggerTest(),
+		ReplaySize: 10,
+		BufferSize: 10,
+	})
+	client := todoverbav1.NewTaskServiceClient(dial(t, service.Services{Broker: broker}))
+
+	sub, _ := broker.Subscribe("", nil)
+	broker.Publish(&dto.Event{Id: "e1", Type: dto.EventTaskCreated, TaskId: 1, Actor: "alice"})
+	first := <-sub.C
+	broker.Unsubscribe(sub)
+	broker.Publish(&dto.Event{Id: "e2", Type: dto.EventTaskUpdated, TaskId: 1, Actor: "bob"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.Watch(ctx, &todoverbav1.WatchTasksRequest{LastEventId: first.Id, Actor: "bob"})
+	require.NoError(t, err)
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "e2", event.GetEventId())
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		broker.Publish(&dto.Event{Id: "e3", Type: dto.EventTaskDeleted, TaskId: 1, Actor: "alice"})
+		broker.Publish(&dto.Event{Id: "e4", Type: dto.EventTaskCompleted, TaskId: 1, Actor: "bob"})
+	}()
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "e4", event.GetEventId())
+	assert.Equal(t, dto.EventTaskCompleted, event.GetType())
+}
+
+func TestTaskServer_Watch_invalidFilter(t *testing.T) {
+	client := todoverbav1.NewTaskServiceClient(dial(t, service.Services{}))
+
+	stream, err := client.Watch(context.Background(), &todoverbav1.WatchTasksRequest{Types: []string{"task.archived"}})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_health(t *testing.T) {
+	conn := dial(t, service.Services{})
+
+	for _, name := range []string{"", todoverbav1.TaskService_ServiceDesc.ServiceName} {
+		resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: name})
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	}
+}
+
+func TestServer_reflection(t *testing.T) {
+	conn := dial(t, service.Services{})
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+
+	var names []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		names = append(names, s.GetName())
+	}
+	assert.Contains(t, names, todoverbav1.TaskService_ServiceDesc.ServiceName)
+	assert.Contains(t, names, "grpc.health.v1.Health")
+}
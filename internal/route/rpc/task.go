@@ -0,0 +1,216 @@
+package rpc
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/schemas"
+	"ToDoVerba/internal/service"
+	"ToDoVerba/pkg/logging"
+	todoverbav1 "ToDoVerba/pkg/pb/todoverba/v1"
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"strconv"
+	"time"
+)
+
+// taskServer delegates to the task service and validates requests with the
+// schemas of the REST api, so both accept the same tasks.
+type taskServer struct {
+	todoverbav1.UnimplementedTaskServiceServer
+
+	service service.Services
+	logger  logging.Logger
+}
+
+func (s *taskServer) Create(ctx context.Context, req *todoverbav1.CreateTaskRequest) (*todoverbav1.Task, error) {
+	s.logger.Debug("rpc Create called")
+
+	cTask := schemas.RequestTaskCreate{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		DueDate:     formatTimestamp(req.GetDueDate()),
+		Completed:   req.GetCompleted(),
+	}
+	if err := cTask.Valid(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	cTaskDTO := cTask.ToDTO()
+	cTaskDTO.Actor = requestActor(ctx)
+
+	rTask, err := s.service.Task.Create(cTaskDTO)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProtoTask(rTask), nil
+}
+
+func (s *taskServer) Get(ctx context.Context, req *todoverbav1.GetTaskRequest) (*todoverbav1.Task, error) {
+	s.logger.Debugf("rpc Get called for task %d", req.GetId())
+
+	id, err := taskId(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	rTask, err := s.service.Task.FindByID(id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProtoTask(rTask), nil
+}
+
+func (s *taskServer) List(req *todoverbav1.ListTasksRequest, stream todoverbav1.TaskService_ListServer) error {
+	s.logger.Debug("rpc List called")
+
+	filter := schemas.RequestTaskFilter{
+		DueAfter:  formatTimestamp(req.GetDueAfter()),
+		DueBefore: formatTimestamp(req.GetDueBefore()),
+	}
+	if req.Completed != nil {
+		filter.Completed = strconv.FormatBool(req.GetCompleted())
+	}
+	match := filter.Matcher()
+
+	err := s.service.Task.Export(stream.Context(), func(task *dto.TaskRead) error {
+		if !match(task) {
+			return nil
+		}
+		return stream.Send(toProtoTask(task))
+	})
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return toStatus(err)
+	}
+	return nil
+}
+
+func (s *taskServer) Update(ctx context.Context, req *todoverbav1.UpdateTaskRequest) (*todoverbav1.Task, error) {
+	s.logger.Debugf("rpc Update called for task %d", req.GetId())
+
+	id, err := taskId(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	update := schemas.RequestTaskUpdate{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		DueDate:     formatTimestamp(req.GetDueDate()),
+		Completed:   req.GetCompleted(),
+	}
+	if err = update.Valid(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	updateDTO := update.ToDTO()
+	updateDTO.Actor = requestActor(ctx)
+
+	rTask, err := s.service.Task.UpdateById(id, updateDTO)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProtoTask(rTask), nil
+}
+
+func (s *taskServer) Delete(ctx context.Context, req *todoverbav1.DeleteTaskRequest) (*emptypb.Empty, error) {
+	s.logger.Debugf("rpc Delete called for task %d", req.GetId())
+
+	id, err := taskId(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if err = s.service.Task.DeleteById(id, requestActor(ctx)); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *taskServer) Watch(req *todoverbav1.WatchTasksRequest, stream todoverbav1.TaskService_WatchServer) error {
+	s.logger.Debug("rpc Watch called")
+
+	filter := schemas.RequestStreamFilter{
+		Actor: req.GetActor(),
+		Types: req.GetTypes(),
+	}
+	if req.GetTaskId() != 0 {
+		filter.TaskId = strconv.FormatInt(req.GetTaskId(), 10)
+	}
+	if err := filter.Valid(); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	sub, replay := s.service.Broker.Subscribe(req.GetLastEventId(), filter.ToDTO())
+	defer s.service.Broker.Unsubscribe(sub)
+
+	for i := range replay {
+		if err := stream.Send(toProtoEvent(&replay[i])); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return toStatus(stream.Context().Err())
+		case msg, ok := <-sub.C:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "watcher fell behind, watch again from the last event id")
+			}
+			if err := stream.Send(toProtoEvent(&msg)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func taskId(id int64) (int, error) {
+	if id <= 0 {
+		return 0, status.Error(codes.InvalidArgument, "id must be a positive integer")
+	}
+	return int(id), nil
+}
+
+// formatTimestamp returns ts in the RFC3339 format the schemas validate, or
+// an empty string for a missing one.
+func formatTimestamp(ts *timestamppb.Timestamp) string {
+	if ts == nil {
+		return ""
+	}
+	return ts.AsTime().Format(time.RFC3339)
+}
+
+func toProtoTask(task *dto.TaskRead) *todoverbav1.Task {
+	t := &todoverbav1.Task{
+		Id:          int64(task.Id),
+		Title:       task.Title,
+		Description: task.Description,
+		Completed:   task.Completed,
+		Version:     int32(task.Version),
+	}
+	if task.DueDate.Valid {
+		t.DueDate = timestamppb.New(task.DueDate.Time)
+	}
+	if task.CreatedAt.Valid {
+		t.CreatedAt = timestamppb.New(task.CreatedAt.Time)
+	}
+	if task.UpdatedAt.Valid {
+		t.UpdatedAt = timestamppb.New(task.UpdatedAt.Time)
+	}
+	return t
+}
+
+func toProtoEvent(msg *dto.StreamMessage) *todoverbav1.TaskEvent {
+	e := &todoverbav1.TaskEvent{
+		Id:         msg.Id,
+		EventId:    msg.Event.Id,
+		Type:       msg.Event.Type,
+		TaskId:     int64(msg.Event.TaskId),
+		Actor:      msg.Event.Actor,
+		OccurredAt: timestamppb.New(msg.Event.OccurredAt),
+	}
+	if msg.Event.Task != nil {
+		e.Task = toProtoTask(msg.Event.Task)
+	}
+	return e
+}
@@ -0,0 +1,642 @@
+package schemas
+
+import (
+	"ToDoVerba/internal/dto"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgtype"
+	"io"
+	"net/url"
+	"time"
+)
+
+// Line types of a backup.
+const (
+	backupHeader       = "header"
+	backupTask         = "task"
+	backupTaskEvent    = "task_event"
+	backupTaskVersion  = "task_version"
+	backupReminder     = "reminder"
+	backupCalDAVObject = "caldav_object"
+	backupFooter       = "footer"
+)
+
+// BackupLine is one line of a backup, Data holds the record of Type.
+type BackupLine struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type BackupHeader struct {
+	Format    string `json:"format"`
+	Version   int    `json:"version"`
+	CreatedAt string `json:"created_at"`
+}
+
+func (h *BackupHeader) Valid() error {
+	if h.Format != dto.BackupFormat {
+		return fmt.Errorf("not a %s file", dto.BackupFormat)
+	}
+	if h.Version != dto.BackupVersion {
+		return fmt.Errorf("backup version %d is not supported, expected %d", h.Version, dto.BackupVersion)
+	}
+	return nil
+}
+
+type BackupTask struct {
+	Id          int    `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	DueDate     string `json:"due_date"`
+	Completed   bool   `json:"completed"`
+	Version     int    `json:"version"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+func (t *BackupTask) ScanDTO(task *dto.TaskRead) {
+	t.Id = task.Id
+	t.Title = task.Title
+	t.Description = task.Description
+	t.DueDate = formatBackupTime(task.DueDate)
+	t.Completed = task.Completed
+	t.Version = task.Version
+	t.CreatedAt = formatBackupTime(task.CreatedAt)
+	t.UpdatedAt = formatBackupTime(task.UpdatedAt)
+}
+
+func (t *BackupTask) Valid() error {
+	errStr := ""
+	if t.Id <= 0 {
+		errStr += "id must be positive;"
+	}
+	if t.Title == "" {
+		errStr += "title is required;"
+	}
+	if t.Version <= 0 {
+		errStr += "version must be positive;"
+	}
+	errStr += validBackupTimes(map[string]string{"due_date": t.DueDate, "created_at": t.CreatedAt, "updated_at": t.UpdatedAt})
+	if len(errStr) > 0 {
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+func (t *BackupTask) ToDTO() *dto.TaskRead {
+	return &dto.TaskRead{
+		Id:          t.Id,
+		Title:       t.Title,
+		Description: t.Description,
+		DueDate:     parseBackupTime(t.DueDate),
+		Completed:   t.Completed,
+		CreatedAt:   parseBackupTime(t.CreatedAt),
+		UpdatedAt:   parseBackupTime(t.UpdatedAt),
+		Version:     t.Version,
+	}
+}
+
+type BackupTaskEvent struct {
+	Id        int64                          `json:"id"`
+	TaskId    int                            `json:"task_id"`
+	Action    string                         `json:"action"`
+	Actor     string                         `json:"actor"`
+	Changes   map[string]ResponseFieldChange `json:"changes"`
+	CreatedAt string                         `json:"created_at"`
+}
+
+func (e *BackupTaskEvent) ScanDTO(event *dto.TaskEvent) {
+	e.Id = event.Id
+	e.TaskId = event.TaskId
+	e.Action = event.Action
+	e.Actor = event.Actor
+	e.Changes = make(map[string]ResponseFieldChange, len(event.Changes))
+	for name, change := range event.Changes {
+		e.Changes[name] = ResponseFieldChange{Before: change.Before, After: change.After}
+	}
+	e.CreatedAt = formatBackupTime(event.CreatedAt)
+}
+
+func (e *BackupTaskEvent) Valid() error {
+	errStr := ""
+	if e.TaskId <= 0 {
+		errStr += "task_id must be positive;"
+	}
+	switch e.Action {
+	case dto.TaskEventCreate, dto.TaskEventUpdate, dto.TaskEventDelete:
+	default:
+		errStr += "action must be create, update or delete;"
+	}
+	errStr += validBackupTimes(map[string]string{"created_at": e.CreatedAt})
+	if len(errStr) > 0 {
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+func (e *BackupTaskEvent) ToDTO() *dto.TaskEvent {
+	changes := make(map[string]dto.FieldChange, len(e.Changes))
+	for name, change := range e.Changes {
+		changes[name] = dto.FieldChange{Before: change.Before, After: change.After}
+	}
+	return &dto.TaskEvent{
+		Id:        e.Id,
+		TaskId:    e.TaskId,
+		Action:    e.Action,
+		Actor:     e.Actor,
+		Changes:   changes,
+		CreatedAt: parseBackupTime(e.CreatedAt),
+	}
+}
+
+type BackupTaskVersion struct {
+	TaskId      int    `json:"task_id"`
+	Version     int    `json:"version"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	DueDate     string `json:"due_date"`
+	Completed   bool   `json:"completed"`
+	Actor       string `json:"actor"`
+	CreatedAt   string `json:"created_at"`
+}
+
+func (v *BackupTaskVersion) ScanDTO(version *dto.TaskVersion) {
+	v.TaskId = version.TaskId
+	v.Version = version.Version
+	v.Title = version.Title
+	v.Description = version.Description
+	v.DueDate = formatBackupTime(version.DueDate)
+	v.Completed = version.Completed
+	v.Actor = version.Actor
+	v.CreatedAt = formatBackupTime(version.CreatedAt)
+}
+
+func (v *BackupTaskVersion) Valid() error {
+	errStr := ""
+	if v.TaskId <= 0 {
+		errStr += "task_id must be positive;"
+	}
+	if v.Version <= 0 {
+		errStr += "version must be positive;"
+	}
+	errStr += validBackupTimes(map[string]string{"due_date": v.DueDate, "created_at": v.CreatedAt})
+	if len(errStr) > 0 {
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+func (v *BackupTaskVersion) ToDTO() *dto.TaskVersion {
+	return &dto.TaskVersion{
+		TaskId:      v.TaskId,
+		Version:     v.Version,
+		Title:       v.Title,
+		Description: v.Description,
+		DueDate:     parseBackupTime(v.DueDate),
+		Completed:   v.Completed,
+		Actor:       v.Actor,
+		CreatedAt:   parseBackupTime(v.CreatedAt),
+	}
+}
+
+// BackupReminder keeps the offset in seconds, so it round trips exactly.
+type BackupReminder struct {
+	Id            int64    `json:"id"`
+	TaskId        int      `json:"task_id"`
+	OffsetSeconds int      `json:"offset_seconds"`
+	Channels      []string `json:"channels"`
+	SentChannels  []string `json:"sent_channels"`
+	FireAt        string   `json:"fire_at"`
+	Status        string   `json:"status"`
+	Generation    int      `json:"generation"`
+	Attempts      int      `json:"attempts"`
+	LastError     string   `json:"last_error"`
+	SentAt        string   `json:"sent_at"`
+	CreatedAt     string   `json:"created_at"`
+	UpdatedAt     string   `json:"updated_at"`
+}
+
+func (r *BackupReminder) ScanDTO(reminder *dto.Reminder) {
+	r.Id = reminder.Id
+	r.TaskId = reminder.TaskId
+	r.OffsetSeconds = int(reminder.Offset / time.Second)
+	r.Channels = reminder.Channels
+	r.SentChannels = reminder.SentChannels
+	r.FireAt = formatBackupTime(reminder.FireAt)
+	r.Status = reminder.Status
+	r.Generation = reminder.Generation
+	r.Attempts = reminder.Attempts
+	r.LastError = reminder.LastError
+	r.SentAt = formatBackupTime(reminder.SentAt)
+	r.CreatedAt = formatBackupTime(reminder.CreatedAt)
+	r.UpdatedAt = formatBackupTime(reminder.UpdatedAt)
+}
+
+func (r *BackupReminder) Valid() error {
+	errStr := ""
+	if r.TaskId <= 0 {
+		errStr += "task_id must be positive;"
+	}
+	if r.OffsetSeconds < 0 {
+		errStr += "offset_seconds must not be negative;"
+	}
+	for _, channels := range [][]string{r.Channels, r.SentChannels} {
+		for _, channel := range channels {
+			if !validReminderChannel(channel) {
+				errStr += fmt.Sprintf("unknown channel %q;", channel)
+			}
+		}
+	}
+	switch r.Status {
+	case dto.ReminderPending, dto.ReminderSent, dto.ReminderFailed:
+	default:
+		errStr += "status must be pending, sent or failed;"
+	}
+	if r.Generation <= 0 {
+		errStr += "generation must be positive;"
+	}
+	times := map[string]string{"fire_at": r.FireAt, "created_at": r.CreatedAt, "updated_at": r.UpdatedAt}
+	if r.SentAt != "" {
+		times["sent_at"] = r.SentAt
+	}
+	errStr += validBackupTimes(times)
+	if len(errStr) > 0 {
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+func (r *BackupReminder) ToDTO() *dto.Reminder {
+	reminder := &dto.Reminder{
+		Id:           r.Id,
+		TaskId:       r.TaskId,
+		Offset:       time.Duration(r.OffsetSeconds) * time.Second,
+		Channels:     r.Channels,
+		SentChannels: r.SentChannels,
+		FireAt:       parseBackupTime(r.FireAt),
+		Status:       r.Status,
+		Generation:   r.Generation,
+		Attempts:     r.Attempts,
+		LastError:    r.LastError,
+		SentAt:       parseBackupTime(r.SentAt),
+		CreatedAt:    parseBackupTime(r.CreatedAt),
+		UpdatedAt:    parseBackupTime(r.UpdatedAt),
+	}
+	// The columns are NOT NULL, an empty list must not be stored as NULL.
+	if reminder.Channels == nil {
+		reminder.Channels = []string{}
+	}
+	if reminder.SentChannels == nil {
+		reminder.SentChannels = []string{}
+	}
+	return reminder
+}
+
+type BackupCalDAVObject struct {
+	TaskId int    `json:"task_id"`
+	Href   string `json:"href"`
+	Uid    string `json:"uid"`
+}
+
+func (o *BackupCalDAVObject) ScanDTO(object *dto.CalDAVObject) {
+	o.TaskId = object.TaskId
+	o.Href = object.Href
+	o.Uid = object.Uid
+}
+
+func (o *BackupCalDAVObject) Valid() error {
+	errStr := ""
+	if o.TaskId <= 0 {
+		errStr += "task_id must be positive;"
+	}
+	if o.Href == "" {
+		errStr += "href is required;"
+	}
+	if o.Uid == "" {
+		errStr += "uid is required;"
+	}
+	if len(errStr) > 0 {
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+func (o *BackupCalDAVObject) ToDTO() *dto.CalDAVObject {
+	return &dto.CalDAVObject{TaskId: o.TaskId, Href: o.Href, Uid: o.Uid}
+}
+
+// ResponseBackupStats counts records. It is the footer of a backup and the
+// result of a restore.
+type ResponseBackupStats struct {
+	Tasks         int `json:"tasks"`
+	TaskEvents    int `json:"task_events"`
+	TaskVersions  int `json:"task_versions"`
+	Reminders     int `json:"reminders"`
+	CalDAVObjects int `json:"caldav_objects"`
+}
+
+func (s *ResponseBackupStats) ScanDTO(stats *dto.BackupStats) {
+	s.Tasks = stats.Tasks
+	s.TaskEvents = stats.TaskEvents
+	s.TaskVersions = stats.TaskVersions
+	s.Reminders = stats.Reminders
+	s.CalDAVObjects = stats.CalDAVObjects
+}
+
+func (s *ResponseBackupStats) ToDTO() *dto.BackupStats {
+	return &dto.BackupStats{
+		Tasks:         s.Tasks,
+		TaskEvents:    s.TaskEvents,
+		TaskVersions:  s.TaskVersions,
+		Reminders:     s.Reminders,
+		CalDAVObjects: s.CalDAVObjects,
+	}
+}
+
+type RequestRestore struct {
+	Mode string
+}
+
+func (r *RequestRestore) ScanQuery(q url.Values) {
+	r.Mode = q.Get("mode")
+	if r.Mode == "" {
+		r.Mode = dto.BackupModeMerge
+	}
+}
+
+func (r *RequestRestore) Valid() error {
+	if r.Mode != dto.BackupModeMerge && r.Mode != dto.BackupModeReplace {
+		return errors.New("mode must be merge or replace;")
+	}
+	return nil
+}
+
+// BackupWriter writes a backup as NDJSON: a header line naming format and
+// version, one line per record and a footer with the record counts that
+// tells a complete backup from a cut off one. The header is written with the
+// first record, so nothing is written before there is something to back up.
+type BackupWriter struct {
+	w       io.Writer
+	stats   dto.BackupStats
+	started bool
+}
+
+func NewBackupWriter(w io.Writer) *BackupWriter {
+	return &BackupWriter{w: w}
+}
+
+func (b *BackupWriter) Write(record *dto.BackupRecord) error {
+	if err := b.start(); err != nil {
+		return err
+	}
+
+	switch {
+	case record.Task != nil:
+		data := BackupTask{}
+		data.ScanDTO(record.Task)
+		b.stats.Tasks++
+		return b.line(backupTask, data)
+	case record.TaskEvent != nil:
+		data := BackupTaskEvent{}
+		data.ScanDTO(record.TaskEvent)
+		b.stats.TaskEvents++
+		return b.line(backupTaskEvent, data)
+	case record.TaskVersion != nil:
+		data := BackupTaskVersion{}
+		data.ScanDTO(record.TaskVersion)
+		b.stats.TaskVersions++
+		return b.line(backupTaskVersion, data)
+	case record.Reminder != nil:
+		data := BackupReminder{}
+		data.ScanDTO(record.Reminder)
+		b.stats.Reminders++
+		return b.line(backupReminder, data)
+	case record.CalDAVObject != nil:
+		data := BackupCalDAVObject{}
+		data.ScanDTO(record.CalDAVObject)
+		b.stats.CalDAVObjects++
+		return b.line(backupCalDAVObject, data)
+	}
+	return errors.New("empty backup record")
+}
+
+// Close writes the footer. A backup without it is rejected on restore.
+func (b *BackupWriter) Close() (*dto.BackupStats, error) {
+	if err := b.start(); err != nil {
+		return nil, err
+	}
+
+	footer := ResponseBackupStats{}
+	footer.ScanDTO(&b.stats)
+	if err := b.line(backupFooter, footer); err != nil {
+		return nil, err
+	}
+	return &b.stats, nil
+}
+
+func (b *BackupWriter) start() error {
+	if b.started {
+		return nil
+	}
+	b.started = true
+	return b.line(backupHeader, BackupHeader{
+		Format:    dto.BackupFormat,
+		Version:   dto.BackupVersion,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+func (b *BackupWriter) line(kind string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(BackupLine{Type: kind, Data: raw})
+	if err != nil {
+		return err
+	}
+	_, err = b.w.Write(append(line, '\n'))
+	return err
+}
+
+// BackupReader reads a backup written by BackupWriter. Errors name the line
+// they were found on.
+type BackupReader struct {
+	r     *bufio.Reader
+	line  int
+	stats dto.BackupStats
+	done  bool
+}
+
+func NewBackupReader(r io.Reader) *BackupReader {
+	return &BackupReader{r: bufio.NewReader(r)}
+}
+
+// ReadHeader reads the header and checks format and version. It must be
+// called before Next.
+func (b *BackupReader) ReadHeader() (*BackupHeader, error) {
+	line, err := b.next()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("backup is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if line.Type != backupHeader {
+		return nil, b.errorf("expected header, got %q", line.Type)
+	}
+
+	header := &BackupHeader{}
+	if err = json.Unmarshal(line.Data, header); err != nil {
+		return nil, b.errorf("%s", err)
+	}
+	if err = header.Valid(); err != nil {
+		return nil, b.errorf("%s", err)
+	}
+	return header, nil
+}
+
+// Next returns the next record, or io.EOF after a footer whose counts match
+// the records read.
+func (b *BackupReader) Next() (*dto.BackupRecord, error) {
+	if b.done {
+		return nil, io.EOF
+	}
+
+	line, err := b.next()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("backup is truncated, footer is missing")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	record := &dto.BackupRecord{}
+	switch line.Type {
+	case backupTask:
+		data := BackupTask{}
+		if err = b.decode(line.Data, &data, data.Valid); err == nil {
+			record.Task = data.ToDTO()
+			b.stats.Tasks++
+		}
+	case backupTaskEvent:
+		data := BackupTaskEvent{}
+		if err = b.decode(line.Data, &data, data.Valid); err == nil {
+			record.TaskEvent = data.ToDTO()
+			b.stats.TaskEvents++
+		}
+	case backupTaskVersion:
+		data := BackupTaskVersion{}
+		if err = b.decode(line.Data, &data, data.Valid); err == nil {
+			record.TaskVersion = data.ToDTO()
+			b.stats.TaskVersions++
+		}
+	case backupReminder:
+		data := BackupReminder{}
+		if err = b.decode(line.Data, &data, data.Valid); err == nil {
+			record.Reminder = data.ToDTO()
+			b.stats.Reminders++
+		}
+	case backupCalDAVObject:
+		data := BackupCalDAVObject{}
+		if err = b.decode(line.Data, &data, data.Valid); err == nil {
+			record.CalDAVObject = data.ToDTO()
+			b.stats.CalDAVObjects++
+		}
+	case backupFooter:
+		return nil, b.footer(line.Data)
+	default:
+		return nil, b.errorf("unknown record type %q", line.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (b *BackupReader) footer(data json.RawMessage) error {
+	footer := ResponseBackupStats{}
+	if err := json.Unmarshal(data, &footer); err != nil {
+		return b.errorf("%s", err)
+	}
+	if *footer.ToDTO() != b.stats {
+		return b.errorf("footer counts don't match the records read")
+	}
+	if _, err := b.next(); !errors.Is(err, io.EOF) {
+		return b.errorf("unexpected data after footer")
+	}
+	b.done = true
+	return io.EOF
+}
+
+// decode unmarshals data into v and validates it. valid is a method value
+// of v, so it sees the decoded fields.
+func (b *BackupReader) decode(data json.RawMessage, v any, valid func() error) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return b.errorf("%s", err)
+	}
+	if err := valid(); err != nil {
+		return b.errorf("%s", err)
+	}
+	return nil
+}
+
+// next returns the next non blank line.
+func (b *BackupReader) next() (*BackupLine, error) {
+	for {
+		raw, err := b.r.ReadBytes('\n')
+		if len(raw) == 0 && err != nil {
+			return nil, err
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		b.line++
+
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 {
+			continue
+		}
+		line := &BackupLine{}
+		if err = json.Unmarshal(raw, line); err != nil {
+			return nil, b.errorf("%s", err)
+		}
+		return line, nil
+	}
+}
+
+func (b *BackupReader) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: "+format, append([]any{b.line}, args...)...)
+}
+
+func formatBackupTime(t pgtype.Timestamptz) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.UTC().Format(time.RFC3339Nano)
+}
+
+func parseBackupTime(s string) pgtype.Timestamptz {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func validBackupTimes(times map[string]string) string {
+	errStr := ""
+	for _, name := range []string{"due_date", "fire_at", "sent_at", "created_at", "updated_at"} {
+		value, ok := times[name]
+		if !ok {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+			errStr += name + " must be in RFC3339 format;"
+		}
+	}
+	return errStr
+}
@@ -0,0 +1,354 @@
+package schemas
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/ical"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	NamespaceDAV          = "DAV:"
+	NamespaceCalDAV       = "urn:ietf:params:xml:ns:caldav"
+	NamespaceCalendarServ = "http://calendarserver.org/ns/"
+
+	TaskHrefPrefix = "task-"
+	TaskHrefSuffix = ".ics"
+
+	syncTokenPrefix = "urn:todoverba:sync:"
+)
+
+// ErrUnsupportedComponent is returned for calendar data without a VTODO.
+var ErrUnsupportedComponent = errors.New("calendar object must contain a single VTODO")
+
+// TaskHref is the resource name of a task created outside of CalDAV.
+func TaskHref(id int) string {
+	return TaskHrefPrefix + strconv.Itoa(id) + TaskHrefSuffix
+}
+
+func CalDAVSyncToken(token int64) string {
+	return syncTokenPrefix + strconv.FormatInt(token, 10)
+}
+
+// ParseCalDAVSyncToken returns 0 for an empty token, which asks for an
+// initial sync.
+func ParseCalDAVSyncToken(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	raw, ok := strings.CutPrefix(s, syncTokenPrefix)
+	if !ok {
+		return 0, fmt.Errorf("unknown sync token %q", s)
+	}
+	return strconv.ParseInt(raw, 10, 64)
+}
+
+// RequestCalDAVObject is the VTODO of a PUT request body.
+type RequestCalDAVObject struct {
+	Uid         string
+	Title       string
+	Description string
+	DueDate     time.Time
+	Completed   bool
+
+	errStr string
+}
+
+// ScanCalendar reads the single VTODO of cal. Problems with its properties
+// are reported by Valid.
+func (o *RequestCalDAVObject) ScanCalendar(cal *ical.Component) error {
+	todos := cal.Children("VTODO")
+	if cal.Name != "VCALENDAR" || len(todos) != 1 {
+		return ErrUnsupportedComponent
+	}
+	todo := todos[0]
+
+	if p, ok := todo.Get("UID"); ok {
+		o.Uid = p.Value
+	}
+	if p, ok := todo.Get("SUMMARY"); ok {
+		o.Title = ical.ParseText(p.Value)
+	}
+	if p, ok := todo.Get("DESCRIPTION"); ok {
+		o.Description = ical.ParseText(p.Value)
+	}
+	if p, ok := todo.Get("DUE"); ok {
+		due, err := ical.ParseTime(p)
+		if err != nil {
+			o.errStr += "DUE must be a DATE or DATE-TIME;"
+		}
+		o.DueDate = due
+	}
+	if p, ok := todo.Get("STATUS"); ok {
+		o.Completed = strings.EqualFold(p.Value, "COMPLETED")
+	} else if _, ok := todo.Get("COMPLETED"); ok {
+		o.Completed = true
+	}
+	return nil
+}
+
+func (o *RequestCalDAVObject) Valid() error {
+	errStr := o.errStr
+	if o.Uid == "" {
+		errStr += "UID is required;"
+	}
+	if len(errStr) > 0 {
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+// ToDTO leaves DueDate invalid when the VTODO has no DUE.
+func (o *RequestCalDAVObject) ToDTO() *dto.TaskUpdate {
+	update := &dto.TaskUpdate{
+		Title:       o.Title,
+		Description: o.Description,
+		Completed:   o.Completed,
+	}
+	if !o.DueDate.IsZero() {
+		update.DueDate.Time, update.DueDate.Valid = o.DueDate, true
+	}
+	return update
+}
+
+// ResourceToCalendar renders a resource as the VCALENDAR of its GET.
+func ResourceToCalendar(resource *dto.CalDAVResource) *ical.Component {
+	cal := NewCalendar("")
+	cal.Components = append(cal.Components, TaskToVTodo(&resource.Task).Set("UID", resource.Uid))
+	return cal
+}
+
+// DAVPropNames collects the names of the elements of a DAV:prop.
+type DAVPropNames []xml.Name
+
+func (n *DAVPropNames) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			*n = append(*n, t.Name)
+			if err = d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// RequestPropfind is a PROPFIND body. An empty body asks for allprop.
+type RequestPropfind struct {
+	XMLName  xml.Name     `xml:"DAV: propfind"`
+	AllProp  *struct{}    `xml:"DAV: allprop"`
+	PropName *struct{}    `xml:"DAV: propname"`
+	Prop     DAVPropNames `xml:"DAV: prop"`
+}
+
+// RequestReport is the body of a calendar-query, calendar-multiget or
+// sync-collection REPORT; XMLName tells them apart.
+type RequestReport struct {
+	XMLName   xml.Name
+	Prop      DAVPropNames      `xml:"DAV: prop"`
+	Hrefs     []string          `xml:"DAV: href"`
+	SyncToken string            `xml:"DAV: sync-token"`
+	Filter    *CalDAVCompFilter `xml:"urn:ietf:params:xml:ns:caldav filter>comp-filter"`
+}
+
+type CalDAVTimeRange struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+}
+
+type CalDAVTextMatch struct {
+	Value           string `xml:",chardata"`
+	NegateCondition string `xml:"negate-condition,attr"`
+}
+
+type CalDAVPropFilter struct {
+	Name         string           `xml:"name,attr"`
+	IsNotDefined *struct{}        `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TimeRange    *CalDAVTimeRange `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	TextMatch    *CalDAVTextMatch `xml:"urn:ietf:params:xml:ns:caldav text-match"`
+}
+
+type CalDAVCompFilter struct {
+	Name         string             `xml:"name,attr"`
+	IsNotDefined *struct{}          `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TimeRange    *CalDAVTimeRange   `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	CompFilters  []CalDAVCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	PropFilters  []CalDAVPropFilter `xml:"urn:ietf:params:xml:ns:caldav prop-filter"`
+}
+
+// Match reports whether c passes the filter. Time ranges of a component are
+// checked against the span of its DTSTART, DUE and COMPLETED, text matches
+// are case-insensitive substring matches.
+func (f *CalDAVCompFilter) Match(c *ical.Component) bool {
+	if !strings.EqualFold(f.Name, c.Name) {
+		return false
+	}
+	if f.TimeRange != nil && !f.TimeRange.overlaps(componentTimes(c)) {
+		return false
+	}
+	for i := range f.CompFilters {
+		cf := &f.CompFilters[i]
+		children := c.Children(cf.Name)
+		if cf.IsNotDefined != nil {
+			if len(children) > 0 {
+				return false
+			}
+			continue
+		}
+		matched := false
+		for _, child := range children {
+			if cf.Match(child) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for i := range f.PropFilters {
+		if !f.PropFilters[i].match(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *CalDAVPropFilter) match(c *ical.Component) bool {
+	var props []ical.Prop
+	for _, p := range c.Props {
+		if strings.EqualFold(p.Name, f.Name) {
+			props = append(props, p)
+		}
+	}
+	if f.IsNotDefined != nil {
+		return len(props) == 0
+	}
+	for _, p := range props {
+		if f.TimeRange != nil {
+			t, err := ical.ParseTime(p)
+			if err != nil || !f.TimeRange.overlaps([]time.Time{t}) {
+				continue
+			}
+		}
+		if f.TextMatch != nil {
+			contains := strings.Contains(strings.ToLower(ical.ParseText(p.Value)), strings.ToLower(f.TextMatch.Value))
+			if contains == (f.TextMatch.NegateCondition == "yes") {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func componentTimes(c *ical.Component) []time.Time {
+	var times []time.Time
+	for _, name := range []string{"DTSTART", "DUE", "COMPLETED"} {
+		if p, ok := c.Get(name); ok {
+			if t, err := ical.ParseTime(p); err == nil {
+				times = append(times, t)
+			}
+		}
+	}
+	return times
+}
+
+// overlaps reports whether the span of times intersects the range. A
+// component without times matches any range.
+func (r *CalDAVTimeRange) overlaps(times []time.Time) bool {
+	if len(times) == 0 {
+		return true
+	}
+	first, last := times[0], times[0]
+	for _, t := range times[1:] {
+		if t.Before(first) {
+			first = t
+		}
+		if t.After(last) {
+			last = t
+		}
+	}
+	if start, err := time.Parse(ical.DateTimeFormat, r.Start); err == nil && last.Before(start) {
+		return false
+	}
+	if end, err := time.Parse(ical.DateTimeFormat, r.End); err == nil && !first.Before(end) {
+		return false
+	}
+	return true
+}
+
+// ResponseMultistatus is a DAV:multistatus. Properties are rendered by the
+// handler, so a prop holds its children as raw XML.
+type ResponseMultistatus struct {
+	XMLName   xml.Name      `xml:"D:multistatus"`
+	XmlnsD    string        `xml:"xmlns:D,attr"`
+	XmlnsC    string        `xml:"xmlns:C,attr"`
+	XmlnsCS   string        `xml:"xmlns:CS,attr"`
+	Responses []ResponseDAV `xml:"D:response"`
+	SyncToken string        `xml:"D:sync-token,omitempty"`
+}
+
+type ResponseDAV struct {
+	Href      string             `xml:"D:href"`
+	Propstats []ResponsePropstat `xml:"D:propstat,omitempty"`
+	Status    string             `xml:"D:status,omitempty"`
+}
+
+type ResponsePropstat struct {
+	Prop   ResponseProp `xml:"D:prop"`
+	Status string       `xml:"D:status"`
+}
+
+type ResponseProp struct {
+	InnerXML string `xml:",innerxml"`
+}
+
+func NewMultistatus() *ResponseMultistatus {
+	return &ResponseMultistatus{
+		XmlnsD:  NamespaceDAV,
+		XmlnsC:  NamespaceCalDAV,
+		XmlnsCS: NamespaceCalendarServ,
+	}
+}
+
+// DAVElement renders a property element with inner as its raw content.
+func DAVElement(name xml.Name, inner string) string {
+	var tag string
+	switch name.Space {
+	case NamespaceDAV:
+		tag = "D:" + name.Local
+	case NamespaceCalDAV:
+		tag = "C:" + name.Local
+	case NamespaceCalendarServ:
+		tag = "CS:" + name.Local
+	default:
+		escaped := &strings.Builder{}
+		xml.EscapeText(escaped, []byte(name.Space))
+		if inner == "" {
+			return "<" + name.Local + ` xmlns="` + escaped.String() + `"/>`
+		}
+		return "<" + name.Local + ` xmlns="` + escaped.String() + `">` + inner + "</" + name.Local + ">"
+	}
+	if inner == "" {
+		return "<" + tag + "/>"
+	}
+	return "<" + tag + ">" + inner + "</" + tag + ">"
+}
+
+// DAVText escapes s as XML character data.
+func DAVText(s string) string {
+	b := &strings.Builder{}
+	xml.EscapeText(b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,109 @@
+package schemas
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/ical"
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+const (
+	CalendarVTodo  = "vtodo"
+	CalendarVEvent = "vevent"
+
+	calendarProdId = "-//ToDoVerba//Tasks//EN"
+	taskUIDSuffix  = "@todoverba"
+)
+
+// RequestCalendarFeed selects the component tasks are rendered as together
+// with the task filter of GET /tasks.
+type RequestCalendarFeed struct {
+	Token     string
+	Component string
+	Filter    RequestTaskFilter
+}
+
+func (f *RequestCalendarFeed) ScanQuery(q url.Values) {
+	f.Token = q.Get("token")
+	f.Component = q.Get("component")
+	if f.Component == "" {
+		f.Component = CalendarVTodo
+	}
+	f.Filter.ScanQuery(q)
+}
+
+func (f *RequestCalendarFeed) Valid() error {
+	errStr := ""
+	if f.Component != CalendarVTodo && f.Component != CalendarVEvent {
+		errStr += "component must be vtodo or vevent;"
+	}
+	if err := f.Filter.Valid(); err != nil {
+		errStr += err.Error()
+	}
+	if len(errStr) > 0 {
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+type ResponseCalendarToken struct {
+	Token string `json:"token"`
+	Url   string `json:"url"`
+}
+
+// TaskUID is the stable iCalendar UID of a task.
+func TaskUID(id int) string {
+	return "task-" + strconv.Itoa(id) + taskUIDSuffix
+}
+
+// NewCalendar returns an empty VCALENDAR.
+func NewCalendar(name string) *ical.Component {
+	cal := ical.NewComponent("VCALENDAR").
+		Add("VERSION", "2.0").
+		Add("PRODID", calendarProdId).
+		Add("CALSCALE", "GREGORIAN")
+	if name != "" {
+		cal.Add("X-WR-CALNAME", ical.Text(name))
+	}
+	return cal
+}
+
+// TaskToVTodo renders a task as VTODO. DTSTAMP is the last modification so
+// the output only changes with the task.
+func TaskToVTodo(task *dto.TaskRead) *ical.Component {
+	todo := ical.NewComponent("VTODO")
+	addTaskProps(todo, task)
+	todo.Add("DUE", ical.DateTime(task.DueDate.Time))
+	if task.Completed {
+		todo.Add("STATUS", "COMPLETED").
+			Add("COMPLETED", ical.DateTime(task.UpdatedAt.Time)).
+			Add("PERCENT-COMPLETE", "100")
+	} else {
+		todo.Add("STATUS", "NEEDS-ACTION")
+	}
+	return todo
+}
+
+// TaskToVEvent renders a task as an instant VEVENT at its due date, for
+// clients that do not show VTODO.
+func TaskToVEvent(task *dto.TaskRead) *ical.Component {
+	event := ical.NewComponent("VEVENT")
+	addTaskProps(event, task)
+	event.Add("DTSTART", ical.DateTime(task.DueDate.Time)).
+		Add("STATUS", "CONFIRMED").
+		Add("TRANSP", "TRANSPARENT")
+	return event
+}
+
+func addTaskProps(c *ical.Component, task *dto.TaskRead) {
+	c.Add("UID", TaskUID(task.Id)).
+		Add("DTSTAMP", ical.DateTime(task.UpdatedAt.Time)).
+		Add("CREATED", ical.DateTime(task.CreatedAt.Time)).
+		Add("LAST-MODIFIED", ical.DateTime(task.UpdatedAt.Time)).
+		Add("SEQUENCE", strconv.Itoa(max(task.Version-1, 0))).
+		Add("SUMMARY", ical.Text(task.Title))
+	if task.Description != "" {
+		c.Add("DESCRIPTION", ical.Text(task.Description))
+	}
+}
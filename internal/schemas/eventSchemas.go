@@ -0,0 +1,46 @@
+package schemas
+
+import (
+	"ToDoVerba/internal/dto"
+	"time"
+)
+
+var EventTypes = []string{
+	dto.EventTaskCreated,
+	dto.EventTaskUpdated,
+	dto.EventTaskCompleted,
+	dto.EventTaskDeleted,
+}
+
+func ValidEventType(eventType string) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type EventPayload struct {
+	Id          string            `json:"id"`
+	Type        string            `json:"type"`
+	TaskId      int               `json:"task_id"`
+	Actor       string            `json:"actor"`
+	Task        *ResponseTaskRead `json:"task"`
+	OrderingKey string            `json:"ordering_key"`
+	OccurredAt  string            `json:"occurred_at"`
+}
+
+func (p *EventPayload) ScanDTO(event *dto.Event) {
+	p.Id = event.Id
+	p.Type = event.Type
+	p.TaskId = event.TaskId
+	p.Actor = event.Actor
+	p.Task = nil
+	if event.Task != nil {
+		p.Task = &ResponseTaskRead{}
+		p.Task.ScanDTO(event.Task)
+	}
+	p.OrderingKey = event.OrderingKey
+	p.OccurredAt = event.OccurredAt.Format(time.RFC3339Nano)
+}
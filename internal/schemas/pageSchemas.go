@@ -0,0 +1,49 @@
+package schemas
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+// RequestPage is the limit and offset of a list page.
+type RequestPage struct {
+	Limit  string
+	Offset string
+}
+
+func (p *RequestPage) ScanQuery(q url.Values) {
+	p.Limit = q.Get("limit")
+	p.Offset = q.Get("offset")
+}
+
+func (p *RequestPage) Valid() error {
+	var errs []error
+	if p.Limit != "" {
+		if n, err := strconv.Atoi(p.Limit); err != nil || n < 1 || n > MaxPageLimit {
+			errs = append(errs, fmt.Errorf("limit must be a number from 1 to %d", MaxPageLimit))
+		}
+	}
+	if p.Offset != "" {
+		if n, err := strconv.Atoi(p.Offset); err != nil || n < 0 {
+			errs = append(errs, errors.New("offset must be a number from 0"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Bounds returns the limit, DefaultPageLimit when not given, and the offset.
+func (p *RequestPage) Bounds() (limit, offset int) {
+	limit, err := strconv.Atoi(p.Limit)
+	if err != nil {
+		limit = DefaultPageLimit
+	}
+	offset, _ = strconv.Atoi(p.Offset)
+	return limit, offset
+}
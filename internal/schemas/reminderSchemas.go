@@ -0,0 +1,131 @@
+package schemas
+
+import (
+	"ToDoVerba/internal/dto"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var ReminderChannels = []string{
+	dto.ReminderChannelEmail,
+	dto.ReminderChannelWebhook,
+	dto.ReminderChannelLog,
+}
+
+type RequestReminderCreate struct {
+	Offset   string   `json:"offset"`
+	Channels []string `json:"channels"`
+}
+
+func (t *RequestReminderCreate) ToDTO(taskId int) *dto.ReminderCreate {
+	offset, _ := time.ParseDuration(t.Offset)
+	return &dto.ReminderCreate{
+		TaskId:   taskId,
+		Offset:   offset,
+		Channels: t.Channels,
+	}
+}
+
+func (t *RequestReminderCreate) Valid() error {
+	errStr := ""
+	if offset, err := time.ParseDuration(t.Offset); err != nil || offset < 0 {
+		errStr += "offset is required and must be a non-negative duration such as 15m or 24h;"
+	}
+	for _, channel := range t.Channels {
+		if !validReminderChannel(channel) {
+			errStr += "channels must be email, webhook or log;"
+			break
+		}
+	}
+	if len(errStr) > 0 {
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+func validReminderChannel(channel string) bool {
+	for _, c := range ReminderChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+type RequestReminderSnooze struct {
+	For string `json:"for"`
+}
+
+func (t *RequestReminderSnooze) Duration() time.Duration {
+	d, _ := time.ParseDuration(t.For)
+	return d
+}
+
+func (t *RequestReminderSnooze) Valid() error {
+	if d, err := time.ParseDuration(t.For); err != nil || d <= 0 {
+		return errors.New("for is required and must be a positive duration such as 10m;")
+	}
+	return nil
+}
+
+type ResponseReminder struct {
+	Id           int64    `json:"id"`
+	TaskId       int      `json:"task_id"`
+	Offset       string   `json:"offset"`
+	Channels     []string `json:"channels"`
+	SentChannels []string `json:"sent_channels" extensions:"x-nullable"`
+	FireAt       string   `json:"fire_at"`
+	Status       string   `json:"status"`
+	Attempts     int      `json:"attempts"`
+	LastError    string   `json:"last_error"`
+	SentAt       string   `json:"sent_at"`
+	CreatedAt    string   `json:"created_at"`
+}
+
+func (t *ResponseReminder) ScanDTO(reminder *dto.Reminder) {
+	t.Id = reminder.Id
+	t.TaskId = reminder.TaskId
+	t.Offset = reminder.Offset.String()
+	t.Channels = reminder.Channels
+	t.SentChannels = reminder.SentChannels
+	t.FireAt = reminder.FireAt.Time.Format(time.RFC3339)
+	t.Status = reminder.Status
+	t.Attempts = reminder.Attempts
+	t.LastError = reminder.LastError
+	t.SentAt = ""
+	if reminder.SentAt.Valid {
+		t.SentAt = reminder.SentAt.Time.Format(time.RFC3339)
+	}
+	t.CreatedAt = reminder.CreatedAt.Time.Format(time.RFC3339)
+}
+
+// ReminderPayload is the body posted by the webhook reminder channel.
+// DeliveryId is the same for retries of one firing, so receivers can
+// drop duplicates.
+type ReminderPayload struct {
+	DeliveryId string            `json:"delivery_id"`
+	ReminderId int64             `json:"reminder_id"`
+	Offset     string            `json:"offset"`
+	FireAt     string            `json:"fire_at"`
+	Task       *ResponseTaskRead `json:"task"`
+}
+
+func (p *ReminderPayload) ScanDTO(reminder *dto.Reminder) {
+	p.DeliveryId = ReminderDeliveryId(reminder)
+	p.ReminderId = reminder.Id
+	p.Offset = reminder.Offset.String()
+	p.FireAt = reminder.FireAt.Time.UTC().Format(time.RFC3339)
+	p.Task = nil
+	if reminder.Task != nil {
+		p.Task = &ResponseTaskRead{}
+		p.Task.ScanDTO(reminder.Task)
+	}
+}
+
+// ReminderDeliveryId identifies one firing of a reminder: a snoozed or
+// rescheduled reminder fires under a new id, a retried one under the same.
+// Every claim bumps both the generation and the attempts, see dto.Reminder.
+func ReminderDeliveryId(reminder *dto.Reminder) string {
+	return "reminder-" + strconv.FormatInt(reminder.Id, 10) + "-" + strconv.Itoa(reminder.Generation-reminder.Attempts)
+}
@@ -0,0 +1,87 @@
+package schemas
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	SocketSubscribe   = "subscribe"
+	SocketUnsubscribe = "unsubscribe"
+	SocketCreate      = "create"
+	SocketUpdate      = "update"
+
+	SocketAck   = "ack"
+	SocketError = "error"
+	SocketEvent = "event"
+
+	// TopicTasks receives changes of every task, "task:<id>" of a single one.
+	TopicTasks      = "tasks"
+	topicTaskPrefix = "task:"
+)
+
+// TaskTopic returns the topic of a single task.
+func TaskTopic(taskId int) string {
+	return topicTaskPrefix + strconv.Itoa(taskId)
+}
+
+// SocketCommand is a message sent by a /ws client. Data holds a
+// RequestTaskCreate or RequestTaskUpdate for create and update.
+type SocketCommand struct {
+	Id     string          `json:"id"`
+	Type   string          `json:"type"`
+	Topic  string          `json:"topic,omitempty"`
+	TaskId int             `json:"task_id,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+func (c *SocketCommand) Valid() error {
+	errStr := ""
+	switch c.Type {
+	case SocketSubscribe, SocketUnsubscribe:
+		if !validTopic(c.Topic) {
+			errStr += "topic must be tasks or task:<id>;"
+		}
+	case SocketCreate:
+		if len(c.Data) == 0 {
+			errStr += "data is required;"
+		}
+	case SocketUpdate:
+		if c.TaskId <= 0 {
+			errStr += "task_id must be a positive integer;"
+		}
+		if len(c.Data) == 0 {
+			errStr += "data is required;"
+		}
+	default:
+		errStr += "type must be one of subscribe, unsubscribe, create, update;"
+	}
+	if len(errStr) > 0 {
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+func validTopic(topic string) bool {
+	if topic == TopicTasks {
+		return true
+	}
+	id, ok := strings.CutPrefix(topic, topicTaskPrefix)
+	if !ok {
+		return false
+	}
+	n, err := strconv.Atoi(id)
+	return err == nil && n > 0
+}
+
+// SocketReply is a message sent to a /ws client: the ack or error of the
+// command with the same id, or a pushed event of a subscribed topic.
+type SocketReply struct {
+	Type  string            `json:"type"`
+	Id    string            `json:"id,omitempty"`
+	Task  *ResponseTaskRead `json:"task,omitempty"`
+	Event *EventPayload     `json:"event,omitempty"`
+	Error string            `json:"error,omitempty"`
+}
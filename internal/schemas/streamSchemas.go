@@ -0,0 +1,56 @@
+package schemas
+
+import (
+	"ToDoVerba/internal/dto"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type RequestStreamFilter struct {
+	Actor  string
+	TaskId string
+	Types  []string
+}
+
+func (f *RequestStreamFilter) ScanQuery(q url.Values) {
+	f.Actor = q.Get("actor")
+	f.TaskId = q.Get("task_id")
+	f.Types = nil
+	for _, types := range q["types"] {
+		for _, t := range strings.Split(types, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				f.Types = append(f.Types, t)
+			}
+		}
+	}
+}
+
+func (f *RequestStreamFilter) ToDTO() *dto.StreamFilter {
+	taskId, _ := strconv.Atoi(f.TaskId)
+	return &dto.StreamFilter{
+		Actor:  f.Actor,
+		TaskId: taskId,
+		Types:  f.Types,
+	}
+}
+
+func (f *RequestStreamFilter) Valid() error {
+	errStr := ""
+	if f.TaskId != "" {
+		if id, err := strconv.Atoi(f.TaskId); err != nil || id <= 0 {
+			errStr += "task_id must be a positive integer;"
+		}
+	}
+	for _, t := range f.Types {
+		if !ValidEventType(t) {
+			errStr += "types must be one of task.created, task.updated, task.completed, task.deleted;"
+			break
+		}
+	}
+	if len(errStr) > 0 {
+		return errors.New(errStr)
+	}
+	return nil
+}
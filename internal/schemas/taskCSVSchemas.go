@@ -0,0 +1,260 @@
+package schemas
+
+import (
+	"ToDoVerba/internal/dto"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TaskCSVColumns is the header of an export. Imports read the same names
+// unless mapped to other ones.
+var TaskCSVColumns = []string{"id", "title", "description", "due_date", "completed", "created_at", "updated_at"}
+
+// importFields are the task fields an import can set.
+var importFields = []string{"title", "description", "due_date", "completed"}
+
+// importDateLayouts are accepted for due dates without an offset, which are
+// read in the timezone of the import.
+var importDateLayouts = []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// csvFormulaPrefixes start a cell that spreadsheets read as a formula. The
+// quote is escaped too, so that unescaping an import is lossless.
+const csvFormulaPrefixes = "=+-@\t\r'"
+
+// escapeCSVCell keeps a spreadsheet from running a cell as a formula by
+// prefixing it with a quote, which the spreadsheet doesn't show.
+func escapeCSVCell(s string) string {
+	if s != "" && strings.ContainsRune(csvFormulaPrefixes, rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// unescapeCSVCell undoes escapeCSVCell.
+func unescapeCSVCell(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+// TaskToCSVRecord renders the task as a row of an export. The texts are
+// escaped, as any client can write a formula into them.
+func TaskToCSVRecord(task *dto.TaskRead) []string {
+	return []string{
+		strconv.Itoa(task.Id),
+		escapeCSVCell(task.Title),
+		escapeCSVCell(task.Description),
+		task.DueDate.Time.Format(time.RFC3339),
+		strconv.FormatBool(task.Completed),
+		task.CreatedAt.Time.Format(time.RFC3339),
+		task.UpdatedAt.Time.Format(time.RFC3339),
+	}
+}
+
+// RequestTaskImport holds the query options of a CSV import. Mapping maps
+// a task field to the header of its column, given as map=field:header.
+type RequestTaskImport struct {
+	DryRun   string
+	Timezone string
+	Mapping  []string
+}
+
+func (i *RequestTaskImport) ScanQuery(q url.Values) {
+	i.DryRun = q.Get("dry_run")
+	i.Timezone = q.Get("timezone")
+	i.Mapping = q["map"]
+}
+
+func (i *RequestTaskImport) Valid() error {
+	errStr := ""
+	if i.DryRun != "" {
+		if _, err := strconv.ParseBool(i.DryRun); err != nil {
+			errStr += "dry_run must be true or false;"
+		}
+	}
+	if i.Timezone != "" {
+		if _, err := time.LoadLocation(i.Timezone); err != nil {
+			errStr += "timezone must be an IANA time zone;"
+		}
+	}
+	seen := make(map[string]bool)
+	for _, m := range i.Mapping {
+		field, header, ok := strings.Cut(m, ":")
+		switch {
+		case !ok || header == "":
+			errStr += fmt.Sprintf("map %q must be field:header;", m)
+		case !slices.Contains(importFields, field):
+			errStr += fmt.Sprintf("map field %q must be one of %s;", field, strings.Join(importFields, ", "))
+		case seen[field]:
+			errStr += fmt.Sprintf("map field %q is given twice;", field)
+		}
+		seen[field] = true
+	}
+	if len(errStr) > 0 {
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+func (i *RequestTaskImport) IsDryRun() bool {
+	dryRun, _ := strconv.ParseBool(i.DryRun)
+	return dryRun
+}
+
+// ScanCSV reads the tasks of a CSV with a header row. Invalid rows are
+// reported by line number rather than failing the read, an unreadable CSV or
+// a missing column is an error.
+func (i *RequestTaskImport) ScanCSV(r io.Reader, actor string) ([]dto.TaskCreate, []ResponseImportError, error) {
+	loc := time.UTC
+	if i.Timezone != "" {
+		loc, _ = time.LoadLocation(i.Timezone)
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil, errors.New("csv is empty")
+		}
+		return nil, nil, err
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	columns, err := i.columns(header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tasks []dto.TaskCreate
+	reports := make([]ResponseImportError, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			if index, ok := columns[name]; ok && index < len(record) {
+				return strings.TrimSpace(record[index])
+			}
+			return ""
+		}
+
+		cTask := RequestTaskCreate{
+			Title:       unescapeCSVCell(field("title")),
+			Description: unescapeCSVCell(field("description")),
+			DueDate:     field("due_date"),
+		}
+		errStr := ""
+		due, err := parseImportDue(cTask.DueDate, loc)
+		if err != nil {
+			errStr += "due_date is required and must be in RFC3339 format or a local date and time;"
+		}
+		cTask.DueDate = due.UTC().Format(time.RFC3339)
+		if completed := field("completed"); completed != "" {
+			cTask.Completed, err = parseImportBool(completed)
+			if err != nil {
+				errStr += "completed must be true or false;"
+			}
+		}
+		if err = cTask.Valid(); err != nil {
+			errStr += err.Error()
+		}
+		if errStr != "" {
+			reports = append(reports, ResponseImportError{Row: line, Error: errStr})
+			continue
+		}
+
+		cTaskDTO := cTask.ToDTO()
+		cTaskDTO.Actor = actor
+		tasks = append(tasks, *cTaskDTO)
+	}
+
+	if len(tasks) == 0 && len(reports) == 0 {
+		return nil, nil, errors.New("csv has no rows")
+	}
+	return tasks, reports, nil
+}
+
+// columns returns the column index of every task field found in header.
+func (i *RequestTaskImport) columns(header []string) (map[string]int, error) {
+	names := make(map[string]string, len(importFields))
+	for _, field := range importFields {
+		names[field] = field
+	}
+	for _, m := range i.Mapping {
+		field, name, _ := strings.Cut(m, ":")
+		names[field] = name
+	}
+
+	columns := make(map[string]int)
+	for index, name := range header {
+		for field, want := range names {
+			if strings.EqualFold(strings.TrimSpace(name), want) {
+				columns[field] = index
+			}
+		}
+	}
+
+	errStr := ""
+	for _, field := range []string{"title", "due_date"} {
+		if _, ok := columns[field]; !ok {
+			errStr += fmt.Sprintf("column %q for %s not found;", names[field], field)
+		}
+	}
+	if len(errStr) > 0 {
+		return nil, errors.New(errStr)
+	}
+	return columns, nil
+}
+
+func parseImportDue(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range importDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format %q", s)
+}
+
+func parseImportBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+type ResponseImportError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ResponseTaskImport reports an import. Nothing is imported when Errors is
+// not empty or on a dry run.
+type ResponseTaskImport struct {
+	DryRun   bool                  `json:"dry_run"`
+	Rows     int                   `json:"rows"`
+	Imported int                   `json:"imported"`
+	Errors   []ResponseImportError `json:"errors"`
+}
@@ -0,0 +1,120 @@
+package schemas
+
+import (
+	"ToDoVerba/internal/dto"
+	"errors"
+	"github.com/jackc/pgx/v5/pgtype"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultEventLimit = 100
+	maxEventLimit     = 1000
+)
+
+type RequestTaskEventFilter struct {
+	TaskId string
+	Actor  string
+	Action string
+	From   string
+	To     string
+	Offset string
+	Limit  string
+}
+
+func (f *RequestTaskEventFilter) ScanQuery(q url.Values) {
+	f.TaskId = q.Get("task_id")
+	f.Actor = q.Get("actor")
+	f.Action = q.Get("action")
+	f.From = q.Get("from")
+	f.To = q.Get("to")
+	f.Offset = q.Get("offset")
+	f.Limit = q.Get("limit")
+}
+
+func (f *RequestTaskEventFilter) ToDTO() *dto.TaskEventFilter {
+	filter := &dto.TaskEventFilter{
+		Actor:  f.Actor,
+		Action: f.Action,
+		Limit:  defaultEventLimit,
+	}
+	filter.TaskId, _ = strconv.Atoi(f.TaskId)
+	filter.Offset, _ = strconv.Atoi(f.Offset)
+	if limit, err := strconv.Atoi(f.Limit); err == nil {
+		filter.Limit = limit
+	}
+	if parsedTime, err := time.Parse(time.RFC3339, f.From); err == nil {
+		filter.From = pgtype.Timestamptz{Time: parsedTime, Valid: true}
+	}
+	if parsedTime, err := time.Parse(time.RFC3339, f.To); err == nil {
+		filter.To = pgtype.Timestamptz{Time: parsedTime, Valid: true}
+	}
+
+	return filter
+}
+
+func (f *RequestTaskEventFilter) Valid() error {
+	errStr := ""
+	if f.TaskId != "" {
+		if id, err := strconv.Atoi(f.TaskId); err != nil || id <= 0 {
+			errStr += "task_id must be a positive integer;"
+		}
+	}
+	switch f.Action {
+	case "", dto.TaskEventCreate, dto.TaskEventUpdate, dto.TaskEventDelete:
+	default:
+		errStr += "action must be one of create, update, delete;"
+	}
+	if f.From != "" {
+		if _, err := time.Parse(time.RFC3339, f.From); err != nil {
+			errStr += "from must be in RFC3339 format;"
+		}
+	}
+	if f.To != "" {
+		if _, err := time.Parse(time.RFC3339, f.To); err != nil {
+			errStr += "to must be in RFC3339 format;"
+		}
+	}
+	if f.Offset != "" {
+		if offset, err := strconv.Atoi(f.Offset); err != nil || offset < 0 {
+			errStr += "offset must be a non-negative integer;"
+		}
+	}
+	if f.Limit != "" {
+		if limit, err := strconv.Atoi(f.Limit); err != nil || limit <= 0 || limit > maxEventLimit {
+			errStr += "limit must be an integer between 1 and 1000;"
+		}
+	}
+	if len(errStr) > 0 {
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+type ResponseFieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+type ResponseTaskEvent struct {
+	Id        int64                          `json:"id"`
+	TaskId    int                            `json:"task_id"`
+	Action    string                         `json:"action"`
+	Actor     string                         `json:"actor"`
+	Changes   map[string]ResponseFieldChange `json:"changes"`
+	CreatedAt string                         `json:"created_at"`
+}
+
+func (e *ResponseTaskEvent) ScanDTO(event *dto.TaskEvent) {
+	e.Id = event.Id
+	e.TaskId = event.TaskId
+	e.Action = event.Action
+	e.Actor = event.Actor
+	e.Changes = make(map[string]ResponseFieldChange, len(event.Changes))
+	for name, change := range event.Changes {
+		e.Changes[name] = ResponseFieldChange{Before: change.Before, After: change.After}
+	}
+	e.CreatedAt = event.CreatedAt.Time.Format(time.RFC3339)
+}
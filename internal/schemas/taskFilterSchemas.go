@@ -0,0 +1,82 @@
+package schemas
+
+import (
+	"ToDoVerba/internal/dto"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RequestTaskFilter narrows a task list. Empty fields match every task.
+type RequestTaskFilter struct {
+	Completed string
+	DueAfter  string
+	DueBefore string
+}
+
+func (f *RequestTaskFilter) ScanQuery(q url.Values) {
+	f.Completed = q.Get("completed")
+	f.DueAfter = q.Get("due_after")
+	f.DueBefore = q.Get("due_before")
+}
+
+func (f *RequestTaskFilter) Valid() error {
+	errStr := ""
+	if f.Completed != "" {
+		if _, err := strconv.ParseBool(f.Completed); err != nil {
+			errStr += "completed must be true or false;"
+		}
+	}
+	if f.DueAfter != "" {
+		if _, err := time.Parse(time.RFC3339, f.DueAfter); err != nil {
+			errStr += "due_after must be in RFC3339 format;"
+		}
+	}
+	if f.DueBefore != "" {
+		if _, err := time.Parse(time.RFC3339, f.DueBefore); err != nil {
+			errStr += "due_before must be in RFC3339 format;"
+		}
+	}
+	if len(errStr) > 0 {
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+// Apply returns the tasks matching the filter in their original order.
+func (f *RequestTaskFilter) Apply(tasks []dto.TaskRead) []dto.TaskRead {
+	if f.Completed == "" && f.DueAfter == "" && f.DueBefore == "" {
+		return tasks
+	}
+	match := f.Matcher()
+
+	filtered := make([]dto.TaskRead, 0, len(tasks))
+	for i := range tasks {
+		if match(&tasks[i]) {
+			filtered = append(filtered, tasks[i])
+		}
+	}
+	return filtered
+}
+
+// Matcher returns the filter as a predicate, for tasks that are not
+// collected in a slice.
+func (f *RequestTaskFilter) Matcher() func(task *dto.TaskRead) bool {
+	completed, errCompleted := strconv.ParseBool(f.Completed)
+	dueAfter, errAfter := time.Parse(time.RFC3339, f.DueAfter)
+	dueBefore, errBefore := time.Parse(time.RFC3339, f.DueBefore)
+
+	return func(task *dto.TaskRead) bool {
+		if errCompleted == nil && task.Completed != completed {
+			return false
+		}
+		if errAfter == nil && task.DueDate.Time.Before(dueAfter) {
+			return false
+		}
+		if errBefore == nil && !task.DueDate.Time.Before(dueBefore) {
+			return false
+		}
+		return true
+	}
+}
@@ -11,6 +11,7 @@ type RequestTaskCreate struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	DueDate     string `json:"due_date"`
+	Completed   bool   `json:"completed"`
 }
 
 func (t *RequestTaskCreate) ToDTO() *dto.TaskCreate {
@@ -25,6 +26,7 @@ func (t *RequestTaskCreate) ToDTO() *dto.TaskCreate {
 		Title:       t.Title,
 		Description: t.Description,
 		DueDate:     dueDate,
+		Completed:   t.Completed,
 	}
 }
 
@@ -49,6 +51,7 @@ type RequestTaskUpdate struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	DueDate     string `json:"due_date"`
+	Completed   bool   `json:"completed"`
 }
 
 func (t *RequestTaskUpdate) ToDTO() *dto.TaskUpdate {
@@ -63,6 +66,7 @@ func (t *RequestTaskUpdate) ToDTO() *dto.TaskUpdate {
 		Title:       t.Title,
 		Description: t.Description,
 		DueDate:     dueDate,
+		Completed:   t.Completed,
 	}
 }
 
@@ -88,6 +92,7 @@ type ResponseTaskRead struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	DueDate     string `json:"due_date"`
+	Completed   bool   `json:"completed"`
 	CreatedAt   string `json:"created_at"`
 	UpdatedAt   string `json:"updated_at"`
 }
@@ -98,6 +103,7 @@ func (t *ResponseTaskRead) ScanDTO(task *dto.TaskRead) {
 	t.Title = task.Title
 	t.Description = task.Description
 	t.DueDate = task.DueDate.Time.Format(time.RFC3339)
+	t.Completed = task.Completed
 	t.CreatedAt = task.CreatedAt.Time.Format(time.RFC3339)
 	t.UpdatedAt = task.UpdatedAt.Time.Format(time.RFC3339)
 }
@@ -0,0 +1,159 @@
+package schemas
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/checklist"
+	"ToDoVerba/pkg/todotxt"
+	"bufio"
+	"errors"
+	"github.com/jackc/pgx/v5/pgtype"
+	"io"
+	"strings"
+	"time"
+)
+
+// todoDueTag is the key:value tag holding the due date of a todo.txt item.
+const todoDueTag = "due"
+
+// Tasks have no priority, project or context of their own. An imported item
+// keeps them in the description, written as the todo.txt body without the
+// due tag, and its title is the text without +project, @context and
+// key:value words:
+//
+//	(A) Call mom +family @phone due:2024-09-10
+//
+// becomes the task "Call mom" due 2024-09-10 with the description
+// "(A) Call mom +family @phone". An export reads them back from such a
+// description and falls back to the title for any other one.
+
+// TaskToTodoItem returns the task as a todo.txt item with the dates of the
+// task: created_at as creation date, updated_at as completion date.
+func TaskToTodoItem(task *dto.TaskRead) todotxt.Item {
+	item := todotxt.Item{Text: strings.Join(strings.Fields(task.Title), " ")}
+	if body, err := todotxt.ParseBody(task.Description); err == nil && body.CreationDate.IsZero() &&
+		!strings.Contains(task.Description, "\n") && body.Plain() == item.Text {
+		item = body
+	}
+
+	item.Completed = task.Completed
+	item.CreationDate = todoDate(task.CreatedAt)
+	if task.Completed {
+		item.CompletionDate = todoDate(task.UpdatedAt)
+	}
+	if task.DueDate.Valid {
+		item.SetTag(todoDueTag, formatTodoDue(task.DueDate.Time))
+	}
+	return item
+}
+
+// TaskToChecklistItem returns the task as a Markdown checklist line.
+func TaskToChecklistItem(task *dto.TaskRead) string {
+	item := TaskToTodoItem(task)
+	return checklist.Format(item)
+}
+
+func todoDate(t pgtype.Timestamptz) time.Time {
+	if !t.Valid {
+		return time.Time{}
+	}
+	year, month, day := t.Time.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// formatTodoDue writes a due date at midnight UTC as a plain date, the way
+// todo.txt tools write it, and any other one in RFC3339.
+func formatTodoDue(due time.Time) string {
+	due = due.UTC()
+	if due.Equal(todoDate(pgtype.Timestamptz{Time: due, Valid: true})) {
+		return due.Format(todotxt.DateFormat)
+	}
+	return due.Format(time.RFC3339)
+}
+
+// ScanTodoTxt reads the tasks of a todo.txt file, one per non blank line.
+// Invalid lines are reported by line number like the rows of a CSV.
+func (i *RequestTaskImport) ScanTodoTxt(r io.Reader, actor string) ([]dto.TaskCreate, []ResponseImportError, error) {
+	return i.scanTodoLines(r, actor, func(line string) (todotxt.Item, bool, error) {
+		if strings.TrimSpace(line) == "" {
+			return todotxt.Item{}, false, nil
+		}
+		item, err := todotxt.Parse(line)
+		return item, true, err
+	})
+}
+
+// ScanChecklist reads the tasks of the Markdown checklist items of r. Other
+// lines such as headings and prose are skipped.
+func (i *RequestTaskImport) ScanChecklist(r io.Reader, actor string) ([]dto.TaskCreate, []ResponseImportError, error) {
+	return i.scanTodoLines(r, actor, checklist.Parse)
+}
+
+func (i *RequestTaskImport) scanTodoLines(r io.Reader, actor string,
+	parse func(line string) (todotxt.Item, bool, error)) ([]dto.TaskCreate, []ResponseImportError, error) {
+	loc := time.UTC
+	if i.Timezone != "" {
+		loc, _ = time.LoadLocation(i.Timezone)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	var tasks []dto.TaskCreate
+	reports := make([]ResponseImportError, 0)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+
+		item, ok, err := parse(text)
+		if !ok {
+			continue
+		}
+		if err != nil {
+			reports = append(reports, ResponseImportError{Row: line, Error: err.Error() + ";"})
+			continue
+		}
+
+		cTask, errStr := todoItemToTask(item, loc)
+		if err = cTask.Valid(); err != nil {
+			errStr += err.Error()
+		}
+		if errStr != "" {
+			reports = append(reports, ResponseImportError{Row: line, Error: errStr})
+			continue
+		}
+
+		cTaskDTO := cTask.ToDTO()
+		cTaskDTO.Actor = actor
+		tasks = append(tasks, *cTaskDTO)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(tasks) == 0 && len(reports) == 0 {
+		return nil, nil, errors.New("no tasks found")
+	}
+	return tasks, reports, nil
+}
+
+// todoItemToTask maps an item as described above. The due date is read like
+// the one of a CSV row, so a plain date is midnight in loc.
+func todoItemToTask(item todotxt.Item, loc *time.Location) (RequestTaskCreate, string) {
+	errStr := ""
+	dueTag, _ := item.Tag(todoDueTag)
+	due, err := parseImportDue(dueTag, loc)
+	if err != nil {
+		errStr += "due tag is required and must be a date or in RFC3339 format;"
+	}
+	item.RemoveTag(todoDueTag)
+
+	return RequestTaskCreate{
+		Title:       item.Plain(),
+		Description: item.Body(),
+		DueDate:     due.UTC().Format(time.RFC3339),
+		Completed:   item.Completed,
+	}, errStr
+}
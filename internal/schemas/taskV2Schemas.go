@@ -0,0 +1,95 @@
+package schemas
+
+import (
+	"ToDoVerba/internal/dto"
+	"errors"
+	"github.com/jackc/pgx/v5/pgtype"
+	"time"
+)
+
+// RequestTaskV2 is the task of a create or a full update in API v2. The
+// description may be null or left out.
+type RequestTaskV2 struct {
+	Title       string  `json:"title"`
+	Description *string `json:"description" extensions:"x-nullable"`
+	DueDate     *string `json:"due_date" example:"2024-09-05T15:04:05Z"`
+	Completed   bool    `json:"completed"`
+}
+
+// Valid returns all problems of the task joined, one error each.
+func (t *RequestTaskV2) Valid() error {
+	var errs []error
+	if t.Title == "" {
+		errs = append(errs, errors.New("title is required"))
+	}
+	if t.DueDate == nil {
+		errs = append(errs, errors.New("due_date is required"))
+	} else if _, err := time.Parse(time.RFC3339, *t.DueDate); err != nil {
+		errs = append(errs, errors.New("due_date must be in RFC3339 format"))
+	}
+	return errors.Join(errs...)
+}
+
+func (t *RequestTaskV2) ToCreateDTO() *dto.TaskCreate {
+	return &dto.TaskCreate{
+		Title:       t.Title,
+		Description: t.description(),
+		DueDate:     t.dueDate(),
+		Completed:   t.Completed,
+	}
+}
+
+func (t *RequestTaskV2) ToUpdateDTO() *dto.TaskUpdate {
+	return &dto.TaskUpdate{
+		Title:       t.Title,
+		Description: t.description(),
+		DueDate:     t.dueDate(),
+		Completed:   t.Completed,
+	}
+}
+
+func (t *RequestTaskV2) description() string {
+	if t.Description == nil {
+		return ""
+	}
+	return *t.Description
+}
+
+func (t *RequestTaskV2) dueDate() pgtype.Timestamptz {
+	parsedTime, err := time.Parse(time.RFC3339, *t.DueDate)
+	return pgtype.Timestamptz{Time: parsedTime, Valid: err == nil}
+}
+
+// ResponseTaskV2 is a task of API v2. Unlike v1 it sends null for an empty
+// description and for times the task does not have, rather than zero values.
+type ResponseTaskV2 struct {
+	Id          int        `json:"id"`
+	Title       string     `json:"title"`
+	Description *string    `json:"description" extensions:"x-nullable"`
+	DueDate     *time.Time `json:"due_date" extensions:"x-nullable"`
+	Completed   bool       `json:"completed"`
+	Version     int        `json:"version"`
+	CreatedAt   *time.Time `json:"created_at" extensions:"x-nullable"`
+	UpdatedAt   *time.Time `json:"updated_at" extensions:"x-nullable"`
+}
+
+func (t *ResponseTaskV2) ScanDTO(task *dto.TaskRead) {
+	t.Id = task.Id
+	t.Title = task.Title
+	t.Description = nil
+	if task.Description != "" {
+		t.Description = &task.Description
+	}
+	t.DueDate = nullableTime(task.DueDate)
+	t.Completed = task.Completed
+	t.Version = task.Version
+	t.CreatedAt = nullableTime(task.CreatedAt)
+	t.UpdatedAt = nullableTime(task.UpdatedAt)
+}
+
+func nullableTime(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
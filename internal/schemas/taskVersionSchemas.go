@@ -0,0 +1,40 @@
+package schemas
+
+import (
+	"ToDoVerba/internal/dto"
+	"errors"
+	"time"
+)
+
+type RequestTaskRevert struct {
+	Version int `json:"version"`
+}
+
+func (t *RequestTaskRevert) Valid() error {
+	if t.Version <= 0 {
+		return errors.New("Version is required and must be positive;")
+	}
+	return nil
+}
+
+type ResponseTaskVersion struct {
+	TaskId      int    `json:"task_id"`
+	Version     int    `json:"version"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	DueDate     string `json:"due_date"`
+	Completed   bool   `json:"completed"`
+	Actor       string `json:"actor"`
+	CreatedAt   string `json:"created_at"`
+}
+
+func (t *ResponseTaskVersion) ScanDTO(version *dto.TaskVersion) {
+	t.TaskId = version.TaskId
+	t.Version = version.Version
+	t.Title = version.Title
+	t.Description = version.Description
+	t.DueDate = version.DueDate.Time.Format(time.RFC3339)
+	t.Completed = version.Completed
+	t.Actor = version.Actor
+	t.CreatedAt = version.CreatedAt.Time.Format(time.RFC3339)
+}
@@ -0,0 +1,139 @@
+package schemas
+
+import (
+	"ToDoVerba/internal/dto"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+)
+
+type RequestWebhookCreate struct {
+	Url    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+func (t *RequestWebhookCreate) ToDTO() *dto.WebhookCreate {
+	active := true
+	if t.Active != nil {
+		active = *t.Active
+	}
+	events := t.Events
+	if events == nil {
+		events = []string{}
+	}
+
+	return &dto.WebhookCreate{
+		Url:    t.Url,
+		Secret: t.Secret,
+		Events: events,
+		Active: active,
+	}
+}
+
+func (t *RequestWebhookCreate) Valid() error {
+	errStr := validWebhookUrl(t.Url) + validWebhookEvents(t.Events)
+	if len(errStr) > 0 {
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+type RequestWebhookUpdate struct {
+	Url    string   `json:"url"`
+	Events []string `json:"events"`
+	Active bool     `json:"active"`
+}
+
+func (t *RequestWebhookUpdate) ToDTO() *dto.WebhookUpdate {
+	events := t.Events
+	if events == nil {
+		events = []string{}
+	}
+
+	return &dto.WebhookUpdate{
+		Url:    t.Url,
+		Events: events,
+		Active: t.Active,
+	}
+}
+
+func (t *RequestWebhookUpdate) Valid() error {
+	errStr := validWebhookUrl(t.Url) + validWebhookEvents(t.Events)
+	if len(errStr) > 0 {
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+func validWebhookUrl(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "Url is required and must be an absolute http(s) url;"
+	}
+	return ""
+}
+
+func validWebhookEvents(events []string) string {
+	for _, e := range events {
+		if !ValidEventType(e) {
+			return "Events must be one of task.created, task.updated, task.completed, task.deleted;"
+		}
+	}
+	return ""
+}
+
+type ResponseWebhookRead struct {
+	Id        int      `json:"id"`
+	Url       string   `json:"url"`
+	Secret    string   `json:"secret,omitempty"`
+	Events    []string `json:"events"`
+	Active    bool     `json:"active"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+// ScanDTO leaves the secret out; it is only shown once, on create.
+func (t *ResponseWebhookRead) ScanDTO(webhook *dto.WebhookRead) {
+	t.Id = webhook.Id
+	t.Url = webhook.Url
+	t.Events = webhook.Events
+	if t.Events == nil {
+		t.Events = []string{}
+	}
+	t.Active = webhook.Active
+	t.CreatedAt = webhook.CreatedAt.Time.Format(time.RFC3339)
+	t.UpdatedAt = webhook.UpdatedAt.Time.Format(time.RFC3339)
+}
+
+type ResponseWebhookDelivery struct {
+	Id            int64           `json:"id"`
+	WebhookId     int             `json:"webhook_id"`
+	EventId       string          `json:"event_id"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"payload" swaggertype:"object"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt string          `json:"next_attempt_at"`
+	LastError     string          `json:"last_error"`
+	ResponseCode  int             `json:"response_code"`
+	CreatedAt     string          `json:"created_at"`
+	UpdatedAt     string          `json:"updated_at"`
+}
+
+func (t *ResponseWebhookDelivery) ScanDTO(delivery *dto.WebhookDelivery) {
+	t.Id = delivery.Id
+	t.WebhookId = delivery.WebhookId
+	t.EventId = delivery.EventId
+	t.Event = delivery.Event
+	t.Payload = delivery.Payload
+	t.Status = delivery.Status
+	t.Attempts = delivery.Attempts
+	t.NextAttemptAt = delivery.NextAttemptAt.Time.Format(time.RFC3339)
+	t.LastError = delivery.LastError
+	t.ResponseCode = delivery.ResponseCode
+	t.CreatedAt = delivery.CreatedAt.Time.Format(time.RFC3339)
+	t.UpdatedAt = delivery.UpdatedAt.Time.Format(time.RFC3339)
+}
@@ -0,0 +1,104 @@
+package backupService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/repos"
+	"ToDoVerba/internal/schemas"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrUnauthorized is returned for a wrong admin token, and for every token
+	// when none is configured.
+	ErrUnauthorized = errors.New("invalid or missing admin token")
+	// ErrInvalidBackup wraps everything wrong with the backup itself, as
+	// opposed to the database failing to take it.
+	ErrInvalidBackup = errors.New("invalid backup")
+)
+
+type Deps struct {
+	Repo       repos.BackupRepository
+	Logger     logging.Logger
+	AdminToken string
+}
+
+// BackupService writes and restores backups of the tasks and their related
+// data in the NDJSON format of schemas.BackupWriter. Both run as long as ctx
+// allows, a backup has no fixed size.
+type BackupService struct {
+	repo       repos.BackupRepository
+	logger     logging.Logger
+	adminToken string
+}
+
+func (s *BackupService) Authorize(token string) error {
+	if s.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+func (s *BackupService) Backup(ctx context.Context, w io.Writer) (*dto.BackupStats, error) {
+	writer := schemas.NewBackupWriter(w)
+	err := s.repo.Export(ctx, writer.Write)
+	if err != nil {
+		s.logger.Errorf("service error on backup: %s", err)
+		return nil, err
+	}
+
+	stats, err := writer.Close()
+	if err != nil {
+		s.logger.Errorf("service error on backup footer: %s", err)
+		return nil, err
+	}
+
+	s.logger.Infof("service backed up %d tasks", stats.Tasks)
+	return stats, nil
+}
+
+// Restore reads a backup from r and restores it all or nothing. mode is one
+// of dto.BackupModeMerge and dto.BackupModeReplace.
+func (s *BackupService) Restore(ctx context.Context, r io.Reader, mode string) (*dto.BackupStats, error) {
+	if mode != dto.BackupModeMerge && mode != dto.BackupModeReplace {
+		return nil, fmt.Errorf("unknown restore mode %q", mode)
+	}
+
+	reader := schemas.NewBackupReader(r)
+	header, err := reader.ReadHeader()
+	if err != nil {
+		s.logger.Debugf("service rejected backup: %s", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBackup, err)
+	}
+
+	stats, err := s.repo.Restore(ctx, mode, func() (*dto.BackupRecord, error) {
+		record, err := reader.Next()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidBackup, err)
+		}
+		return record, err
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidBackup) {
+			s.logger.Debugf("service rejected backup: %s", err)
+		} else {
+			s.logger.Errorf("service error on restore: %s", err)
+		}
+		return nil, err
+	}
+
+	s.logger.Infof("service restored %d tasks of a backup from %s, mode %s", stats.Tasks, header.CreatedAt, mode)
+	return stats, nil
+}
+
+func NewBackupService(d Deps) *BackupService {
+	return &BackupService{
+		repo:       d.Repo,
+		logger:     d.Logger,
+		adminToken: d.AdminToken,
+	}
+}
@@ -0,0 +1,172 @@
+package backupService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"bytes"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// backupRepo exports records and keeps the ones restored. A restore that
+// fails keeps nothing, like the transaction of the real one.
+type backupRepo struct {
+	records  []dto.BackupRecord
+	restored []dto.BackupRecord
+	mode     string
+}
+
+func (r *backupRepo) Export(ctx context.Context, each func(record *dto.BackupRecord) error) error {
+	for i := range r.records {
+		if err := each(&r.records[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *backupRepo) Restore(ctx context.Context, mode string, next func() (*dto.BackupRecord, error)) (*dto.BackupStats, error) {
+	var restored []dto.BackupRecord
+	stats := &dto.BackupStats{}
+	for {
+		record, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		restored = append(restored, *record)
+		stats.Tasks++
+	}
+	r.restored, r.mode = restored, mode
+	return stats, nil
+}
+
+func newTestService(repo *backupRepo) *BackupService {
+	return NewBackupService(Deps{
+		Repo:       repo,
+		Logger:     logging.GetLoggerTest(),
+		AdminToken: "secret",
+	})
+}
+
+func TestBackupService_roundTrip(t *testing.T) {
+	ts := func(s string) pgtype.Timestamptz {
+		t, _ := time.Parse(time.RFC3339Nano, s)
+		return pgtype.Timestamptz{Time: t, Valid: true}
+	}
+	records := []dto.BackupRecord{
+		{Task: &dto.TaskRead{Id: 7, Title: "Buy milk", Description: "2 l", DueDate: ts("2024-09-05T10:00:00.5Z"),
+			Completed: true, CreatedAt: ts("2024-09-01T10:00:00Z"), UpdatedAt: ts("2024-09-02T10:00:00Z"), Version: 2}},
+		{TaskEvent: &dto.TaskEvent{Id: 11, TaskId: 7, Action: dto.TaskEventUpdate, Actor: "alice",
+			Changes:   map[string]dto.FieldChange{"completed": {Before: false, After: true}},
+			CreatedAt: ts("2024-09-02T10:00:00Z")}},
+		{TaskVersion: &dto.TaskVersion{TaskId: 7, Version: 2, Title: "Buy milk", Description: "2 l",
+			DueDate: ts("2024-09-05T10:00:00.5Z"), Completed: true, Actor: "alice", CreatedAt: ts("2024-09-02T10:00:00Z")}},
+		{Reminder: &dto.Reminder{Id: 3, TaskId: 7, Offset: time.Hour, Channels: []string{"log"}, SentChannels: []string{},
+			FireAt: ts("2024-09-05T09:00:00.5Z"), Status: dto.ReminderPending, Generation: 1,
+			CreatedAt: ts("2024-09-01T10:00:00Z"), UpdatedAt: ts("2024-09-01T10:00:00Z")}},
+		{CalDAVObject: &dto.CalDAVObject{TaskId: 7, Href: "A1.ics", Uid: "A1"}},
+	}
+	repo := &backupRepo{records: records}
+	service := newTestService(repo)
+
+	buf := &bytes.Buffer{}
+	stats, err := service.Backup(context.Background(), buf)
+	require.NoError(t, err)
+	assert.Equal(t, dto.BackupStats{Tasks: 1, TaskEvents: 1, TaskVersions: 1, Reminders: 1, CalDAVObjects: 1}, *stats)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 7)
+	assert.Contains(t, lines[0], `"type":"header","data":{"format":"todoverba-backup","version":1,`)
+	assert.Equal(t, `{"type":"footer","data":{"tasks":1,"task_events":1,"task_versions":1,"reminders":1,"caldav_objects":1}}`, lines[6])
+
+	_, err = service.Restore(context.Background(), buf, dto.BackupModeReplace)
+	require.NoError(t, err)
+	assert.Equal(t, dto.BackupModeReplace, repo.mode)
+	assert.Equal(t, records, repo.restored)
+}
+
+func TestBackupService_Restore_invalid(t *testing.T) {
+	header := `{"type":"header","data":{"format":"todoverba-backup","version":1,"created_at":"2024-09-01T10:00:00Z"}}` + "\n"
+	task := `{"type":"task","data":{"id":1,"title":"a","due_date":"2024-09-05T10:00:00Z","version":1,` +
+		`"created_at":"2024-09-01T10:00:00Z","updated_at":"2024-09-01T10:00:00Z"}}` + "\n"
+	footer := `{"type":"footer","data":{"tasks":1}}` + "\n"
+
+	testTable := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{
+			name:        "empty",
+			expectedErr: "invalid backup: backup is empty",
+		},
+		{
+			name:        "newer_version",
+			input:       `{"type":"header","data":{"format":"todoverba-backup","version":2}}`,
+			expectedErr: "invalid backup: line 1: backup version 2 is not supported, expected 1",
+		},
+		{
+			name:        "not_a_backup",
+			input:       `{"type":"header","data":{"format":"other"}}`,
+			expectedErr: "invalid backup: line 1: not a todoverba-backup file",
+		},
+		{
+			name:        "truncated",
+			input:       header + task,
+			expectedErr: "invalid backup: backup is truncated, footer is missing",
+		},
+		{
+			name:        "counts_mismatch",
+			input:       header + task + task + footer,
+			expectedErr: "invalid backup: line 4: footer counts don't match the records read",
+		},
+		{
+			name:        "data_after_footer",
+			input:       header + task + footer + task,
+			expectedErr: "invalid backup: line 4: unexpected data after footer",
+		},
+		{
+			name:  "invalid_record",
+			input: header + "\n" + `{"type":"task","data":{"id":0,"title":"","version":1}}` + "\n" + footer,
+			expectedErr: "invalid backup: line 3: id must be positive;title is required;due_date must be in RFC3339 format;" +
+				"created_at must be in RFC3339 format;updated_at must be in RFC3339 format;",
+		},
+		{
+			name:        "unknown_type",
+			input:       header + `{"type":"webhook","data":{}}` + "\n",
+			expectedErr: "invalid backup: line 2: unknown record type \"webhook\"",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := &backupRepo{}
+			service := newTestService(repo)
+
+			_, err := service.Restore(context.Background(), strings.NewReader(testCase.input), dto.BackupModeMerge)
+			assert.ErrorIs(t, err, ErrInvalidBackup)
+			assert.EqualError(t, err, testCase.expectedErr)
+			assert.Empty(t, repo.restored)
+		})
+	}
+}
+
+func TestBackupService_Authorize(t *testing.T) {
+	service := newTestService(&backupRepo{})
+	assert.NoError(t, service.Authorize("secret"))
+	assert.ErrorIs(t, service.Authorize("wrong"), ErrUnauthorized)
+	assert.ErrorIs(t, service.Authorize(""), ErrUnauthorized)
+
+	disabled := NewBackupService(Deps{Repo: &backupRepo{}, Logger: logging.GetLoggerTest()})
+	assert.ErrorIs(t, disabled.Authorize(""), ErrUnauthorized)
+}
@@ -0,0 +1,155 @@
+package brokerService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Deps struct {
+	Logger     logging.Logger
+	ReplaySize int
+	BufferSize int
+}
+
+// Subscription receives matching events until it is unsubscribed. C is closed
+// when the subscriber falls behind by more than the buffer; it should then
+// resubscribe with the id of the last message it handled.
+type Subscription struct {
+	C      <-chan dto.StreamMessage
+	c      chan dto.StreamMessage
+	filter *dto.StreamFilter
+}
+
+// BrokerService fans task events out to live subscribers and keeps the last
+// ReplaySize events so that reconnecting subscribers can catch up.
+type BrokerService struct {
+	logger     logging.Logger
+	bufferSize int
+
+	mu     sync.Mutex
+	epoch  string
+	seq    uint64
+	replay []dto.StreamMessage
+	next   int
+	full   bool
+	subs   map[*Subscription]struct{}
+}
+
+func (b *BrokerService) Publish(event *dto.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	msg := dto.StreamMessage{Id: b.epoch + "-" + strconv.FormatUint(b.seq, 10), Event: *event}
+
+	if len(b.replay) > 0 {
+		b.replay[b.next] = msg
+		b.next = (b.next + 1) % len(b.replay)
+		b.full = b.full || b.next == 0
+	}
+
+	for sub := range b.subs {
+		if !matches(sub.filter, event) {
+			continue
+		}
+		select {
+		case sub.c <- msg:
+		default:
+			b.logger.Debugf("broker subscriber fell behind, dropping it at %s", msg.Id)
+			delete(b.subs, sub)
+			close(sub.c)
+		}
+	}
+}
+
+// Subscribe registers a subscriber and returns the buffered events published
+// after lastEventId. An unknown or expired lastEventId replays the whole buffer.
+func (b *BrokerService) Subscribe(lastEventId string, filter *dto.StreamFilter) (*Subscription, []dto.StreamMessage) {
+	c := make(chan dto.StreamMessage, b.bufferSize)
+	sub := &Subscription{C: c, c: c, filter: filter}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []dto.StreamMessage
+	if lastEventId != "" {
+		after := b.replayAfter(lastEventId)
+		for _, msg := range b.buffered() {
+			if seqOf(msg.Id) > after && matches(filter, &msg.Event) {
+				replay = append(replay, msg)
+			}
+		}
+	}
+
+	b.subs[sub] = struct{}{}
+	return sub, replay
+}
+
+func (b *BrokerService) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.c)
+	}
+}
+
+// replayAfter returns the sequence number to replay from.
+func (b *BrokerService) replayAfter(lastEventId string) uint64 {
+	epoch, _, _ := strings.Cut(lastEventId, "-")
+	if epoch != b.epoch {
+		return 0
+	}
+	return seqOf(lastEventId)
+}
+
+// buffered returns the replay buffer oldest first.
+func (b *BrokerService) buffered() []dto.StreamMessage {
+	if !b.full {
+		return b.replay[:b.next]
+	}
+	return append(append([]dto.StreamMessage{}, b.replay[b.next:]...), b.replay[:b.next]...)
+}
+
+func seqOf(id string) uint64 {
+	_, seq, _ := strings.Cut(id, "-")
+	n, _ := strconv.ParseUint(seq, 10, 64)
+	return n
+}
+
+func matches(filter *dto.StreamFilter, event *dto.Event) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.Actor != "" && filter.Actor != event.Actor {
+		return false
+	}
+	if filter.TaskId != 0 && filter.TaskId != event.TaskId {
+		return false
+	}
+	if len(filter.Types) == 0 {
+		return true
+	}
+	for _, t := range filter.Types {
+		if t == event.Type {
+			return true
+		}
+	}
+	return false
+}
+
+func NewBrokerService(d Deps) *BrokerService {
+	return &BrokerService{
+		logger:     d.Logger,
+		bufferSize: d.BufferSize,
+		epoch:      fmt.Sprintf("%x", time.Now().UnixNano()),
+		replay:     make([]dto.StreamMessage, d.ReplaySize),
+		subs:       make(map[*Subscription]struct{}),
+	}
+}
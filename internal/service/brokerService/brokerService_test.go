@@ -0,0 +1,76 @@
+package brokerService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func newTestBroker(replaySize, bufferSize int) *BrokerService {
+	return NewBrokerService(Deps{Logger: logging.GetLoggerTest(), ReplaySize: replaySize, BufferSize: bufferSize})
+}
+
+func taskIds(msgs []dto.StreamMessage) []int {
+	ids := make([]int, 0, len(msgs))
+	for _, m := range msgs {
+		ids = append(ids, m.Event.TaskId)
+	}
+	return ids
+}
+
+func TestBrokerService_ResumeFromReplayBuffer(t *testing.T) {
+	b := newTestBroker(3, 8)
+
+	sub, replay := b.Subscribe("", nil)
+	assert.Empty(t, replay)
+	for id := 1; id <= 5; id++ {
+		b.Publish(&dto.Event{Type: dto.EventTaskCreated, TaskId: id})
+	}
+	var received []dto.StreamMessage
+	for i := 0; i < 5; i++ {
+		received = append(received, <-sub.C)
+	}
+	b.Unsubscribe(sub)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, taskIds(received))
+
+	_, replay = b.Subscribe(received[3].Id, nil)
+	assert.Equal(t, []int{5}, taskIds(replay))
+
+	// Older than the buffer and from another process: replay what is left.
+	_, replay = b.Subscribe(received[0].Id, nil)
+	assert.Equal(t, []int{3, 4, 5}, taskIds(replay))
+	_, replay = b.Subscribe("deadbeef-2", nil)
+	assert.Equal(t, []int{3, 4, 5}, taskIds(replay))
+}
+
+func TestBrokerService_Filter(t *testing.T) {
+	b := newTestBroker(10, 8)
+
+	sub, _ := b.Subscribe("", &dto.StreamFilter{Actor: "alice", Types: []string{dto.EventTaskDeleted}})
+	b.Publish(&dto.Event{Type: dto.EventTaskDeleted, TaskId: 1, Actor: "bob"})
+	b.Publish(&dto.Event{Type: dto.EventTaskUpdated, TaskId: 2, Actor: "alice"})
+	b.Publish(&dto.Event{Type: dto.EventTaskDeleted, TaskId: 3, Actor: "alice"})
+
+	msg := <-sub.C
+	assert.Equal(t, 3, msg.Event.TaskId)
+	assert.Empty(t, sub.C)
+}
+
+func TestBrokerService_DropsSlowSubscriber(t *testing.T) {
+	b := newTestBroker(10, 1)
+
+	sub, _ := b.Subscribe("", nil)
+	b.Publish(&dto.Event{Type: dto.EventTaskCreated, TaskId: 1})
+	b.Publish(&dto.Event{Type: dto.EventTaskCreated, TaskId: 2})
+
+	msg, ok := <-sub.C
+	require.True(t, ok)
+	_, ok = <-sub.C
+	assert.False(t, ok)
+
+	_, replay := b.Subscribe(msg.Id, nil)
+	assert.Equal(t, []int{2}, taskIds(replay))
+	b.Unsubscribe(sub)
+}
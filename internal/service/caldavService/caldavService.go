@@ -0,0 +1,306 @@
+package caldavService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/repos"
+	"ToDoVerba/internal/schemas"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const changesPageSize = 500
+
+var (
+	// ErrInvalidSyncToken is returned for a sync token this server never issued.
+	ErrInvalidSyncToken = errors.New("invalid sync token")
+	// ErrUidConflict is returned when a PUT changes the UID of a resource.
+	ErrUidConflict = errors.New("uid of an existing resource can't change")
+)
+
+// Tasks is the task service CalDAV edits go through, so they are audited,
+// versioned and published like the ones of the REST API. A task created with
+// its resource is published through Created.
+type Tasks interface {
+	Created(rTask *dto.TaskRead, actor string)
+	FindByID(id int) (*dto.TaskRead, error)
+	List() ([]dto.TaskRead, error)
+	UpdateById(id int, update *dto.TaskUpdate) (*dto.TaskRead, error)
+	DeleteByIdVersion(id, version int, actor string) error
+}
+
+type Deps struct {
+	Repo      repos.CalDAVRepository
+	EventRepo repos.TaskEventRepository
+	Tasks     Tasks
+	Logger    logging.Logger
+}
+
+// CalDAVService exposes the tasks as calendar object resources. A task is
+// named by the href its client chose, or task-<id>.ics when it was created
+// elsewhere. The task event ids double as sync tokens.
+type CalDAVService struct {
+	repo      repos.CalDAVRepository
+	eventRepo repos.TaskEventRepository
+	tasks     Tasks
+	logger    logging.Logger
+}
+
+func (s *CalDAVService) List() ([]dto.CalDAVResource, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tasks, err := s.tasks.List()
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := s.repo.List(ctx)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		s.logger.Errorf("service error on list caldav objects: %s", err)
+		return nil, err
+	}
+	byTask := make(map[int]dto.CalDAVObject, len(objects))
+	for _, object := range objects {
+		byTask[object.TaskId] = object
+	}
+
+	resources := make([]dto.CalDAVResource, 0, len(tasks))
+	for _, task := range tasks {
+		resources = append(resources, newResource(task, byTask[task.Id]))
+	}
+	return resources, nil
+}
+
+// Find returns the resource at href, pgx.ErrNoRows when there is none.
+func (s *CalDAVService) Find(href string) (*dto.CalDAVResource, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	object, err := s.findObject(ctx, href)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debugf("no caldav resource %s", href)
+		} else {
+			s.logger.Errorf("service error on find caldav resource %s: %s", href, err)
+		}
+		return nil, err
+	}
+
+	task, err := s.tasks.FindByID(object.TaskId)
+	if err != nil {
+		return nil, err
+	}
+	resource := newResource(*task, *object)
+	return &resource, nil
+}
+
+// findObject resolves href to a task id. Default names only resolve for
+// tasks without a client chosen one, so a task has exactly one href.
+func (s *CalDAVService) findObject(ctx context.Context, href string) (*dto.CalDAVObject, error) {
+	object, err := s.repo.FindByHref(ctx, href)
+	if err == nil || !errors.Is(err, pgx.ErrNoRows) {
+		return object, err
+	}
+
+	id, ok := parseTaskHref(href)
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	_, err = s.repo.FindByTaskID(ctx, id)
+	if err == nil {
+		return nil, pgx.ErrNoRows
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	return &dto.CalDAVObject{TaskId: id}, nil
+}
+
+// Put stores the task at href and reports whether it was created. Without a
+// due date the one of the existing task, or now, is kept. An update with a
+// version only applies to that version of the task, and returns
+// dto.ErrVersionConflict when the resource changed or went away, or when a
+// concurrent Put created it first.
+func (s *CalDAVService) Put(href, uid string, update *dto.TaskUpdate) (*dto.CalDAVResource, bool, error) {
+	existing, err := s.Find(href)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, err
+	}
+	if existing == nil && update.Version != 0 {
+		return nil, false, dto.ErrVersionConflict
+	}
+
+	if existing != nil {
+		if uid != existing.Uid {
+			return nil, false, ErrUidConflict
+		}
+		if !update.DueDate.Valid {
+			update.DueDate = existing.Task.DueDate
+		}
+		rTask, err := s.tasks.UpdateById(existing.Task.Id, update)
+		if err != nil {
+			return nil, false, err
+		}
+		resource := newResource(*rTask, dto.CalDAVObject{Href: existing.Href, Uid: existing.Uid})
+		return &resource, false, nil
+	}
+
+	if !update.DueDate.Valid {
+		update.DueDate.Time, update.DueDate.Valid = time.Now().UTC(), true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	object := dto.CalDAVObject{Href: href, Uid: uid}
+	rTask, err := s.repo.Create(ctx, &dto.TaskCreate{
+		Title:       update.Title,
+		Description: update.Description,
+		DueDate:     update.DueDate,
+		Completed:   update.Completed,
+		Actor:       update.Actor,
+	}, &object)
+	if err != nil {
+		if errors.Is(err, dto.ErrVersionConflict) {
+			s.logger.Debugf("caldav resource %s was created concurrently", href)
+		} else {
+			s.logger.Errorf("service error on create caldav resource %s: %s", href, err)
+		}
+		return nil, false, err
+	}
+	s.tasks.Created(rTask, update.Actor)
+	s.logger.Debugf("service caldav resource %s created for task %d", href, rTask.Id)
+
+	resource := newResource(*rTask, object)
+	return &resource, true, nil
+}
+
+// Delete deletes the task at href, only at version unless it is 0.
+func (s *CalDAVService) Delete(href string, version int, actor string) error {
+	resource, err := s.Find(href)
+	if err != nil {
+		return err
+	}
+	return s.tasks.DeleteByIdVersion(resource.Task.Id, version, actor)
+}
+
+// SyncToken returns the current sync token of the collection.
+func (s *CalDAVService) SyncToken() (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	token, err := s.eventRepo.LatestID(ctx)
+	if err != nil {
+		s.logger.Errorf("service error on caldav sync token: %s", err)
+		return 0, err
+	}
+	return token, nil
+}
+
+// Changes lists what changed after the sync token. Token 0 is an initial
+// sync and returns every resource.
+func (s *CalDAVService) Changes(token int64) (*dto.CalDAVChanges, error) {
+	latest, err := s.SyncToken()
+	if err != nil {
+		return nil, err
+	}
+	if token < 0 || token > latest {
+		return nil, ErrInvalidSyncToken
+	}
+
+	if token == 0 {
+		resources, err := s.List()
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		return &dto.CalDAVChanges{Changed: resources, SyncToken: latest}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	changes := &dto.CalDAVChanges{SyncToken: token}
+	seen := make(map[int]bool)
+	var taskIds []int
+	for {
+		events, err := s.eventRepo.List(ctx, &dto.TaskEventFilter{AfterId: changes.SyncToken, Limit: changesPageSize})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Errorf("service error on list caldav changes: %s", err)
+			return nil, err
+		}
+		for _, event := range events {
+			changes.SyncToken = event.Id
+			if !seen[event.TaskId] {
+				seen[event.TaskId] = true
+				taskIds = append(taskIds, event.TaskId)
+			}
+		}
+		if len(events) < changesPageSize {
+			break
+		}
+	}
+
+	for _, id := range taskIds {
+		object, err := s.repo.FindByTaskID(ctx, id)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		if object == nil {
+			object = &dto.CalDAVObject{TaskId: id}
+		}
+
+		task, err := s.tasks.FindByID(id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			changes.Removed = append(changes.Removed, newResource(dto.TaskRead{Id: id}, *object).Href)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		changes.Changed = append(changes.Changed, newResource(*task, *object))
+	}
+
+	return changes, nil
+}
+
+// newResource names the task by its object, falling back to the defaults.
+func newResource(task dto.TaskRead, object dto.CalDAVObject) dto.CalDAVResource {
+	resource := dto.CalDAVResource{Href: object.Href, Uid: object.Uid, Task: task}
+	if resource.Href == "" {
+		resource.Href = schemas.TaskHref(task.Id)
+	}
+	if resource.Uid == "" {
+		resource.Uid = schemas.TaskUID(task.Id)
+	}
+	return resource
+}
+
+func parseTaskHref(href string) (int, bool) {
+	name, ok := strings.CutPrefix(href, schemas.TaskHrefPrefix)
+	if !ok {
+		return 0, false
+	}
+	name, ok = strings.CutSuffix(name, schemas.TaskHrefSuffix)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.Atoi(name)
+	if err != nil || id <= 0 || strconv.Itoa(id) != name {
+		return 0, false
+	}
+	return id, true
+}
+
+func NewCalDAVService(d Deps) *CalDAVService {
+	return &CalDAVService{
+		repo:      d.Repo,
+		eventRepo: d.EventRepo,
+		tasks:     d.Tasks,
+		logger:    d.Logger,
+	}
+}
@@ -0,0 +1,316 @@
+package caldavService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// store keeps tasks, their audit events and the caldav objects in memory.
+type store struct {
+	tasks     map[int]dto.TaskRead
+	events    []dto.TaskEvent
+	objects   map[string]dto.CalDAVObject
+	nextId    int
+	published []int
+	createErr error
+	// beforeCreate runs once before the next resource is created.
+	beforeCreate func()
+}
+
+func newStore() *store {
+	return &store{tasks: map[int]dto.TaskRead{}, objects: map[string]dto.CalDAVObject{}}
+}
+
+func (s *store) record(taskId int, action string) {
+	s.events = append(s.events, dto.TaskEvent{Id: int64(len(s.events) + 1), TaskId: taskId, Action: action})
+}
+
+func (s *store) Create(cTask *dto.TaskCreate) (*dto.TaskRead, error) {
+	s.nextId++
+	task := dto.TaskRead{Id: s.nextId, Title: cTask.Title, DueDate: cTask.DueDate, Completed: cTask.Completed, Version: 1}
+	s.tasks[task.Id] = task
+	s.record(task.Id, dto.TaskEventCreate)
+	return &task, nil
+}
+
+func (s *store) Created(rTask *dto.TaskRead, actor string) {
+	s.published = append(s.published, rTask.Id)
+}
+
+func (s *store) FindByID(id int) (*dto.TaskRead, error) {
+	task, ok := s.tasks[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &task, nil
+}
+
+func (s *store) List() ([]dto.TaskRead, error) {
+	var tasks []dto.TaskRead
+	for id := 1; id <= s.nextId; id++ {
+		if task, ok := s.tasks[id]; ok {
+			tasks = append(tasks, task)
+		}
+	}
+	if len(tasks) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return tasks, nil
+}
+
+func (s *store) UpdateById(id int, update *dto.TaskUpdate) (*dto.TaskRead, error) {
+	task, ok := s.tasks[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	if update.Version != 0 && update.Version != task.Version {
+		return nil, dto.ErrVersionConflict
+	}
+	task.Title, task.DueDate, task.Completed = update.Title, update.DueDate, update.Completed
+	task.Version++
+	s.tasks[id] = task
+	s.record(id, dto.TaskEventUpdate)
+	return &task, nil
+}
+
+func (s *store) DeleteById(id int, actor string) error {
+	return s.DeleteByIdVersion(id, 0, actor)
+}
+
+func (s *store) DeleteByIdVersion(id, version int, actor string) error {
+	task, ok := s.tasks[id]
+	if !ok {
+		return pgx.ErrNoRows
+	}
+	if version != 0 && version != task.Version {
+		return dto.ErrVersionConflict
+	}
+	delete(s.tasks, id)
+	s.record(id, dto.TaskEventDelete)
+	return nil
+}
+
+type objectRepo struct{ *store }
+
+func (r objectRepo) List(ctx context.Context) ([]dto.CalDAVObject, error) {
+	var objects []dto.CalDAVObject
+	for _, object := range r.objects {
+		objects = append(objects, object)
+	}
+	if len(objects) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return objects, nil
+}
+
+func (r objectRepo) FindByHref(ctx context.Context, href string) (*dto.CalDAVObject, error) {
+	object, ok := r.objects[href]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &object, nil
+}
+
+func (r objectRepo) FindByTaskID(ctx context.Context, taskId int) (*dto.CalDAVObject, error) {
+	for _, object := range r.objects {
+		if object.TaskId == taskId {
+			return &object, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (r objectRepo) Create(ctx context.Context, cTask *dto.TaskCreate, object *dto.CalDAVObject) (*dto.TaskRead, error) {
+	if before := r.beforeCreate; before != nil {
+		r.beforeCreate = nil
+		before()
+	}
+	if r.createErr != nil {
+		return nil, r.createErr
+	}
+	if taken, ok := r.objects[object.Href]; ok {
+		if _, ok = r.tasks[taken.TaskId]; ok {
+			return nil, dto.ErrVersionConflict
+		}
+	}
+	rTask, _ := r.store.Create(cTask)
+	object.TaskId = rTask.Id
+	r.objects[object.Href] = *object
+	return rTask, nil
+}
+
+type eventRepo struct{ *store }
+
+func (r eventRepo) ListByTaskID(ctx context.Context, taskId int) ([]dto.TaskEvent, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func (r eventRepo) ListByTaskIDs(ctx context.Context, taskIds []int) ([]dto.TaskEvent, error) {
+	return nil, nil
+}
+
+func (r eventRepo) List(ctx context.Context, filter *dto.TaskEventFilter) ([]dto.TaskEvent, error) {
+	var events []dto.TaskEvent
+	for _, event := range r.events {
+		if event.Id > filter.AfterId && len(events) < filter.Limit {
+			events = append(events, event)
+		}
+	}
+	if len(events) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return events, nil
+}
+
+func (r eventRepo) LatestID(ctx context.Context) (int64, error) {
+	return int64(len(r.events)), nil
+}
+
+func newTestService(s *store) *CalDAVService {
+	return NewCalDAVService(Deps{
+		Repo:      objectRepo{s},
+		EventRepo: eventRepo{s},
+		Tasks:     s,
+		Logger:    logging.GetLoggerTest(),
+	})
+}
+
+func TestCalDAVService_Put(t *testing.T) {
+	s := newStore()
+	service := newTestService(s)
+	due := pgtype.Timestamptz{Time: time.Date(2024, 9, 5, 10, 0, 0, 0, time.UTC), Valid: true}
+
+	created, ok, err := service.Put("A1B2.ics", "A1B2", &dto.TaskUpdate{Title: "Buy milk", DueDate: due})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, dto.CalDAVResource{Href: "A1B2.ics", Uid: "A1B2", Task: s.tasks[1]}, *created)
+
+	// The client chosen name replaces the default one.
+	_, err = service.Find("task-1.ics")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	updated, ok, err := service.Put("A1B2.ics", "A1B2", &dto.TaskUpdate{Title: "Buy oat milk", Completed: true})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 2, updated.Task.Version)
+	assert.Equal(t, due, updated.Task.DueDate)
+	assert.True(t, updated.Task.Completed)
+
+	_, _, err = service.Put("A1B2.ics", "OTHER", &dto.TaskUpdate{Title: "x"})
+	assert.ErrorIs(t, err, ErrUidConflict)
+
+	// Tasks created elsewhere are reachable by their default name.
+	s.Create(&dto.TaskCreate{Title: "From REST", DueDate: due})
+	resource, err := service.Find("task-2.ics")
+	require.NoError(t, err)
+	assert.Equal(t, "task-2@todoverba", resource.Uid)
+
+	require.NoError(t, service.Delete("A1B2.ics", 0, "alice"))
+	_, err = service.Find("A1B2.ics")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestCalDAVService_Put_createFails(t *testing.T) {
+	s := newStore()
+	service := newTestService(s)
+	s.createErr = errors.New("connection refused")
+
+	_, _, err := service.Put("a.ics", "a", &dto.TaskUpdate{Title: "a"})
+	assert.ErrorIs(t, err, s.createErr)
+	assert.Empty(t, s.tasks)
+	assert.Empty(t, s.events)
+	assert.Empty(t, s.published, "nothing is published for a task that wasn't created")
+}
+
+func TestCalDAVService_Put_concurrentCreate(t *testing.T) {
+	s := newStore()
+	service := newTestService(s)
+
+	// Another Put creates the resource after this one found it missing.
+	s.beforeCreate = func() {
+		_, created, err := service.Put("a.ics", "a", &dto.TaskUpdate{Title: "first"})
+		require.NoError(t, err)
+		require.True(t, created)
+	}
+	_, _, err := service.Put("a.ics", "a", &dto.TaskUpdate{Title: "second"})
+	assert.ErrorIs(t, err, dto.ErrVersionConflict)
+	assert.Len(t, s.tasks, 1)
+	assert.Equal(t, "first", s.tasks[s.objects["a.ics"].TaskId].Title)
+	assert.Equal(t, []int{1}, s.published)
+
+	// The href of a deleted task is taken over.
+	require.NoError(t, service.Delete("a.ics", 0, "alice"))
+	created, ok, err := service.Put("a.ics", "a", &dto.TaskUpdate{Title: "third"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, created.Task.Id)
+}
+
+func TestCalDAVService_versionConflict(t *testing.T) {
+	s := newStore()
+	service := newTestService(s)
+	due := pgtype.Timestamptz{Time: time.Date(2024, 9, 5, 10, 0, 0, 0, time.UTC), Valid: true}
+
+	created, _, err := service.Put("a.ics", "a", &dto.TaskUpdate{Title: "a", DueDate: due})
+	require.NoError(t, err)
+	_, _, err = service.Put("a.ics", "a", &dto.TaskUpdate{Title: "a2", Version: created.Task.Version})
+	require.NoError(t, err)
+
+	// Writes checked against the first version lose to the update.
+	_, _, err = service.Put("a.ics", "a", &dto.TaskUpdate{Title: "a3", Version: created.Task.Version})
+	assert.ErrorIs(t, err, dto.ErrVersionConflict)
+	assert.ErrorIs(t, service.Delete("a.ics", created.Task.Version, "alice"), dto.ErrVersionConflict)
+	assert.Equal(t, "a2", s.tasks[created.Task.Id].Title)
+
+	// A checked write doesn't create the resource again once it is gone.
+	require.NoError(t, service.Delete("a.ics", created.Task.Version+1, "alice"))
+	_, _, err = service.Put("a.ics", "a", &dto.TaskUpdate{Title: "a4", Version: created.Task.Version + 1})
+	assert.ErrorIs(t, err, dto.ErrVersionConflict)
+	assert.Len(t, s.tasks, 0)
+}
+
+func TestCalDAVService_Changes(t *testing.T) {
+	s := newStore()
+	service := newTestService(s)
+	due := pgtype.Timestamptz{Time: time.Date(2024, 9, 5, 10, 0, 0, 0, time.UTC), Valid: true}
+
+	service.Put("a.ics", "a", &dto.TaskUpdate{Title: "a", DueDate: due})
+	s.Create(&dto.TaskCreate{Title: "b", DueDate: due})
+
+	initial, err := service.Changes(0)
+	require.NoError(t, err)
+	assert.Len(t, initial.Changed, 2)
+	assert.Empty(t, initial.Removed)
+	assert.Equal(t, int64(2), initial.SyncToken)
+
+	service.Put("a.ics", "a", &dto.TaskUpdate{Title: "a2"})
+	service.Put("a.ics", "a", &dto.TaskUpdate{Title: "a3"})
+	require.NoError(t, service.Delete("task-2.ics", 0, ""))
+	s.Create(&dto.TaskCreate{Title: "c", DueDate: due})
+	require.NoError(t, s.DeleteById(3, ""))
+
+	changes, err := service.Changes(initial.SyncToken)
+	require.NoError(t, err)
+	require.Len(t, changes.Changed, 1)
+	assert.Equal(t, "a.ics", changes.Changed[0].Href)
+	assert.Equal(t, "a3", changes.Changed[0].Task.Title)
+	assert.Equal(t, []string{"task-2.ics", "task-3.ics"}, changes.Removed)
+	assert.Equal(t, int64(7), changes.SyncToken)
+
+	unchanged, err := service.Changes(changes.SyncToken)
+	require.NoError(t, err)
+	assert.Empty(t, unchanged.Changed)
+	assert.Empty(t, unchanged.Removed)
+	assert.Equal(t, changes.SyncToken, unchanged.SyncToken)
+
+	_, err = service.Changes(100)
+	assert.ErrorIs(t, err, ErrInvalidSyncToken)
+}
@@ -0,0 +1,139 @@
+package idempotencyService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/repos"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/jackc/pgx/v5/pgtype"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrMismatch is returned when a key comes back with another request.
+	ErrMismatch = errors.New("idempotency key was already used with a different request")
+	// ErrInProgress is returned while the first request with the key runs.
+	ErrInProgress = errors.New("a request with this idempotency key is in progress")
+)
+
+const (
+	// staleAfter is how long a request may hold its key without a response
+	// before a retry takes the key over, in case its server went away. It is
+	// well past the 5s the services give a request: a request still running
+	// then runs a second time, the claim token only keeps the two responses
+	// apart.
+	staleAfter = time.Minute
+	// minCleanupInterval keeps a tiny TTL from running the cleanup in a loop.
+	minCleanupInterval = time.Second
+)
+
+type Deps struct {
+	Repo   repos.IdempotencyRepository
+	Logger logging.Logger
+	TTL    time.Duration
+}
+
+// IdempotencyService runs a request once per Idempotency-Key and replays its
+// response to the retries until the key expires. Only responses other than
+// server errors are kept, so a retry after one runs the request again.
+type IdempotencyService struct {
+	repo   repos.IdempotencyRepository
+	logger logging.Logger
+	ttl    time.Duration
+}
+
+// Do runs handle unless the key was used before. It returns the response and
+// whether it is the stored response of an earlier request.
+func (s *IdempotencyService) Do(key, fingerprint string, handle func() (int, http.Header, []byte)) (*dto.IdempotencyRecord, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	token, err := newClaimToken()
+	if err != nil {
+		s.logger.Errorf("service error on claim idempotency key: %s", err)
+		return nil, false, err
+	}
+
+	curTime := time.Now()
+	record, claimed, err := s.repo.Claim(ctx, &dto.IdempotencyClaim{
+		Key:         key,
+		Token:       token,
+		Fingerprint: fingerprint,
+		ExpiresAt:   pgtype.Timestamptz{Time: curTime.Add(s.ttl), Valid: true},
+		StaleBefore: pgtype.Timestamptz{Time: curTime.Add(-staleAfter), Valid: true},
+	})
+	if err != nil {
+		s.logger.Errorf("service error on claim idempotency key: %s", err)
+		return nil, false, err
+	}
+	if !claimed {
+		switch {
+		case record.Fingerprint != fingerprint:
+			return nil, false, ErrMismatch
+		case record.Status == 0:
+			return nil, false, ErrInProgress
+		}
+		s.logger.Debugf("service idempotency key replayed: %s", key)
+		return record, true, nil
+	}
+
+	status, header, body := handle()
+	record = &dto.IdempotencyRecord{Key: key, Token: token, Fingerprint: fingerprint, Status: status, Header: header, Body: body}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if status < http.StatusInternalServerError {
+		err = s.repo.Complete(ctx, record)
+		if err == nil {
+			return record, false, nil
+		}
+		s.logger.Errorf("service error on store idempotent response: %s", err)
+	}
+	if err = s.repo.Release(ctx, key, token); err != nil {
+		s.logger.Errorf("service error on release idempotency key: %s", err)
+	}
+	return record, false, nil
+}
+
+// newClaimToken returns a random token for a claim of a key.
+func newClaimToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// Run deletes the expired keys until ctx is cancelled.
+func (s *IdempotencyService) Run(ctx context.Context) {
+	ticker := time.NewTicker(max(s.ttl/10, minCleanupInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := s.repo.DeleteExpired(ctx)
+		if err != nil {
+			s.logger.Errorf("idempotency error on cleanup: %s", err)
+		} else if deleted > 0 {
+			s.logger.Debugf("idempotency deleted %d expired keys", deleted)
+		}
+	}
+}
+
+func NewIdempotencyService(d Deps) *IdempotencyService {
+	return &IdempotencyService{
+		repo:   d.Repo,
+		logger: d.Logger,
+		ttl:    d.TTL,
+	}
+}
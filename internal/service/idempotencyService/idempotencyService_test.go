@@ -0,0 +1,198 @@
+package idempotencyService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/memory"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newTestService(ttl time.Duration) (*IdempotencyService, *memory.IdempotencyMemory) {
+	repo := memory.NewIdempotencyMemory(logging.GetLoggerTest())
+	return NewIdempotencyService(Deps{Repo: repo, Logger: logging.GetLoggerTest(), TTL: ttl}), repo
+}
+
+func TestIdempotencyService_Do(t *testing.T) {
+	s, _ := newTestService(time.Hour)
+	calls := 0
+	handle := func() (int, http.Header, []byte) {
+		calls++
+		return http.StatusCreated, http.Header{"Location": {"/tasks/1"}}, []byte(`{"id":1}`)
+	}
+
+	record, replayed, err := s.Do("key", "a", handle)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, http.StatusCreated, record.Status)
+
+	record, replayed, err = s.Do("key", "a", handle)
+	require.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, http.StatusCreated, record.Status)
+	assert.Equal(t, "/tasks/1", record.Header.Get("Location"))
+	assert.Equal(t, `{"id":1}`, string(record.Body))
+	assert.Equal(t, 1, calls, "a retry is answered from the store")
+
+	_, _, err = s.Do("key", "b", handle)
+	assert.ErrorIs(t, err, ErrMismatch)
+
+	_, replayed, err = s.Do("other", "b", handle)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyService_Do_serverError(t *testing.T) {
+	s, _ := newTestService(time.Hour)
+	status := http.StatusInternalServerError
+	handle := func() (int, http.Header, []byte) {
+		return status, nil, nil
+	}
+
+	record, _, err := s.Do("key", "a", handle)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, record.Status)
+
+	status = http.StatusBadRequest
+	record, replayed, err := s.Do("key", "a", handle)
+	require.NoError(t, err)
+	assert.False(t, replayed, "server errors are not kept")
+	assert.Equal(t, http.StatusBadRequest, record.Status)
+
+	status = http.StatusCreated
+	record, replayed, err = s.Do("key", "a", handle)
+	require.NoError(t, err)
+	assert.True(t, replayed, "client errors are kept")
+	assert.Equal(t, http.StatusBadRequest, record.Status)
+}
+
+func TestIdempotencyService_Do_inProgress(t *testing.T) {
+	s, repo := newTestService(time.Hour)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, err := s.Do("key", "a", func() (int, http.Header, []byte) {
+			close(started)
+			<-release
+			return http.StatusCreated, nil, nil
+		})
+		assert.NoError(t, err)
+	}()
+	<-started
+
+	_, _, err := s.Do("key", "a", func() (int, http.Header, []byte) {
+		t.Error("a concurrent duplicate must not run")
+		return 0, nil, nil
+	})
+	assert.ErrorIs(t, err, ErrInProgress)
+	close(release)
+	<-done
+
+	// A claim that was never completed is taken over once it is stale.
+	_, claimed, err := repo.Claim(context.Background(), &dto.IdempotencyClaim{
+		Key:         "lost",
+		Fingerprint: "a",
+		ExpiresAt:   pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+	require.True(t, claimed)
+	_, _, err = s.Do("lost", "a", func() (int, http.Header, []byte) { return http.StatusCreated, nil, nil })
+	assert.ErrorIs(t, err, ErrInProgress)
+
+	s.repo = staleRepo{repo}
+	_, replayed, err := s.Do("lost", "a", func() (int, http.Header, []byte) { return http.StatusCreated, nil, nil })
+	require.NoError(t, err)
+	assert.False(t, replayed)
+}
+
+// staleRepo claims as if staleAfter had passed.
+type staleRepo struct {
+	*memory.IdempotencyMemory
+}
+
+func (r staleRepo) Claim(ctx context.Context, claim *dto.IdempotencyClaim) (*dto.IdempotencyRecord, bool, error) {
+	claim.StaleBefore.Time = time.Now().Add(time.Second)
+	return r.IdempotencyMemory.Claim(ctx, claim)
+}
+
+// A request that runs past staleAfter loses its key to a retry. Finishing
+// first, it neither stores its response on the retry's claim nor releases it.
+func TestIdempotencyService_Do_staleTakeover(t *testing.T) {
+	s, repo := newTestService(time.Hour)
+	retry := NewIdempotencyService(Deps{Repo: staleRepo{repo}, Logger: logging.GetLoggerTest(), TTL: time.Hour})
+
+	// slow runs Do with a handle that answers body once release is closed.
+	slow := func(s *IdempotencyService, body string) (release, done chan struct{}) {
+		started := make(chan struct{})
+		release, done = make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _, err := s.Do("key", "a", func() (int, http.Header, []byte) {
+				close(started)
+				<-release
+				return http.StatusCreated, nil, []byte(body)
+			})
+			assert.NoError(t, err)
+		}()
+		<-started
+		return release, done
+	}
+
+	releaseFirst, firstDone := slow(s, `{"id":1}`)
+	releaseRetry, retryDone := slow(retry, `{"id":2}`)
+	close(releaseFirst)
+	<-firstDone
+
+	_, _, err := s.Do("key", "a", func() (int, http.Header, []byte) {
+		t.Error("the retry's claim must still be in progress")
+		return 0, nil, nil
+	})
+	assert.ErrorIs(t, err, ErrInProgress)
+
+	close(releaseRetry)
+	<-retryDone
+	record, replayed, err := s.Do("key", "a", func() (int, http.Header, []byte) { return 0, nil, nil })
+	require.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, `{"id":2}`, string(record.Body))
+}
+
+func TestIdempotencyService_expiry(t *testing.T) {
+	s, repo := newTestService(20 * time.Millisecond)
+	calls := 0
+	handle := func() (int, http.Header, []byte) {
+		calls++
+		return http.StatusCreated, nil, nil
+	}
+
+	_, _, err := s.Do("key", "a", handle)
+	require.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+
+	_, replayed, err := s.Do("key", "b", handle)
+	require.NoError(t, err, "an expired key is free for any request")
+	assert.False(t, replayed)
+	assert.Equal(t, 2, calls)
+
+	time.Sleep(30 * time.Millisecond)
+	deleted, err := repo.DeleteExpired(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
+
+func TestIdempotencyService_Run_tinyTTL(t *testing.T) {
+	s, _ := newTestService(time.Nanosecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.NotPanics(t, func() { s.Run(ctx) })
+}
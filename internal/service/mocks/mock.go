@@ -11,7 +11,12 @@ package mock_service
 
 import (
 	dto "ToDoVerba/internal/dto"
+	brokerService "ToDoVerba/internal/service/brokerService"
+	context "context"
+	io "io"
+	http "net/http"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -55,17 +60,45 @@ func (mr *MockITaskServiceMockRecorder) Create(cTask any) *gomock.Call {
 }
 
 // DeleteById mocks base method.
-func (m *MockITaskService) DeleteById(id int) error {
+func (m *MockITaskService) DeleteById(id int, actor string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteById", id)
+	ret := m.ctrl.Call(m, "DeleteById", id, actor)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteById indicates an expected call of DeleteById.
-func (mr *MockITaskServiceMockRecorder) DeleteById(id any) *gomock.Call {
+func (mr *MockITaskServiceMockRecorder) DeleteById(id, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteById", reflect.TypeOf((*MockITaskService)(nil).DeleteById), id, actor)
+}
+
+// DeleteByIdVersion mocks base method.
+func (m *MockITaskService) DeleteByIdVersion(id, version int, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByIdVersion", id, version, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByIdVersion indicates an expected call of DeleteByIdVersion.
+func (mr *MockITaskServiceMockRecorder) DeleteByIdVersion(id, version, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByIdVersion", reflect.TypeOf((*MockITaskService)(nil).DeleteByIdVersion), id, version, actor)
+}
+
+// Export mocks base method.
+func (m *MockITaskService) Export(ctx context.Context, each func(*dto.TaskRead) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", ctx, each)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockITaskServiceMockRecorder) Export(ctx, each any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteById", reflect.TypeOf((*MockITaskService)(nil).DeleteById), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockITaskService)(nil).Export), ctx, each)
 }
 
 // FindByID mocks base method.
@@ -83,6 +116,21 @@ func (mr *MockITaskServiceMockRecorder) FindByID(id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockITaskService)(nil).FindByID), id)
 }
 
+// Import mocks base method.
+func (m *MockITaskService) Import(cTasks []dto.TaskCreate) ([]dto.TaskRead, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", cTasks)
+	ret0, _ := ret[0].([]dto.TaskRead)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import.
+func (mr *MockITaskServiceMockRecorder) Import(cTasks any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockITaskService)(nil).Import), cTasks)
+}
+
 // List mocks base method.
 func (m *MockITaskService) List() ([]dto.TaskRead, error) {
 	m.ctrl.T.Helper()
@@ -112,3 +160,886 @@ func (mr *MockITaskServiceMockRecorder) UpdateById(id, update any) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateById", reflect.TypeOf((*MockITaskService)(nil).UpdateById), id, update)
 }
+
+// MockITaskEventService is a mock of ITaskEventService interface.
+type MockITaskEventService struct {
+	ctrl     *gomock.Controller
+	recorder *MockITaskEventServiceMockRecorder
+}
+
+// MockITaskEventServiceMockRecorder is the mock recorder for MockITaskEventService.
+type MockITaskEventServiceMockRecorder struct {
+	mock *MockITaskEventService
+}
+
+// NewMockITaskEventService creates a new mock instance.
+func NewMockITaskEventService(ctrl *gomock.Controller) *MockITaskEventService {
+	mock := &MockITaskEventService{ctrl: ctrl}
+	mock.recorder = &MockITaskEventServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockITaskEventService) EXPECT() *MockITaskEventServiceMockRecorder {
+	return m.recorder
+}
+
+// History mocks base method.
+func (m *MockITaskEventService) History(taskId int) ([]dto.TaskEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History", taskId)
+	ret0, _ := ret[0].([]dto.TaskEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// History indicates an expected call of History.
+func (mr *MockITaskEventServiceMockRecorder) History(taskId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockITaskEventService)(nil).History), taskId)
+}
+
+// HistoryByTasks mocks base method.
+func (m *MockITaskEventService) HistoryByTasks(taskIds []int) (map[int][]dto.TaskEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HistoryByTasks", taskIds)
+	ret0, _ := ret[0].(map[int][]dto.TaskEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HistoryByTasks indicates an expected call of HistoryByTasks.
+func (mr *MockITaskEventServiceMockRecorder) HistoryByTasks(taskIds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HistoryByTasks", reflect.TypeOf((*MockITaskEventService)(nil).HistoryByTasks), taskIds)
+}
+
+// List mocks base method.
+func (m *MockITaskEventService) List(filter *dto.TaskEventFilter) ([]dto.TaskEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", filter)
+	ret0, _ := ret[0].([]dto.TaskEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockITaskEventServiceMockRecorder) List(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockITaskEventService)(nil).List), filter)
+}
+
+// MockITaskVersionService is a mock of ITaskVersionService interface.
+type MockITaskVersionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockITaskVersionServiceMockRecorder
+}
+
+// MockITaskVersionServiceMockRecorder is the mock recorder for MockITaskVersionService.
+type MockITaskVersionServiceMockRecorder struct {
+	mock *MockITaskVersionService
+}
+
+// NewMockITaskVersionService creates a new mock instance.
+func NewMockITaskVersionService(ctrl *gomock.Controller) *MockITaskVersionService {
+	mock := &MockITaskVersionService{ctrl: ctrl}
+	mock.recorder = &MockITaskVersionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockITaskVersionService) EXPECT() *MockITaskVersionServiceMockRecorder {
+	return m.recorder
+}
+
+// Find mocks base method.
+func (m *MockITaskVersionService) Find(taskId, version int) (*dto.TaskVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", taskId, version)
+	ret0, _ := ret[0].(*dto.TaskVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockITaskVersionServiceMockRecorder) Find(taskId, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockITaskVersionService)(nil).Find), taskId, version)
+}
+
+// List mocks base method.
+func (m *MockITaskVersionService) List(taskId int) ([]dto.TaskVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", taskId)
+	ret0, _ := ret[0].([]dto.TaskVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockITaskVersionServiceMockRecorder) List(taskId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockITaskVersionService)(nil).List), taskId)
+}
+
+// Revert mocks base method.
+func (m *MockITaskVersionService) Revert(taskId, version int, actor string) (*dto.TaskRead, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revert", taskId, version, actor)
+	ret0, _ := ret[0].(*dto.TaskRead)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revert indicates an expected call of Revert.
+func (mr *MockITaskVersionServiceMockRecorder) Revert(taskId, version, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revert", reflect.TypeOf((*MockITaskVersionService)(nil).Revert), taskId, version, actor)
+}
+
+// MockIWebhookService is a mock of IWebhookService interface.
+type MockIWebhookService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIWebhookServiceMockRecorder
+}
+
+// MockIWebhookServiceMockRecorder is the mock recorder for MockIWebhookService.
+type MockIWebhookServiceMockRecorder struct {
+	mock *MockIWebhookService
+}
+
+// NewMockIWebhookService creates a new mock instance.
+func NewMockIWebhookService(ctrl *gomock.Controller) *MockIWebhookService {
+	mock := &MockIWebhookService{ctrl: ctrl}
+	mock.recorder = &MockIWebhookServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIWebhookService) EXPECT() *MockIWebhookServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockIWebhookService) Create(cWebhook *dto.WebhookCreate) (*dto.WebhookRead, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", cWebhook)
+	ret0, _ := ret[0].(*dto.WebhookRead)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIWebhookServiceMockRecorder) Create(cWebhook any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIWebhookService)(nil).Create), cWebhook)
+}
+
+// DeleteById mocks base method.
+func (m *MockIWebhookService) DeleteById(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteById", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteById indicates an expected call of DeleteById.
+func (mr *MockIWebhookServiceMockRecorder) DeleteById(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteById", reflect.TypeOf((*MockIWebhookService)(nil).DeleteById), id)
+}
+
+// Enqueue mocks base method.
+func (m *MockIWebhookService) Enqueue(ctx context.Context, event *dto.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enqueue", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockIWebhookServiceMockRecorder) Enqueue(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockIWebhookService)(nil).Enqueue), ctx, event)
+}
+
+// FindByID mocks base method.
+func (m *MockIWebhookService) FindByID(id int) (*dto.WebhookRead, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", id)
+	ret0, _ := ret[0].(*dto.WebhookRead)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *MockIWebhookServiceMockRecorder) FindByID(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockIWebhookService)(nil).FindByID), id)
+}
+
+// List mocks base method.
+func (m *MockIWebhookService) List() ([]dto.WebhookRead, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]dto.WebhookRead)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockIWebhookServiceMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockIWebhookService)(nil).List))
+}
+
+// ListDeliveries mocks base method.
+func (m *MockIWebhookService) ListDeliveries(webhookId, offset, limit int) ([]dto.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeliveries", webhookId, offset, limit)
+	ret0, _ := ret[0].([]dto.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeliveries indicates an expected call of ListDeliveries.
+func (mr *MockIWebhookServiceMockRecorder) ListDeliveries(webhookId, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeliveries", reflect.TypeOf((*MockIWebhookService)(nil).ListDeliveries), webhookId, offset, limit)
+}
+
+// Run mocks base method.
+func (m *MockIWebhookService) Run(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockIWebhookServiceMockRecorder) Run(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockIWebhookService)(nil).Run), ctx)
+}
+
+// UpdateById mocks base method.
+func (m *MockIWebhookService) UpdateById(id int, update *dto.WebhookUpdate) (*dto.WebhookRead, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateById", id, update)
+	ret0, _ := ret[0].(*dto.WebhookRead)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateById indicates an expected call of UpdateById.
+func (mr *MockIWebhookServiceMockRecorder) UpdateById(id, update any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateById", reflect.TypeOf((*MockIWebhookService)(nil).UpdateById), id, update)
+}
+
+// MockIBrokerService is a mock of IBrokerService interface.
+type MockIBrokerService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIBrokerServiceMockRecorder
+}
+
+// MockIBrokerServiceMockRecorder is the mock recorder for MockIBrokerService.
+type MockIBrokerServiceMockRecorder struct {
+	mock *MockIBrokerService
+}
+
+// NewMockIBrokerService creates a new mock instance.
+func NewMockIBrokerService(ctrl *gomock.Controller) *MockIBrokerService {
+	mock := &MockIBrokerService{ctrl: ctrl}
+	mock.recorder = &MockIBrokerServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIBrokerService) EXPECT() *MockIBrokerServiceMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockIBrokerService) Publish(event *dto.Event) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Publish", event)
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockIBrokerServiceMockRecorder) Publish(event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockIBrokerService)(nil).Publish), event)
+}
+
+// Subscribe mocks base method.
+func (m *MockIBrokerService) Subscribe(lastEventId string, filter *dto.StreamFilter) (*brokerService.Subscription, []dto.StreamMessage) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", lastEventId, filter)
+	ret0, _ := ret[0].(*brokerService.Subscription)
+	ret1, _ := ret[1].([]dto.StreamMessage)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockIBrokerServiceMockRecorder) Subscribe(lastEventId, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockIBrokerService)(nil).Subscribe), lastEventId, filter)
+}
+
+// Unsubscribe mocks base method.
+func (m *MockIBrokerService) Unsubscribe(sub *brokerService.Subscription) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Unsubscribe", sub)
+}
+
+// Unsubscribe indicates an expected call of Unsubscribe.
+func (mr *MockIBrokerServiceMockRecorder) Unsubscribe(sub any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsubscribe", reflect.TypeOf((*MockIBrokerService)(nil).Unsubscribe), sub)
+}
+
+// MockIOutboxService is a mock of IOutboxService interface.
+type MockIOutboxService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIOutboxServiceMockRecorder
+}
+
+// MockIOutboxServiceMockRecorder is the mock recorder for MockIOutboxService.
+type MockIOutboxServiceMockRecorder struct {
+	mock *MockIOutboxService
+}
+
+// NewMockIOutboxService creates a new mock instance.
+func NewMockIOutboxService(ctrl *gomock.Controller) *MockIOutboxService {
+	mock := &MockIOutboxService{ctrl: ctrl}
+	mock.recorder = &MockIOutboxServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIOutboxService) EXPECT() *MockIOutboxServiceMockRecorder {
+	return m.recorder
+}
+
+// Run mocks base method.
+func (m *MockIOutboxService) Run(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockIOutboxServiceMockRecorder) Run(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockIOutboxService)(nil).Run), ctx)
+}
+
+// Subscribe mocks base method.
+func (m *MockIOutboxService) Subscribe(handler func(*dto.OutboxMessage) error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Subscribe", handler)
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockIOutboxServiceMockRecorder) Subscribe(handler any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockIOutboxService)(nil).Subscribe), handler)
+}
+
+// MockINotifyService is a mock of INotifyService interface.
+type MockINotifyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockINotifyServiceMockRecorder
+}
+
+// MockINotifyServiceMockRecorder is the mock recorder for MockINotifyService.
+type MockINotifyServiceMockRecorder struct {
+	mock *MockINotifyService
+}
+
+// NewMockINotifyService creates a new mock instance.
+func NewMockINotifyService(ctrl *gomock.Controller) *MockINotifyService {
+	mock := &MockINotifyService{ctrl: ctrl}
+	mock.recorder = &MockINotifyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockINotifyService) EXPECT() *MockINotifyServiceMockRecorder {
+	return m.recorder
+}
+
+// Run mocks base method.
+func (m *MockINotifyService) Run(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockINotifyServiceMockRecorder) Run(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockINotifyService)(nil).Run), ctx)
+}
+
+// Subscribe mocks base method.
+func (m *MockINotifyService) Subscribe(handler func(*dto.Event)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Subscribe", handler)
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockINotifyServiceMockRecorder) Subscribe(handler any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockINotifyService)(nil).Subscribe), handler)
+}
+
+// MockIUserService is a mock of IUserService interface.
+type MockIUserService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIUserServiceMockRecorder
+}
+
+// MockIUserServiceMockRecorder is the mock recorder for MockIUserService.
+type MockIUserServiceMockRecorder struct {
+	mock *MockIUserService
+}
+
+// NewMockIUserService creates a new mock instance.
+func NewMockIUserService(ctrl *gomock.Controller) *MockIUserService {
+	mock := &MockIUserService{ctrl: ctrl}
+	mock.recorder = &MockIUserServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIUserService) EXPECT() *MockIUserServiceMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockIUserService) Authenticate(token string) (*dto.UserRead, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", token)
+	ret0, _ := ret[0].(*dto.UserRead)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockIUserServiceMockRecorder) Authenticate(token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockIUserService)(nil).Authenticate), token)
+}
+
+// AuthenticateFeed mocks base method.
+func (m *MockIUserService) AuthenticateFeed(token string) (*dto.UserRead, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateFeed", token)
+	ret0, _ := ret[0].(*dto.UserRead)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateFeed indicates an expected call of AuthenticateFeed.
+func (mr *MockIUserServiceMockRecorder) AuthenticateFeed(token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateFeed", reflect.TypeOf((*MockIUserService)(nil).AuthenticateFeed), token)
+}
+
+// Create mocks base method.
+func (m *MockIUserService) Create(name string) (*dto.UserRead, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", name)
+	ret0, _ := ret[0].(*dto.UserRead)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIUserServiceMockRecorder) Create(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIUserService)(nil).Create), name)
+}
+
+// DeleteById mocks base method.
+func (m *MockIUserService) DeleteById(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteById", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteById indicates an expected call of DeleteById.
+func (mr *MockIUserServiceMockRecorder) DeleteById(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteById", reflect.TypeOf((*MockIUserService)(nil).DeleteById), id)
+}
+
+// List mocks base method.
+func (m *MockIUserService) List() ([]dto.UserRead, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]dto.UserRead)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockIUserServiceMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockIUserService)(nil).List))
+}
+
+// RotateFeedToken mocks base method.
+func (m *MockIUserService) RotateFeedToken(id int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateFeedToken", id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateFeedToken indicates an expected call of RotateFeedToken.
+func (mr *MockIUserServiceMockRecorder) RotateFeedToken(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateFeedToken", reflect.TypeOf((*MockIUserService)(nil).RotateFeedToken), id)
+}
+
+// MockIReminderService is a mock of IReminderService interface.
+type MockIReminderService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIReminderServiceMockRecorder
+}
+
+// MockIReminderServiceMockRecorder is the mock recorder for MockIReminderService.
+type MockIReminderServiceMockRecorder struct {
+	mock *MockIReminderService
+}
+
+// NewMockIReminderService creates a new mock instance.
+func NewMockIReminderService(ctrl *gomock.Controller) *MockIReminderService {
+	mock := &MockIReminderService{ctrl: ctrl}
+	mock.recorder = &MockIReminderServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIReminderService) EXPECT() *MockIReminderServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockIReminderService) Create(cReminder *dto.ReminderCreate) (*dto.Reminder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", cReminder)
+	ret0, _ := ret[0].(*dto.Reminder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIReminderServiceMockRecorder) Create(cReminder any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIReminderService)(nil).Create), cReminder)
+}
+
+// DeleteById mocks base method.
+func (m *MockIReminderService) DeleteById(taskId int, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteById", taskId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteById indicates an expected call of DeleteById.
+func (mr *MockIReminderServiceMockRecorder) DeleteById(taskId, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteById", reflect.TypeOf((*MockIReminderService)(nil).DeleteById), taskId, id)
+}
+
+// List mocks base method.
+func (m *MockIReminderService) List(taskId int) ([]dto.Reminder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", taskId)
+	ret0, _ := ret[0].([]dto.Reminder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockIReminderServiceMockRecorder) List(taskId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockIReminderService)(nil).List), taskId)
+}
+
+// ListByTasks mocks base method.
+func (m *MockIReminderService) ListByTasks(taskIds []int) (map[int][]dto.Reminder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByTasks", taskIds)
+	ret0, _ := ret[0].(map[int][]dto.Reminder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByTasks indicates an expected call of ListByTasks.
+func (mr *MockIReminderServiceMockRecorder) ListByTasks(taskIds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByTasks", reflect.TypeOf((*MockIReminderService)(nil).ListByTasks), taskIds)
+}
+
+// Run mocks base method.
+func (m *MockIReminderService) Run(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockIReminderServiceMockRecorder) Run(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockIReminderService)(nil).Run), ctx)
+}
+
+// Snooze mocks base method.
+func (m *MockIReminderService) Snooze(taskId int, id int64, d time.Duration) (*dto.Reminder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Snooze", taskId, id, d)
+	ret0, _ := ret[0].(*dto.Reminder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Snooze indicates an expected call of Snooze.
+func (mr *MockIReminderServiceMockRecorder) Snooze(taskId, id, d any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snooze", reflect.TypeOf((*MockIReminderService)(nil).Snooze), taskId, id, d)
+}
+
+// MockICalDAVService is a mock of ICalDAVService interface.
+type MockICalDAVService struct {
+	ctrl     *gomock.Controller
+	recorder *MockICalDAVServiceMockRecorder
+}
+
+// MockICalDAVServiceMockRecorder is the mock recorder for MockICalDAVService.
+type MockICalDAVServiceMockRecorder struct {
+	mock *MockICalDAVService
+}
+
+// NewMockICalDAVService creates a new mock instance.
+func NewMockICalDAVService(ctrl *gomock.Controller) *MockICalDAVService {
+	mock := &MockICalDAVService{ctrl: ctrl}
+	mock.recorder = &MockICalDAVServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockICalDAVService) EXPECT() *MockICalDAVServiceMockRecorder {
+	return m.recorder
+}
+
+// Changes mocks base method.
+func (m *MockICalDAVService) Changes(token int64) (*dto.CalDAVChanges, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Changes", token)
+	ret0, _ := ret[0].(*dto.CalDAVChanges)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Changes indicates an expected call of Changes.
+func (mr *MockICalDAVServiceMockRecorder) Changes(token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Changes", reflect.TypeOf((*MockICalDAVService)(nil).Changes), token)
+}
+
+// Delete mocks base method.
+func (m *MockICalDAVService) Delete(href string, version int, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", href, version, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockICalDAVServiceMockRecorder) Delete(href, version, actor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockICalDAVService)(nil).Delete), href, version, actor)
+}
+
+// Find mocks base method.
+func (m *MockICalDAVService) Find(href string) (*dto.CalDAVResource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", href)
+	ret0, _ := ret[0].(*dto.CalDAVResource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockICalDAVServiceMockRecorder) Find(href any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockICalDAVService)(nil).Find), href)
+}
+
+// List mocks base method.
+func (m *MockICalDAVService) List() ([]dto.CalDAVResource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]dto.CalDAVResource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockICalDAVServiceMockRecorder) List() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockICalDAVService)(nil).List))
+}
+
+// Put mocks base method.
+func (m *MockICalDAVService) Put(href, uid string, update *dto.TaskUpdate) (*dto.CalDAVResource, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", href, uid, update)
+	ret0, _ := ret[0].(*dto.CalDAVResource)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockICalDAVServiceMockRecorder) Put(href, uid, update any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockICalDAVService)(nil).Put), href, uid, update)
+}
+
+// SyncToken mocks base method.
+func (m *MockICalDAVService) SyncToken() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncToken")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncToken indicates an expected call of SyncToken.
+func (mr *MockICalDAVServiceMockRecorder) SyncToken() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncToken", reflect.TypeOf((*MockICalDAVService)(nil).SyncToken))
+}
+
+// MockIBackupService is a mock of IBackupService interface.
+type MockIBackupService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIBackupServiceMockRecorder
+}
+
+// MockIBackupServiceMockRecorder is the mock recorder for MockIBackupService.
+type MockIBackupServiceMockRecorder struct {
+	mock *MockIBackupService
+}
+
+// NewMockIBackupService creates a new mock instance.
+func NewMockIBackupService(ctrl *gomock.Controller) *MockIBackupService {
+	mock := &MockIBackupService{ctrl: ctrl}
+	mock.recorder = &MockIBackupServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIBackupService) EXPECT() *MockIBackupServiceMockRecorder {
+	return m.recorder
+}
+
+// Authorize mocks base method.
+func (m *MockIBackupService) Authorize(token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockIBackupServiceMockRecorder) Authorize(token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockIBackupService)(nil).Authorize), token)
+}
+
+// Backup mocks base method.
+func (m *MockIBackupService) Backup(ctx context.Context, w io.Writer) (*dto.BackupStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Backup", ctx, w)
+	ret0, _ := ret[0].(*dto.BackupStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Backup indicates an expected call of Backup.
+func (mr *MockIBackupServiceMockRecorder) Backup(ctx, w any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Backup", reflect.TypeOf((*MockIBackupService)(nil).Backup), ctx, w)
+}
+
+// Restore mocks base method.
+func (m *MockIBackupService) Restore(ctx context.Context, r io.Reader, mode string) (*dto.BackupStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, r, mode)
+	ret0, _ := ret[0].(*dto.BackupStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockIBackupServiceMockRecorder) Restore(ctx, r, mode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockIBackupService)(nil).Restore), ctx, r, mode)
+}
+
+// MockIIdempotencyService is a mock of IIdempotencyService interface.
+type MockIIdempotencyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIIdempotencyServiceMockRecorder
+}
+
+// MockIIdempotencyServiceMockRecorder is the mock recorder for MockIIdempotencyService.
+type MockIIdempotencyServiceMockRecorder struct {
+	mock *MockIIdempotencyService
+}
+
+// NewMockIIdempotencyService creates a new mock instance.
+func NewMockIIdempotencyService(ctrl *gomock.Controller) *MockIIdempotencyService {
+	mock := &MockIIdempotencyService{ctrl: ctrl}
+	mock.recorder = &MockIIdempotencyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIIdempotencyService) EXPECT() *MockIIdempotencyServiceMockRecorder {
+	return m.recorder
+}
+
+// Do mocks base method.
+func (m *MockIIdempotencyService) Do(key, fingerprint string, handle func() (int, http.Header, []byte)) (*dto.IdempotencyRecord, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Do", key, fingerprint, handle)
+	ret0, _ := ret[0].(*dto.IdempotencyRecord)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Do indicates an expected call of Do.
+func (mr *MockIIdempotencyServiceMockRecorder) Do(key, fingerprint, handle any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockIIdempotencyService)(nil).Do), key, fingerprint, handle)
+}
+
+// Run mocks base method.
+func (m *MockIIdempotencyService) Run(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockIIdempotencyServiceMockRecorder) Run(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockIIdempotencyService)(nil).Run), ctx)
+}
@@ -0,0 +1,116 @@
+package notifyService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/repos"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"sync"
+	"time"
+)
+
+// Publisher is the local fan-out the changes of other replicas are
+// republished to, typically the broker behind GET /tasks/events.
+type Publisher interface {
+	Publish(event *dto.Event)
+}
+
+type Deps struct {
+	Repo       repos.NotifyRepository
+	TaskRepo   repos.TaskRepository
+	Logger     logging.Logger
+	Publisher  Publisher
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// NotifyService listens for task changes written through other replicas and
+// republishes them locally, so that live streams and local caches of this
+// replica see every write.
+type NotifyService struct {
+	repo       repos.NotifyRepository
+	taskRepo   repos.TaskRepository
+	logger     logging.Logger
+	publisher  Publisher
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	mu       sync.RWMutex
+	handlers []func(event *dto.Event)
+}
+
+// Subscribe registers a handler for changes made by other replicas, e.g. to
+// invalidate a local cache entry of the task.
+func (s *NotifyService) Subscribe(handler func(event *dto.Event)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, handler)
+}
+
+// Run listens until ctx is cancelled and reconnects with exponential backoff
+// whenever the connection is lost.
+func (s *NotifyService) Run(ctx context.Context) {
+	backoff := s.backoff
+	for {
+		err := s.repo.Listen(ctx, func() {
+			backoff = s.backoff
+			s.logger.Infof("notify listening for task changes")
+		}, s.republish)
+		if ctx.Err() != nil {
+			return
+		}
+		s.logger.Errorf("notify listener disconnected, reconnect in %s: %s", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+	}
+}
+
+func (s *NotifyService) republish(event *dto.Event) {
+	if event.Task == nil && event.Type != dto.EventTaskDeleted {
+		s.reloadTask(event)
+	}
+	s.logger.Debugf("notify task change %s %s of task %d", event.Type, event.Id, event.TaskId)
+
+	if s.publisher != nil {
+		s.publisher.Publish(event)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, handler := range s.handlers {
+		handler(event)
+	}
+}
+
+// reloadTask fills in a task that was too large for the notification payload.
+// The event is republished without it if the task is gone by now.
+func (s *NotifyService) reloadTask(event *dto.Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	task, err := s.taskRepo.FindById(ctx, event.TaskId)
+	if err != nil {
+		s.logger.Debugf("notify could not reload task %d: %s", event.TaskId, err)
+		return
+	}
+	event.Task = task
+}
+
+func NewNotifyService(d Deps) *NotifyService {
+	return &NotifyService{
+		repo:       d.Repo,
+		taskRepo:   d.TaskRepo,
+		logger:     d.Logger,
+		publisher:  d.Publisher,
+		backoff:    d.Backoff,
+		maxBackoff: d.MaxBackoff,
+	}
+}
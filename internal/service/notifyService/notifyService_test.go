@@ -0,0 +1,142 @@
+package notifyService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyRepo fails the first connections and delivers its events on the
+// first one that succeeds.
+type flakyRepo struct {
+	failures int
+	events   []dto.Event
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (r *flakyRepo) Listen(ctx context.Context, onListen func(), handle func(event *dto.Event)) error {
+	r.mu.Lock()
+	r.calls++
+	call := r.calls
+	r.mu.Unlock()
+
+	if call <= r.failures {
+		return errors.New("connection refused")
+	}
+	onListen()
+	for i := range r.events {
+		handle(&r.events[i])
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+type taskRepo struct {
+	tasks map[int]dto.TaskRead
+}
+
+func (r *taskRepo) Create(ctx context.Context, cTask *dto.TaskCreate) (*dto.TaskRead, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *taskRepo) FindById(ctx context.Context, id int) (*dto.TaskRead, error) {
+	task, ok := r.tasks[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &task, nil
+}
+
+func (r *taskRepo) List(ctx context.Context) ([]dto.TaskRead, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func (r *taskRepo) Stream(ctx context.Context, each func(task *dto.TaskRead) error) error {
+	return errors.New("not implemented")
+}
+
+func (r *taskRepo) CreateMany(ctx context.Context, cTasks []dto.TaskCreate) ([]dto.TaskRead, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *taskRepo) UpdateByID(ctx context.Context, id int, update *dto.TaskUpdate) (*dto.TaskRead, []string, error) {
+	return nil, nil, errors.New("not implemented")
+}
+
+func (r *taskRepo) DeleteByID(ctx context.Context, id, version int, actor string) (int, error) {
+	return 0, errors.New("not implemented")
+}
+
+type recorder struct {
+	mu     sync.Mutex
+	events []dto.Event
+}
+
+func (r *recorder) Publish(event *dto.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, *event)
+}
+
+func (r *recorder) published() []dto.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]dto.Event{}, r.events...)
+}
+
+func TestNotifyService_ReconnectsAndRepublishes(t *testing.T) {
+	repo := &flakyRepo{
+		failures: 2,
+		events: []dto.Event{
+			{Id: "e1", Type: dto.EventTaskUpdated, TaskId: 1, Task: &dto.TaskRead{Id: 1, Title: "Inline"}},
+			{Id: "e2", Type: dto.EventTaskUpdated, TaskId: 2},
+			{Id: "e3", Type: dto.EventTaskDeleted, TaskId: 3},
+		},
+	}
+	broker := &recorder{}
+	s := NewNotifyService(Deps{
+		Repo:       repo,
+		TaskRepo:   &taskRepo{tasks: map[int]dto.TaskRead{2: {Id: 2, Title: "Reloaded"}}},
+		Logger:     logging.GetLoggerTest(),
+		Publisher:  broker,
+		Backoff:    time.Millisecond,
+		MaxBackoff: 5 * time.Millisecond,
+	})
+
+	invalidated := make(chan int, 3)
+	s.Subscribe(func(event *dto.Event) {
+		invalidated <- event.TaskId
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return len(broker.published()) == 3 }, time.Second, time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+
+	events := broker.published()
+	require.Len(t, events, 3)
+	assert.Equal(t, "Inline", events[0].Task.Title)
+	assert.Equal(t, "Reloaded", events[1].Task.Title)
+	assert.Nil(t, events[2].Task)
+	assert.Equal(t, 3, repo.calls)
+	assert.Equal(t, []int{1, 2, 3}, []int{<-invalidated, <-invalidated, <-invalidated})
+}
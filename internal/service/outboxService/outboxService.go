@@ -0,0 +1,110 @@
+package outboxService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/repos"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"errors"
+	"time"
+)
+
+var errKeyBlocked = errors.New("earlier message with the same ordering key was not published")
+
+type Deps struct {
+	Repo         repos.OutboxRepository
+	Logger       logging.Logger
+	Publishers   []Publisher
+	PollInterval time.Duration
+	BatchSize    int
+	Retention    time.Duration
+}
+
+// OutboxService relays events that TaskCRUD stored in the outbox table to the
+// publishers. Delivery is at-least-once.
+type OutboxService struct {
+	repo         repos.OutboxRepository
+	logger       logging.Logger
+	publishers   []Publisher
+	inProcess    *InProcessPublisher
+	pollInterval time.Duration
+	batchSize    int
+	retention    time.Duration
+}
+
+// Subscribe registers an in-process consumer of relayed messages.
+func (s *OutboxService) Subscribe(handler func(msg *dto.OutboxMessage) error) {
+	s.inProcess.Subscribe(handler)
+}
+
+// Run relays the outbox until ctx is cancelled.
+func (s *OutboxService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	lastCleanup := time.Time{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for ctx.Err() == nil {
+			n, err := s.relayBatch(ctx)
+			if err != nil {
+				s.logger.Errorf("outbox error on relay batch: %s", err)
+				break
+			}
+			if n < s.batchSize {
+				break
+			}
+		}
+
+		if time.Since(lastCleanup) > s.retention/10 {
+			lastCleanup = time.Now()
+			deleted, err := s.repo.DeletePublished(ctx, time.Now().Add(-s.retention))
+			if err != nil {
+				s.logger.Errorf("outbox error on cleanup: %s", err)
+			} else if deleted > 0 {
+				s.logger.Debugf("outbox deleted %d published messages", deleted)
+			}
+		}
+	}
+}
+
+// relayBatch publishes one batch and returns the number of messages it
+// published. Once a message fails, later messages with the same ordering key
+// are held back so a consumer never sees them out of order.
+func (s *OutboxService) relayBatch(ctx context.Context) (int, error) {
+	blocked := make(map[string]bool)
+
+	return s.repo.ProcessBatch(ctx, s.batchSize, func(msg *dto.OutboxMessage) error {
+		if blocked[msg.Event.OrderingKey] {
+			return errKeyBlocked
+		}
+		for _, p := range s.publishers {
+			if err := p.Publish(ctx, msg); err != nil {
+				s.logger.Errorf("outbox error on publish message %d (%s %s): %s",
+					msg.Id, msg.Event.Type, msg.Event.OrderingKey, err)
+				blocked[msg.Event.OrderingKey] = true
+				return err
+			}
+		}
+		s.logger.Debugf("outbox published message %d: %s %s", msg.Id, msg.Event.Type, msg.Event.OrderingKey)
+		return nil
+	})
+}
+
+func NewOutboxService(d Deps) *OutboxService {
+	inProcess := NewInProcessPublisher()
+	return &OutboxService{
+		repo:         d.Repo,
+		logger:       d.Logger,
+		publishers:   append([]Publisher{inProcess}, d.Publishers...),
+		inProcess:    inProcess,
+		pollInterval: d.PollInterval,
+		batchSize:    d.BatchSize,
+		retention:    d.Retention,
+	}
+}
@@ -0,0 +1,101 @@
+package outboxService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type sliceRepo struct {
+	messages  []dto.OutboxMessage
+	published map[int64]bool
+}
+
+func (r *sliceRepo) ProcessBatch(ctx context.Context, limit int, handle func(msg *dto.OutboxMessage) error) (int, error) {
+	n := 0
+	for i := range r.messages {
+		if r.published[r.messages[i].Id] {
+			continue
+		}
+		if handle(&r.messages[i]) == nil {
+			r.published[r.messages[i].Id] = true
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *sliceRepo) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func message(id int64, key, eventType string) dto.OutboxMessage {
+	return dto.OutboxMessage{Id: id, Event: dto.Event{Id: key + eventType, Type: eventType, OrderingKey: key}}
+}
+
+func TestOutboxService_relayBatchKeepsKeyOrder(t *testing.T) {
+	repo := &sliceRepo{
+		messages: []dto.OutboxMessage{
+			message(1, "task:1", dto.EventTaskCreated),
+			message(2, "task:2", dto.EventTaskCreated),
+			message(3, "task:1", dto.EventTaskUpdated),
+			message(4, "task:2", dto.EventTaskDeleted),
+		},
+		published: map[int64]bool{},
+	}
+	s := NewOutboxService(Deps{Repo: repo, Logger: logging.GetLoggerTest(), BatchSize: 10})
+
+	var seen []int64
+	failFirst := true
+	s.Subscribe(func(msg *dto.OutboxMessage) error {
+		if msg.Id == 1 && failFirst {
+			failFirst = false
+			return errors.New("consumer unavailable")
+		}
+		seen = append(seen, msg.Id)
+		return nil
+	})
+
+	n, err := s.relayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int64{2, 4}, seen)
+
+	n, err = s.relayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int64{2, 4, 1, 3}, seen)
+}
+
+func TestNDJSONPublisher_Publish(t *testing.T) {
+	buf := &bytes.Buffer{}
+	p := NewNDJSONPublisher(buf)
+
+	msg := message(5, "task:7", dto.EventTaskDeleted)
+	msg.Event.TaskId = 7
+	msg.Event.Actor = "alice"
+	msg.Event.OccurredAt = time.Date(2024, 9, 5, 10, 4, 5, 0, time.UTC)
+	require.NoError(t, p.Publish(context.Background(), &msg))
+	require.NoError(t, p.Publish(context.Background(), &msg))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{
+		"offset": 5,
+		"event": {
+			"id": "task:7task.deleted",
+			"type": "task.deleted",
+			"task_id": 7,
+			"actor": "alice",
+			"task": null,
+			"ordering_key": "task:7",
+			"occurred_at": "2024-09-05T10:04:05Z"
+		}
+	}`, string(lines[0]))
+}
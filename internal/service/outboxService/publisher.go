@@ -0,0 +1,86 @@
+package outboxService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/schemas"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// Publisher hands an outbox message to a consumer. A message is marked
+// published only when Publish returns nil, otherwise it is retried, so
+// consumers must tolerate duplicates.
+type Publisher interface {
+	Publish(ctx context.Context, msg *dto.OutboxMessage) error
+}
+
+// InProcessPublisher calls subscribed handlers synchronously, in subscription order.
+type InProcessPublisher struct {
+	mu       sync.RWMutex
+	handlers []func(msg *dto.OutboxMessage) error
+}
+
+func (p *InProcessPublisher) Subscribe(handler func(msg *dto.OutboxMessage) error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers = append(p.handlers, handler)
+}
+
+func (p *InProcessPublisher) Publish(ctx context.Context, msg *dto.OutboxMessage) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, handler := range p.handlers {
+		if err := handler(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewInProcessPublisher() *InProcessPublisher {
+	return &InProcessPublisher{}
+}
+
+type WebhookEnqueuer interface {
+	Enqueue(ctx context.Context, event *dto.Event) error
+}
+
+// WebhookPublisher fans messages out to webhook subscriptions. The message is
+// published once the deliveries are stored; the webhook dispatcher retries them.
+type WebhookPublisher struct {
+	webhooks WebhookEnqueuer
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, msg *dto.OutboxMessage) error {
+	return p.webhooks.Enqueue(ctx, &msg.Event)
+}
+
+func NewWebhookPublisher(webhooks WebhookEnqueuer) *WebhookPublisher {
+	return &WebhookPublisher{webhooks: webhooks}
+}
+
+// NDJSONPublisher writes every message as one JSON line.
+type NDJSONPublisher struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+type ndjsonLine struct {
+	Offset int64                `json:"offset"`
+	Event  schemas.EventPayload `json:"event"`
+}
+
+func (p *NDJSONPublisher) Publish(ctx context.Context, msg *dto.OutboxMessage) error {
+	line := ndjsonLine{Offset: msg.Id}
+	line.Event.ScanDTO(&msg.Event)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.enc.Encode(line)
+}
+
+func NewNDJSONPublisher(w io.Writer) *NDJSONPublisher {
+	return &NDJSONPublisher{enc: json.NewEncoder(w)}
+}
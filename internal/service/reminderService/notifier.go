@@ -0,0 +1,122 @@
+package reminderService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/schemas"
+	"ToDoVerba/internal/service/webhookService"
+	"ToDoVerba/pkg/logging"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Notifier delivers a due reminder through one channel.
+type Notifier interface {
+	Notify(ctx context.Context, reminder *dto.Reminder) error
+}
+
+type LogNotifier struct {
+	logger logging.Logger
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, reminder *dto.Reminder) error {
+	n.logger.Infof("reminder %s: task %d %q is due at %s", schemas.ReminderDeliveryId(reminder),
+		reminder.Task.Id, reminder.Task.Title, reminder.Task.DueDate.Time.Format(time.RFC3339))
+	return nil
+}
+
+func NewLogNotifier(logger logging.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+// WebhookNotifier posts a schemas.ReminderPayload signed like task event
+// webhooks.
+type WebhookNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, reminder *dto.Reminder) error {
+	payload := schemas.ReminderPayload{}
+	payload.ScanDTO(reminder)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookService.EventHeader, "task.reminder")
+	req.Header.Set(webhookService.IdHeader, payload.DeliveryId)
+	req.Header.Set(webhookService.SignatureHeader, webhookService.Sign(n.secret, body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func NewWebhookNotifier(url, secret string, client *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{url: url, secret: secret, client: client}
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// EmailNotifier sends a plain text mail through an SMTP server.
+type EmailNotifier struct {
+	conf SMTPConfig
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, reminder *dto.Reminder) error {
+	var auth smtp.Auth
+	if n.conf.Username != "" {
+		auth = smtp.PlainAuth("", n.conf.Username, n.conf.Password, n.conf.Host)
+	}
+	return n.send(net.JoinHostPort(n.conf.Host, n.conf.Port), auth, n.conf.From, n.conf.To, n.message(reminder))
+}
+
+func (n *EmailNotifier) message(reminder *dto.Reminder) []byte {
+	task := reminder.Task
+	msg := &strings.Builder{}
+	fmt.Fprintf(msg, "From: %s\r\n", n.conf.From)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(n.conf.To, ", "))
+	fmt.Fprintf(msg, "Subject: Reminder: %s\r\n", headerSafe(task.Title))
+	fmt.Fprintf(msg, "Message-ID: <%s@todo>\r\n", schemas.ReminderDeliveryId(reminder))
+	fmt.Fprintf(msg, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(msg, "%s is due at %s.\r\n\r\n%s\r\n", task.Title, task.DueDate.Time.Format(time.RFC1123Z), task.Description)
+	return []byte(msg.String())
+}
+
+func headerSafe(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+func NewEmailNotifier(conf SMTPConfig) *EmailNotifier {
+	return &EmailNotifier{conf: conf, send: smtp.SendMail}
+}
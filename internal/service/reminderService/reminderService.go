@@ -0,0 +1,270 @@
+package reminderService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/repos"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"strings"
+	"time"
+)
+
+// ErrChannelDisabled is returned for reminders on a channel that is not
+// configured on this server.
+var ErrChannelDisabled = errors.New("reminder channel is not enabled")
+
+const (
+	maxBackoff  = time.Hour
+	sendTimeout = 30 * time.Second
+	// claimMargin is added to the longest a dispatch can take, see
+	// claimTimeout.
+	claimMargin = time.Minute
+)
+
+type Deps struct {
+	Repo            repos.ReminderRepository
+	Logger          logging.Logger
+	Notifiers       map[string]Notifier
+	DefaultChannels []string
+	PollInterval    time.Duration
+	BatchSize       int
+	MaxAttempts     int
+	Backoff         time.Duration
+}
+
+// ReminderService schedules reminders relative to task due dates and
+// dispatches due ones from Run.
+type ReminderService struct {
+	repo            repos.ReminderRepository
+	logger          logging.Logger
+	notifiers       map[string]Notifier
+	defaultChannels []string
+	pollInterval    time.Duration
+	batchSize       int
+	maxAttempts     int
+	backoff         time.Duration
+}
+
+func (s *ReminderService) Create(cReminder *dto.ReminderCreate) (*dto.Reminder, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if len(cReminder.Channels) == 0 {
+		cReminder.Channels = s.defaultChannels
+	}
+	for _, channel := range cReminder.Channels {
+		if _, ok := s.notifiers[channel]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrChannelDisabled, channel)
+		}
+	}
+
+	rReminder, err := s.repo.Create(ctx, cReminder)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debugf("No rows found with task id %d", cReminder.TaskId)
+		} else {
+			s.logger.Errorf("service error on create reminder: %s", err)
+		}
+		return nil, err
+	}
+
+	s.logger.Debugf("service reminder %d of task %d fires at %s", rReminder.Id, rReminder.TaskId, rReminder.FireAt.Time)
+	return rReminder, nil
+}
+
+func (s *ReminderService) List(taskId int) ([]dto.Reminder, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rReminders, err := s.repo.ListByTaskID(ctx, taskId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debugf("no reminders found for task id %d", taskId)
+		} else {
+			s.logger.Errorf("service error on list reminders of task %d : %s", taskId, err)
+		}
+		return nil, err
+	}
+
+	return rReminders, nil
+}
+
+// ListByTasks returns the reminders of each of the tasks keyed by task id,
+// read with a single query. Tasks without reminders have no entry.
+func (s *ReminderService) ListByTasks(taskIds []int) (map[int][]dto.Reminder, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rReminders, err := s.repo.ListByTaskIDs(ctx, taskIds)
+	if err != nil {
+		s.logger.Errorf("service error on list reminders of %d tasks : %s", len(taskIds), err)
+		return nil, err
+	}
+
+	reminders := make(map[int][]dto.Reminder, len(taskIds))
+	for _, reminder := range rReminders {
+		reminders[reminder.TaskId] = append(reminders[reminder.TaskId], reminder)
+	}
+	return reminders, nil
+}
+
+func (s *ReminderService) Snooze(taskId int, id int64, d time.Duration) (*dto.Reminder, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rReminder, err := s.repo.Snooze(ctx, taskId, id, time.Now().Add(d))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debugf("no reminder %d found for task id %d", id, taskId)
+		} else {
+			s.logger.Errorf("service error on snooze reminder %d : %s", id, err)
+		}
+		return nil, err
+	}
+
+	s.logger.Debugf("service reminder %d snoozed until %s", id, rReminder.FireAt.Time)
+	return rReminder, nil
+}
+
+func (s *ReminderService) DeleteById(taskId int, id int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.repo.DeleteByID(ctx, taskId, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debugf("no reminder %d found for task id %d", id, taskId)
+		} else {
+			s.logger.Errorf("service error on delete reminder %d : %s", id, err)
+		}
+		return err
+	}
+
+	s.logger.Debugf("service reminder deleted: %d", id)
+	return nil
+}
+
+// Run dispatches due reminders until ctx is cancelled.
+func (s *ReminderService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			n, err := s.repo.ProcessDue(ctx, time.Now(), s.batchSize, s.claimTimeout(), func(r *dto.Reminder) *dto.ReminderUpdate {
+				return s.dispatch(ctx, r)
+			})
+			if err != nil {
+				s.logger.Errorf("reminder error on process due reminders: %s", err)
+				break
+			}
+			if n < s.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch sends the reminder through the channels it was not sent through
+// yet. Channels that succeeded are remembered, so a retry does not repeat them.
+// The claim of the reminder has already counted the attempt.
+func (s *ReminderService) dispatch(ctx context.Context, r *dto.Reminder) *dto.ReminderUpdate {
+	update := &dto.ReminderUpdate{
+		Id:           r.Id,
+		Status:       dto.ReminderSent,
+		SentChannels: append([]string{}, r.SentChannels...),
+		FireAt:       r.FireAt,
+		Attempts:     r.Attempts,
+	}
+
+	var failed []string
+	for _, channel := range r.Channels {
+		if contains(update.SentChannels, channel) {
+			continue
+		}
+		notifier, ok := s.notifiers[channel]
+		if !ok {
+			failed = append(failed, channel+": "+ErrChannelDisabled.Error())
+			continue
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err := notifier.Notify(sendCtx, r)
+		cancel()
+		if err != nil {
+			failed = append(failed, channel+": "+err.Error())
+			continue
+		}
+		update.SentChannels = append(update.SentChannels, channel)
+	}
+
+	if len(failed) == 0 {
+		update.SentAt = pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true}
+		s.logger.Debugf("reminder %d of task %d sent", r.Id, r.TaskId)
+		return update
+	}
+
+	update.LastError = strings.Join(failed, "; ")
+	if update.Attempts >= s.maxAttempts {
+		update.Status = dto.ReminderFailed
+		s.logger.Errorf("reminder %d of task %d failed after %d attempts: %s", r.Id, r.TaskId, update.Attempts, update.LastError)
+		return update
+	}
+
+	update.Status = dto.ReminderPending
+	update.FireAt = pgtype.Timestamptz{Time: time.Now().UTC().Add(s.backoffFor(update.Attempts)), Valid: true}
+	s.logger.Debugf("reminder %d of task %d failed, attempt %d: %s", r.Id, r.TaskId, update.Attempts, update.LastError)
+	return update
+}
+
+// claimTimeout is how long a claimed reminder is left to this dispatcher
+// before another one may claim it again: a send timing out on every channel,
+// and a margin. Reminders are claimed one at a time, so the batch size doesn't
+// add to it.
+func (s *ReminderService) claimTimeout() time.Duration {
+	return time.Duration(len(s.notifiers))*sendTimeout + claimMargin
+}
+
+// backoffFor doubles the base backoff with every failed attempt.
+func (s *ReminderService) backoffFor(attempts int) time.Duration {
+	backoff := s.backoff
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func NewReminderService(d Deps) *ReminderService {
+	return &ReminderService{
+		repo:            d.Repo,
+		logger:          d.Logger,
+		notifiers:       d.Notifiers,
+		defaultChannels: d.DefaultChannels,
+		pollInterval:    d.PollInterval,
+		batchSize:       d.BatchSize,
+		maxAttempts:     d.MaxAttempts,
+		backoff:         d.Backoff,
+	}
+}
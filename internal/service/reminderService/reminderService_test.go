@@ -0,0 +1,143 @@
+package reminderService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/schemas"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingNotifier struct {
+	err  error
+	sent []string
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, reminder *dto.Reminder) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, schemas.ReminderDeliveryId(reminder))
+	return nil
+}
+
+// dueReminder is a reminder as its first claim returns it.
+func dueReminder() *dto.Reminder {
+	due, _ := time.Parse(time.RFC3339, "2024-09-05T15:00:00Z")
+	return &dto.Reminder{
+		Id:         3,
+		TaskId:     7,
+		Offset:     15 * time.Minute,
+		Channels:   []string{dto.ReminderChannelLog, dto.ReminderChannelWebhook},
+		FireAt:     pgtype.Timestamptz{Time: due.Add(-15 * time.Minute), Valid: true},
+		Status:     dto.ReminderPending,
+		Generation: 2,
+		Attempts:   1,
+		Task:       &dto.TaskRead{Id: 7, Title: "Ship it", DueDate: pgtype.Timestamptz{Time: due, Valid: true}},
+	}
+}
+
+func TestReminderService_dispatchRetriesOnlyFailedChannels(t *testing.T) {
+	logNotifier := &recordingNotifier{}
+	webhookNotifier := &recordingNotifier{err: errors.New("connection refused")}
+	s := NewReminderService(Deps{
+		Logger: logging.GetLoggerTest(),
+		Notifiers: map[string]Notifier{
+			dto.ReminderChannelLog:     logNotifier,
+			dto.ReminderChannelWebhook: webhookNotifier,
+		},
+		MaxAttempts: 2,
+		Backoff:     time.Minute,
+	})
+
+	r := dueReminder()
+	update := s.dispatch(context.Background(), r)
+	assert.Equal(t, dto.ReminderPending, update.Status)
+	assert.Equal(t, []string{dto.ReminderChannelLog}, update.SentChannels)
+	assert.Equal(t, 1, update.Attempts)
+	assert.Equal(t, "webhook: connection refused", update.LastError)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), update.FireAt.Time, 5*time.Second)
+
+	// The retry only goes to the webhook and keeps the delivery id.
+	r.SentChannels, r.FireAt = update.SentChannels, update.FireAt
+	r.Generation, r.Attempts = r.Generation+1, update.Attempts+1
+	webhookNotifier.err = nil
+	update = s.dispatch(context.Background(), r)
+	assert.Equal(t, dto.ReminderSent, update.Status)
+	assert.True(t, update.SentAt.Valid)
+	assert.Equal(t, []string{"reminder-3-1"}, logNotifier.sent)
+	assert.Equal(t, []string{"reminder-3-1"}, webhookNotifier.sent)
+}
+
+func TestReminderService_dispatchGivesUp(t *testing.T) {
+	s := NewReminderService(Deps{
+		Logger:      logging.GetLoggerTest(),
+		Notifiers:   map[string]Notifier{dto.ReminderChannelLog: &recordingNotifier{}},
+		MaxAttempts: 3,
+		Backoff:     time.Minute,
+	})
+
+	r := dueReminder()
+	r.Generation, r.Attempts = 4, 3
+	update := s.dispatch(context.Background(), r)
+	assert.Equal(t, dto.ReminderFailed, update.Status)
+	assert.Equal(t, "webhook: reminder channel is not enabled", update.LastError)
+}
+
+func TestReminderService_claimTimeout(t *testing.T) {
+	s := NewReminderService(Deps{
+		Logger: logging.GetLoggerTest(),
+		Notifiers: map[string]Notifier{
+			dto.ReminderChannelLog:     &recordingNotifier{},
+			dto.ReminderChannelWebhook: &recordingNotifier{},
+			dto.ReminderChannelEmail:   &recordingNotifier{},
+		},
+		BatchSize: 20,
+	})
+
+	// A claim outlasts the sends of one reminder timing out on every channel,
+	// whatever the batch size.
+	assert.Greater(t, s.claimTimeout(), 3*sendTimeout)
+	assert.Less(t, s.claimTimeout(), 20*sendTimeout)
+}
+
+func TestReminderService_CreateRejectsDisabledChannel(t *testing.T) {
+	s := NewReminderService(Deps{
+		Logger:          logging.GetLoggerTest(),
+		Notifiers:       map[string]Notifier{dto.ReminderChannelLog: &recordingNotifier{}},
+		DefaultChannels: []string{dto.ReminderChannelLog},
+	})
+
+	_, err := s.Create(&dto.ReminderCreate{TaskId: 1, Offset: time.Hour, Channels: []string{dto.ReminderChannelEmail}})
+	assert.ErrorIs(t, err, ErrChannelDisabled)
+}
+
+func TestEmailNotifier_Notify(t *testing.T) {
+	n := NewEmailNotifier(SMTPConfig{Host: "mail.local", Port: "25", From: "todo@local", To: []string{"a@local", "b@local"}})
+
+	var addr string
+	var to []string
+	var msg []byte
+	n.send = func(a string, auth smtp.Auth, from string, rcpt []string, m []byte) error {
+		addr, to, msg = a, rcpt, m
+		return nil
+	}
+
+	r := dueReminder()
+	r.Task.Title = "Ship\r\nBcc: x@evil"
+	require.NoError(t, n.Notify(context.Background(), r))
+
+	assert.Equal(t, "mail.local:25", addr)
+	assert.Equal(t, []string{"a@local", "b@local"}, to)
+	headers, _, _ := strings.Cut(string(msg), "\r\n\r\n")
+	assert.Contains(t, headers, "Subject: Reminder: Ship  Bcc: x@evil\r\n")
+	assert.Contains(t, headers, "Message-ID: <reminder-3-1@todo>")
+	assert.NotContains(t, headers, "\r\nBcc:")
+}
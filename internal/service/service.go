@@ -1,27 +1,161 @@
 package service
 
 import (
+	"ToDoVerba/internal/config"
 	"ToDoVerba/internal/dto"
 	"ToDoVerba/internal/repos"
+	"ToDoVerba/internal/service/backupService"
+	"ToDoVerba/internal/service/brokerService"
+	"ToDoVerba/internal/service/caldavService"
+	"ToDoVerba/internal/service/idempotencyService"
+	"ToDoVerba/internal/service/notifyService"
+	"ToDoVerba/internal/service/outboxService"
+	"ToDoVerba/internal/service/reminderService"
+	"ToDoVerba/internal/service/taskEventService"
 	"ToDoVerba/internal/service/taskService"
+	"ToDoVerba/internal/service/taskVersionService"
+	"ToDoVerba/internal/service/userService"
+	"ToDoVerba/internal/service/webhookService"
 	"ToDoVerba/pkg/logging"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"time"
 )
 
 type Deps struct {
 	Repos  repos.Repositories
 	Logger logging.Logger
+	Config *config.Config
 }
 
 type Services struct {
-	Task ITaskService
+	Task        ITaskService
+	TaskEvent   ITaskEventService
+	TaskVersion ITaskVersionService
+	Webhook     IWebhookService
+	Outbox      IOutboxService
+	Broker      IBrokerService
+	Notify      INotifyService
+	User        IUserService
+	Reminder    IReminderService
+	CalDAV      ICalDAVService
+	Backup      IBackupService
+	Idempotency IIdempotencyService
 }
 
 func NewServices(d Deps) Services {
+	webhooks := webhookService.NewWebhookService(webhookService.Deps{
+		Repo:         d.Repos.Webhook,
+		Logger:       d.Logger,
+		Client:       &http.Client{Timeout: d.Config.Webhook.Timeout},
+		MaxAttempts:  d.Config.Webhook.MaxAttempts,
+		Backoff:      d.Config.Webhook.Backoff,
+		PollInterval: d.Config.Webhook.PollInterval,
+	})
+
+	broker := brokerService.NewBrokerService(brokerService.Deps{
+		Logger:     d.Logger,
+		ReplaySize: d.Config.Stream.ReplaySize,
+		BufferSize: d.Config.Stream.BufferSize,
+	})
+
+	var publishers []outboxService.Publisher
+	for _, name := range d.Config.Outbox.Publishers {
+		switch name {
+		case "webhook":
+			publishers = append(publishers, outboxService.NewWebhookPublisher(webhooks))
+		case "stdout":
+			publishers = append(publishers, outboxService.NewNDJSONPublisher(os.Stdout))
+		default:
+			d.Logger.Errorf("Unknown outbox publisher %q. Skipping", name)
+		}
+	}
+
+	notifiers := map[string]reminderService.Notifier{
+		dto.ReminderChannelLog: reminderService.NewLogNotifier(d.Logger),
+	}
+	if d.Config.Reminder.WebhookUrl != "" {
+		notifiers[dto.ReminderChannelWebhook] = reminderService.NewWebhookNotifier(d.Config.Reminder.WebhookUrl,
+			d.Config.Reminder.WebhookSecret, &http.Client{Timeout: d.Config.Webhook.Timeout})
+	}
+	if d.Config.SMTP.Host != "" && len(d.Config.Reminder.EmailTo) > 0 {
+		notifiers[dto.ReminderChannelEmail] = reminderService.NewEmailNotifier(reminderService.SMTPConfig{
+			Host:     d.Config.SMTP.Host,
+			Port:     d.Config.SMTP.Port,
+			Username: d.Config.SMTP.Username,
+			Password: d.Config.SMTP.Password,
+			From:     d.Config.SMTP.From,
+			To:       d.Config.Reminder.EmailTo,
+		})
+	}
+
+	tasks := taskService.NewTaskService(taskService.Deps{
+		Repo:      d.Repos.Task,
+		Logger:    d.Logger,
+		Publisher: broker,
+	})
+
 	return Services{
-		Task: taskService.NewTaskService(taskService.Deps{
-			Repo:   d.Repos.Task,
+		Task: tasks,
+		TaskEvent: taskEventService.NewTaskEventService(taskEventService.Deps{
+			Repo:   d.Repos.TaskEvent,
+			Logger: d.Logger,
+		}),
+		TaskVersion: taskVersionService.NewTaskVersionService(taskVersionService.Deps{
+			Repo:   d.Repos.TaskVersion,
+			Tasks:  tasks,
 			Logger: d.Logger,
 		}),
+		Webhook: webhooks,
+		Outbox: outboxService.NewOutboxService(outboxService.Deps{
+			Repo:         d.Repos.Outbox,
+			Logger:       d.Logger,
+			Publishers:   publishers,
+			PollInterval: d.Config.Outbox.PollInterval,
+			BatchSize:    d.Config.Outbox.BatchSize,
+			Retention:    d.Config.Outbox.Retention,
+		}),
+		Broker: broker,
+		Notify: notifyService.NewNotifyService(notifyService.Deps{
+			Repo:       d.Repos.Notify,
+			TaskRepo:   d.Repos.Task,
+			Logger:     d.Logger,
+			Publisher:  broker,
+			Backoff:    d.Config.Notify.Backoff,
+			MaxBackoff: d.Config.Notify.MaxBackoff,
+		}),
+		User: userService.NewUserService(userService.Deps{
+			Repo:   d.Repos.User,
+			Logger: d.Logger,
+		}),
+		Reminder: reminderService.NewReminderService(reminderService.Deps{
+			Repo:            d.Repos.Reminder,
+			Logger:          d.Logger,
+			Notifiers:       notifiers,
+			DefaultChannels: d.Config.Reminder.Channels,
+			PollInterval:    d.Config.Reminder.PollInterval,
+			BatchSize:       d.Config.Reminder.BatchSize,
+			MaxAttempts:     d.Config.Reminder.MaxAttempts,
+			Backoff:         d.Config.Reminder.Backoff,
+		}),
+		CalDAV: caldavService.NewCalDAVService(caldavService.Deps{
+			Repo:      d.Repos.CalDAV,
+			EventRepo: d.Repos.TaskEvent,
+			Tasks:     tasks,
+			Logger:    d.Logger,
+		}),
+		Backup: backupService.NewBackupService(backupService.Deps{
+			Repo:       d.Repos.Backup,
+			Logger:     d.Logger,
+			AdminToken: d.Config.Admin.Token,
+		}),
+		Idempotency: idempotencyService.NewIdempotencyService(idempotencyService.Deps{
+			Repo:   d.Repos.Idempotency,
+			Logger: d.Logger,
+			TTL:    d.Config.Idempotency.TTL,
+		}),
 	}
 }
 
@@ -31,6 +165,86 @@ type ITaskService interface {
 	Create(cTask *dto.TaskCreate) (*dto.TaskRead, error)
 	FindByID(id int) (*dto.TaskRead, error)
 	List() ([]dto.TaskRead, error)
+	Export(ctx context.Context, each func(task *dto.TaskRead) error) error
+	Import(cTasks []dto.TaskCreate) ([]dto.TaskRead, error)
 	UpdateById(id int, update *dto.TaskUpdate) (*dto.TaskRead, error)
+	DeleteById(id int, actor string) error
+	DeleteByIdVersion(id, version int, actor string) error
+}
+
+type ITaskEventService interface {
+	History(taskId int) ([]dto.TaskEvent, error)
+	HistoryByTasks(taskIds []int) (map[int][]dto.TaskEvent, error)
+	List(filter *dto.TaskEventFilter) ([]dto.TaskEvent, error)
+}
+
+type ITaskVersionService interface {
+	List(taskId int) ([]dto.TaskVersion, error)
+	Find(taskId, version int) (*dto.TaskVersion, error)
+	Revert(taskId, version int, actor string) (*dto.TaskRead, error)
+}
+
+type IWebhookService interface {
+	Create(cWebhook *dto.WebhookCreate) (*dto.WebhookRead, error)
+	FindByID(id int) (*dto.WebhookRead, error)
+	List() ([]dto.WebhookRead, error)
+	UpdateById(id int, update *dto.WebhookUpdate) (*dto.WebhookRead, error)
 	DeleteById(id int) error
+	ListDeliveries(webhookId, offset, limit int) ([]dto.WebhookDelivery, error)
+	Enqueue(ctx context.Context, event *dto.Event) error
+	Run(ctx context.Context)
+}
+
+type IBrokerService interface {
+	Publish(event *dto.Event)
+	Subscribe(lastEventId string, filter *dto.StreamFilter) (*brokerService.Subscription, []dto.StreamMessage)
+	Unsubscribe(sub *brokerService.Subscription)
+}
+
+type IOutboxService interface {
+	Subscribe(handler func(msg *dto.OutboxMessage) error)
+	Run(ctx context.Context)
+}
+
+type INotifyService interface {
+	Subscribe(handler func(event *dto.Event))
+	Run(ctx context.Context)
+}
+
+type IUserService interface {
+	Create(name string) (*dto.UserRead, string, error)
+	Authenticate(token string) (*dto.UserRead, error)
+	RotateFeedToken(id int) (string, error)
+	AuthenticateFeed(token string) (*dto.UserRead, error)
+	List() ([]dto.UserRead, error)
+	DeleteById(id int) error
+}
+
+type IReminderService interface {
+	Create(cReminder *dto.ReminderCreate) (*dto.Reminder, error)
+	List(taskId int) ([]dto.Reminder, error)
+	ListByTasks(taskIds []int) (map[int][]dto.Reminder, error)
+	Snooze(taskId int, id int64, d time.Duration) (*dto.Reminder, error)
+	DeleteById(taskId int, id int64) error
+	Run(ctx context.Context)
+}
+
+type ICalDAVService interface {
+	List() ([]dto.CalDAVResource, error)
+	Find(href string) (*dto.CalDAVResource, error)
+	Put(href, uid string, update *dto.TaskUpdate) (*dto.CalDAVResource, bool, error)
+	Delete(href string, version int, actor string) error
+	SyncToken() (int64, error)
+	Changes(token int64) (*dto.CalDAVChanges, error)
+}
+
+type IBackupService interface {
+	Authorize(token string) error
+	Backup(ctx context.Context, w io.Writer) (*dto.BackupStats, error)
+	Restore(ctx context.Context, r io.Reader, mode string) (*dto.BackupStats, error)
+}
+
+type IIdempotencyService interface {
+	Do(key, fingerprint string, handle func() (int, http.Header, []byte)) (*dto.IdempotencyRecord, bool, error)
+	Run(ctx context.Context)
 }
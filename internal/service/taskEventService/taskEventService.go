@@ -0,0 +1,84 @@
+package taskEventService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/repos"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type Deps struct {
+	Repo   repos.TaskEventRepository
+	Logger logging.Logger
+}
+
+type TaskEventService struct {
+	repo   repos.TaskEventRepository
+	logger logging.Logger
+}
+
+func (s *TaskEventService) History(taskId int) ([]dto.TaskEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rEvents, err := s.repo.ListByTaskID(ctx, taskId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debugf("no history found for task id %d", taskId)
+		} else {
+			s.logger.Errorf("service error on task history with id %d : %s", taskId, err)
+		}
+		return nil, err
+	}
+
+	s.logger.Debugf("service found %d events for task %d", len(rEvents), taskId)
+	return rEvents, nil
+}
+
+// HistoryByTasks returns the history of each of the tasks keyed by task id,
+// read with a single query. Tasks without history have no entry.
+func (s *TaskEventService) HistoryByTasks(taskIds []int) (map[int][]dto.TaskEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rEvents, err := s.repo.ListByTaskIDs(ctx, taskIds)
+	if err != nil {
+		s.logger.Errorf("service error on history of %d tasks : %s", len(taskIds), err)
+		return nil, err
+	}
+
+	histories := make(map[int][]dto.TaskEvent, len(taskIds))
+	for _, event := range rEvents {
+		histories[event.TaskId] = append(histories[event.TaskId], event)
+	}
+	s.logger.Debugf("service found %d events for %d tasks", len(rEvents), len(taskIds))
+	return histories, nil
+}
+
+func (s *TaskEventService) List(filter *dto.TaskEventFilter) ([]dto.TaskEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rEvents, err := s.repo.List(ctx, filter)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debug("No rows found on list task events")
+		} else {
+			s.logger.Errorf("service error on list task events: %s", err)
+		}
+		return nil, err
+	}
+
+	s.logger.Debugf("service found %d task events", len(rEvents))
+	return rEvents, nil
+}
+
+func NewTaskEventService(d Deps) *TaskEventService {
+	return &TaskEventService{
+		repo:   d.Repo,
+		logger: d.Logger,
+	}
+}
@@ -0,0 +1,37 @@
+package taskService
+
+import (
+	"ToDoVerba/internal/dto"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Publisher receives task events for live subscribers once the repository
+// call succeeded. Reliable delivery goes through the outbox instead.
+type Publisher interface {
+	Publish(event *dto.Event)
+}
+
+func (s *TaskService) publish(eventType string, taskId int, task *dto.TaskRead, actor string) {
+	if s.publisher == nil {
+		return
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		s.logger.Errorf("service error on generate event id: %s", err)
+		return
+	}
+
+	s.publisher.Publish(&dto.Event{
+		Id:          hex.EncodeToString(id),
+		Type:        eventType,
+		TaskId:      taskId,
+		Actor:       actor,
+		Task:        task,
+		OrderingKey: "task:" + strconv.Itoa(taskId),
+		OccurredAt:  time.Now().UTC(),
+	})
+}
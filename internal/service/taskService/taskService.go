@@ -11,13 +11,15 @@ import (
 )
 
 type Deps struct {
-	Repo   repos.TaskRepository
-	Logger logging.Logger
+	Repo      repos.TaskRepository
+	Logger    logging.Logger
+	Publisher Publisher
 }
 
 type TaskService struct {
-	repo   repos.TaskRepository
-	logger logging.Logger
+	repo      repos.TaskRepository
+	logger    logging.Logger
+	publisher Publisher
 }
 
 func (s *TaskService) Create(cTask *dto.TaskCreate) (*dto.TaskRead, error) {
@@ -30,9 +32,17 @@ func (s *TaskService) Create(cTask *dto.TaskCreate) (*dto.TaskRead, error) {
 		return nil, err
 	}
 	s.logger.Debugf("service task created: %+v", rTask)
+	s.publish(dto.EventTaskCreated, rTask.Id, rTask, cTask.Actor)
 	return rTask, nil
 }
 
+// Created publishes a task created by another repository together with its
+// own records, such as a CalDAV resource, as Create would.
+func (s *TaskService) Created(rTask *dto.TaskRead, actor string) {
+	s.logger.Debugf("service task created: %+v", rTask)
+	s.publish(dto.EventTaskCreated, rTask.Id, rTask, actor)
+}
+
 func (s *TaskService) FindByID(id int) (*dto.TaskRead, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -69,14 +79,44 @@ func (s *TaskService) List() ([]dto.TaskRead, error) {
 	return rTasks, nil
 }
 
+// Export streams every task to each. It runs under ctx rather than the usual
+// timeout, as a large export takes as long as the client needs to read it.
+func (s *TaskService) Export(ctx context.Context, each func(task *dto.TaskRead) error) error {
+	err := s.repo.Stream(ctx, each)
+	if err != nil {
+		s.logger.Errorf("service error on export tasks: %s", err)
+		return err
+	}
+	return nil
+}
+
+// Import creates all the tasks or none of them.
+func (s *TaskService) Import(cTasks []dto.TaskCreate) ([]dto.TaskRead, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rTasks, err := s.repo.CreateMany(ctx, cTasks)
+	if err != nil {
+		s.logger.Errorf("service error on import tasks: %s", err)
+		return nil, err
+	}
+	s.logger.Debugf("service imported %d tasks", len(rTasks))
+	for i := range rTasks {
+		s.publish(dto.EventTaskCreated, rTasks[i].Id, &rTasks[i], cTasks[i].Actor)
+	}
+	return rTasks, nil
+}
+
 func (s *TaskService) UpdateById(id int, update *dto.TaskUpdate) (*dto.TaskRead, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	rTask, err := s.repo.UpdateByID(ctx, id, update)
+	rTask, eventTypes, err := s.repo.UpdateByID(ctx, id, update)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			s.logger.Debugf("no rows found with task id %d", id)
+		} else if errors.Is(err, dto.ErrVersionConflict) {
+			s.logger.Debugf("task %d is not at version %d", id, update.Version)
 		} else {
 			s.logger.Errorf("service error on list task: %s", err)
 		}
@@ -84,29 +124,43 @@ func (s *TaskService) UpdateById(id int, update *dto.TaskUpdate) (*dto.TaskRead,
 	}
 
 	s.logger.Debugf("service task updated: %+v", rTask)
+	for _, eventType := range eventTypes {
+		s.publish(eventType, rTask.Id, rTask, update.Actor)
+	}
 	return rTask, nil
 }
 
-func (s *TaskService) DeleteById(id int) error {
+func (s *TaskService) DeleteById(id int, actor string) error {
+	return s.DeleteByIdVersion(id, 0, actor)
+}
+
+// DeleteByIdVersion deletes the task only if it is at version, and returns
+// dto.ErrVersionConflict otherwise. Version 0 deletes any version.
+func (s *TaskService) DeleteByIdVersion(id, version int, actor string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err := s.repo.DeleteByID(ctx, id)
+	_, err := s.repo.DeleteByID(ctx, id, version, actor)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			s.logger.Debugf("no rows found with task id %d", id)
+		} else if errors.Is(err, dto.ErrVersionConflict) {
+			s.logger.Debugf("task %d is not at version %d", id, version)
 		} else {
 			s.logger.Errorf("service error on delete task: %s", err)
 		}
+		return err
 	}
 
 	s.logger.Debugf("service task deleted: %d", id)
-	return err
+	s.publish(dto.EventTaskDeleted, id, nil, actor)
+	return nil
 }
 
 func NewTaskService(d Deps) *TaskService {
 	return &TaskService{
-		repo:   d.Repo,
-		logger: d.Logger,
+		repo:      d.Repo,
+		logger:    d.Logger,
+		publisher: d.Publisher,
 	}
 }
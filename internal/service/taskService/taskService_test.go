@@ -0,0 +1,44 @@
+package taskService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/memory"
+	"ToDoVerba/internal/service/brokerService"
+	"ToDoVerba/pkg/logging"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTaskService_UpdateById_publishesCompleted(t *testing.T) {
+	logger := logging.GetLoggerTest()
+	broker := brokerService.NewBrokerService(brokerService.Deps{Logger: logger, ReplaySize: 8, BufferSize: 8})
+	s := NewTaskService(Deps{Repo: memory.NewTaskMemory(logger), Logger: logger, Publisher: broker})
+
+	dueDate := pgtype.Timestamptz{Time: time.Date(2024, 9, 5, 15, 4, 5, 0, time.UTC), Valid: true}
+	rTask, err := s.Create(&dto.TaskCreate{Title: "Task", DueDate: dueDate})
+	require.NoError(t, err)
+
+	sub, _ := broker.Subscribe("", &dto.StreamFilter{TaskId: rTask.Id})
+	defer broker.Unsubscribe(sub)
+
+	update := &dto.TaskUpdate{Title: "Task", DueDate: dueDate, Completed: true, Actor: "alice"}
+	_, err = s.UpdateById(rTask.Id, update)
+	require.NoError(t, err)
+	_, err = s.UpdateById(rTask.Id, update)
+	require.NoError(t, err)
+
+	var eventTypes []string
+	for len(eventTypes) < 3 {
+		select {
+		case msg := <-sub.C:
+			eventTypes = append(eventTypes, msg.Event.Type)
+		case <-time.After(time.Second):
+			t.Fatalf("got events %v", eventTypes)
+		}
+	}
+	// Only the update that completed the task is followed by task.completed.
+	assert.Equal(t, []string{dto.EventTaskUpdated, dto.EventTaskCompleted, dto.EventTaskUpdated}, eventTypes)
+}
@@ -0,0 +1,109 @@
+package taskVersionService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/repos"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+// Tasks is the task service a revert writes through, so that it is published
+// to live subscribers like any other update.
+type Tasks interface {
+	UpdateById(id int, update *dto.TaskUpdate) (*dto.TaskRead, error)
+}
+
+type Deps struct {
+	Repo   repos.TaskVersionRepository
+	Tasks  Tasks
+	Logger logging.Logger
+}
+
+type TaskVersionService struct {
+	repo   repos.TaskVersionRepository
+	tasks  Tasks
+	logger logging.Logger
+}
+
+func (s *TaskVersionService) List(taskId int) ([]dto.TaskVersion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rVersions, err := s.repo.ListByTaskID(ctx, taskId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debugf("no versions found for task id %d", taskId)
+		} else {
+			s.logger.Errorf("service error on list versions of task %d : %s", taskId, err)
+		}
+		return nil, err
+	}
+
+	s.logger.Debugf("service found %d versions of task %d", len(rVersions), taskId)
+	return rVersions, nil
+}
+
+func (s *TaskVersionService) Find(taskId, version int) (*dto.TaskVersion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rVersion, err := s.repo.FindByVersion(ctx, taskId, version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debugf("no version %d found for task id %d", version, taskId)
+		} else {
+			s.logger.Errorf("service error on find version %d of task %d : %s", version, taskId, err)
+		}
+		return nil, err
+	}
+
+	s.logger.Debugf("service task version found: %+v", rVersion)
+	return rVersion, nil
+}
+
+// Revert restores the snapshot as a regular update, so the revert gets its own
+// version and can be reverted as well.
+func (s *TaskVersionService) Revert(taskId, version int, actor string) (*dto.TaskRead, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rVersion, err := s.repo.FindByVersion(ctx, taskId, version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debugf("no version %d found for task id %d", version, taskId)
+		} else {
+			s.logger.Errorf("service error on revert task %d to version %d : %s", taskId, version, err)
+		}
+		return nil, err
+	}
+
+	rTask, err := s.tasks.UpdateById(taskId, &dto.TaskUpdate{
+		Title:       rVersion.Title,
+		Description: rVersion.Description,
+		DueDate:     rVersion.DueDate,
+		Completed:   rVersion.Completed,
+		Actor:       actor,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debugf("no rows found with task id %d", taskId)
+		} else {
+			s.logger.Errorf("service error on revert task %d to version %d : %s", taskId, version, err)
+		}
+		return nil, err
+	}
+
+	s.logger.Debugf("service task %d reverted to version %d: %+v", taskId, version, rTask)
+	return rTask, nil
+}
+
+func NewTaskVersionService(d Deps) *TaskVersionService {
+	return &TaskVersionService{
+		repo:   d.Repo,
+		tasks:  d.Tasks,
+		logger: d.Logger,
+	}
+}
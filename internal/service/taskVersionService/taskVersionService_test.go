@@ -0,0 +1,67 @@
+package taskVersionService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/memory"
+	"ToDoVerba/internal/service/brokerService"
+	"ToDoVerba/internal/service/taskService"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// versions keeps the snapshots of one task.
+type versions map[int]dto.TaskVersion
+
+func (v versions) ListByTaskID(ctx context.Context, taskId int) ([]dto.TaskVersion, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func (v versions) FindByVersion(ctx context.Context, taskId, version int) (*dto.TaskVersion, error) {
+	snapshot, ok := v[version]
+	if !ok || snapshot.TaskId != taskId {
+		return nil, pgx.ErrNoRows
+	}
+	return &snapshot, nil
+}
+
+func TestTaskVersionService_Revert_publishes(t *testing.T) {
+	logger := logging.GetLoggerTest()
+	broker := brokerService.NewBrokerService(brokerService.Deps{Logger: logger, ReplaySize: 8, BufferSize: 8})
+	tasks := taskService.NewTaskService(taskService.Deps{
+		Repo:      memory.NewTaskMemory(logger),
+		Logger:    logger,
+		Publisher: broker,
+	})
+	dueDate := pgtype.Timestamptz{Time: time.Date(2024, 9, 5, 15, 4, 5, 0, time.UTC), Valid: true}
+	rTask, err := tasks.Create(&dto.TaskCreate{Title: "New", DueDate: dueDate})
+	require.NoError(t, err)
+
+	s := NewTaskVersionService(Deps{
+		Repo:   versions{1: {TaskId: rTask.Id, Version: 1, Title: "Old", DueDate: dueDate}},
+		Tasks:  tasks,
+		Logger: logger,
+	})
+
+	sub, _ := broker.Subscribe("", &dto.StreamFilter{TaskId: rTask.Id})
+	defer broker.Unsubscribe(sub)
+
+	reverted, err := s.Revert(rTask.Id, 1, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "Old", reverted.Title)
+
+	select {
+	case msg := <-sub.C:
+		assert.Equal(t, dto.EventTaskUpdated, msg.Event.Type)
+		assert.Equal(t, rTask.Id, msg.Event.TaskId)
+		assert.Equal(t, "alice", msg.Event.Actor)
+		assert.Equal(t, "Old", msg.Event.Task.Title)
+	case <-time.After(time.Second):
+		t.Fatal("the revert was not published to the subscriber")
+	}
+}
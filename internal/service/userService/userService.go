@@ -0,0 +1,166 @@
+package userService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/repos"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+// ErrUnauthorized is returned for a missing or unknown API token.
+var ErrUnauthorized = errors.New("invalid or missing api token")
+
+type Deps struct {
+	Repo   repos.UserRepository
+	Logger logging.Logger
+}
+
+// UserService manages API users. Only a hash of the token is stored, so the
+// token is returned once, on create.
+type UserService struct {
+	repo   repos.UserRepository
+	logger logging.Logger
+}
+
+func (s *UserService) Create(name string) (*dto.UserRead, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	token, err := newToken()
+	if err != nil {
+		s.logger.Errorf("service error on generate api token: %s", err)
+		return nil, "", err
+	}
+
+	rUser, err := s.repo.Create(ctx, &dto.UserCreate{Name: name, TokenHash: HashToken(token)})
+	if err != nil {
+		s.logger.Errorf("service error on create user: %s", err)
+		return nil, "", err
+	}
+	s.logger.Debugf("service user created: %d %s", rUser.Id, rUser.Name)
+	return rUser, token, nil
+}
+
+func (s *UserService) Authenticate(token string) (*dto.UserRead, error) {
+	if token == "" {
+		return nil, ErrUnauthorized
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rUser, err := s.repo.FindByTokenHash(ctx, HashToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debug("no user found for api token")
+			return nil, ErrUnauthorized
+		}
+		s.logger.Errorf("service error on authenticate: %s", err)
+		return nil, err
+	}
+
+	return rUser, nil
+}
+
+// RotateFeedToken issues a new calendar feed token for the user. The feed
+// token only grants read access to the feed, so it can be embedded in
+// subscription urls; the previous one stops working.
+func (s *UserService) RotateFeedToken(id int) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	token, err := newToken()
+	if err != nil {
+		s.logger.Errorf("service error on generate feed token: %s", err)
+		return "", err
+	}
+
+	if err = s.repo.SetFeedTokenHash(ctx, id, HashToken(token)); err != nil {
+		s.logger.Errorf("service error on set feed token of user %d: %s", id, err)
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *UserService) AuthenticateFeed(token string) (*dto.UserRead, error) {
+	if token == "" {
+		return nil, ErrUnauthorized
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rUser, err := s.repo.FindByFeedTokenHash(ctx, HashToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debug("no user found for feed token")
+			return nil, ErrUnauthorized
+		}
+		s.logger.Errorf("service error on authenticate feed: %s", err)
+		return nil, err
+	}
+
+	return rUser, nil
+}
+
+func (s *UserService) List() ([]dto.UserRead, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rUsers, err := s.repo.List(ctx)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debug("No rows found on list user")
+		} else {
+			s.logger.Errorf("service error on list user: %s", err)
+		}
+		return nil, err
+	}
+
+	return rUsers, nil
+}
+
+func (s *UserService) DeleteById(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.repo.DeleteByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debugf("no rows found with user id %d", id)
+		} else {
+			s.logger.Errorf("service error on delete user: %s", err)
+		}
+		return err
+	}
+
+	s.logger.Debugf("service user deleted: %d", id)
+	return nil
+}
+
+func newToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// HashToken returns the stored form of an API token.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func NewUserService(d Deps) *UserService {
+	return &UserService{
+		repo:   d.Repo,
+		logger: d.Logger,
+	}
+}
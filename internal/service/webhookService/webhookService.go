@@ -0,0 +1,350 @@
+package webhookService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/internal/repos"
+	"ToDoVerba/internal/schemas"
+	"ToDoVerba/pkg/logging"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	IdHeader        = "X-Webhook-Id"
+
+	claimBatch = 50
+	maxBackoff = time.Hour
+)
+
+type Deps struct {
+	Repo         repos.WebhookRepository
+	Logger       logging.Logger
+	Client       *http.Client
+	MaxAttempts  int
+	Backoff      time.Duration
+	PollInterval time.Duration
+}
+
+type WebhookService struct {
+	repo         repos.WebhookRepository
+	logger       logging.Logger
+	client       *http.Client
+	maxAttempts  int
+	backoff      time.Duration
+	pollInterval time.Duration
+	lease        time.Duration
+	wake         chan struct{}
+}
+
+func (s *WebhookService) Create(cWebhook *dto.WebhookCreate) (*dto.WebhookRead, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if cWebhook.Secret == "" {
+		secret, err := randomHex(32)
+		if err != nil {
+			s.logger.Errorf("service error on generate webhook secret: %s", err)
+			return nil, err
+		}
+		cWebhook.Secret = secret
+	}
+
+	rWebhook, err := s.repo.Create(ctx, cWebhook)
+	if err != nil {
+		s.logger.Errorf("service error on create webhook: %s", err)
+		return nil, err
+	}
+	s.logger.Debugf("service webhook created: %d %s", rWebhook.Id, rWebhook.Url)
+	return rWebhook, nil
+}
+
+func (s *WebhookService) FindByID(id int) (*dto.WebhookRead, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rWebhook, err := s.repo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debugf("No rows found with webhook id %d", id)
+		} else {
+			s.logger.Errorf("service error on find webhook with id %d : %s", id, err)
+		}
+		return nil, err
+	}
+
+	return rWebhook, nil
+}
+
+func (s *WebhookService) List() ([]dto.WebhookRead, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rWebhooks, err := s.repo.List(ctx)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debug("No rows found on list webhook")
+		} else {
+			s.logger.Errorf("service error on list webhook: %s", err)
+		}
+		return nil, err
+	}
+
+	s.logger.Debugf("service found %d webhooks", len(rWebhooks))
+	return rWebhooks, nil
+}
+
+func (s *WebhookService) UpdateById(id int, update *dto.WebhookUpdate) (*dto.WebhookRead, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rWebhook, err := s.repo.UpdateByID(ctx, id, update)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debugf("no rows found with webhook id %d", id)
+		} else {
+			s.logger.Errorf("service error on update webhook: %s", err)
+		}
+		return nil, err
+	}
+
+	s.logger.Debugf("service webhook updated: %d %s", rWebhook.Id, rWebhook.Url)
+	return rWebhook, nil
+}
+
+func (s *WebhookService) DeleteById(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.repo.DeleteByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debugf("no rows found with webhook id %d", id)
+		} else {
+			s.logger.Errorf("service error on delete webhook: %s", err)
+		}
+		return err
+	}
+
+	s.logger.Debugf("service webhook deleted: %d", id)
+	return nil
+}
+
+func (s *WebhookService) ListDeliveries(webhookId, offset, limit int) ([]dto.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rDeliveries, err := s.repo.ListDeliveries(ctx, webhookId, offset, limit)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.logger.Debugf("No deliveries found for webhook id %d", webhookId)
+		} else {
+			s.logger.Errorf("service error on list deliveries of webhook %d : %s", webhookId, err)
+		}
+		return nil, err
+	}
+
+	return rDeliveries, nil
+}
+
+// Enqueue records a pending delivery of the event for every matching
+// subscription and wakes the dispatcher. Delivery itself happens in Run.
+func (s *WebhookService) Enqueue(ctx context.Context, event *dto.Event) error {
+	webhooks, err := s.repo.ListActiveByEvent(ctx, event.Type)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		s.logger.Errorf("webhook error on list subscriptions for %s: %s", event.Type, err)
+		return err
+	}
+
+	payload := schemas.EventPayload{}
+	payload.ScanDTO(event)
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		s.logger.Errorf("webhook error on marshal event %s: %s", event.Id, err)
+		return err
+	}
+
+	deliveries := make([]dto.WebhookDeliveryCreate, 0, len(webhooks))
+	for _, w := range webhooks {
+		deliveries = append(deliveries, dto.WebhookDeliveryCreate{
+			WebhookId: w.Id,
+			EventId:   event.Id,
+			Event:     event.Type,
+			Payload:   payloadJSON,
+		})
+	}
+
+	if err = s.repo.CreateDeliveries(ctx, deliveries); err != nil {
+		s.logger.Errorf("webhook error on create deliveries for event %s: %s", event.Id, err)
+		return err
+	}
+	s.logger.Debugf("webhook event %s %s queued for %d subscriptions", event.Type, event.Id, len(deliveries))
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run delivers due deliveries until ctx is cancelled.
+func (s *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+			s.deliverDue(ctx)
+		case <-ticker.C:
+			s.deliverDue(ctx)
+		}
+	}
+}
+
+func (s *WebhookService) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		deliveries, err := s.repo.ClaimDueDeliveries(ctx, claimBatch, s.lease)
+		if err != nil {
+			s.logger.Errorf("webhook error on claim deliveries: %s", err)
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+
+		var wg sync.WaitGroup
+		for i := range deliveries {
+			wg.Add(1)
+			go func(d *dto.WebhookDelivery) {
+				defer wg.Done()
+				s.deliver(ctx, d)
+			}(&deliveries[i])
+		}
+		wg.Wait()
+	}
+}
+
+func (s *WebhookService) deliver(ctx context.Context, d *dto.WebhookDelivery) {
+	update := &dto.WebhookDeliveryUpdate{
+		Id:       d.Id,
+		Status:   dto.DeliveryDelivered,
+		Attempts: d.Attempts + 1,
+	}
+
+	code, err := s.post(ctx, d)
+	update.ResponseCode = code
+	if err != nil {
+		update.LastError = err.Error()
+		if update.Attempts >= s.maxAttempts {
+			update.Status = dto.DeliveryDead
+			s.logger.Errorf("webhook delivery %d to %s is dead after %d attempts: %s", d.Id, d.Url, update.Attempts, err)
+		} else {
+			update.Status = dto.DeliveryPending
+			update.NextAttemptAt = pgtype.Timestamptz{
+				Time:  time.Now().UTC().Add(s.backoffFor(update.Attempts)),
+				Valid: true,
+			}
+			s.logger.Debugf("webhook delivery %d to %s failed, attempt %d: %s", d.Id, d.Url, update.Attempts, err)
+		}
+	}
+	if !update.NextAttemptAt.Valid {
+		update.NextAttemptAt = pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true}
+	}
+
+	if err = s.repo.UpdateDelivery(ctx, update); err != nil {
+		s.logger.Errorf("webhook error on update delivery %d: %s", d.Id, err)
+	}
+}
+
+func (s *WebhookService) post(ctx context.Context, d *dto.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.Url, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(IdHeader, d.EventId)
+	req.Header.Set(SignatureHeader, Sign(d.Secret, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoffFor doubles the base backoff with every failed attempt.
+func (s *WebhookService) backoffFor(attempts int) time.Duration {
+	backoff := s.backoff
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// Sign returns the value of the signature header for body: the hex encoded
+// HMAC-SHA256 of the body keyed with the subscription secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func NewWebhookService(d Deps) *WebhookService {
+	client := d.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	// The lease must outlast the slowest attempt in a batch.
+	lease := 2 * client.Timeout
+	if lease == 0 {
+		lease = time.Minute
+	}
+	return &WebhookService{
+		repo:         d.Repo,
+		logger:       d.Logger,
+		client:       client,
+		maxAttempts:  d.MaxAttempts,
+		backoff:      d.Backoff,
+		pollInterval: d.PollInterval,
+		lease:        lease,
+		wake:         make(chan struct{}, 1),
+	}
+}
@@ -0,0 +1,245 @@
+package webhookService
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"encoding/json"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryRepo is a minimal in-memory repos.WebhookRepository for the dispatcher.
+type memoryRepo struct {
+	mu         sync.Mutex
+	webhooks   []dto.WebhookRead
+	deliveries []dto.WebhookDelivery
+}
+
+func (m *memoryRepo) Create(ctx context.Context, c *dto.WebhookCreate) (*dto.WebhookRead, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w := dto.WebhookRead{Id: len(m.webhooks) + 1, Url: c.Url, Secret: c.Secret, Events: c.Events, Active: c.Active}
+	m.webhooks = append(m.webhooks, w)
+	return &w, nil
+}
+
+func (m *memoryRepo) FindById(ctx context.Context, id int) (*dto.WebhookRead, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func (m *memoryRepo) List(ctx context.Context) ([]dto.WebhookRead, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func (m *memoryRepo) ListActiveByEvent(ctx context.Context, event string) ([]dto.WebhookRead, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []dto.WebhookRead
+	for _, w := range m.webhooks {
+		match := len(w.Events) == 0
+		for _, e := range w.Events {
+			match = match || e == event
+		}
+		if w.Active && match {
+			res = append(res, w)
+		}
+	}
+	if len(res) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return res, nil
+}
+
+func (m *memoryRepo) UpdateByID(ctx context.Context, id int, u *dto.WebhookUpdate) (*dto.WebhookRead, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func (m *memoryRepo) DeleteByID(ctx context.Context, id int) (int, error) {
+	return 0, pgx.ErrNoRows
+}
+
+func (m *memoryRepo) CreateDeliveries(ctx context.Context, deliveries []dto.WebhookDeliveryCreate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range deliveries {
+		m.deliveries = append(m.deliveries, dto.WebhookDelivery{
+			Id:        int64(len(m.deliveries) + 1),
+			WebhookId: d.WebhookId,
+			EventId:   d.EventId,
+			Event:     d.Event,
+			Payload:   d.Payload,
+			Status:    dto.DeliveryPending,
+		})
+	}
+	return nil
+}
+
+func (m *memoryRepo) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]dto.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	var res []dto.WebhookDelivery
+	for i := range m.deliveries {
+		d := &m.deliveries[i]
+		if d.Status != dto.DeliveryPending || d.NextAttemptAt.Time.After(now) || len(res) == limit {
+			continue
+		}
+		d.NextAttemptAt.Time = now.Add(lease)
+		claimed := *d
+		claimed.Url = m.webhooks[d.WebhookId-1].Url
+		claimed.Secret = m.webhooks[d.WebhookId-1].Secret
+		res = append(res, claimed)
+	}
+	return res, nil
+}
+
+func (m *memoryRepo) UpdateDelivery(ctx context.Context, u *dto.WebhookDeliveryUpdate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d := &m.deliveries[u.Id-1]
+	d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.ResponseCode =
+		u.Status, u.Attempts, u.NextAttemptAt, u.LastError, u.ResponseCode
+	return nil
+}
+
+func (m *memoryRepo) ListDeliveries(ctx context.Context, webhookId, offset, limit int) ([]dto.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []dto.WebhookDelivery
+	for _, d := range m.deliveries {
+		if d.WebhookId == webhookId {
+			res = append(res, d)
+		}
+	}
+	return res, nil
+}
+
+func newTestService(repo *memoryRepo, maxAttempts int) *WebhookService {
+	return NewWebhookService(Deps{
+		Repo:         repo,
+		Logger:       logging.GetLoggerTest(),
+		Client:       &http.Client{Timeout: time.Second},
+		MaxAttempts:  maxAttempts,
+		Backoff:      time.Millisecond,
+		PollInterval: 5 * time.Millisecond,
+	})
+}
+
+func deliveryStatus(repo *memoryRepo, webhookId int) func() string {
+	return func() string {
+		deliveries, _ := repo.ListDeliveries(context.Background(), webhookId, 0, 1)
+		if len(deliveries) == 0 {
+			return ""
+		}
+		return deliveries[0].Status
+	}
+}
+
+func TestWebhookService_DeliversSignedPayload(t *testing.T) {
+	received := make(chan *http.Request, 4)
+	bodies := make(chan []byte, 4)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	repo := &memoryRepo{}
+	s := newTestService(repo, 3)
+	created, err := s.Create(&dto.WebhookCreate{Url: receiver.URL, Events: []string{dto.EventTaskCreated}, Active: true})
+	require.NoError(t, err)
+	require.NotEmpty(t, created.Secret)
+	_, err = s.Create(&dto.WebhookCreate{Url: receiver.URL, Events: []string{dto.EventTaskDeleted}, Active: true})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	err = s.Enqueue(ctx, &dto.Event{
+		Id:         "evt-1",
+		Type:       dto.EventTaskCreated,
+		TaskId:     7,
+		Actor:      "alice",
+		Task:       &dto.TaskRead{Id: 7, Title: "First Task"},
+		OccurredAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	var req *http.Request
+	var body []byte
+	select {
+	case req = <-received:
+		body = <-bodies
+	case <-time.After(2 * time.Second):
+		t.Fatal("receiver got no delivery")
+	}
+
+	assert.Equal(t, Sign(created.Secret, body), req.Header.Get(SignatureHeader))
+	assert.Equal(t, dto.EventTaskCreated, req.Header.Get(EventHeader))
+	assert.Equal(t, "evt-1", req.Header.Get(IdHeader))
+
+	payload := map[string]any{}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, "task.created", payload["type"])
+	assert.Equal(t, "alice", payload["actor"])
+	assert.Equal(t, "First Task", payload["task"].(map[string]any)["title"])
+
+	assert.Eventually(t, func() bool { return deliveryStatus(repo, created.Id)() == dto.DeliveryDelivered },
+		time.Second, 5*time.Millisecond)
+
+	select {
+	case <-received:
+		t.Fatal("subscription filtered on task.deleted received task.created")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWebhookService_RetriesUntilDead(t *testing.T) {
+	var hits atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	repo := &memoryRepo{}
+	s := newTestService(repo, 3)
+	created, err := s.Create(&dto.WebhookCreate{Url: receiver.URL, Events: []string{}, Active: true})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	err = s.Enqueue(ctx, &dto.Event{Id: "evt-2", Type: dto.EventTaskDeleted, TaskId: 7, OccurredAt: time.Now()})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return deliveryStatus(repo, created.Id)() == dto.DeliveryDead },
+		2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(3), hits.Load())
+
+	deliveries, _ := repo.ListDeliveries(context.Background(), created.Id, 0, 1)
+	assert.Equal(t, 3, deliveries[0].Attempts)
+	assert.Equal(t, http.StatusInternalServerError, deliveries[0].ResponseCode)
+}
+
+func TestWebhookService_backoffFor(t *testing.T) {
+	s := &WebhookService{backoff: 30 * time.Second}
+
+	assert.Equal(t, 30*time.Second, s.backoffFor(1))
+	assert.Equal(t, time.Minute, s.backoffFor(2))
+	assert.Equal(t, 4*time.Minute, s.backoffFor(4))
+	assert.Equal(t, time.Hour, s.backoffFor(20))
+}
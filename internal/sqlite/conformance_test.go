@@ -0,0 +1,19 @@
+package sqlite_test
+
+import (
+	"ToDoVerba/internal/repos"
+	"ToDoVerba/internal/repos/repostest"
+	"ToDoVerba/internal/sqlite"
+	"ToDoVerba/pkg/logging"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTaskSQLite_conformance(t *testing.T) {
+	repostest.TestTaskRepository(t, func(t *testing.T) repos.TaskRepository {
+		db, err := sqlite.Open(":memory:")
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		return sqlite.NewTaskSQLite(db, logging.GetLoggerTest())
+	})
+}
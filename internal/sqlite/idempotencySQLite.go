@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// IdempotencySQLite keeps the idempotency keys in the database, so that a
+// retry after a restart still gets the stored response.
+type IdempotencySQLite struct {
+	db     *sql.DB
+	logger logging.Logger
+}
+
+// Claim inserts the key, or takes over an expired or stale claim of it, see
+// crud.IdempotencyCRUD.
+func (c *IdempotencySQLite) Claim(ctx context.Context, claim *dto.IdempotencyClaim) (*dto.IdempotencyRecord, bool, error) {
+	qClaim := `INSERT INTO idempotency_keys (key, claim_token, fingerprint, created_at, expires_at)
+		  VALUES (?, ?, ?, ?, ?)
+		  ON CONFLICT (key) DO UPDATE
+		  SET (claim_token, fingerprint, status, header, body, created_at, expires_at) =
+		      (excluded.claim_token, excluded.fingerprint, NULL, NULL, NULL, excluded.created_at, excluded.expires_at)
+		  WHERE idempotency_keys.expires_at <= excluded.created_at
+		     OR (idempotency_keys.status IS NULL AND idempotency_keys.created_at < ?)
+		  RETURNING key`
+	qFind := `SELECT key, fingerprint, COALESCE(status, 0), header, body, created_at, expires_at
+		  FROM idempotency_keys
+		  WHERE key = ?`
+
+	// The key may be released between the two queries, it is claimed again
+	// then.
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		var key string
+		err = c.db.QueryRowContext(ctx, qClaim, claim.Key, claim.Token, claim.Fingerprint, formatTime(time.Now()),
+			formatTime(claim.ExpiresAt.Time), formatTime(claim.StaleBefore.Time)).
+			Scan(&key)
+		if err == nil {
+			return nil, true, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, false, err
+		}
+
+		record := &dto.IdempotencyRecord{}
+		var header sql.NullString
+		err = c.db.QueryRowContext(ctx, qFind, claim.Key).
+			Scan(&record.Key, &record.Fingerprint, &record.Status, &header, &record.Body,
+				timestamptz{&record.CreatedAt}, timestamptz{&record.ExpiresAt})
+		if err == nil {
+			if header.Valid {
+				if err = json.Unmarshal([]byte(header.String), &record.Header); err != nil {
+					return nil, false, err
+				}
+			}
+			return record, false, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, false, err
+		}
+	}
+
+	return nil, false, noRows(err)
+}
+
+// Complete stores the response of the request that claimed the key, unless
+// its claim was taken over.
+func (c *IdempotencySQLite) Complete(ctx context.Context, record *dto.IdempotencyRecord) error {
+	q := `UPDATE idempotency_keys
+		  SET (status, header, body) = (?, ?, ?)
+		  WHERE key = ? AND claim_token = ? AND status IS NULL
+		  RETURNING key`
+
+	var header sql.NullString
+	if record.Header != nil {
+		headerJSON, err := json.Marshal(record.Header)
+		if err != nil {
+			return err
+		}
+		header = sql.NullString{String: string(headerJSON), Valid: true}
+	}
+
+	var key string
+	err := c.db.QueryRowContext(ctx, q, record.Status, header, record.Body, record.Key, record.Token).Scan(&key)
+	return noRows(err)
+}
+
+// Release deletes a claim still in progress, so the key can be retried. A
+// claim taken over in the meantime is left alone.
+func (c *IdempotencySQLite) Release(ctx context.Context, key, token string) error {
+	q := `DELETE FROM idempotency_keys
+		  WHERE key = ? AND claim_token = ? AND status IS NULL`
+
+	_, err := c.db.ExecContext(ctx, q, key, token)
+	return err
+}
+
+func (c *IdempotencySQLite) DeleteExpired(ctx context.Context) (int64, error) {
+	q := `DELETE FROM idempotency_keys
+		  WHERE expires_at <= ?`
+
+	res, err := c.db.ExecContext(ctx, q, formatTime(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func NewIdempotencySQLite(db *sql.DB, logger logging.Logger) *IdempotencySQLite {
+	return &IdempotencySQLite{
+		db:     db,
+		logger: logger,
+	}
+}
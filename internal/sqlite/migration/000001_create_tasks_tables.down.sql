@@ -0,0 +1,3 @@
+DROP TABLE task_versions;
+DROP TABLE task_events;
+DROP TABLE tasks;
@@ -0,0 +1,39 @@
+-- Timestamps are TEXT in UTC with microseconds, so they order like they
+-- compare. AUTOINCREMENT never reuses the id of a deleted task, like SERIAL.
+CREATE TABLE tasks
+(
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    title       TEXT    NOT NULL,
+    description TEXT    NOT NULL,
+    due_date    TEXT    NOT NULL,
+    completed   INTEGER NOT NULL DEFAULT 0,
+    created_at  TEXT    NOT NULL,
+    updated_at  TEXT    NOT NULL,
+    version     INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE TABLE task_events
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id    INTEGER NOT NULL,
+    action     TEXT    NOT NULL,
+    actor      TEXT    NOT NULL,
+    changes    TEXT    NOT NULL,
+    created_at TEXT    NOT NULL
+);
+
+CREATE INDEX task_events_task_id_idx ON task_events (task_id, id);
+CREATE INDEX task_events_created_at_idx ON task_events (created_at);
+
+CREATE TABLE task_versions
+(
+    task_id     INTEGER NOT NULL,
+    version     INTEGER NOT NULL,
+    title       TEXT    NOT NULL,
+    description TEXT    NOT NULL,
+    due_date    TEXT    NOT NULL,
+    completed   INTEGER NOT NULL DEFAULT 0,
+    actor       TEXT    NOT NULL,
+    created_at  TEXT    NOT NULL,
+    PRIMARY KEY (task_id, version)
+);
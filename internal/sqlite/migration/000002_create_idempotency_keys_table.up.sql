@@ -0,0 +1,13 @@
+-- header is the JSON of the response headers.
+CREATE TABLE idempotency_keys
+(
+    key         TEXT PRIMARY KEY,
+    fingerprint TEXT NOT NULL,
+    status      INTEGER,
+    header      TEXT,
+    body        BLOB,
+    created_at  TEXT NOT NULL,
+    expires_at  TEXT NOT NULL
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,2 @@
+-- claim_token tells the claims of a key apart, see dto.IdempotencyClaim.
+ALTER TABLE idempotency_keys ADD COLUMN claim_token TEXT NOT NULL DEFAULT '';
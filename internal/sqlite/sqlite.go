@@ -0,0 +1,107 @@
+// Package sqlite stores the tasks, their history and versions, and the
+// idempotency keys in a SQLite file with a pure-Go driver, for installs without a Postgres server. It
+// mirrors the SQL of package crud, the differences are:
+//
+//   - timestamps are TEXT in UTC with the microseconds of timestamptz, see
+//     formatTime;
+//   - ANY($1) becomes IN with one parameter per value;
+//   - sql.ErrNoRows is returned as pgx.ErrNoRows, which the services check.
+package sqlite
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/golang-migrate/migrate/v4"
+	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	_ "modernc.org/sqlite"
+	"net/url"
+	"time"
+)
+
+//go:embed migration/*.sql
+var migrations embed.FS
+
+// Open opens the database file, creating it if needed, and migrates it to
+// the last version. ":memory:" opens a database that lives as long as the
+// returned DB.
+func Open(path string) (*sql.DB, error) {
+	dsn := "file:" + path + "?" + url.Values{
+		"_pragma": {"busy_timeout(5000)", "journal_mode(WAL)", "foreign_keys(1)"},
+	}.Encode()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite has a single writer, and a memory database exists per
+	// connection.
+	db.SetMaxOpenConns(1)
+
+	if err = migrateUp(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate sqlite database: %w", err)
+	}
+	return db, nil
+}
+
+func migrateUp(db *sql.DB) error {
+	src, err := iofs.New(migrations, "migration")
+	if err != nil {
+		return err
+	}
+	driver, err := migratesqlite.WithInstance(db, &migratesqlite.Config{})
+	if err != nil {
+		return err
+	}
+	// Closing m would close db, the embedded source needs no closing.
+	m, err := migrate.NewWithInstance("iofs", src, "sqlite", driver)
+	if err != nil {
+		return err
+	}
+	if err = m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// timeLayout keeps the microseconds of timestamptz with a fixed width, so
+// the stored text compares like the times.
+const timeLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+func formatTime(t time.Time) string {
+	return t.UTC().Round(time.Microsecond).Format(timeLayout)
+}
+
+func now() pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: time.Now().UTC().Round(time.Microsecond), Valid: true}
+}
+
+// timestamptz scans a time stored by formatTime.
+type timestamptz struct {
+	dst *pgtype.Timestamptz
+}
+
+func (t timestamptz) Scan(src any) error {
+	s, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("can't scan %T into a timestamp", src)
+	}
+	parsed, err := time.Parse(timeLayout, s)
+	if err != nil {
+		return err
+	}
+	*t.dst = pgtype.Timestamptz{Time: parsed, Valid: true}
+	return nil
+}
+
+// noRows returns pgx.ErrNoRows for sql.ErrNoRows.
+func noRows(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return pgx.ErrNoRows
+	}
+	return err
+}
@@ -0,0 +1,293 @@
+package sqlite
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func due(s string) pgtype.Timestamptz {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func TestTaskSQLite(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	logger := logging.GetLoggerTest()
+	tasks := NewTaskSQLite(db, logger)
+	events := NewTaskEventSQLite(db, logger)
+	versions := NewTaskVersionSQLite(db, logger)
+
+	_, err = tasks.List(ctx)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	created, err := tasks.Create(ctx, &dto.TaskCreate{
+		Title:   "a",
+		DueDate: due("2030-01-02T03:04:05.1234567+02:00"),
+		Actor:   "alice",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created.Id)
+	assert.Equal(t, 1, created.Version)
+	assert.Equal(t, "2030-01-02T01:04:05.123457Z", created.DueDate.Time.Format(time.RFC3339Nano),
+		"due dates are kept in microseconds like timestamptz")
+	assert.Equal(t, created.CreatedAt, created.UpdatedAt)
+
+	found, err := tasks.FindById(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, created, found)
+
+	updated, _, err := tasks.UpdateByID(ctx, created.Id, &dto.TaskUpdate{
+		Title:     "b",
+		DueDate:   created.DueDate,
+		Completed: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+	assert.True(t, updated.Completed)
+	assert.Equal(t, created.CreatedAt, updated.CreatedAt)
+
+	id, err := tasks.DeleteByID(ctx, created.Id, 0, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, created.Id, id)
+
+	_, err = tasks.FindById(ctx, created.Id)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	_, _, err = tasks.UpdateByID(ctx, created.Id, &dto.TaskUpdate{DueDate: created.DueDate})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	_, err = tasks.DeleteByID(ctx, created.Id, 0, "")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	history, err := events.ListByTaskID(ctx, created.Id)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, []string{dto.TaskEventCreate, dto.TaskEventUpdate, dto.TaskEventDelete},
+		[]string{history[0].Action, history[1].Action, history[2].Action})
+	assert.Equal(t, []string{"alice", "anonymous", "bob"},
+		[]string{history[0].Actor, history[1].Actor, history[2].Actor})
+	assert.Equal(t, dto.FieldChange{Before: "a", After: "b"}, history[1].Changes["title"])
+	assert.Equal(t, dto.FieldChange{Before: false, After: true}, history[1].Changes["completed"])
+
+	latest, err := events.LatestID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, history[2].Id, latest)
+
+	taskVersions, err := versions.ListByTaskID(ctx, created.Id)
+	require.NoError(t, err)
+	require.Len(t, taskVersions, 2, "versions are kept after delete")
+	assert.Equal(t, "a", taskVersions[0].Title)
+	assert.Equal(t, updated.UpdatedAt, taskVersions[1].CreatedAt)
+
+	_, err = versions.FindByVersion(ctx, created.Id, 3)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	next, err := tasks.Create(ctx, &dto.TaskCreate{Title: "c", DueDate: created.DueDate})
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.Id, "ids are not reused")
+}
+
+func TestTaskSQLite_createMany(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	tasks := NewTaskSQLite(db, logging.GetLoggerTest())
+
+	_, err = tasks.CreateMany(ctx, []dto.TaskCreate{
+		{Title: "a", DueDate: due("2030-01-01T00:00:00Z")},
+		{Title: "b"},
+	})
+	assert.Error(t, err, "a task without due date violates NOT NULL")
+	_, err = tasks.List(ctx)
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "nothing is created when one task fails")
+
+	created, err := tasks.CreateMany(ctx, []dto.TaskCreate{
+		{Title: "a", DueDate: due("2030-01-01T00:00:00Z")},
+		{Title: "b", DueDate: due("2030-01-02T00:00:00Z")},
+	})
+	require.NoError(t, err)
+
+	var streamed []dto.TaskRead
+	err = tasks.Stream(ctx, func(task *dto.TaskRead) error {
+		streamed = append(streamed, *task)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, created, streamed)
+}
+
+func TestTaskSQLite_Stream_pages(t *testing.T) {
+	defer func(size int) { streamPageSize = size }(streamPageSize)
+	streamPageSize = 2
+
+	ctx := context.Background()
+	db, err := Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	tasks := NewTaskSQLite(db, logging.GetLoggerTest())
+
+	var created []dto.TaskCreate
+	for _, title := range []string{"a", "b", "c", "d", "e"} {
+		created = append(created, dto.TaskCreate{Title: title, DueDate: due("2030-01-01T00:00:00Z")})
+	}
+	_, err = tasks.CreateMany(ctx, created)
+	require.NoError(t, err)
+
+	var titles []string
+	err = tasks.Stream(ctx, func(task *dto.TaskRead) error {
+		// The connection is free while each runs.
+		found, err := tasks.FindById(ctx, task.Id)
+		if err != nil {
+			return err
+		}
+		titles = append(titles, found.Title)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, titles)
+}
+
+func TestTaskEventSQLite_List(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	logger := logging.GetLoggerTest()
+	tasks := NewTaskSQLite(db, logger)
+	events := NewTaskEventSQLite(db, logger)
+
+	for _, actor := range []string{"alice", "bob", "alice"} {
+		_, err = tasks.Create(ctx, &dto.TaskCreate{Title: actor, DueDate: due("2030-01-01T00:00:00Z"), Actor: actor})
+		require.NoError(t, err)
+	}
+
+	list, err := events.List(ctx, &dto.TaskEventFilter{Actor: "alice", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, []int{1, 3}, []int{list[0].TaskId, list[1].TaskId})
+
+	list, err = events.List(ctx, &dto.TaskEventFilter{AfterId: 1, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, int64(2), list[0].Id)
+
+	from := pgtype.Timestamptz{Time: list[0].CreatedAt.Time, Valid: true}
+	list, err = events.List(ctx, &dto.TaskEventFilter{From: from, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, list, 2, "from is inclusive and compares as time")
+
+	_, err = events.List(ctx, &dto.TaskEventFilter{Actor: "carol", Limit: 10})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	byTask, err := events.ListByTaskIDs(ctx, []int{3, 1, 42})
+	require.NoError(t, err)
+	assert.Len(t, byTask, 2)
+}
+
+func TestOpen_file(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "todo.db")
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	_, err = NewTaskSQLite(db, logging.GetLoggerTest()).
+		Create(ctx, &dto.TaskCreate{Title: "kept", DueDate: due("2030-01-01T00:00:00Z")})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = Open(path)
+	require.NoError(t, err, "opening a migrated file again is no change")
+	defer db.Close()
+	task, err := NewTaskSQLite(db, logging.GetLoggerTest()).FindById(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "kept", task.Title)
+}
+
+func TestIdempotencySQLite(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "todo.db")
+	db, err := Open(path)
+	require.NoError(t, err)
+	keys := NewIdempotencySQLite(db, logging.GetLoggerTest())
+
+	claim := &dto.IdempotencyClaim{
+		Key:         "key",
+		Token:       "t1",
+		Fingerprint: "a",
+		ExpiresAt:   pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+		StaleBefore: pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true},
+	}
+	_, claimed, err := keys.Claim(ctx, claim)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	record, claimed, err := keys.Claim(ctx, claim)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	assert.Equal(t, 0, record.Status, "the first request is in progress")
+
+	require.NoError(t, keys.Complete(ctx, &dto.IdempotencyRecord{
+		Key:    "key",
+		Token:  "t1",
+		Status: 201,
+		Header: http.Header{"Location": {"/tasks/1"}},
+		Body:   []byte(`{"id":1}`),
+	}))
+	assert.ErrorIs(t, keys.Complete(ctx, &dto.IdempotencyRecord{Key: "key", Token: "t1", Status: 201}), pgx.ErrNoRows)
+	require.NoError(t, db.Close())
+
+	db, err = Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	keys = NewIdempotencySQLite(db, logging.GetLoggerTest())
+
+	record, claimed, err = keys.Claim(ctx, claim)
+	require.NoError(t, err, "the keys outlive the process")
+	assert.False(t, claimed)
+	assert.Equal(t, 201, record.Status)
+	assert.Equal(t, "/tasks/1", record.Header.Get("Location"))
+	assert.Equal(t, `{"id":1}`, string(record.Body))
+
+	// An in-progress claim is released, an expired one taken over.
+	_, claimed, err = keys.Claim(ctx, &dto.IdempotencyClaim{Key: "lost", Token: "t2", ExpiresAt: claim.ExpiresAt, StaleBefore: claim.StaleBefore})
+	require.NoError(t, err)
+	require.True(t, claimed)
+	require.NoError(t, keys.Release(ctx, "lost", "t2"))
+
+	// A stale claim taken over can neither complete nor release the new one.
+	_, claimed, err = keys.Claim(ctx, &dto.IdempotencyClaim{Key: "lost", Token: "t3", ExpiresAt: claim.ExpiresAt, StaleBefore: claim.StaleBefore})
+	require.NoError(t, err)
+	require.True(t, claimed)
+	_, claimed, err = keys.Claim(ctx, &dto.IdempotencyClaim{Key: "lost", Token: "t4", ExpiresAt: claim.ExpiresAt,
+		StaleBefore: pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true}})
+	require.NoError(t, err)
+	require.True(t, claimed)
+	assert.ErrorIs(t, keys.Complete(ctx, &dto.IdempotencyRecord{Key: "lost", Token: "t3", Status: 201}), pgx.ErrNoRows)
+	require.NoError(t, keys.Release(ctx, "lost", "t3"))
+	require.NoError(t, keys.Complete(ctx, &dto.IdempotencyRecord{Key: "lost", Token: "t4", Status: 201}))
+	_, claimed, err = keys.Claim(ctx, &dto.IdempotencyClaim{
+		Key:         "key",
+		Fingerprint: "b",
+		ExpiresAt:   pgtype.Timestamptz{Time: time.Now().Add(-time.Second), Valid: true},
+		StaleBefore: claim.StaleBefore,
+	})
+	require.NoError(t, err)
+	assert.False(t, claimed, "the stored response has not expired yet")
+
+	_, err = db.Exec(`UPDATE idempotency_keys SET expires_at = ?`, formatTime(time.Now().Add(-time.Second)))
+	require.NoError(t, err)
+	deleted, err := keys.DeleteExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted, "key and lost")
+}
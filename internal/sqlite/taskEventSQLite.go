@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+)
+
+type TaskEventSQLite struct {
+	db     *sql.DB
+	logger logging.Logger
+}
+
+func (c *TaskEventSQLite) ListByTaskID(ctx context.Context, taskId int) ([]dto.TaskEvent, error) {
+	q := `SELECT id, task_id, action, actor, changes, created_at
+		  FROM task_events
+		  WHERE task_id = ?
+		  ORDER BY id`
+
+	events, err := c.query(ctx, q, taskId)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, noRows(sql.ErrNoRows)
+	}
+
+	return events, nil
+}
+
+// ListByTaskIDs returns the events of all the tasks ordered by id. Tasks
+// without events are simply missing from the result.
+func (c *TaskEventSQLite) ListByTaskIDs(ctx context.Context, taskIds []int) ([]dto.TaskEvent, error) {
+	if len(taskIds) == 0 {
+		return nil, nil
+	}
+
+	args := make([]any, len(taskIds))
+	for i, id := range taskIds {
+		args[i] = id
+	}
+	q := `SELECT id, task_id, action, actor, changes, created_at
+		  FROM task_events
+		  WHERE task_id IN (?` + strings.Repeat(", ?", len(taskIds)-1) + `)
+		  ORDER BY id`
+
+	return c.query(ctx, q, args...)
+}
+
+func (c *TaskEventSQLite) List(ctx context.Context, filter *dto.TaskEventFilter) ([]dto.TaskEvent, error) {
+	var conds []string
+	var args []any
+	addCond := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, cond)
+	}
+
+	if filter.TaskId != 0 {
+		addCond("task_id = ?", filter.TaskId)
+	}
+	if filter.Actor != "" {
+		addCond("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		addCond("action = ?", filter.Action)
+	}
+	if filter.From.Valid {
+		addCond("created_at >= ?", formatTime(filter.From.Time))
+	}
+	if filter.To.Valid {
+		addCond("created_at < ?", formatTime(filter.To.Time))
+	}
+	if filter.AfterId != 0 {
+		addCond("id > ?", filter.AfterId)
+	}
+
+	q := `SELECT id, task_id, action, actor, changes, created_at
+		  FROM task_events`
+	if len(conds) > 0 {
+		q += " WHERE " + strings.Join(conds, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	q += " ORDER BY id LIMIT ? OFFSET ?"
+
+	events, err := c.query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, noRows(sql.ErrNoRows)
+	}
+
+	return events, nil
+}
+
+// LatestID returns the id of the last recorded event, 0 when there is none.
+func (c *TaskEventSQLite) LatestID(ctx context.Context) (int64, error) {
+	q := `SELECT COALESCE(MAX(id), 0) FROM task_events`
+
+	var id int64
+	err := c.db.QueryRowContext(ctx, q).Scan(&id)
+	return id, err
+}
+
+func (c *TaskEventSQLite) query(ctx context.Context, q string, args ...any) ([]dto.TaskEvent, error) {
+	rows, err := c.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []dto.TaskEvent
+
+	for rows.Next() {
+		event := dto.TaskEvent{}
+		var changes string
+		err := rows.Scan(&event.Id, &event.TaskId, &event.Action, &event.Actor, &changes, timestamptz{&event.CreatedAt})
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(changes), &event.Changes); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func NewTaskEventSQLite(db *sql.DB, logger logging.Logger) *TaskEventSQLite {
+	return &TaskEventSQLite{
+		db:     db,
+		logger: logger,
+	}
+}
@@ -0,0 +1,321 @@
+package sqlite
+
+import (
+	"ToDoVerba/internal/crud"
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const taskColumns = `id, title, description, due_date, completed, created_at, updated_at, version`
+
+type TaskSQLite struct {
+	db     *sql.DB
+	logger logging.Logger
+}
+
+func (c *TaskSQLite) Create(ctx context.Context, cTask *dto.TaskCreate) (*dto.TaskRead, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rTask, err := createTask(ctx, tx, cTask)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return rTask, nil
+}
+
+// CreateMany inserts the tasks in a single transaction, so either all of
+// them are created or none.
+func (c *TaskSQLite) CreateMany(ctx context.Context, cTasks []dto.TaskCreate) ([]dto.TaskRead, error) {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	tasks := make([]dto.TaskRead, 0, len(cTasks))
+	for i := range cTasks {
+		rTask, err := createTask(ctx, tx, &cTasks[i])
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *rTask)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// createTask inserts a task with its history and first version.
+func createTask(ctx context.Context, tx *sql.Tx, cTask *dto.TaskCreate) (*dto.TaskRead, error) {
+	q := `INSERT INTO tasks (title, description, due_date, completed, created_at, updated_at)
+		  VALUES (?, ?, ?, ?, ?, ?)
+		  RETURNING ` + taskColumns
+
+	curTime := formatTime(now().Time)
+
+	rTask, err := scanTask(tx.QueryRowContext(ctx, q,
+		cTask.Title, cTask.Description, timeValue(cTask.DueDate), cTask.Completed, curTime, curTime))
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertTaskEvent(ctx, tx, rTask.Id, dto.TaskEventCreate, cTask.Actor, crud.TaskDiff(nil, rTask))
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertTaskVersion(ctx, tx, rTask, cTask.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	return rTask, nil
+}
+
+func (c *TaskSQLite) FindById(ctx context.Context, id int) (*dto.TaskRead, error) {
+	q := `SELECT ` + taskColumns + `
+		  FROM tasks
+		  WHERE id = ?`
+
+	rTask, err := scanTask(c.db.QueryRowContext(ctx, q, id))
+	if err != nil {
+		return nil, noRows(err)
+	}
+
+	return rTask, nil
+}
+
+func (c *TaskSQLite) List(ctx context.Context) ([]dto.TaskRead, error) {
+	q := `SELECT ` + taskColumns + `
+		  FROM tasks
+		  ORDER BY id`
+
+	tasks, err := c.query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, noRows(sql.ErrNoRows)
+	}
+
+	return tasks, nil
+}
+
+// streamPageSize is the number of tasks Stream reads at a time.
+var streamPageSize = 500
+
+// Stream calls each for every task in id order. The tasks are read a page at
+// a time and each runs between the pages: the database has a single
+// connection, which each may need.
+func (c *TaskSQLite) Stream(ctx context.Context, each func(task *dto.TaskRead) error) error {
+	q := `SELECT ` + taskColumns + `
+		  FROM tasks
+		  WHERE id > ?
+		  ORDER BY id
+		  LIMIT ?`
+
+	afterId := 0
+	for {
+		tasks, err := c.query(ctx, q, afterId, streamPageSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range tasks {
+			if err = each(&tasks[i]); err != nil {
+				return err
+			}
+		}
+		if len(tasks) < streamPageSize {
+			return nil
+		}
+		afterId = tasks[len(tasks)-1].Id
+	}
+}
+
+func (c *TaskSQLite) query(ctx context.Context, q string, args ...any) ([]dto.TaskRead, error) {
+	rows, err := c.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []dto.TaskRead
+
+	for rows.Next() {
+		rTask, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *rTask)
+	}
+
+	return tasks, rows.Err()
+}
+
+func (c *TaskSQLite) UpdateByID(ctx context.Context, id int, update *dto.TaskUpdate) (*dto.TaskRead, []string, error) {
+	qSelect := `SELECT ` + taskColumns + `
+		  FROM tasks
+		  WHERE id = ?`
+	q := `UPDATE tasks
+		  SET (title, description, due_date, completed, updated_at, version) = (?, ?, ?, ?, ?, version + 1)
+		  WHERE id = ? AND (? = 0 OR version = ?)
+		  RETURNING ` + taskColumns
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	// The single connection makes the transaction exclusive, no FOR UPDATE
+	// is needed.
+	oldTask, err := scanTask(tx.QueryRowContext(ctx, qSelect, id))
+	if err != nil {
+		return nil, nil, noRows(err)
+	}
+
+	rTask, err := scanTask(tx.QueryRowContext(ctx, q, update.Title, update.Description,
+		timeValue(update.DueDate), update.Completed, formatTime(now().Time), id, update.Version, update.Version))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, dto.ErrVersionConflict
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = insertTaskEvent(ctx, tx, rTask.Id, dto.TaskEventUpdate, update.Actor, crud.TaskDiff(oldTask, rTask))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = insertTaskVersion(ctx, tx, rTask, update.Actor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return rTask, dto.TaskUpdateEvents(oldTask, rTask), nil
+}
+
+func (c *TaskSQLite) DeleteByID(ctx context.Context, id, version int, actor string) (int, error) {
+	q := `DELETE FROM tasks
+		  WHERE id = ? AND (? = 0 OR version = ?)
+		  RETURNING ` + taskColumns
+	qExists := `SELECT EXISTS (SELECT 1 FROM tasks WHERE id = ?)`
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	oldTask, err := scanTask(tx.QueryRowContext(ctx, q, id, version, version))
+	if errors.Is(err, sql.ErrNoRows) && version != 0 {
+		var exists bool
+		if err = tx.QueryRowContext(ctx, qExists, id).Scan(&exists); err != nil {
+			return 0, err
+		}
+		if exists {
+			return 0, dto.ErrVersionConflict
+		}
+		return 0, noRows(sql.ErrNoRows)
+	}
+	if err != nil {
+		return 0, noRows(err)
+	}
+
+	err = insertTaskEvent(ctx, tx, oldTask.Id, dto.TaskEventDelete, actor, crud.TaskDiff(oldTask, nil))
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return oldTask.Id, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(row scanner) (*dto.TaskRead, error) {
+	rTask := &dto.TaskRead{}
+	err := row.Scan(&rTask.Id, &rTask.Title, &rTask.Description, timestamptz{&rTask.DueDate}, &rTask.Completed,
+		timestamptz{&rTask.CreatedAt}, timestamptz{&rTask.UpdatedAt}, &rTask.Version)
+	if err != nil {
+		return nil, err
+	}
+	return rTask, nil
+}
+
+// timeValue returns the time to store, NULL for an invalid one like pgx.
+func timeValue(t pgtype.Timestamptz) any {
+	if !t.Valid {
+		return nil
+	}
+	return formatTime(t.Time)
+}
+
+// insertTaskEvent records a task mutation in the audit trail. It must be
+// called with the transaction that performed the mutation.
+func insertTaskEvent(ctx context.Context, tx *sql.Tx, taskId int, action, actor string, changes map[string]dto.FieldChange) error {
+	q := `INSERT INTO task_events (task_id, action, actor, changes, created_at)
+		  VALUES (?, ?, ?, ?, ?)`
+
+	if actor == "" {
+		actor = crud.AnonymousActor
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, q, taskId, action, actor, string(changesJSON), formatTime(now().Time))
+	return err
+}
+
+// insertTaskVersion stores an immutable snapshot of the task as it is after
+// a mutation. It must be called with the transaction that performed the
+// mutation.
+func insertTaskVersion(ctx context.Context, tx *sql.Tx, task *dto.TaskRead, actor string) error {
+	q := `INSERT INTO task_versions (task_id, version, title, description, due_date, completed, actor, created_at)
+		  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	if actor == "" {
+		actor = crud.AnonymousActor
+	}
+
+	_, err := tx.ExecContext(ctx, q, task.Id, task.Version, task.Title, task.Description,
+		timeValue(task.DueDate), task.Completed, actor, timeValue(task.UpdatedAt))
+	return err
+}
+
+func NewTaskSQLite(db *sql.DB, logger logging.Logger) *TaskSQLite {
+	return &TaskSQLite{
+		db:     db,
+		logger: logger,
+	}
+}
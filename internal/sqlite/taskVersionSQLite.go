@@ -0,0 +1,76 @@
+package sqlite
+
+import (
+	"ToDoVerba/internal/dto"
+	"ToDoVerba/pkg/logging"
+	"context"
+	"database/sql"
+)
+
+const taskVersionColumns = `task_id, version, title, description, due_date, completed, actor, created_at`
+
+type TaskVersionSQLite struct {
+	db     *sql.DB
+	logger logging.Logger
+}
+
+func (c *TaskVersionSQLite) ListByTaskID(ctx context.Context, taskId int) ([]dto.TaskVersion, error) {
+	q := `SELECT ` + taskVersionColumns + `
+		  FROM task_versions
+		  WHERE task_id = ?
+		  ORDER BY version`
+
+	rows, err := c.db.QueryContext(ctx, q, taskId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []dto.TaskVersion
+
+	for rows.Next() {
+		rVersion, err := scanTaskVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *rVersion)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, noRows(sql.ErrNoRows)
+	}
+
+	return versions, nil
+}
+
+func (c *TaskVersionSQLite) FindByVersion(ctx context.Context, taskId, version int) (*dto.TaskVersion, error) {
+	q := `SELECT ` + taskVersionColumns + `
+		  FROM task_versions
+		  WHERE task_id = ? AND version = ?`
+
+	rVersion, err := scanTaskVersion(c.db.QueryRowContext(ctx, q, taskId, version))
+	if err != nil {
+		return nil, noRows(err)
+	}
+
+	return rVersion, nil
+}
+
+func scanTaskVersion(row scanner) (*dto.TaskVersion, error) {
+	rVersion := &dto.TaskVersion{}
+	err := row.Scan(&rVersion.TaskId, &rVersion.Version, &rVersion.Title, &rVersion.Description,
+		timestamptz{&rVersion.DueDate}, &rVersion.Completed, &rVersion.Actor, timestamptz{&rVersion.CreatedAt})
+	if err != nil {
+		return nil, err
+	}
+	return rVersion, nil
+}
+
+func NewTaskVersionSQLite(db *sql.DB, logger logging.Logger) *TaskVersionSQLite {
+	return &TaskVersionSQLite{
+		db:     db,
+		logger: logger,
+	}
+}
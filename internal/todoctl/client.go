@@ -0,0 +1,203 @@
+package todoctl
+
+import (
+	"ToDoVerba/internal/schemas"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// taskV2Path is the path of a task in API v2, which unlike v1 tells its
+// version and takes it back in If-Match.
+const taskV2Path = "/api/v2/tasks/"
+
+// apiError is an error answer of the api, its message taken from the
+// {"error": ...} body the handlers write.
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.Status)
+}
+
+// client calls the task endpoints of the REST api.
+type client struct {
+	baseURL string
+	token   string
+	actor   string
+	http    *http.Client
+}
+
+func (c *client) create(cTask *schemas.RequestTaskCreate) (*schemas.ResponseTaskRead, error) {
+	rTask := &schemas.ResponseTaskRead{}
+	return rTask, c.do("POST", "/tasks", cTask, rTask)
+}
+
+func (c *client) list(filter *schemas.RequestTaskFilter) ([]schemas.ResponseTaskRead, error) {
+	q := url.Values{}
+	if filter.Completed != "" {
+		q.Set("completed", filter.Completed)
+	}
+	if filter.DueAfter != "" {
+		q.Set("due_after", filter.DueAfter)
+	}
+	if filter.DueBefore != "" {
+		q.Set("due_before", filter.DueBefore)
+	}
+	path := "/tasks"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	var rTasks []schemas.ResponseTaskRead
+	return rTasks, c.do("GET", path, nil, &rTasks)
+}
+
+func (c *client) get(id int) (*schemas.ResponseTaskRead, error) {
+	rTask := &schemas.ResponseTaskRead{}
+	return rTask, c.do("GET", "/tasks/"+strconv.Itoa(id), nil, rTask)
+}
+
+// getVersioned reads a task along with its version, for a later replace.
+func (c *client) getVersioned(id int) (*schemas.ResponseTaskRead, int, error) {
+	env := struct {
+		Data schemas.ResponseTaskV2 `json:"data"`
+	}{}
+	if err := c.do("GET", taskV2Path+strconv.Itoa(id), nil, &env); err != nil {
+		return nil, 0, err
+	}
+	return taskFromV2(&env.Data), env.Data.Version, nil
+}
+
+// replace updates a task only if it is still at version. A task changed
+// since it was read is reported as a conflict rather than overwritten.
+func (c *client) replace(id, version int, update *schemas.RequestTaskUpdate) (*schemas.ResponseTaskRead, error) {
+	task := schemas.RequestTaskV2{
+		Title:       update.Title,
+		Description: &update.Description,
+		DueDate:     &update.DueDate,
+		Completed:   update.Completed,
+	}
+	env := struct {
+		Data schemas.ResponseTaskV2 `json:"data"`
+	}{}
+	header := http.Header{"If-Match": {strconv.Quote(strconv.Itoa(version))}}
+	err := c.doWith("PUT", taskV2Path+strconv.Itoa(id), header, &task, &env)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusPreconditionFailed {
+		return nil, fmt.Errorf("task %d was changed since it was read, run the command again", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return taskFromV2(&env.Data), nil
+}
+
+// taskFromV2 converts a task of API v2 to the v1 form todoctl prints, with
+// zero values where v2 has nulls.
+func taskFromV2(t *schemas.ResponseTaskV2) *schemas.ResponseTaskRead {
+	rTask := &schemas.ResponseTaskRead{
+		Id:        t.Id,
+		Title:     t.Title,
+		Completed: t.Completed,
+		DueDate:   formatV2Time(t.DueDate),
+		CreatedAt: formatV2Time(t.CreatedAt),
+		UpdatedAt: formatV2Time(t.UpdatedAt),
+	}
+	if t.Description != nil {
+		rTask.Description = *t.Description
+	}
+	return rTask
+}
+
+func formatV2Time(t *time.Time) string {
+	if t == nil {
+		return time.Time{}.Format(time.RFC3339)
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (c *client) delete(id int) error {
+	return c.do("DELETE", "/tasks/"+strconv.Itoa(id), nil, nil)
+}
+
+func (c *client) do(method, path string, body, out any) error {
+	return c.doWith(method, path, nil, body, out)
+}
+
+func (c *client) doWith(method, path string, header http.Header, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.baseURL, "/")+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.actor != "" {
+		req.Header.Set("X-Actor", c.actor)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return &apiError{Status: resp.StatusCode, Message: errorMessage(data, resp.StatusCode)}
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// errorMessage is the message of an error body, {"error": "..."} in v1 and
+// {"error": {"message": "..."}} in v2, or the status text without one.
+func errorMessage(data []byte, status int) string {
+	errBody := struct {
+		Error json.RawMessage `json:"error"`
+	}{}
+	if json.Unmarshal(data, &errBody) == nil {
+		var message string
+		if json.Unmarshal(errBody.Error, &message) == nil && message != "" {
+			return message
+		}
+		envelope := struct {
+			Message string `json:"message"`
+		}{}
+		if json.Unmarshal(errBody.Error, &envelope) == nil && envelope.Message != "" {
+			return envelope.Message
+		}
+	}
+	return http.StatusText(status)
+}
@@ -0,0 +1,280 @@
+package todoctl
+
+import (
+	"ToDoVerba/internal/schemas"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateFormat = "2006-01-02"
+
+// parseDue accepts RFC3339 or a plain date, which is midnight local time.
+func parseDue(s string) (string, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.Format(time.RFC3339), nil
+	}
+	if t, err := time.ParseInLocation(dateFormat, s, time.Local); err == nil {
+		return t.Format(time.RFC3339), nil
+	}
+	return "", fmt.Errorf("due date %q must be a date (%s) or in RFC3339 format", s, dateFormat)
+}
+
+func parseIds(flags *flag.FlagSet) ([]int, error) {
+	if flags.NArg() == 0 {
+		fmt.Fprintln(flags.Output(), "task id is required")
+		flags.Usage()
+		return nil, errUsage
+	}
+	ids := make([]int, 0, flags.NArg())
+	for _, arg := range flags.Args() {
+		id, err := strconv.Atoi(arg)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid task id %q", arg)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// description returns the -d flag, stdin for "-", or the text written in
+// the editor if it is empty.
+func (c *CLI) description(flagValue, current string) (string, error) {
+	switch flagValue {
+	case "":
+		return c.editText(current, "Write the description of the task above.")
+	case "-":
+		data, err := io.ReadAll(c.Stdin)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return flagValue, nil
+}
+
+func (c *CLI) add(g *globalFlags, args []string) error {
+	flags := c.newFlags("add", "<title>", g)
+	desc := flags.String("d", "", "`description`, - reads it from stdin; opens $EDITOR if not given")
+	due := flags.String("due", "", "due `date`, a date or RFC3339 (required)")
+	completed := flags.Bool("done", false, "create the task as done")
+	if err := parse(flags, g, args); err != nil {
+		return err
+	}
+	title := strings.Join(flags.Args(), " ")
+	if title == "" || *due == "" {
+		fmt.Fprintln(flags.Output(), "title and -due are required")
+		flags.Usage()
+		return errUsage
+	}
+
+	cTask := schemas.RequestTaskCreate{Title: title, Completed: *completed}
+	var err error
+	if cTask.DueDate, err = parseDue(*due); err != nil {
+		return err
+	}
+	if cTask.Description, err = c.description(*desc, ""); err != nil {
+		return err
+	}
+
+	api, err := c.client(g)
+	if err != nil {
+		return err
+	}
+	rTask, err := api.create(&cTask)
+	if err != nil {
+		return err
+	}
+	return printTask(c.Stdout, g.output, rTask)
+}
+
+func (c *CLI) ls(g *globalFlags, args []string) error {
+	flags := c.newFlags("ls", "", g)
+	completed := flags.String("completed", "", "only done (true) or open (false) tasks")
+	dueAfter := flags.String("due-after", "", "only tasks due after the `date`")
+	dueBefore := flags.String("due-before", "", "only tasks due before the `date`")
+	quiet := flags.Bool("q", false, "print task ids only")
+	if err := parse(flags, g, args); err != nil {
+		return err
+	}
+
+	filter := schemas.RequestTaskFilter{Completed: *completed}
+	var err error
+	if *dueAfter != "" {
+		if filter.DueAfter, err = parseDue(*dueAfter); err != nil {
+			return err
+		}
+	}
+	if *dueBefore != "" {
+		if filter.DueBefore, err = parseDue(*dueBefore); err != nil {
+			return err
+		}
+	}
+	if err = filter.Valid(); err != nil {
+		return err
+	}
+
+	api, err := c.client(g)
+	if err != nil {
+		return err
+	}
+	rTasks, err := api.list(&filter)
+	if err != nil {
+		return err
+	}
+	if *quiet {
+		for _, task := range rTasks {
+			fmt.Fprintln(c.Stdout, task.Id)
+		}
+		return nil
+	}
+	return printTasks(c.Stdout, g.output, rTasks)
+}
+
+func (c *CLI) show(g *globalFlags, args []string) error {
+	flags := c.newFlags("show", "<id>", g)
+	if err := parse(flags, g, args); err != nil {
+		return err
+	}
+	ids, err := parseIds(flags)
+	if err != nil {
+		return err
+	}
+	if len(ids) > 1 {
+		return errors.New("show takes a single task id")
+	}
+
+	api, err := c.client(g)
+	if err != nil {
+		return err
+	}
+	rTask, err := api.get(ids[0])
+	if err != nil {
+		return err
+	}
+	return printTask(c.Stdout, g.output, rTask)
+}
+
+func (c *CLI) edit(g *globalFlags, args []string) error {
+	flags := c.newFlags("edit", "<id>", g)
+	title := flags.String("title", "", "new `title`")
+	desc := flags.String("d", "", "new `description`, - reads it from stdin")
+	due := flags.String("due", "", "new due `date`, a date or RFC3339")
+	editDesc := flags.Bool("e", false, "edit the description in $EDITOR, the default without other flags")
+	if err := parse(flags, g, args); err != nil {
+		return err
+	}
+	ids, err := parseIds(flags)
+	if err != nil {
+		return err
+	}
+	if len(ids) > 1 {
+		return errors.New("edit takes a single task id")
+	}
+	changed := 0
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "title", "d", "due":
+			changed++
+		}
+	})
+
+	api, err := c.client(g)
+	if err != nil {
+		return err
+	}
+	rTask, version, err := api.getVersioned(ids[0])
+	if err != nil {
+		return err
+	}
+
+	update := schemas.RequestTaskUpdate{
+		Title:       firstOf(*title, rTask.Title),
+		Description: rTask.Description,
+		DueDate:     rTask.DueDate,
+		Completed:   rTask.Completed,
+	}
+	if *due != "" {
+		if update.DueDate, err = parseDue(*due); err != nil {
+			return err
+		}
+	}
+	if *desc != "" {
+		update.Description, err = c.description(*desc, rTask.Description)
+	} else if *editDesc || changed == 0 {
+		update.Description, err = c.description("", rTask.Description)
+	}
+	if err != nil {
+		return err
+	}
+
+	rTask, err = api.replace(ids[0], version, &update)
+	if err != nil {
+		return err
+	}
+	return printTask(c.Stdout, g.output, rTask)
+}
+
+func (c *CLI) done(g *globalFlags, args []string) error {
+	flags := c.newFlags("done", "<id>...", g)
+	undo := flags.Bool("undo", false, "mark the tasks as open again")
+	if err := parse(flags, g, args); err != nil {
+		return err
+	}
+	ids, err := parseIds(flags)
+	if err != nil {
+		return err
+	}
+
+	api, err := c.client(g)
+	if err != nil {
+		return err
+	}
+	rTasks := make([]schemas.ResponseTaskRead, 0, len(ids))
+	for _, id := range ids {
+		rTask, version, err := api.getVersioned(id)
+		if err != nil {
+			return err
+		}
+		rTask, err = api.replace(id, version, &schemas.RequestTaskUpdate{
+			Title:       rTask.Title,
+			Description: rTask.Description,
+			DueDate:     rTask.DueDate,
+			Completed:   !*undo,
+		})
+		if err != nil {
+			return err
+		}
+		rTasks = append(rTasks, *rTask)
+	}
+	return printTasks(c.Stdout, g.output, rTasks)
+}
+
+func (c *CLI) rm(g *globalFlags, args []string) error {
+	flags := c.newFlags("rm", "<id>...", g)
+	if err := parse(flags, g, args); err != nil {
+		return err
+	}
+	ids, err := parseIds(flags)
+	if err != nil {
+		return err
+	}
+
+	api, err := c.client(g)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err = api.delete(id); err != nil {
+			return err
+		}
+		if g.output == outputTable {
+			fmt.Fprintf(c.Stdout, "Deleted task %d\n", id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package todoctl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Task ids are completed with "todoctl ls -q", so they come from the
+// current profile.
+
+const bashCompletion = `# bash completion of todoctl, load with: source <(todoctl completion bash)
+_todoctl() {
+    local cur=${COMP_WORDS[COMP_CWORD]} cmd=${COMP_WORDS[1]}
+    if [[ $COMP_CWORD -eq 1 ]]; then
+        COMPREPLY=($(compgen -W "%[1]s" -- "$cur"))
+        return
+    fi
+    case $cur in
+    -*)
+        COMPREPLY=($(compgen -W "$(_todoctl_flags "$cmd")" -- "$cur"))
+        return
+        ;;
+    esac
+    case $cmd in
+    show|edit|done|rm)
+        COMPREPLY=($(compgen -W "$(todoctl ls -q 2>/dev/null)" -- "$cur"))
+        ;;
+    config)
+        COMPREPLY=($(compgen -W "ls set use rm" -- "$cur"))
+        ;;
+    completion)
+        COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
+        ;;
+    esac
+}
+_todoctl_flags() {
+    local global="-o -profile -url -token"
+    case $1 in
+%[2]s    esac
+}
+complete -F _todoctl todoctl
+`
+
+const zshCompletion = `#compdef todoctl
+# zsh completion of todoctl, load with: source <(todoctl completion zsh)
+_todoctl() {
+    if (( CURRENT == 2 )); then
+        compadd %[1]s
+        return
+    fi
+    case $words[2] in
+    show|edit|done|rm) compadd -- ${(f)"$(todoctl ls -q 2>/dev/null)"} ;;
+    config) compadd ls set use rm ;;
+    completion) compadd bash zsh fish ;;
+    esac
+}
+compdef _todoctl todoctl
+`
+
+const fishCompletion = `# fish completion of todoctl, load with: todoctl completion fish | source
+complete -c todoctl -f
+complete -c todoctl -n __fish_use_subcommand -a "%[1]s"
+complete -c todoctl -n "__fish_seen_subcommand_from show edit done rm" -a "(todoctl ls -q 2>/dev/null)"
+complete -c todoctl -n "__fish_seen_subcommand_from config" -a "ls set use rm"
+complete -c todoctl -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+complete -c todoctl -o o -x -a "table json yaml" -d "output format"
+complete -c todoctl -o profile -x -d "profile to use"
+complete -c todoctl -o url -x -d "base url of the api"
+complete -c todoctl -o token -x -d "api token"
+`
+
+// commandFlags are the flags of each command besides the global ones.
+var commandFlags = map[string]string{
+	"add":  "-d -due -done",
+	"ls":   "-completed -due-after -due-before -q",
+	"edit": "-title -d -due -e",
+	"done": "-undo",
+}
+
+func (c *CLI) completion(g *globalFlags, args []string) error {
+	if len(args) != 1 {
+		fmt.Fprintln(c.Stderr, "Usage: todoctl completion <bash | zsh | fish>")
+		return errUsage
+	}
+
+	names := strings.Join(commandNames(), " ")
+	switch args[0] {
+	case "bash":
+		var cases strings.Builder
+		for _, name := range commandNames() {
+			fmt.Fprintf(&cases, "    %s) echo \"$global %s\" ;;\n", name, commandFlags[name])
+		}
+		fmt.Fprintf(&cases, "    *) echo \"$global\" ;;\n")
+		fmt.Fprintf(c.Stdout, bashCompletion, names, cases.String())
+	case "zsh":
+		fmt.Fprintf(c.Stdout, zshCompletion, names)
+	case "fish":
+		fmt.Fprintf(c.Stdout, fishCompletion, names)
+	default:
+		return fmt.Errorf("unknown shell %q, use bash, zsh or fish", args[0])
+	}
+	return nil
+}
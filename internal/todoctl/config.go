@@ -0,0 +1,111 @@
+package todoctl
+
+import (
+	"errors"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+)
+
+const (
+	defaultProfile = "default"
+	defaultURL     = "http://localhost:8082"
+)
+
+// Profile is a server todoctl talks to. Actor is sent as X-Actor and
+// recorded in the task history, it defaults to $USER.
+type Profile struct {
+	URL   string `yaml:"url"`
+	Token string `yaml:"token,omitempty"`
+	Actor string `yaml:"actor,omitempty"`
+}
+
+// Config is the config file, ~/.config/todoctl/config.yaml unless
+// $TODOCTL_CONFIG names another one.
+type Config struct {
+	Current  string              `yaml:"current"`
+	Profiles map[string]*Profile `yaml:"profiles"`
+}
+
+func (c *CLI) configPath() (string, error) {
+	if path := c.Getenv("TODOCTL_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "todoctl", "config.yaml"), nil
+}
+
+// loadConfig reads the config file. A missing file is an empty config.
+func (c *CLI) loadConfig() (*Config, error) {
+	conf := &Config{Profiles: make(map[string]*Profile)}
+	path, err := c.configPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return conf, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(data, conf); err != nil {
+		return nil, err
+	}
+	if conf.Profiles == nil {
+		conf.Profiles = make(map[string]*Profile)
+	}
+	return conf, nil
+}
+
+func (c *CLI) saveConfig(conf *Config) error {
+	path, err := c.configPath()
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(conf)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	// The file holds api tokens.
+	return os.WriteFile(path, data, 0o600)
+}
+
+// profile resolves the profile to use: the -profile flag, $TODOCTL_PROFILE
+// or the current one of the config, with -url and -token, or
+// $TODOCTL_URL and $TODOCTL_TOKEN, taking precedence over its settings.
+func (c *CLI) profile(g *globalFlags) (*Profile, error) {
+	conf, err := c.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	name := firstOf(g.profile, c.Getenv("TODOCTL_PROFILE"), conf.Current, defaultProfile)
+	p := Profile{}
+	if stored, ok := conf.Profiles[name]; ok {
+		p = *stored
+	} else if g.profile != "" {
+		return nil, errors.New("profile " + name + " not found")
+	}
+
+	p.URL = firstOf(g.url, c.Getenv("TODOCTL_URL"), p.URL, defaultURL)
+	p.Token = firstOf(g.token, c.Getenv("TODOCTL_TOKEN"), p.Token)
+	p.Actor = firstOf(p.Actor, c.Getenv("USER"))
+	return &p, nil
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
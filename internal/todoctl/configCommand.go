@@ -0,0 +1,105 @@
+package todoctl
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+)
+
+const configUsage = `Usage: todoctl config <ls | set | use | rm> [flags] [name]
+
+  ls                list the profiles
+  set <name>        create or change a profile with -url, -token and -actor
+  use <name>        make the profile the current one
+  rm <name>         delete a profile
+`
+
+func (c *CLI) config(g *globalFlags, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(c.Stderr, configUsage)
+		return errUsage
+	}
+
+	conf, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "ls":
+		return c.configList(conf)
+	case "set":
+		return c.configSet(conf, args[1:])
+	case "use", "rm":
+		if len(args) != 2 {
+			fmt.Fprint(c.Stderr, configUsage)
+			return errUsage
+		}
+		name := args[1]
+		if _, ok := conf.Profiles[name]; !ok {
+			return errors.New("profile " + name + " not found")
+		}
+		if args[0] == "use" {
+			conf.Current = name
+		} else {
+			delete(conf.Profiles, name)
+			if conf.Current == name {
+				conf.Current = ""
+			}
+		}
+		return c.saveConfig(conf)
+	}
+	fmt.Fprint(c.Stderr, configUsage)
+	return errUsage
+}
+
+func (c *CLI) configList(conf *Config) error {
+	names := make([]string, 0, len(conf.Profiles))
+	for name := range conf.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(c.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CURRENT\tNAME\tURL\tTOKEN\tACTOR")
+	for _, name := range names {
+		p := conf.Profiles[name]
+		current, token := "", ""
+		if name == firstOf(conf.Current, defaultProfile) {
+			current = "*"
+		}
+		if p.Token != "" {
+			token = "(set)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", current, name, p.URL, token, p.Actor)
+	}
+	return tw.Flush()
+}
+
+func (c *CLI) configSet(conf *Config, args []string) error {
+	g := &globalFlags{}
+	flags := c.newFlags("config", "set <name>", g)
+	flags.Usage = func() { fmt.Fprint(c.Stderr, configUsage) }
+	actor := flags.String("actor", "", "`name` recorded in the task history")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+
+	name := flags.Arg(0)
+	p, ok := conf.Profiles[name]
+	if !ok {
+		p = &Profile{URL: defaultURL}
+		conf.Profiles[name] = p
+	}
+	p.URL = firstOf(g.url, p.URL)
+	p.Token = firstOf(g.token, p.Token)
+	p.Actor = firstOf(*actor, p.Actor)
+	if conf.Current == "" {
+		conf.Current = name
+	}
+	return c.saveConfig(conf)
+}
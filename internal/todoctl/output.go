@@ -0,0 +1,103 @@
+package todoctl
+
+import (
+	"ToDoVerba/internal/schemas"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"text/tabwriter"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// printTasks writes the tasks in the output format. JSON and YAML use the
+// field names of the api, so scripts can switch between todoctl and curl.
+func printTasks(w io.Writer, format string, tasks []schemas.ResponseTaskRead) error {
+	if tasks == nil {
+		tasks = make([]schemas.ResponseTaskRead, 0)
+	}
+	switch format {
+	case outputJSON:
+		return printJSON(w, tasks)
+	case outputYAML:
+		return printYAML(w, tasks)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDONE\tDUE\tTITLE")
+	for _, task := range tasks {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", task.Id, checkbox(task.Completed), task.DueDate, task.Title)
+	}
+	return tw.Flush()
+}
+
+func printTask(w io.Writer, format string, task *schemas.ResponseTaskRead) error {
+	switch format {
+	case outputJSON:
+		return printJSON(w, task)
+	case outputYAML:
+		return printYAML(w, task)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%d\n", task.Id)
+	fmt.Fprintf(tw, "Title:\t%s\n", task.Title)
+	fmt.Fprintf(tw, "Due:\t%s\n", task.DueDate)
+	fmt.Fprintf(tw, "Done:\t%s\n", checkbox(task.Completed))
+	fmt.Fprintf(tw, "Created:\t%s\n", task.CreatedAt)
+	fmt.Fprintf(tw, "Updated:\t%s\n", task.UpdatedAt)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%s\n", task.Description)
+	return err
+}
+
+func checkbox(completed bool) string {
+	if completed {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printYAML writes v as YAML with its JSON field names and order. JSON is
+// YAML, so the JSON encoding is read as a node tree and written back in
+// block style.
+func printYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	node := &yaml.Node{}
+	if err = yaml.Unmarshal(data, node); err != nil {
+		return err
+	}
+	blockStyle(node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err = enc.Encode(node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle drops the flow style of the parsed JSON. The encoder still
+// quotes strings such as "true" that would read back as another type.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
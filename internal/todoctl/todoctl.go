@@ -0,0 +1,236 @@
+// Package todoctl is a command-line client of the task api, built as
+// cmd/todoctl.
+package todoctl
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+const usage = `Usage: todoctl [flags] <command> [flags] [args]
+
+Commands:
+  add         create a task
+  ls          list tasks
+  show        show a task
+  edit        change a task
+  done        mark tasks as done
+  rm          delete tasks
+  config      manage server profiles
+  completion  print a shell completion script
+
+Flags of every command:
+  -o format   output format: table, json or yaml (default table)
+  -profile    profile of the config file to use
+  -url        base url of the api, overrides the profile
+  -token      api token, overrides the profile
+
+Run "todoctl <command> -h" for the flags of a command.
+`
+
+// CLI runs todoctl commands. Tests replace its streams, environment and
+// editor.
+type CLI struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	Getenv func(key string) string
+	// Edit opens the file in the editor of the user and waits for it.
+	Edit       func(path string) error
+	HTTPClient *http.Client
+}
+
+// New returns a CLI using the process streams and environment.
+func New() *CLI {
+	c := &CLI{
+		Stdin:      os.Stdin,
+		Stdout:     os.Stdout,
+		Stderr:     os.Stderr,
+		Getenv:     os.Getenv,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	c.Edit = c.runEditor
+	return c
+}
+
+type command struct {
+	summary string
+	run     func(c *CLI, g *globalFlags, args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"add":        {"create a task", (*CLI).add},
+		"ls":         {"list tasks", (*CLI).ls},
+		"show":       {"show a task", (*CLI).show},
+		"edit":       {"change a task", (*CLI).edit},
+		"done":       {"mark tasks as done", (*CLI).done},
+		"rm":         {"delete tasks", (*CLI).rm},
+		"config":     {"manage server profiles", (*CLI).config},
+		"completion": {"print a shell completion script", (*CLI).completion},
+	}
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// globalFlags are accepted before the command as well as after it.
+type globalFlags struct {
+	output  string
+	profile string
+	url     string
+	token   string
+}
+
+func (g *globalFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&g.output, "o", g.output, "output `format`: table, json or yaml")
+	flags.StringVar(&g.profile, "profile", g.profile, "`name` of the profile to use")
+	flags.StringVar(&g.url, "url", g.url, "base `url` of the api")
+	flags.StringVar(&g.token, "token", g.token, "api `token`")
+}
+
+// errUsage reports wrong arguments, the flag package has printed why.
+var errUsage = errors.New("usage")
+
+// Run runs the command of args and returns the exit status: 0 on success,
+// 1 if the command failed and 2 for wrong arguments.
+func (c *CLI) Run(args []string) int {
+	g := &globalFlags{output: outputTable}
+	flags := flag.NewFlagSet("todoctl", flag.ContinueOnError)
+	flags.SetOutput(c.Stderr)
+	flags.Usage = func() { fmt.Fprint(c.Stderr, usage) }
+	g.register(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitStatus(err)
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(c.Stderr, "todoctl: unknown command %q\n\n", flags.Arg(0))
+		flags.Usage()
+		return 2
+	}
+
+	if err := cmd.run(c, g, flags.Args()[1:]); err != nil {
+		if !errors.Is(err, errUsage) && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(c.Stderr, "todoctl %s: %s\n", flags.Arg(0), err)
+		}
+		return exitStatus(err)
+	}
+	return 0
+}
+
+func exitStatus(err error) int {
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	}
+	return 1
+}
+
+// newFlags returns the flag set of a command with the global flags.
+func (c *CLI) newFlags(name, args string, g *globalFlags) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.Stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(c.Stderr, "Usage: todoctl %s [flags] %s\n\n%s\n\nFlags:\n", name, args, commands[name].summary)
+		flags.PrintDefaults()
+	}
+	g.register(flags)
+	return flags
+}
+
+// parse parses the flags of a command and checks the output format.
+func parse(flags *flag.FlagSet, g *globalFlags, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	switch g.output {
+	case outputTable, outputJSON, outputYAML:
+		return nil
+	}
+	fmt.Fprintf(flags.Output(), "invalid output format %q\n", g.output)
+	flags.Usage()
+	return errUsage
+}
+
+func (c *CLI) client(g *globalFlags) (*client, error) {
+	p, err := c.profile(g)
+	if err != nil {
+		return nil, err
+	}
+	return &client{baseURL: p.URL, token: p.Token, actor: p.Actor, http: c.HTTPClient}, nil
+}
+
+// runEditor opens the file in $VISUAL or $EDITOR, vi if neither is set. The
+// variable may hold arguments such as "code --wait".
+func (c *CLI) runEditor(path string) error {
+	editor := strings.Fields(firstOf(c.Getenv("VISUAL"), c.Getenv("EDITOR"), "vi"))
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// editText lets the user edit text in the editor and returns the result
+// without trailing whitespace. Lines starting with # are dropped, they hold
+// the hint.
+func (c *CLI) editText(text, hint string) (string, error) {
+	file, err := os.CreateTemp("", "todoctl-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = fmt.Fprintf(file, "%s\n# %s\n# Lines starting with # are ignored, an empty text aborts.\n", text, hint)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err = c.Edit(file.Name()); err != nil {
+		return "", fmt.Errorf("editor: %w", err)
+	}
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	edited := strings.TrimSpace(strings.Join(lines, "\n"))
+	if edited == "" {
+		return "", errors.New("empty description, aborted")
+	}
+	return edited, nil
+}
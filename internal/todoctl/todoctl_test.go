@@ -0,0 +1,362 @@
+package todoctl
+
+import (
+	"ToDoVerba/internal/dto"
+	v1 "ToDoVerba/internal/route/api/v1"
+	v2 "ToDoVerba/internal/route/api/v2"
+	"ToDoVerba/internal/service"
+	mockservice "ToDoVerba/internal/service/mocks"
+	"ToDoVerba/pkg/logging"
+	"bytes"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testDue = time.Date(2024, 9, 10, 12, 0, 0, 0, time.UTC)
+
+func testTask(id int, completed bool) *dto.TaskRead {
+	return &dto.TaskRead{
+		Id:          id,
+		Title:       "Buy milk",
+		Description: "two bottles",
+		DueDate:     pgtype.Timestamptz{Time: testDue, Valid: true},
+		Completed:   completed,
+		Version:     3,
+		CreatedAt:   pgtype.Timestamptz{Time: testDue.Add(-time.Hour), Valid: true},
+		UpdatedAt:   pgtype.Timestamptz{Time: testDue.Add(-time.Hour), Valid: true},
+	}
+}
+
+type testCLI struct {
+	*CLI
+	stdout *bytes.Buffer
+	stderr *bytes.Buffer
+	env    map[string]string
+}
+
+// newTestCLI returns a CLI talking to the v1 and v2 handlers served with
+// tasks, and a config file in a temporary directory.
+func newTestCLI(t *testing.T, tasks service.ITaskService) *testCLI {
+	r := httprouter.New()
+	v1.NewHandler(v1.Deps{
+		Service: service.Services{Task: tasks},
+		Logger:  logging.GetLoggerTest(),
+	}).Init(r)
+	v2.NewHandler(v2.Deps{
+		Service: service.Services{Task: tasks},
+		Logger:  logging.GetLoggerTest(),
+	}).Init(r)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	c := &testCLI{
+		stdout: &bytes.Buffer{},
+		stderr: &bytes.Buffer{},
+		env: map[string]string{
+			"TODOCTL_CONFIG": filepath.Join(t.TempDir(), "config.yaml"),
+			"TODOCTL_URL":    server.URL,
+			"USER":           "alice",
+		},
+	}
+	c.CLI = &CLI{
+		Stdin:      strings.NewReader(""),
+		Stdout:     c.stdout,
+		Stderr:     c.stderr,
+		Getenv:     func(key string) string { return c.env[key] },
+		HTTPClient: server.Client(),
+		Edit: func(path string) error {
+			t.Fatal("unexpected editor call")
+			return nil
+		},
+	}
+	return c
+}
+
+func TestCLI_add(t *testing.T) {
+	testTable := []struct {
+		name           string
+		args           []string
+		stdin          string
+		editor         func(path string) error
+		expectedDesc   string
+		expectedStatus int
+		expectedStdout string
+		stderrContains string
+	}{
+		{
+			name:           "description flag",
+			args:           []string{"add", "-d", "two bottles", "-due", "2024-09-10T12:00:00Z", "-o", "json", "Buy", "milk"},
+			expectedDesc:   "two bottles",
+			expectedStatus: 0,
+			expectedStdout: `{
+  "id": 1,
+  "title": "Buy milk",
+  "description": "two bottles",
+  "due_date": "2024-09-10T12:00:00Z",
+  "completed": false,
+  "created_at": "2024-09-10T11:00:00Z",
+  "updated_at": "2024-09-10T11:00:00Z"
+}
+`,
+		},
+		{
+			name:           "description from stdin",
+			args:           []string{"-o", "yaml", "add", "-d", "-", "-due", "2024-09-10T12:00:00Z", "Buy milk"},
+			stdin:          "two bottles\n",
+			expectedDesc:   "two bottles",
+			expectedStatus: 0,
+			expectedStdout: `id: 1
+title: Buy milk
+description: two bottles
+due_date: "2024-09-10T12:00:00Z"
+completed: false
+created_at: "2024-09-10T11:00:00Z"
+updated_at: "2024-09-10T11:00:00Z"
+`,
+		},
+		{
+			name: "description from editor",
+			args: []string{"add", "-due", "2024-09-10T12:00:00Z", "-o", "json", "Buy milk"},
+			editor: func(path string) error {
+				return os.WriteFile(path, []byte("two bottles\n\n# hint\n"), 0o600)
+			},
+			expectedDesc:   "two bottles",
+			expectedStatus: 0,
+		},
+		{
+			name: "empty editor text",
+			args: []string{"add", "-due", "2024-09-10T12:00:00Z", "Buy milk"},
+			editor: func(path string) error {
+				return os.WriteFile(path, []byte("# hint\n"), 0o600)
+			},
+			expectedStatus: 1,
+			stderrContains: "todoctl add: empty description, aborted",
+		},
+		{
+			name:           "missing due date",
+			args:           []string{"add", "-d", "two bottles", "Buy milk"},
+			expectedStatus: 2,
+			stderrContains: "title and -due are required",
+		},
+		{
+			name:           "invalid output",
+			args:           []string{"add", "-o", "xml", "-d", "two bottles", "-due", "2024-09-10", "Buy milk"},
+			expectedStatus: 2,
+			stderrContains: `invalid output format "xml"`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tasks := mockservice.NewMockITaskService(c)
+			if testCase.expectedDesc != "" {
+				tasks.EXPECT().Create(&dto.TaskCreate{
+					Title:       "Buy milk",
+					Description: testCase.expectedDesc,
+					DueDate:     pgtype.Timestamptz{Time: testDue, Valid: true},
+					Actor:       "alice",
+				}).Return(testTask(1, false), nil)
+			}
+			cli := newTestCLI(t, tasks)
+			cli.Stdin = strings.NewReader(testCase.stdin)
+			if testCase.editor != nil {
+				cli.Edit = testCase.editor
+			}
+
+			status := cli.Run(testCase.args)
+
+			assert.Equal(t, testCase.expectedStatus, status, cli.stderr.String())
+			if testCase.expectedStdout != "" {
+				assert.Equal(t, testCase.expectedStdout, cli.stdout.String())
+			}
+			assert.Contains(t, cli.stderr.String(), testCase.stderrContains)
+		})
+	}
+}
+
+func TestCLI_ls(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tasks := mockservice.NewMockITaskService(c)
+	tasks.EXPECT().List().Return([]dto.TaskRead{*testTask(1, false), *testTask(2, true)}, nil).Times(3)
+	cli := newTestCLI(t, tasks)
+
+	require.Equal(t, 0, cli.Run([]string{"ls"}))
+	assert.Equal(t, `ID  DONE  DUE                   TITLE
+1   [ ]   2024-09-10T12:00:00Z  Buy milk
+2   [x]   2024-09-10T12:00:00Z  Buy milk
+`, cli.stdout.String())
+
+	cli.stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"ls", "-completed", "true", "-q"}))
+	assert.Equal(t, "2\n", cli.stdout.String())
+
+	cli.stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"ls", "-completed", "false", "-o", "json"}))
+	assert.JSONEq(t, `[{"id":1,"title":"Buy milk","description":"two bottles","due_date":"2024-09-10T12:00:00Z",
+		"completed":false,"created_at":"2024-09-10T11:00:00Z","updated_at":"2024-09-10T11:00:00Z"}]`, cli.stdout.String())
+
+	assert.Equal(t, 1, cli.Run([]string{"ls", "-completed", "maybe"}))
+	assert.Contains(t, cli.stderr.String(), "todoctl ls: completed must be true or false;")
+}
+
+func TestCLI_show(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tasks := mockservice.NewMockITaskService(c)
+	tasks.EXPECT().FindByID(1).Return(testTask(1, false), nil)
+	tasks.EXPECT().FindByID(2).Return(nil, pgx.ErrNoRows)
+	cli := newTestCLI(t, tasks)
+
+	require.Equal(t, 0, cli.Run([]string{"show", "1"}))
+	assert.Equal(t, `ID:       1
+Title:    Buy milk
+Due:      2024-09-10T12:00:00Z
+Done:     [ ]
+Created:  2024-09-10T11:00:00Z
+Updated:  2024-09-10T11:00:00Z
+
+two bottles
+`, cli.stdout.String())
+
+	assert.Equal(t, 1, cli.Run([]string{"show", "2"}))
+	assert.Equal(t, "todoctl show: no rows in result set (HTTP 404)\n", cli.stderr.String())
+
+	assert.Equal(t, 2, cli.Run([]string{"show"}))
+}
+
+func TestCLI_edit(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tasks := mockservice.NewMockITaskService(c)
+	tasks.EXPECT().FindByID(1).Return(testTask(1, false), nil).Times(2)
+	tasks.EXPECT().UpdateById(1, &dto.TaskUpdate{
+		Title:       "Buy oat milk",
+		Description: "two bottles",
+		DueDate:     pgtype.Timestamptz{Time: testDue, Valid: true},
+		Actor:       "alice",
+		Version:     3,
+	}).Return(testTask(1, false), nil)
+	tasks.EXPECT().UpdateById(1, &dto.TaskUpdate{
+		Title:       "Buy milk",
+		Description: "three bottles",
+		DueDate:     pgtype.Timestamptz{Time: testDue, Valid: true},
+		Actor:       "alice",
+		Version:     3,
+	}).Return(testTask(1, false), nil)
+	cli := newTestCLI(t, tasks)
+
+	require.Equal(t, 0, cli.Run([]string{"edit", "-title", "Buy oat milk", "1"}))
+
+	cli.Edit = func(path string) error {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(data), "two bottles\n#"))
+		return os.WriteFile(path, []byte("three bottles\n"), 0o600)
+	}
+	require.Equal(t, 0, cli.Run([]string{"edit", "1"}), cli.stderr.String())
+}
+
+// A task changed between the read and the write of done is reported rather
+// than overwritten.
+func TestCLI_doneConflict(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tasks := mockservice.NewMockITaskService(c)
+	tasks.EXPECT().FindByID(1).Return(testTask(1, false), nil)
+	tasks.EXPECT().UpdateById(1, gomock.Any()).Return(nil, dto.ErrVersionConflict)
+	cli := newTestCLI(t, tasks)
+
+	assert.Equal(t, 1, cli.Run([]string{"done", "1"}))
+	assert.Contains(t, cli.stderr.String(), "task 1 was changed since it was read")
+	assert.Empty(t, cli.stdout.String())
+}
+
+func TestCLI_doneAndRm(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tasks := mockservice.NewMockITaskService(c)
+	for _, id := range []int{1, 2} {
+		tasks.EXPECT().FindByID(id).Return(testTask(id, false), nil)
+		tasks.EXPECT().UpdateById(id, &dto.TaskUpdate{
+			Title:       "Buy milk",
+			Description: "two bottles",
+			DueDate:     pgtype.Timestamptz{Time: testDue, Valid: true},
+			Completed:   true,
+			Actor:       "alice",
+			Version:     3,
+		}).Return(testTask(id, true), nil)
+	}
+	tasks.EXPECT().DeleteById(1, "alice").Return(nil)
+	cli := newTestCLI(t, tasks)
+
+	require.Equal(t, 0, cli.Run([]string{"done", "-o", "json", "1", "2"}))
+	assert.Equal(t, 2, strings.Count(cli.stdout.String(), `"completed": true`))
+
+	cli.stdout.Reset()
+	require.Equal(t, 0, cli.Run([]string{"rm", "1"}))
+	assert.Equal(t, "Deleted task 1\n", cli.stdout.String())
+
+	assert.Equal(t, 1, cli.Run([]string{"rm", "x"}))
+	assert.Contains(t, cli.stderr.String(), `invalid task id "x"`)
+}
+
+func TestCLI_profiles(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tasks := mockservice.NewMockITaskService(c)
+	tasks.EXPECT().DeleteById(1, "bob").Return(nil)
+	cli := newTestCLI(t, tasks)
+	url := cli.env["TODOCTL_URL"]
+	delete(cli.env, "TODOCTL_URL")
+
+	require.Equal(t, 0, cli.Run([]string{"config", "set", "-url", "http://127.0.0.1:1", "local"}))
+	require.Equal(t, 0, cli.Run([]string{"config", "set", "-url", url, "-token", "secret", "-actor", "bob", "test"}))
+	require.Equal(t, 0, cli.Run([]string{"config", "use", "test"}))
+	require.Equal(t, 0, cli.Run([]string{"config", "ls"}))
+	lines := strings.Split(strings.TrimSpace(cli.stdout.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"local", "http://127.0.0.1:1"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"*", "test", url, "(set)", "bob"}, strings.Fields(lines[2]))
+
+	require.Equal(t, 0, cli.Run([]string{"rm", "1"}), cli.stderr.String())
+
+	assert.Equal(t, 1, cli.Run([]string{"-profile", "missing", "rm", "1"}))
+	assert.Contains(t, cli.stderr.String(), "profile missing not found")
+
+	data, err := os.ReadFile(cli.env["TODOCTL_CONFIG"])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "current: test")
+}
+
+func TestCLI_completion(t *testing.T) {
+	cli := newTestCLI(t, nil)
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		cli.stdout.Reset()
+		require.Equal(t, 0, cli.Run([]string{"completion", shell}))
+		assert.Contains(t, cli.stdout.String(), "add completion config done edit ls rm show")
+		assert.Contains(t, cli.stdout.String(), "todoctl ls -q")
+	}
+
+	assert.Equal(t, 1, cli.Run([]string{"completion", "tcsh"}))
+}
@@ -0,0 +1 @@
+DROP TABLE public.task_events;
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE public.task_events
+(
+    id   BIGSERIAL PRIMARY KEY ,
+    task_id   INTEGER NOT NULL ,
+    action   TEXT NOT NULL ,
+    actor   TEXT NOT NULL ,
+    changes   JSONB NOT NULL ,
+    created_at timestamptz DEFAULT CURRENT_TIMESTAMP(0) NOT NULL
+);
+
+CREATE INDEX task_events_task_id_idx ON public.task_events (task_id, id);
+CREATE INDEX task_events_created_at_idx ON public.task_events (created_at);
@@ -0,0 +1,3 @@
+DROP TABLE public.task_versions;
+
+ALTER TABLE public.tasks DROP COLUMN version;
\ No newline at end of file
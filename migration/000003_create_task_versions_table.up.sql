@@ -0,0 +1,16 @@
+ALTER TABLE public.tasks ADD COLUMN version INTEGER DEFAULT 1 NOT NULL;
+
+CREATE TABLE public.task_versions
+(
+    task_id   INTEGER NOT NULL ,
+    version   INTEGER NOT NULL ,
+    title   TEXT NOT NULL ,
+    description   TEXT NOT NULL ,
+    due_date timestamptz NOT NULL,
+    actor   TEXT NOT NULL ,
+    created_at timestamptz DEFAULT CURRENT_TIMESTAMP(0) NOT NULL,
+    PRIMARY KEY (task_id, version)
+);
+
+INSERT INTO public.task_versions (task_id, version, title, description, due_date, actor, created_at)
+SELECT id, version, title, description, due_date, 'anonymous', updated_at FROM public.tasks;
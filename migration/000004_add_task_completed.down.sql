@@ -0,0 +1,3 @@
+ALTER TABLE public.task_versions DROP COLUMN completed;
+
+ALTER TABLE public.tasks DROP COLUMN completed;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE public.tasks ADD COLUMN completed BOOLEAN DEFAULT false NOT NULL;
+
+ALTER TABLE public.task_versions ADD COLUMN completed BOOLEAN DEFAULT false NOT NULL;
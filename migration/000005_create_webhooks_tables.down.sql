@@ -0,0 +1,3 @@
+DROP TABLE public.webhook_deliveries;
+
+DROP TABLE public.webhooks;
\ No newline at end of file
@@ -0,0 +1,29 @@
+CREATE TABLE public.webhooks
+(
+    id   SERIAL PRIMARY KEY ,
+    url   TEXT NOT NULL ,
+    secret   TEXT NOT NULL ,
+    events   TEXT[] NOT NULL ,
+    active   BOOLEAN DEFAULT true NOT NULL ,
+    created_at timestamptz DEFAULT CURRENT_TIMESTAMP(0) NOT NULL,
+    updated_at timestamptz DEFAULT CURRENT_TIMESTAMP(0) NOT NULL
+);
+
+CREATE TABLE public.webhook_deliveries
+(
+    id   BIGSERIAL PRIMARY KEY ,
+    webhook_id   INTEGER NOT NULL REFERENCES public.webhooks (id) ON DELETE CASCADE ,
+    event_id   TEXT NOT NULL ,
+    event   TEXT NOT NULL ,
+    payload   JSONB NOT NULL ,
+    status   TEXT NOT NULL ,
+    attempts   INTEGER DEFAULT 0 NOT NULL ,
+    next_attempt_at timestamptz NOT NULL,
+    last_error   TEXT DEFAULT '' NOT NULL ,
+    response_code   INTEGER DEFAULT 0 NOT NULL ,
+    created_at timestamptz DEFAULT CURRENT_TIMESTAMP(0) NOT NULL,
+    updated_at timestamptz DEFAULT CURRENT_TIMESTAMP(0) NOT NULL
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON public.webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_webhook_id_idx ON public.webhook_deliveries (webhook_id, id);
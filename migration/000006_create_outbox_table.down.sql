@@ -0,0 +1 @@
+DROP TABLE public.outbox;
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE public.outbox
+(
+    id   BIGSERIAL PRIMARY KEY ,
+    event_id   TEXT NOT NULL ,
+    event_type   TEXT NOT NULL ,
+    ordering_key   TEXT NOT NULL ,
+    payload   JSONB NOT NULL ,
+    created_at timestamptz DEFAULT CURRENT_TIMESTAMP(0) NOT NULL,
+    published_at timestamptz
+);
+
+CREATE INDEX outbox_unpublished_idx ON public.outbox (id) WHERE published_at IS NULL;
+CREATE INDEX outbox_published_at_idx ON public.outbox (published_at) WHERE published_at IS NOT NULL;
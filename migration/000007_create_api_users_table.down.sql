@@ -0,0 +1 @@
+DROP TABLE public.api_users;
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE public.api_users
+(
+    id   SERIAL PRIMARY KEY ,
+    name   TEXT NOT NULL UNIQUE ,
+    token_hash   TEXT NOT NULL UNIQUE ,
+    created_at timestamptz DEFAULT CURRENT_TIMESTAMP(0) NOT NULL
+);
@@ -0,0 +1 @@
+DROP TABLE public.task_reminders;
\ No newline at end of file
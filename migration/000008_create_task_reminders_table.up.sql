@@ -0,0 +1,19 @@
+CREATE TABLE public.task_reminders
+(
+    id   BIGSERIAL PRIMARY KEY ,
+    task_id   INTEGER NOT NULL REFERENCES public.tasks (id) ON DELETE CASCADE ,
+    offset_seconds   INTEGER NOT NULL ,
+    channels   TEXT[] NOT NULL ,
+    sent_channels   TEXT[] DEFAULT '{}' NOT NULL ,
+    fire_at timestamptz NOT NULL,
+    status   TEXT NOT NULL ,
+    generation   INTEGER DEFAULT 1 NOT NULL ,
+    attempts   INTEGER DEFAULT 0 NOT NULL ,
+    last_error   TEXT DEFAULT '' NOT NULL ,
+    sent_at timestamptz,
+    created_at timestamptz DEFAULT CURRENT_TIMESTAMP(0) NOT NULL,
+    updated_at timestamptz DEFAULT CURRENT_TIMESTAMP(0) NOT NULL,
+    UNIQUE (task_id, offset_seconds)
+);
+
+CREATE INDEX task_reminders_fire_at_idx ON public.task_reminders (fire_at) WHERE status = 'pending';
@@ -0,0 +1 @@
+ALTER TABLE public.api_users DROP COLUMN feed_token_hash;
\ No newline at end of file
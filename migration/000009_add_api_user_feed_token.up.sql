@@ -0,0 +1 @@
+ALTER TABLE public.api_users ADD COLUMN feed_token_hash TEXT UNIQUE;
\ No newline at end of file
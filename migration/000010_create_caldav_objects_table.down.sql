@@ -0,0 +1 @@
+DROP TABLE public.caldav_objects;
\ No newline at end of file
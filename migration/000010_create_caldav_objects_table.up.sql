@@ -0,0 +1,7 @@
+CREATE TABLE public.caldav_objects
+(
+    task_id   INTEGER PRIMARY KEY ,
+    href   TEXT NOT NULL UNIQUE ,
+    uid   TEXT NOT NULL ,
+    created_at timestamptz DEFAULT CURRENT_TIMESTAMP(0) NOT NULL
+);
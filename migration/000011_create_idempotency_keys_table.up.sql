@@ -0,0 +1,11 @@
+CREATE TABLE public.idempotency_keys
+(
+    key   TEXT PRIMARY KEY ,
+    fingerprint   TEXT NOT NULL ,
+    status   INTEGER ,
+    body   BYTEA ,
+    created_at timestamptz NOT NULL ,
+    expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON public.idempotency_keys (expires_at);
@@ -0,0 +1 @@
+ALTER TABLE public.idempotency_keys DROP COLUMN header;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE public.idempotency_keys ADD COLUMN header JSONB;
\ No newline at end of file
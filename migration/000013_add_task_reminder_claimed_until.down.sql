@@ -0,0 +1 @@
+ALTER TABLE public.task_reminders DROP COLUMN claimed_until;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE public.task_reminders ADD COLUMN claimed_until timestamptz;
\ No newline at end of file
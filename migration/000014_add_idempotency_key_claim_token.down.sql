@@ -0,0 +1 @@
+ALTER TABLE public.idempotency_keys DROP COLUMN claim_token;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE public.idempotency_keys ADD COLUMN claim_token TEXT NOT NULL DEFAULT '';
\ No newline at end of file
@@ -0,0 +1,11 @@
+// Package migration holds the SQL migrations of the database, embedded in
+// the binary so it needs no files next to it.
+package migration
+
+import "embed"
+
+// FS holds the migrations in the naming of golang-migrate:
+// NNNNNN_name.up.sql and NNNNNN_name.down.sql.
+//
+//go:embed *.sql
+var FS embed.FS
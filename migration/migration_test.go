@@ -0,0 +1,39 @@
+package migration
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+var migrationName = regexp.MustCompile(`^(\d{6})_\w+\.(up|down)\.sql$`)
+
+// TestFS checks the migrations are numbered without gaps and can all be
+// reverted.
+func TestFS(t *testing.T) {
+	files, err := fs.Glob(FS, "*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	ups := make(map[int]string)
+	downs := make(map[int]string)
+	for _, file := range files {
+		match := migrationName.FindStringSubmatch(file)
+		require.NotNil(t, match, "unexpected file name %s", file)
+		version, _ := strconv.Atoi(match[1])
+		if match[2] == "up" {
+			ups[version] = file
+		} else {
+			downs[version] = file
+		}
+	}
+
+	for version := 1; version <= len(ups); version++ {
+		assert.Contains(t, ups, version, "missing up migration %06d", version)
+		assert.Contains(t, downs, version, "missing down migration %06d", version)
+	}
+	assert.Len(t, downs, len(ups))
+}
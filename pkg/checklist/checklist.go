@@ -0,0 +1,38 @@
+// Package checklist reads and writes Markdown task lists ("- [ ] task").
+// The text after the checkbox follows the todo.txt body syntax, so an item
+// may carry a priority, +projects, @contexts and key:value tags.
+package checklist
+
+import (
+	"ToDoVerba/pkg/todotxt"
+	"regexp"
+	"strings"
+)
+
+var itemPattern = regexp.MustCompile(`^\s*[-*+] \[([ xX])\]\s+(.*)$`)
+
+// Parse reads a list item. ok is false for lines that are not one, such as
+// headings, prose or plain list items.
+func Parse(line string) (item todotxt.Item, ok bool, err error) {
+	match := itemPattern.FindStringSubmatch(line)
+	if match == nil {
+		return todotxt.Item{}, false, nil
+	}
+
+	item, err = todotxt.ParseBody(match[2])
+	if err != nil {
+		return todotxt.Item{}, true, err
+	}
+	item.Completed = strings.EqualFold(match[1], "x")
+	return item, true, nil
+}
+
+// Format writes the item as a list item. Dates other than tags have no
+// place in a checklist and are left out.
+func Format(item todotxt.Item) string {
+	box := "[ ]"
+	if item.Completed {
+		box = "[x]"
+	}
+	return "- " + box + " " + item.Body()
+}
@@ -0,0 +1,37 @@
+package checklist
+
+import (
+	"ToDoVerba/pkg/todotxt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	item, ok, err := Parse("  * [X] (A) Call mom +family due:2024-09-10")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, todotxt.Item{Completed: true, Priority: "A", Text: "Call mom +family due:2024-09-10"}, item)
+
+	for _, line := range []string{"# Groceries", "- milk", "- [] milk", "Some prose"} {
+		_, ok, err = Parse(line)
+		assert.NoError(t, err)
+		assert.False(t, ok, line)
+	}
+
+	_, ok, err = Parse("- [ ]  ")
+	assert.True(t, ok)
+	assert.EqualError(t, err, "task text is empty")
+}
+
+func TestFormat_roundTrip(t *testing.T) {
+	for _, line := range []string{
+		"- [ ] Buy milk",
+		"- [x] (B) Call mom +family @phone due:2024-09-10",
+	} {
+		item, ok, err := Parse(line)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, line, Format(item))
+	}
+}
@@ -0,0 +1,160 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Decode reads a single component, usually a VCALENDAR. Folded lines are
+// joined and both CRLF and bare LF line endings are accepted.
+func Decode(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var root *Component
+	var stack []*Component
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("ical: line %d: %w", i+1, err)
+		}
+
+		switch {
+		case strings.EqualFold(p.Name, "BEGIN"):
+			if root != nil && len(stack) == 0 {
+				return nil, fmt.Errorf("ical: line %d: content after END:%s", i+1, root.Name)
+			}
+			c := NewComponent(strings.ToUpper(p.Value))
+			if len(stack) == 0 {
+				root = c
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, c)
+			}
+			stack = append(stack, c)
+		case strings.EqualFold(p.Name, "END"):
+			if len(stack) == 0 || !strings.EqualFold(stack[len(stack)-1].Name, p.Value) {
+				return nil, fmt.Errorf("ical: line %d: unexpected END:%s", i+1, p.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("ical: line %d: property %s outside of a component", i+1, p.Name)
+			}
+			c := stack[len(stack)-1]
+			c.Props = append(c.Props, p)
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("ical: no component")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("ical: missing END:%s", stack[len(stack)-1].Name)
+	}
+	return root, nil
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseLine splits a content line into name, params and value. The value
+// starts at the first colon that is not inside a quoted parameter value.
+func parseLine(line string) (Prop, error) {
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if quoted {
+				continue
+			}
+			name, params, _ := strings.Cut(line[:i], ";")
+			if name == "" {
+				return Prop{}, errors.New("missing property name")
+			}
+			return Prop{Name: strings.ToUpper(name), Params: params, Value: line[i+1:]}, nil
+		}
+	}
+	return Prop{}, errors.New("missing ':'")
+}
+
+// Param returns the value of a property parameter, unquoted.
+func (p Prop) Param(name string) string {
+	quoted := false
+	start := 0
+	for i := 0; i <= len(p.Params); i++ {
+		if i < len(p.Params) && p.Params[i] == '"' {
+			quoted = !quoted
+		}
+		if i < len(p.Params) && (quoted || p.Params[i] != ';') {
+			continue
+		}
+		key, value, _ := strings.Cut(p.Params[start:i], "=")
+		if strings.EqualFold(key, name) {
+			return strings.Trim(value, `"`)
+		}
+		start = i + 1
+	}
+	return ""
+}
+
+// Children returns the direct children with the name.
+func (c *Component) Children(name string) []*Component {
+	var children []*Component
+	for _, child := range c.Components {
+		if strings.EqualFold(child.Name, name) {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+// ParseText reverses Text.
+func ParseText(s string) string {
+	return textUnescaper.Replace(s)
+}
+
+// ParseTime parses a DATE or DATE-TIME property. Floating times and unknown
+// TZIDs are taken as UTC, dates as midnight UTC.
+func ParseTime(p Prop) (time.Time, error) {
+	value := p.Value
+	if strings.EqualFold(p.Param("VALUE"), "DATE") || len(value) == len(DateFormat) {
+		return time.Parse(DateFormat, value)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(DateTimeFormat, value)
+	}
+
+	loc := time.UTC
+	if tzid := p.Param("TZID"); tzid != "" {
+		if l, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			loc = l
+		}
+	}
+	return time.ParseInLocation(strings.TrimSuffix(DateTimeFormat, "Z"), value, loc)
+}
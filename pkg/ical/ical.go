@@ -0,0 +1,113 @@
+// Package ical reads and writes iCalendar (RFC 5545) objects.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	DateTimeFormat = "20060102T150405Z"
+	DateFormat     = "20060102"
+	maxLineOctets  = 75
+)
+
+// Prop is a content line. Value is written as is; use Text for TEXT values.
+type Prop struct {
+	Name   string
+	Params string
+	Value  string
+}
+
+// Component is a BEGIN/END block such as VCALENDAR, VTODO or VEVENT.
+type Component struct {
+	Name       string
+	Props      []Prop
+	Components []*Component
+}
+
+func NewComponent(name string) *Component {
+	return &Component{Name: name}
+}
+
+// Add appends a property and returns the component for chaining.
+func (c *Component) Add(name, value string) *Component {
+	c.Props = append(c.Props, Prop{Name: name, Value: value})
+	return c
+}
+
+// Set replaces the value of the first property with the name, or adds it.
+func (c *Component) Set(name, value string) *Component {
+	for i := range c.Props {
+		if strings.EqualFold(c.Props[i].Name, name) {
+			c.Props[i] = Prop{Name: name, Value: value}
+			return c
+		}
+	}
+	return c.Add(name, value)
+}
+
+// Get returns the value of the first property with the name.
+func (c *Component) Get(name string) (Prop, bool) {
+	for _, p := range c.Props {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return Prop{}, false
+}
+
+// Encode writes the component with CRLF line endings and folded lines.
+func Encode(w io.Writer, c *Component) error {
+	bw := bufio.NewWriter(w)
+	encode(bw, c)
+	return bw.Flush()
+}
+
+func encode(w *bufio.Writer, c *Component) {
+	writeLine(w, "BEGIN:"+c.Name)
+	for _, p := range c.Props {
+		line := p.Name
+		if p.Params != "" {
+			line += ";" + p.Params
+		}
+		writeLine(w, line+":"+p.Value)
+	}
+	for _, child := range c.Components {
+		encode(w, child)
+	}
+	writeLine(w, "END:"+c.Name)
+}
+
+// writeLine folds lines longer than 75 octets without splitting a UTF-8
+// sequence. Continuation lines start with a single space.
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// Text escapes a TEXT value.
+func Text(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// DateTime formats t as a UTC DATE-TIME value.
+func DateTime(t time.Time) string {
+	return t.UTC().Format(DateTimeFormat)
+}
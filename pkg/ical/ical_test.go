@@ -0,0 +1,116 @@
+package ical
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	todo := NewComponent("VTODO").
+		Add("UID", "task-1@todoverba").
+		Add("DUE", DateTime(time.Date(2024, 9, 5, 15, 4, 5, 0, time.FixedZone("", 5*3600)))).
+		Add("SUMMARY", Text("Buy milk, eggs; and \\ bread\nsoon"))
+	cal := NewComponent("VCALENDAR").Add("VERSION", "2.0")
+	cal.Components = append(cal.Components, todo)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, Encode(buf, cal))
+
+	assert.Equal(t, "BEGIN:VCALENDAR\r\n"+
+		"VERSION:2.0\r\n"+
+		"BEGIN:VTODO\r\n"+
+		"UID:task-1@todoverba\r\n"+
+		"DUE:20240905T100405Z\r\n"+
+		"SUMMARY:Buy milk\\, eggs\\; and \\\\ bread\\nsoon\r\n"+
+		"END:VTODO\r\n"+
+		"END:VCALENDAR\r\n", buf.String())
+}
+
+func TestEncode_folding(t *testing.T) {
+	c := NewComponent("VTODO").Add("DESCRIPTION", strings.Repeat("ж", 100))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, Encode(buf, c))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	unfolded := ""
+	for i, line := range lines {
+		assert.LessOrEqual(t, len(line), 75)
+		if i > 1 && i < len(lines)-1 {
+			require.True(t, strings.HasPrefix(line, " "))
+			line = line[1:]
+		}
+		unfolded += line
+	}
+	assert.Equal(t, "BEGIN:VTODODESCRIPTION:"+strings.Repeat("ж", 100)+"END:VTODO", unfolded)
+}
+
+func TestDecode(t *testing.T) {
+	raw := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:ABC-123\r\n" +
+		"SUMMARY:Buy milk\\, eggs\\; and \\\\ bread\\nso\r\n" +
+		" on\r\n" +
+		"DESCRIPTION;ALTREP=\"http://example.com/a;b\":x\r\n" +
+		"DUE;TZID=Europe/Berlin:20240905T150405\r\n" +
+		"DTSTART;VALUE=DATE:20240901\r\n" +
+		"COMPLETED:20240906T090000Z\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	cal, err := Decode(strings.NewReader(raw))
+	require.NoError(t, err)
+	assert.Equal(t, "VCALENDAR", cal.Name)
+	todos := cal.Children("vtodo")
+	require.Len(t, todos, 1)
+
+	summary, ok := todos[0].Get("SUMMARY")
+	require.True(t, ok)
+	assert.Equal(t, "Buy milk, eggs; and \\ bread\nsoon", ParseText(summary.Value))
+
+	description, _ := todos[0].Get("DESCRIPTION")
+	assert.Equal(t, "http://example.com/a;b", description.Param("altrep"))
+	assert.Equal(t, "x", description.Value)
+
+	for name, want := range map[string]time.Time{
+		"DUE":       time.Date(2024, 9, 5, 13, 4, 5, 0, time.UTC),
+		"DTSTART":   time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+		"COMPLETED": time.Date(2024, 9, 6, 9, 0, 0, 0, time.UTC),
+	} {
+		p, ok := todos[0].Get(name)
+		require.True(t, ok, name)
+		got, err := ParseTime(p)
+		require.NoError(t, err, name)
+		assert.True(t, want.Equal(got), "%s: %s", name, got)
+	}
+}
+
+func TestDecode_malformed(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"SUMMARY:x\r\n",
+		"BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCALENDAR\r\nno colon\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCALENDAR\r\n",
+	} {
+		_, err := Decode(strings.NewReader(raw))
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestDecode_roundTrip(t *testing.T) {
+	c := NewComponent("VTODO").Add("DESCRIPTION", Text(strings.Repeat("ж, ", 50)))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, Encode(buf, c))
+	decoded, err := Decode(buf)
+	require.NoError(t, err)
+
+	p, _ := decoded.Get("DESCRIPTION")
+	assert.Equal(t, strings.Repeat("ж, ", 50), ParseText(p.Value))
+}
@@ -78,6 +78,17 @@ func (l *Logger) SetLevel(level logrus.Level) {
 	l.Logger.SetLevel(level)
 }
 
+// SetWriter sends the log lines to w instead of stdout, for commands that
+// write their own output there.
+func (l *Logger) SetWriter(w io.Writer) {
+	hooks := make(logrus.LevelHooks)
+	hooks.Add(&writerHook{
+		Writer:   []io.Writer{w},
+		LogLevel: logrus.AllLevels,
+	})
+	l.Logger.ReplaceHooks(hooks)
+}
+
 func GetLogger() Logger {
 	return Logger{logEntry}
 }
@@ -0,0 +1,85 @@
+// Package metrics keeps process-wide counters and serves them in the
+// Prometheus text format. It covers the few numbers the app exports without
+// pulling in a client library.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Counter is a value that only goes up, such as a number of requests.
+type Counter struct {
+	name  string
+	help  string
+	value atomic.Uint64
+}
+
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+func (c *Counter) Add(n uint64) {
+	c.value.Add(n)
+}
+
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+var (
+	mu       sync.Mutex
+	counters = map[string]*Counter{}
+)
+
+// NewCounter registers a counter. Like expvar it panics when the name is
+// taken or invalid, counters are meant to be package variables.
+func NewCounter(name, help string) *Counter {
+	if !namePattern.MatchString(name) {
+		panic(fmt.Sprintf("metrics: invalid name %q", name))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := counters[name]; ok {
+		panic(fmt.Sprintf("metrics: counter %q registered twice", name))
+	}
+	c := &Counter{name: name, help: help}
+	counters[name] = c
+	return c
+}
+
+// Handler writes all counters in name order.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		list := make([]*Counter, 0, len(counters))
+		for _, c := range counters {
+			list = append(list, c)
+		}
+		mu.Unlock()
+		slices.SortFunc(list, func(a, b *Counter) int { return strings.Compare(a.name, b.name) })
+
+		var b strings.Builder
+		for _, c := range list {
+			fmt.Fprintf(&b, "# HELP %s %s\n", c.name, escapeHelp(c.help))
+			fmt.Fprintf(&b, "# TYPE %s counter\n", c.name)
+			fmt.Fprintf(&b, "%s %d\n", c.name, c.Value())
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write([]byte(b.String()))
+	})
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
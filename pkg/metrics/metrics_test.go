@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	b := NewCounter("test_b_total", "Second\nline.")
+	a := NewCounter("test_a_total", "First.")
+	a.Inc()
+	b.Add(3)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `# HELP test_a_total First.
+# TYPE test_a_total counter
+test_a_total 1
+# HELP test_b_total Second\nline.
+# TYPE test_b_total counter
+test_b_total 3
+`, rec.Body.String())
+
+	assert.Panics(t, func() { NewCounter("test_a_total", "") }, "taken")
+	assert.Panics(t, func() { NewCounter("test-c", "") }, "invalid")
+}
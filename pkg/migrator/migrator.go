@@ -1,41 +1,230 @@
 package migrator
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+	"io/fs"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
 )
 
+// ErrNoChange is returned when the database already is at the version asked
+// for.
+var ErrNoChange = migrate.ErrNoChange
+
+// ErrDirty is returned when the last migration failed halfway. The database
+// has to be fixed by hand and its version set with Force before migrating
+// again.
+type ErrDirty = migrate.ErrDirty
+
 type Deps struct {
 	Username string
 	Password string
 	Host     string
 	Port     string
 	Database string
-	Source   string
+	// Source is the URL of the migrations, file://migration for example. It
+	// takes precedence over Files.
+	Source string
+	// Files holds the migrations when Source is empty.
+	Files fs.FS
+	// LockTimeout bounds the wait for another migrator to finish, 0 waits
+	// as long as it takes.
+	LockTimeout time.Duration
 }
 
 type Migrator struct {
-	migrator *migrate.Migrate
+	migrator    *migrate.Migrate
+	openSource  func() (source.Driver, error)
+	conn        *pgx.Conn
+	lockId      int64
+	lockTimeout time.Duration
+}
+
+// Migration is a migration of the source and whether the database has it.
+type Migration struct {
+	Version uint
+	Name    string
+	Applied bool
+	// Dirty marks the current version if its migration failed halfway.
+	Dirty bool
 }
 
 func (m Migrator) Up() error {
-	return m.migrator.Up()
+	return m.locked(m.migrator.Up)
+}
+
+// Down reverts all migrations.
+func (m Migrator) Down() error {
+	return m.locked(m.migrator.Down)
+}
+
+// Steps applies n migrations, or reverts them if n is negative.
+func (m Migrator) Steps(n int) error {
+	return m.locked(func() error { return m.migrator.Steps(n) })
+}
+
+// Goto migrates up or down to the version.
+func (m Migrator) Goto(version uint) error {
+	return m.locked(func() error { return m.migrator.Migrate(version) })
+}
+
+// Force sets the version without running migrations and clears the dirty
+// flag, to recover from a failed migration fixed by hand. -1 means no
+// migration is applied.
+func (m Migrator) Force(version int) error {
+	return m.locked(func() error { return m.migrator.Force(version) })
+}
+
+// Version returns the current version, 0 if no migration is applied.
+func (m Migrator) Version() (uint, bool, error) {
+	version, dirty, err := m.migrator.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// Status lists the migrations of the source in order. Migrations apply in
+// sequence, so the applied ones are those up to the current version.
+func (m Migrator) Status() ([]Migration, error) {
+	current, dirty, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := m.openSource()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	var migrations []Migration
+	version, err := src.First()
+	for err == nil {
+		var name string
+		if name, err = migrationName(src, version); err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    name,
+			Applied: version <= current,
+			Dirty:   dirty && version == current,
+		})
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return migrations, nil
+}
+
+func migrationName(src source.Driver, version uint) (string, error) {
+	r, name, err := src.ReadUp(version)
+	if errors.Is(err, os.ErrNotExist) {
+		// A version with a down migration only.
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return name, r.Close()
+}
+
+// locked runs f holding a session advisory lock of the database, so the
+// servers of a rollout migrate one after the other. The ones that wait find
+// the database migrated and get ErrNoChange. golang-migrate locks too, but
+// gives up after 15 seconds, too soon for a long migration.
+func (m Migrator) locked(f func() error) error {
+	ctx := context.Background()
+	if m.lockTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.lockTimeout)
+		defer cancel()
+	}
+	if _, err := m.conn.Exec(ctx, "SELECT pg_advisory_lock($1)", m.lockId); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("timed out after %s waiting for another migration", m.lockTimeout)
+		}
+		return fmt.Errorf("can't lock migrations: %w", err)
+	}
+
+	err := f()
+	if _, unlockErr := m.conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", m.lockId); unlockErr != nil {
+		err = errors.Join(err, fmt.Errorf("can't unlock migrations: %w", unlockErr))
+	}
+	return err
 }
 
 func (m Migrator) Close() (error, error) {
-	return m.migrator.Close()
+	source, database := m.migrator.Close()
+	if err := m.conn.Close(context.Background()); err != nil {
+		database = errors.Join(database, err)
+	}
+	return source, database
 }
 
 func NewMigrator(d Deps) (*Migrator, error) {
-	url := fmt.Sprintf("pgx5://%s:%s@%s:%s/%s?sslmode=disable",
-		d.Username, d.Password, d.Host, d.Port, d.Database)
+	dbURL := url.URL{
+		Scheme:   "pgx5",
+		User:     url.UserPassword(d.Username, d.Password),
+		Host:     d.Host + ":" + d.Port,
+		Path:     "/" + d.Database,
+		RawQuery: "sslmode=disable",
+	}
 
-	m, err := migrate.New(d.Source, url)
+	openSource := func() (source.Driver, error) {
+		return iofs.New(d.Files, ".")
+	}
+	if d.Source != "" {
+		openSource = func() (source.Driver, error) {
+			return source.Open(d.Source)
+		}
+	}
+	src, err := openSource()
 	if err != nil {
 		return nil, err
 	}
+	m, err := migrate.NewWithSourceInstance("migrations", src, dbURL.String())
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+
+	// The lock id differs from the one of golang-migrate, which takes its
+	// own lock on another connection while this one is held.
+	id, err := database.GenerateAdvisoryLockId(d.Database, "todoverba_migrator")
+	if err != nil {
+		m.Close()
+		return nil, err
+	}
+	lockId, _ := strconv.ParseInt(id, 10, 64)
+	dbURL.Scheme = "postgres"
+	conn, err := pgx.Connect(context.Background(), dbURL.String())
+	if err != nil {
+		m.Close()
+		return nil, err
+	}
+	if d.LockTimeout > 0 {
+		m.LockTimeout = d.LockTimeout
+	}
 
-	return &Migrator{m}, nil
+	return &Migrator{
+		migrator:    m,
+		openSource:  openSource,
+		conn:        conn,
+		lockId:      lockId,
+		lockTimeout: d.LockTimeout,
+	}, nil
 }
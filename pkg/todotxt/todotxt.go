@@ -0,0 +1,178 @@
+// Package todotxt reads and writes task lines in the todo.txt format
+// (https://github.com/todotxt/todo.txt).
+package todotxt
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const DateFormat = "2006-01-02"
+
+var priorityPattern = regexp.MustCompile(`^\([A-Z]\)$`)
+
+// Item is one task line. Text holds the description including its +project,
+// @context and key:value words, which are read from it on demand.
+type Item struct {
+	Completed      bool
+	Priority       string
+	CompletionDate time.Time
+	CreationDate   time.Time
+	Text           string
+}
+
+// Parse reads a task line: an optional "x " completion mark and completion
+// date, then the body as read by ParseBody.
+func Parse(line string) (Item, error) {
+	rest := strings.TrimSpace(line)
+	completed := false
+	var completionDate time.Time
+	if after, ok := strings.CutPrefix(rest, "x "); ok {
+		completed = true
+		rest = strings.TrimLeft(after, " ")
+		if date, after, ok := cutDate(rest); ok {
+			completionDate = date
+			rest = after
+		}
+	}
+
+	item, err := ParseBody(rest)
+	if err != nil {
+		return Item{}, err
+	}
+	item.Completed = completed
+	item.CompletionDate = completionDate
+	return item, nil
+}
+
+// ParseBody reads a line without completion mark: an optional (A) priority,
+// an optional creation date and the text.
+func ParseBody(body string) (Item, error) {
+	item := Item{}
+	rest := strings.TrimSpace(body)
+	if word, after, _ := strings.Cut(rest, " "); priorityPattern.MatchString(word) {
+		item.Priority = word[1:2]
+		rest = strings.TrimLeft(after, " ")
+	}
+	if date, after, ok := cutDate(rest); ok {
+		item.CreationDate = date
+		rest = after
+	}
+
+	item.Text = strings.Join(strings.Fields(rest), " ")
+	if item.Text == "" {
+		return Item{}, errors.New("task text is empty")
+	}
+	return item, nil
+}
+
+func cutDate(s string) (time.Time, string, bool) {
+	word, after, _ := strings.Cut(s, " ")
+	date, err := time.Parse(DateFormat, word)
+	if err != nil {
+		return time.Time{}, s, false
+	}
+	return date, strings.TrimLeft(after, " "), true
+}
+
+// String formats the item as a task line.
+func (i Item) String() string {
+	var parts []string
+	if i.Completed {
+		parts = append(parts, "x")
+		if !i.CompletionDate.IsZero() {
+			parts = append(parts, i.CompletionDate.Format(DateFormat))
+		}
+	}
+	if i.Priority != "" {
+		parts = append(parts, "("+i.Priority+")")
+	}
+	if !i.CreationDate.IsZero() {
+		parts = append(parts, i.CreationDate.Format(DateFormat))
+	}
+	return strings.Join(append(parts, i.Text), " ")
+}
+
+// Body formats priority and text, the part of the line that Parse hands to
+// ParseBody apart from the creation date.
+func (i Item) Body() string {
+	if i.Priority == "" {
+		return i.Text
+	}
+	return "(" + i.Priority + ") " + i.Text
+}
+
+// Projects returns the +project names in the order they appear.
+func (i Item) Projects() []string {
+	return i.prefixed('+')
+}
+
+// Contexts returns the @context names in the order they appear.
+func (i Item) Contexts() []string {
+	return i.prefixed('@')
+}
+
+func (i Item) prefixed(prefix byte) []string {
+	var names []string
+	for _, word := range strings.Fields(i.Text) {
+		if len(word) > 1 && word[0] == prefix {
+			names = append(names, word[1:])
+		}
+	}
+	return names
+}
+
+// Tag returns the value of the first key:value word with the key.
+func (i Item) Tag(key string) (string, bool) {
+	for _, word := range strings.Fields(i.Text) {
+		if k, v, ok := cutTag(word); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// SetTag replaces the key:value words with the key by a single one at the
+// end of the text.
+func (i *Item) SetTag(key, value string) {
+	i.RemoveTag(key)
+	i.Text = strings.TrimSpace(i.Text + " " + key + ":" + value)
+}
+
+func (i *Item) RemoveTag(key string) {
+	var words []string
+	for _, word := range strings.Fields(i.Text) {
+		if k, _, ok := cutTag(word); ok && k == key {
+			continue
+		}
+		words = append(words, word)
+	}
+	i.Text = strings.Join(words, " ")
+}
+
+// Plain returns the text without +project, @context and key:value words.
+func (i Item) Plain() string {
+	var words []string
+	for _, word := range strings.Fields(i.Text) {
+		if len(word) > 1 && (word[0] == '+' || word[0] == '@') {
+			continue
+		}
+		if _, _, ok := cutTag(word); ok {
+			continue
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " ")
+}
+
+// cutTag splits a key:value word. Urls such as https://example.com are not
+// tags.
+func cutTag(word string) (string, string, bool) {
+	key, value, ok := strings.Cut(word, ":")
+	if !ok || key == "" || value == "" || strings.HasPrefix(value, "//") || strings.ContainsAny(key, "+@") {
+		return "", "", false
+	}
+	return key, value, true
+}
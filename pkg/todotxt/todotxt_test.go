@@ -0,0 +1,85 @@
+package todotxt
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := time.Parse(DateFormat, s)
+		return d
+	}
+
+	testTable := []struct {
+		name     string
+		line     string
+		expected Item
+	}{
+		{
+			name:     "plain",
+			line:     "Buy milk",
+			expected: Item{Text: "Buy milk"},
+		},
+		{
+			name:     "priority_and_creation_date",
+			line:     "(A) 2024-09-01 Call mom +family @phone due:2024-09-10",
+			expected: Item{Priority: "A", CreationDate: date("2024-09-01"), Text: "Call mom +family @phone due:2024-09-10"},
+		},
+		{
+			name: "completed_with_dates",
+			line: "x 2024-09-05 2024-09-01 Review  report  +work",
+			expected: Item{Completed: true, CompletionDate: date("2024-09-05"), CreationDate: date("2024-09-01"),
+				Text: "Review report +work"},
+		},
+		{
+			name:     "not_a_priority_or_mark",
+			line:     "(a) xylophone lesson",
+			expected: Item{Text: "(a) xylophone lesson"},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			item, err := Parse(testCase.line)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, item)
+		})
+	}
+
+	_, err := Parse("x 2024-09-05")
+	assert.EqualError(t, err, "task text is empty")
+}
+
+func TestItem_roundTrip(t *testing.T) {
+	for _, line := range []string{
+		"Buy milk",
+		"(B) 2024-09-01 Call mom +family @phone due:2024-09-10",
+		"x 2024-09-05 2024-09-01 Review report +work",
+		"x (C) Pay rent",
+	} {
+		item, err := Parse(line)
+		require.NoError(t, err)
+		assert.Equal(t, line, item.String())
+	}
+}
+
+func TestItem_words(t *testing.T) {
+	item := Item{Text: "Call mom +family @phone +home due:2024-09-10 see https://example.com pri:A"}
+
+	assert.Equal(t, []string{"family", "home"}, item.Projects())
+	assert.Equal(t, []string{"phone"}, item.Contexts())
+	due, ok := item.Tag("due")
+	assert.True(t, ok)
+	assert.Equal(t, "2024-09-10", due)
+	_, ok = item.Tag("https")
+	assert.False(t, ok)
+	assert.Equal(t, "Call mom see https://example.com", item.Plain())
+
+	item.SetTag("due", "2024-09-11")
+	assert.Equal(t, "Call mom +family @phone +home see https://example.com pri:A due:2024-09-11", item.Text)
+	item.RemoveTag("pri")
+	assert.Equal(t, "Call mom +family @phone +home see https://example.com due:2024-09-11", item.Text)
+}